@@ -22,6 +22,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -71,6 +72,9 @@ type Server struct {
 	ops     bench.Operations
 	aggrDur time.Duration
 
+	// adjust changes the running benchmark, if set.
+	adjust *bench.Adjuster
+
 	// lock for Server
 	mu sync.Mutex
 }
@@ -85,6 +89,14 @@ func (s *Server) OperationsReady(ops bench.Operations, filename, cmdLine string)
 	s.mu.Unlock()
 }
 
+// SetAdjuster allows the concurrency and request rate of the running benchmark
+// to be changed using `/v1/adjust`.
+func (s *Server) SetAdjuster(a *bench.Adjuster) {
+	s.mu.Lock()
+	s.adjust = a
+	s.mu.Unlock()
+}
+
 // SetLnLoggers can be used to set upstream loggers.
 // When logging to the servers these will be called.
 func (s *Server) SetLnLoggers(info, err func(data ...interface{})) {
@@ -248,6 +260,62 @@ func (s *Server) handleDownloadJSON(w http.ResponseWriter, req *http.Request) {
 	enc.Encode(ops)
 }
 
+// AdjustStatus contains the concurrency and request rate of a running benchmark.
+type AdjustStatus struct {
+	Concurrency    int     `json:"concurrency"`
+	MaxConcurrency int     `json:"max_concurrency"`
+	RPS            float64 `json:"rps"`
+}
+
+// handleAdjust handles requests to `/v1/adjust`.
+// GET returns the current values, POST changes the values given
+// as "concurrency" and "rps" parameters and returns the new values.
+func (s *Server) handleAdjust(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	a := s.adjust
+	s.mu.Unlock()
+	if a == nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("benchmark cannot be adjusted"))
+		return
+	}
+	switch req.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		q := req.URL.Query()
+		if v := q.Get("concurrency"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err == nil {
+				err = a.SetConcurrency(n)
+			}
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(err.Error()))
+				return
+			}
+		}
+		if v := q.Get("rps"); v != "" {
+			rps, err := strconv.ParseFloat(v, 64)
+			if err == nil {
+				err = a.SetRPS(rps)
+			}
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(err.Error()))
+				return
+			}
+		}
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var st AdjustStatus
+	st.Concurrency, st.MaxConcurrency = a.Concurrency()
+	st.RPS = a.RPS()
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	json.NewEncoder(w).Encode(st)
+}
+
 // handleStop handles requests to `/v1/stop`, stops the service.
 func (s *Server) handleStop(w http.ResponseWriter, req *http.Request) {
 	if req.Method != http.MethodDelete {
@@ -268,6 +336,7 @@ func NewBenchmarkMonitor(listenAddr string) *Server {
 	s.ctx, s.cancel = context.WithCancel(context.Background())
 	mux := http.NewServeMux()
 	mux.HandleFunc("/v1/stop", s.handleStop)
+	mux.HandleFunc("/v1/adjust", s.handleAdjust)
 	mux.HandleFunc("/v1/status", s.handleStatus)
 	mux.HandleFunc("/v1/aggregated", s.handleAggregated)
 	mux.HandleFunc("/v1/operations/json", s.handleDownloadJSON)
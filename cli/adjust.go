@@ -0,0 +1,87 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"os"
+	"os/signal"
+
+	"github.com/minio/cli"
+	"github.com/minio/warp/api"
+	"github.com/minio/warp/pkg/bench"
+	"golang.org/x/time/rate"
+)
+
+// newAdjuster returns an adjuster for the benchmark if --adjust is set.
+// If limiter is nil, an unlimited limiter is returned that must be used for the benchmark.
+func newAdjuster(ctx *cli.Context, limiter *rate.Limiter) (*bench.Adjuster, *rate.Limiter) {
+	if !ctx.Bool("adjust") {
+		return nil, limiter
+	}
+	if limiter == nil {
+		limiter = rate.NewLimiter(rate.Inf, 1)
+	}
+	return bench.NewAdjuster(ctx.Int("concurrent"), ctx.Int("adjust.concurrent"), limiter), limiter
+}
+
+// startAdjust starts limiting the active threads of the benchmark
+// and accepts adjustments from signals and the monitor API, if set, until the returned function is called.
+func startAdjust(ctx *cli.Context, c *bench.Common, monitor *api.Server) (stop func()) {
+	a := c.Adjust
+	if a == nil {
+		return func() {}
+	}
+	a.Start()
+	if monitor != nil {
+		monitor.SetAdjuster(a)
+	}
+	if len(adjustSignals) == 0 {
+		return func() {}
+	}
+	step := ctx.Int("adjust.step")
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, adjustSignals...)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case sig := <-sigs:
+				active, threads := a.Concurrency()
+				n := active + step
+				if sig != adjustSignals[0] {
+					n = active - step
+				}
+				n = min(max(n, 1), threads)
+				if n == active {
+					continue
+				}
+				if err := a.SetConcurrency(n); err != nil {
+					printError("Unable to adjust concurrency:", err)
+					continue
+				}
+				printInfo("Concurrency adjusted to", n)
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(sigs)
+		close(done)
+	}
+}
@@ -22,20 +22,27 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/dustin/go-humanize"
 	"github.com/fatih/color"
-	"github.com/klauspost/compress/zstd"
 	"github.com/minio/cli"
 	"github.com/minio/mc/pkg/probe"
 	"github.com/minio/pkg/v2/console"
 	"github.com/minio/warp/api"
 	"github.com/minio/warp/pkg/aggregate"
 	"github.com/minio/warp/pkg/bench"
+	"github.com/minio/warp/pkg/generator"
+	"github.com/minio/warp/pkg/nulls3"
 )
 
 var analyzeFlags = []cli.Flag{
@@ -49,6 +56,26 @@ var analyzeFlags = []cli.Flag{
 		Value: "",
 		Usage: "Output aggregated data as to file",
 	},
+	cli.BoolFlag{
+		Name:  "analyze.align",
+		Usage: "Align analysis segments to wall clock multiples of the segment duration, for example every minute on the minute.",
+	},
+	cli.StringFlag{
+		Name:  "analyze.percentiles-out",
+		Usage: "Output request latency percentiles of each segment as tab separated values to file",
+	},
+	cli.StringFlag{
+		Name:  "analyze.cdf-out",
+		Usage: "Output the cumulative latency distribution of each segment as tab separated values to file",
+	},
+	cli.StringFlag{
+		Name:  "analyze.thresholds",
+		Usage: "Comma separated latency thresholds, eg. '10ms,100ms,1s'. Used by --analyze.thresholds-out",
+	},
+	cli.StringFlag{
+		Name:  "analyze.thresholds-out",
+		Usage: "Output the fraction of requests completed within each of --analyze.thresholds for each segment as tab separated values to file",
+	},
 	cli.StringFlag{
 		Name:  "analyze.op",
 		Value: "",
@@ -77,6 +104,48 @@ var analyzeFlags = []cli.Flag{
 		Hidden: true,
 		Value:  0,
 	},
+	cli.StringFlag{
+		Name:  "html",
+		Usage: "Write a self-contained HTML report with charts to this file.",
+	},
+	cli.StringFlag{
+		Name:  "analyze.cache-header",
+		Usage: "Response header with the cache status to display results by, if not X-Cache, CF-Cache-Status or Cache-Status. The header must be recorded with --record-headers.",
+	},
+	cli.BoolFlag{
+		Name:  "analyze.prepare",
+		Usage: "Display results of operations made when preparing compared to the benchmark.",
+	},
+	cli.BoolFlag{
+		Name:  "analyze.prefix",
+		Usage: "Display results by object prefix.",
+	},
+	cli.IntFlag{
+		Name:  "analyze.slowest",
+		Usage: "Display the specified number of slowest requests of each operation type.",
+	},
+	cli.StringFlag{
+		Name:  "analyze.size-buckets",
+		Value: "1MiB,16MiB",
+		Usage: "Comma separated object sizes separating buckets to display results by when objects have different sizes. Set to empty to disable.",
+	},
+	cli.Float64Flag{
+		Name:  "analyze.co-rate",
+		Usage: "Requests per second of each client to correct latencies for coordinated omission. Defaults to the --rps-limit of the benchmark.",
+	},
+	cli.IntFlag{
+		Name:  "analyze.objects",
+		Usage: "Number of objects present when the benchmark started, used when objects are added and removed. Defaults to --objects of the benchmark.",
+	},
+	cli.StringFlag{
+		Name:  "analyze.dataset-out",
+		Usage: "Output the number of live objects and latency of reads in each segment as tab separated values to file",
+	},
+	cli.Float64Flag{
+		Name:  "analyze.downtime-threshold",
+		Value: aggregate.DefaultDowntimeThreshold,
+		Usage: "Error rate of an analysis segment above which it is counted as downtime in availability analysis.",
+	},
 	cli.BoolFlag{
 		Name:  "analyze.v",
 		Usage: "Display additional analysis data.",
@@ -92,7 +161,7 @@ var analyzeCmd = cli.Command{
 	Usage:  "analyze existing benchmark data",
 	Action: mainAnalyze,
 	Before: setGlobalsFromContext,
-	Flags:  combineFlags(globalFlags, analyzeFlags),
+	Flags:  combineFlags(globalFlags, analyzeFlags, inputFlags),
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
@@ -117,8 +186,6 @@ func mainAnalyze(ctx *cli.Context) error {
 	if len(args) > 1 {
 		console.Fatal("Only one benchmark file can be given")
 	}
-	zstdDec, _ := zstd.NewReader(nil)
-	defer zstdDec.Close()
 	monitor := api.NewBenchmarkMonitor(ctx.String(serverFlagName))
 	defer monitor.Done()
 	log := console.Printf
@@ -126,22 +193,25 @@ func mainAnalyze(ctx *cli.Context) error {
 		log = nil
 	}
 	for _, arg := range args {
-		var input io.Reader
-		if arg == "-" {
-			input = os.Stdin
-		} else {
-			f, err := os.Open(arg)
-			fatalIf(probe.NewError(err), "Unable to open input file")
-			defer f.Close()
-			input = f
-		}
-		err := zstdDec.Reset(input)
+		input, err := openInput(ctx, arg)
+		fatalIf(probe.NewError(err), "Unable to open input file")
+		defer input.Close()
+		dec, err := newResultsReader(input)
+		fatalIf(probe.NewError(err), "Unable to read input")
+		defer dec.Close()
+		tags, rd, err := bench.TagsFromCSV(dec)
 		fatalIf(probe.NewError(err), "Unable to read input")
-		ops, err := bench.OperationsFromCSV(zstdDec, true, ctx.Int("analyze.offset"), ctx.Int("analyze.limit"), log)
+		ops, err := bench.OperationsFromCSV(rd, true, ctx.Int("analyze.offset"), ctx.Int("analyze.limit"), log)
 		fatalIf(probe.NewError(err), "Unable to parse input")
 
-		printAnalysis(ctx, ops)
-		monitor.OperationsReady(ops, strings.TrimSuffix(filepath.Base(arg), ".csv.zst"), commandLine(ctx))
+		if !ctx.IsSet("analyze.objects") && arg != "-" {
+			// Use the number of objects of the benchmark from the run manifest.
+			if m, err := readManifest(ctx, arg); err == nil && m.Flags["objects"] != "" {
+				ctx.Set("analyze.objects", m.Flags["objects"])
+			}
+		}
+		printAnalysis(ctx, ops, tags)
+		monitor.OperationsReady(ops, trimResultsExt(filepath.Base(arg)), commandLine(ctx))
 	}
 	return nil
 }
@@ -190,6 +260,7 @@ func printMixedOpAnalysis(ctx *cli.Context, aggr aggregate.Aggregated, details b
 					console.Println(err)
 				}
 			}
+			printErrorTimeline(ops.ErrorTimeline, details)
 			console.SetColor("Print", color.New(color.FgWhite))
 		}
 		eps := ops.ThroughputByHost
@@ -234,10 +305,45 @@ func printMixedOpAnalysis(ctx *cli.Context, aggr aggregate.Aggregated, details b
 	}
 }
 
-func printAnalysis(ctx *cli.Context, o bench.Operations) {
+func printAnalysis(ctx *cli.Context, o bench.Operations, tags bench.Tags) {
 	details := ctx.Bool("analyze.v")
 	var wrSegs io.Writer
 	prefiltered := false
+	o, events := o.SplitEndpointEvents()
+	o, prepare := o.SplitPrepare()
+	if len(prepare) > 0 && ctx.Bool("analyze.prepare") && !globalJSON {
+		defer printPrepareAnalysis(o, prepare)
+	}
+	var adjusts, pacing, tcpStats, samples, endpointEvents bench.Operations
+	for _, ev := range events {
+		switch ev.OpType {
+		case bench.AdjustOp:
+			adjusts = append(adjusts, ev)
+		case bench.PacingOp:
+			pacing = append(pacing, ev)
+		case bench.TCPStatsOp:
+			tcpStats = append(tcpStats, ev)
+		case bench.OpSampleOp:
+			samples = append(samples, ev)
+		default:
+			endpointEvents = append(endpointEvents, ev)
+		}
+	}
+	if len(samples) > 0 && !globalJSON {
+		defer printOpSampleAnalysis(samples)
+	}
+	if len(tcpStats) > 0 && !globalJSON {
+		defer printTCPStatsAnalysis(tcpStats)
+	}
+	if len(pacing) > 0 && !globalJSON {
+		defer printPacingAnalysis(pacing)
+	}
+	if len(adjusts) > 0 && !globalJSON {
+		defer printAdjustAnalysis(o, adjusts)
+	}
+	if len(endpointEvents) > 0 && !globalJSON {
+		defer printFailoverAnalysis(o, endpointEvents)
+	}
 	if fn := ctx.String("analyze.out"); fn != "" {
 		if fn == "-" {
 			wrSegs = os.Stdout
@@ -274,15 +380,55 @@ func printAnalysis(ctx *cli.Context, o bench.Operations) {
 		return analysisDur(ctx, total)
 	}
 	aggr := aggregate.Aggregate(o, aggregate.Options{
-		Prefiltered: prefiltered,
-		DurFunc:     durFn,
-		SkipDur:     ctx.Duration("analyze.skip"),
+		Prefiltered:       prefiltered,
+		DurFunc:           durFn,
+		SkipDur:           ctx.Duration("analyze.skip"),
+		Align:             ctx.Bool("analyze.align"),
+		DowntimeThreshold: ctx.Float64("analyze.downtime-threshold"),
 	})
+	aggr.Tags = tags
 	if wrSegs != nil {
 		for _, ops := range aggr.Operations {
 			writeSegs(ctx, wrSegs, o.FilterByOp(ops.Type), !(aggr.Mixed || prefiltered), details)
 		}
 	}
+	for _, out := range []struct {
+		flag string
+		cdf  bool
+	}{{flag: "analyze.percentiles-out"}, {flag: "analyze.cdf-out", cdf: true}} {
+		if fn := ctx.String(out.flag); fn != "" {
+			err := writeSegmentLatencies(ctx, fn, o, out.cdf)
+			fatalIf(probe.NewError(err), "Unable to write latency segments")
+			if fn != "-" && !globalJSON {
+				defer console.Println("Segment latencies saved to", fn)
+			}
+		}
+	}
+
+	if fn := ctx.String("analyze.thresholds-out"); fn != "" {
+		thresholds, _ := latencyThresholds(ctx)
+		err := writeSegmentThresholds(ctx, fn, o, thresholds)
+		fatalIf(probe.NewError(err), "Unable to write latency thresholds")
+		if fn != "-" && !globalJSON {
+			defer console.Println("Latency thresholds saved to", fn)
+		}
+	}
+
+	if fn := ctx.String("analyze.dataset-out"); fn != "" {
+		err := writeDatasetSegments(ctx, fn, o)
+		fatalIf(probe.NewError(err), "Unable to write dataset segments")
+		if fn != "-" && !globalJSON {
+			defer console.Println("Dataset segments saved to", fn)
+		}
+	}
+
+	if fn := ctx.String("html"); fn != "" {
+		err := writeHTMLReport(ctx, fn, o, aggr, tags)
+		fatalIf(probe.NewError(err), "Unable to write HTML report")
+		if !globalJSON {
+			defer console.Println("HTML report saved to", fn)
+		}
+	}
 
 	if globalJSON {
 		b, err := json.MarshalIndent(aggr, "", "  ")
@@ -294,6 +440,41 @@ func printAnalysis(ctx *cli.Context, o bench.Operations) {
 		return
 	}
 
+	if len(tags) > 0 {
+		console.SetColor("Print", color.New(color.FgWhite))
+		console.Println("Tags:", tags.String())
+	}
+	if nullBackendOps(o) {
+		defer printNullBackendCalibration(aggr)
+	}
+
+	if ctx.Bool("analyze.prefix") {
+		defer printPrefixAnalysis(o)
+	}
+	defer printIsolationAnalysis(o)
+	defer printThrottleAnalysis(o)
+	defer printAvailability(aggr.Availability, details)
+	defer printIPFamilyAnalysis(o)
+	defer printRegionAnalysis(o)
+	defer printAddressingAnalysis(o)
+	defer printStorageClassAnalysis(o)
+	defer printEncryptionAnalysis(o)
+	defer printCacheStatusAnalysis(ctx, o)
+	defer printContentTypeAnalysis(o)
+	defer printSizeBucketAnalysis(ctx, o)
+	defer printInFlightAnalysis(ctx, o, details)
+	if n := ctx.Int("analyze.slowest"); n > 0 {
+		defer printSlowest(o, n)
+	}
+	defer printOmissionAnalysis(ctx, o)
+	defer printClientAnalysis(o)
+	defer printStallAnalysis(o)
+	defer printNameClassAnalysis(o)
+	defer printWireAnalysis(o)
+	defer printConnAnalysis(o)
+	defer printPhaseAnalysis(o)
+	defer printDatasetAnalysis(ctx, o)
+
 	if aggr.Mixed {
 		printMixedOpAnalysis(ctx, aggr, details)
 		return
@@ -341,6 +522,7 @@ func printAnalysis(ctx *cli.Context, o bench.Operations) {
 				}
 				console.Println("")
 			}
+			printErrorTimeline(ops.ErrorTimeline, details)
 		}
 
 		if ops.Skipped {
@@ -400,6 +582,878 @@ func printAnalysis(ctx *cli.Context, o bench.Operations) {
 	}
 }
 
+// printPrefixAnalysis prints the requests of each operation type by object prefix.
+// The prefixes with most requests are shown.
+func printPrefixAnalysis(o bench.Operations) {
+	const maxPrefixes = 10
+	for _, typ := range o.OpTypes() {
+		ops := o.FilterByOp(typ)
+		start, end := ops.ActiveTimeRange(!ops.IsMixed())
+		dur := end.Sub(start)
+		byPrefix := make(map[string]bench.Operations)
+		for _, op := range ops {
+			if op.File == "" {
+				continue
+			}
+			prefix := path.Dir(op.File)
+			if prefix == "." {
+				prefix = "(none)"
+			}
+			byPrefix[prefix] = append(byPrefix[prefix], op)
+		}
+		if len(byPrefix) == 0 || dur <= 0 {
+			continue
+		}
+		prefixes := stringKeysSorted(byPrefix)
+		sort.SliceStable(prefixes, func(i, j int) bool {
+			return len(byPrefix[prefixes[i]]) > len(byPrefix[prefixes[j]])
+		})
+		console.SetColor("Print", color.New(color.FgHiWhite))
+		console.Printf("\n%s requests by prefix (%d prefixes):\n", typ, len(prefixes))
+		console.SetColor("Print", color.New(color.FgWhite))
+		for i, prefix := range prefixes {
+			if i == maxPrefixes {
+				console.Println(" * ...", len(prefixes)-maxPrefixes, "more prefixes")
+				break
+			}
+			pOps := byPrefix[prefix]
+			line := fmt.Sprintf(" * %s: %d requests (%.01f%%), %.02f obj/s", prefix, len(pOps), 100*float64(len(pOps))/float64(len(ops)), float64(len(pOps))/dur.Seconds())
+			if lat := summaryLatencies(pOps.FilterSuccessful()); lat != nil {
+				line += fmt.Sprintf(", avg: %.01fms, 99%%: %.01fms", lat.Average, lat.P99)
+			}
+			if errs := pOps.NErrors(); errs > 0 {
+				line += fmt.Sprintf(", errors: %d", errs)
+			}
+			console.Println(line)
+		}
+	}
+}
+
+// printPhaseAnalysis prints the average time spent in each request phase per operation type,
+// if phases were recorded.
+func printPhaseAnalysis(o bench.Operations) {
+	header := false
+	for _, typ := range o.OpTypes() {
+		ops := o.FilterByOp(typ).FilterSuccessful()
+		p, n := ops.AvgPhases()
+		if n == 0 {
+			continue
+		}
+		if !header {
+			console.SetColor("Print", color.New(color.FgHiWhite))
+			console.Println("\nRequest phases (average):")
+			console.SetColor("Print", color.New(color.FgWhite))
+			header = true
+		}
+		total := p.DNS + p.Connect + p.TLS + p.Send + p.TTFB + p.Transfer + p.Continue
+		pct := func(d time.Duration) string {
+			if total <= 0 {
+				return fmt.Sprint(d.Round(time.Microsecond))
+			}
+			return fmt.Sprintf("%v (%.1f%%)", d.Round(time.Microsecond), 100*float64(d)/float64(total))
+		}
+		cont := ""
+		if p.Continue > 0 {
+			cont = ", 100-Continue: " + pct(p.Continue)
+		}
+		console.Printf(" * %s: DNS: %s, Connect: %s, TLS: %s%s, Send: %s, TTFB: %s, Transfer: %s. %d requests.\n",
+			typ, pct(p.DNS), pct(p.Connect), pct(p.TLS), cont, pct(p.Send), pct(p.TTFB), pct(p.Transfer), n)
+	}
+}
+
+// slowClientPct is the percentage below the average throughput of all clients
+// at which a client is marked as slow.
+const slowClientPct = 20
+
+// printClientAnalysis prints throughput, request times and errors per client for each operation type,
+// if operations from more than one client are present.
+// Clients with throughput well below the average are marked.
+func printClientAnalysis(o bench.Operations) {
+	if len(o.ClientIDs()) < 2 {
+		return
+	}
+	console.SetColor("Print", color.New(color.FgHiWhite))
+	console.Println("\nResults by client:")
+	console.SetColor("Print", color.New(color.FgWhite))
+	for _, typ := range o.OpTypes() {
+		ops := o.FilterByOp(typ)
+		start, end := ops.ActiveTimeRange(!o.IsMixed())
+		dur := end.Sub(start)
+		if dur <= 0 {
+			continue
+		}
+		clients := ops.FilterInsideRange(start, end).SortSplitByClient()
+		if len(clients) == 0 {
+			continue
+		}
+		objPerSec := make(map[string]float64, len(clients))
+		var total float64
+		for id, cOps := range clients {
+			objPerSec[id] = float64(len(cOps)) / dur.Seconds()
+			total += objPerSec[id]
+		}
+		avg := total / float64(len(clients))
+		console.Println(" *", typ+":")
+		for _, id := range stringKeysSorted(clients) {
+			cOps := clients[id]
+			var bytes int64
+			for _, op := range cOps {
+				bytes += op.Size
+			}
+			line := fmt.Sprintf("   - %s: %d requests, %.02f obj/s, %v", id, len(cOps), objPerSec[id], bench.Throughput(float64(bytes)/dur.Seconds()))
+			if lat := summaryLatencies(cOps.FilterSuccessful()); lat != nil {
+				line += fmt.Sprintf(", avg: %.01fms, 99%%: %.01fms", lat.Average, lat.P99)
+			}
+			if errs := cOps.NErrors(); errs > 0 {
+				line += fmt.Sprintf(", errors: %d", errs)
+			}
+			if objPerSec[id] < avg*(100-slowClientPct)/100 {
+				line += fmt.Sprintf(" (%.0f%% below average)", 100-100*objPerSec[id]/avg)
+			}
+			console.Println(line)
+		}
+	}
+}
+
+// printSlowest prints the n slowest requests of each operation type.
+func printSlowest(o bench.Operations, n int) {
+	for _, typ := range o.OpTypes() {
+		ops := o.FilterByOp(typ)
+		ops.SortByDuration()
+		if len(ops) > n {
+			ops = ops[len(ops)-n:]
+		}
+		console.SetColor("Print", color.New(color.FgHiWhite))
+		console.Printf("\nSlowest %s requests:\n", typ)
+		console.SetColor("Print", color.New(color.FgWhite))
+		for i := len(ops) - 1; i >= 0; i-- {
+			op := ops[i]
+			line := fmt.Sprintf(" * %v at %s, %s", op.Duration().Round(time.Microsecond), op.Start.Format("15:04:05.000"), op.Endpoint)
+			if op.File != "" {
+				line += ", " + op.File
+			}
+			if op.Size > 0 {
+				line += ", " + humanize.IBytes(uint64(op.Size))
+			}
+			if op.FirstByte != nil {
+				line += fmt.Sprintf(", TTFB: %v", op.TTFB().Round(time.Microsecond))
+			}
+			if op.RequestID != "" {
+				line += ", request ID: " + op.RequestID
+			}
+			if op.Err != "" {
+				line += ", error: " + op.Err
+			}
+			console.Println(line)
+		}
+	}
+}
+
+// printInFlightAnalysis prints the number of operations in flight compared to the number of threads,
+// to show whether the concurrency was sustained.
+// If details is set the number in flight is printed for each segment.
+func printInFlightAnalysis(ctx *cli.Context, o bench.Operations, details bool) {
+	threads := make(map[string]struct{})
+	for _, op := range o {
+		threads[fmt.Sprint(op.ClientID, "-", op.Thread)] = struct{}{}
+	}
+	start, end := o.ActiveTimeRange(true)
+	segs := o.InFlight(start, end, analysisDur(ctx, end.Sub(start)))
+	if len(segs) == 0 || len(threads) == 0 {
+		return
+	}
+	var sum float64
+	lowest := segs[0]
+	for _, seg := range segs {
+		sum += seg.Avg
+		if seg.Avg < lowest.Avg {
+			lowest = seg
+		}
+	}
+	avg := sum / float64(len(segs))
+	console.SetColor("Print", color.New(color.FgHiWhite))
+	console.Println("\nRequests in flight:")
+	console.SetColor("Print", color.New(color.FgWhite))
+	console.Printf(" * Threads: %d. Average in flight: %.1f (%.1f%%). Lowest segment: %.1f at %s, minimum %d.\n",
+		len(threads), avg, 100*avg/float64(len(threads)), lowest.Avg, lowest.Start.Format("15:04:05"), lowest.Min)
+	if !details {
+		return
+	}
+	for _, seg := range segs {
+		console.Printf("   - %s: avg: %.1f, min: %d, max: %d\n", seg.Start.Format("15:04:05"), seg.Avg, seg.Min, seg.Max)
+	}
+}
+
+// sizeBuckets returns the size bucket bounds given in "analyze.size-buckets".
+func sizeBuckets(ctx *cli.Context) ([]int64, error) {
+	var bounds []int64
+	for _, v := range strings.Split(ctx.String("analyze.size-buckets"), ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		sz, err := toSize(v)
+		if err != nil {
+			return nil, err
+		}
+		bounds = append(bounds, int64(sz))
+	}
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i] < bounds[j] })
+	return bounds, nil
+}
+
+// printSizeBucketAnalysis prints throughput and latency of each operation type by object size,
+// if objects have different sizes.
+func printSizeBucketAnalysis(ctx *cli.Context, o bench.Operations) {
+	bounds, err := sizeBuckets(ctx)
+	if err != nil || len(bounds) == 0 {
+		return
+	}
+	for _, typ := range o.OpTypes() {
+		ops := o.FilterByOp(typ)
+		if !ops.MultipleSizes() {
+			continue
+		}
+		start, end := ops.ActiveTimeRange(!o.IsMixed())
+		dur := end.Sub(start)
+		if dur <= 0 {
+			continue
+		}
+		ops = ops.FilterInsideRange(start, end)
+		console.SetColor("Print", color.New(color.FgHiWhite))
+		console.Printf("\n%s requests by object size (%d requests):\n", typ, len(ops))
+		console.SetColor("Print", color.New(color.FgWhite))
+		for _, seg := range ops.SplitSizeBuckets(bounds) {
+			var label string
+			switch {
+			case seg.Smallest == 0:
+				label = "< " + humanize.IBytes(uint64(seg.Biggest))
+			case seg.Biggest == math.MaxInt64:
+				label = ">= " + humanize.IBytes(uint64(seg.Smallest))
+			default:
+				label = humanize.IBytes(uint64(seg.Smallest)) + " -> " + humanize.IBytes(uint64(seg.Biggest))
+			}
+			var bytes int64
+			for _, op := range seg.Ops {
+				bytes += op.Size
+			}
+			line := fmt.Sprintf(" * %s: %d requests (%.01f%%), %.02f obj/s, %v", label, len(seg.Ops), 100*float64(len(seg.Ops))/float64(len(ops)), float64(len(seg.Ops))/dur.Seconds(), bench.Throughput(float64(bytes)/dur.Seconds()))
+			if lat := summaryLatencies(seg.Ops.FilterSuccessful()); lat != nil {
+				line += fmt.Sprintf(", avg: %.01fms, 50%%: %.01fms, 99%%: %.01fms", lat.Average, lat.P50, lat.P99)
+			}
+			if errs := seg.Ops.NErrors(); errs > 0 {
+				line += fmt.Sprintf(", errors: %d", errs)
+			}
+			console.Println(line)
+		}
+	}
+}
+
+// printAddressingAnalysis prints the results per bucket addressing style for each operation type,
+// if more than one style was used.
+func printAddressingAnalysis(o bench.Operations) {
+	printResultsBy(o, "addressing style", "auto", func(op bench.Operation) string { return op.Addressing })
+}
+
+// printIPFamilyAnalysis prints the results per address family for each operation type,
+// if both IPv4 and IPv6 connections were used.
+func printIPFamilyAnalysis(o bench.Operations) {
+	families := make(map[string]bool)
+	for _, op := range o {
+		families[op.IPFamily] = true
+	}
+	if !families[bench.IPv4] || !families[bench.IPv6] {
+		return
+	}
+	printResultsBy(o, "address family", "unknown", func(op bench.Operation) string { return op.IPFamily })
+}
+
+// printRegionAnalysis prints the results per bucket region for each operation type,
+// if endpoints in more than one region were used.
+func printRegionAnalysis(o bench.Operations) {
+	regions := make(map[string]bool)
+	for _, op := range o {
+		if op.Region != "" {
+			regions[op.Region] = true
+		}
+	}
+	if len(regions) < 2 {
+		return
+	}
+	printResultsBy(o, "region", "unknown", func(op bench.Operation) string { return op.Region })
+}
+
+// Recorded response headers with the storage class and encryption of objects.
+const (
+	storageClassHeader = "x-amz-storage-class"
+	sseHeader          = "x-amz-server-side-encryption"
+	sseCustomerHeader  = "x-amz-server-side-encryption-customer-algorithm"
+)
+
+// printStorageClassAnalysis prints the results per storage class for each operation type,
+// if more than one storage class was recorded.
+// Objects without a storage class are STANDARD, since it is not returned for those.
+func printStorageClassAnalysis(o bench.Operations) {
+	o = filterHeaderOpTypes(o, storageClassHeader)
+	printResultsBy(o, "storage class", "STANDARD", func(op bench.Operation) string { return op.Headers[storageClassHeader] })
+}
+
+// printEncryptionAnalysis prints the results per server-side encryption type for each operation type,
+// if more than one type was recorded.
+func printEncryptionAnalysis(o bench.Operations) {
+	o = filterHeaderOpTypes(o, sseHeader, sseCustomerHeader)
+	printResultsBy(o, "encryption", "none", sseType)
+}
+
+// sseType returns the server-side encryption type of the object of the operation.
+// An empty string is returned for unencrypted objects.
+func sseType(op bench.Operation) string {
+	if op.Headers[sseCustomerHeader] != "" {
+		return "SSE-C"
+	}
+	switch v := op.Headers[sseHeader]; v {
+	case "":
+		return ""
+	case "AES256":
+		return "SSE-S3"
+	case "aws:kms":
+		return "SSE-KMS"
+	case "aws:kms:dsse":
+		return "DSSE-KMS"
+	default:
+		return v
+	}
+}
+
+// cacheHeaders are recorded response headers with the cache status of a CDN or caching proxy.
+var cacheHeaders = []string{"x-cache", "cf-cache-status", "cache-status"}
+
+// printCacheStatusAnalysis prints the results per cache status for each operation type,
+// if more than one cache status was recorded.
+func printCacheStatusAnalysis(ctx *cli.Context, o bench.Operations) {
+	headers := cacheHeaders
+	if h := ctx.String("analyze.cache-header"); h != "" {
+		headers = []string{strings.ToLower(h)}
+	}
+	o = filterHeaderOpTypes(o, headers...)
+	printResultsBy(o, "cache status", "none", func(op bench.Operation) string {
+		for _, h := range headers {
+			if v := op.Headers[h]; v != "" {
+				return cacheStatus(h, v)
+			}
+		}
+		return ""
+	})
+}
+
+// cacheStatus returns "hit" or "miss" for the value of a cache header.
+// Other values are returned in lower case, for example "expired" or "bypass".
+// Cache-Status (RFC 9211) lists a status per cache, where the last is closest to the client.
+func cacheStatus(header, v string) string {
+	v = strings.ToLower(strings.TrimSpace(v))
+	if header == "cache-status" {
+		if i := strings.LastIndexByte(v, ','); i >= 0 {
+			v = v[i+1:]
+		}
+		for _, param := range strings.Split(v, ";")[1:] {
+			param = strings.TrimSpace(param)
+			switch {
+			case param == "hit":
+				return "hit"
+			case strings.HasPrefix(param, "fwd="):
+				return "miss"
+			}
+		}
+		return "unknown"
+	}
+	switch {
+	case strings.Contains(v, "hit"):
+		return "hit"
+	case strings.Contains(v, "miss"):
+		return "miss"
+	}
+	return v
+}
+
+// filterHeaderOpTypes returns the operations of the types where any operation has one of the headers recorded.
+// Other types are removed, since missing headers cannot be told apart from headers not returned.
+func filterHeaderOpTypes(o bench.Operations, headers ...string) bench.Operations {
+	types := make(map[string]bool)
+	for _, op := range o {
+		for _, h := range headers {
+			if op.Headers[h] != "" {
+				types[op.OpType] = true
+			}
+		}
+	}
+	if len(types) == 0 {
+		return nil
+	}
+	res := make(bench.Operations, 0, len(o))
+	for _, op := range o {
+		if types[op.OpType] {
+			res = append(res, op)
+		}
+	}
+	return res
+}
+
+// printResultsBy prints the results for each operation type split by the key of each operation,
+// if there is more than one key. Operations with an empty key are printed as unknown.
+func printResultsBy(o bench.Operations, title, unknown string, key func(op bench.Operation) string) {
+	byKey := make(map[string]bench.Operations)
+	for _, op := range o {
+		byKey[key(op)] = append(byKey[key(op)], op)
+	}
+	if len(byKey) < 2 {
+		return
+	}
+	console.SetColor("Print", color.New(color.FgHiWhite))
+	console.Println("\nResults by " + title + ":")
+	console.SetColor("Print", color.New(color.FgWhite))
+	for _, typ := range o.OpTypes() {
+		for _, k := range stringKeysSorted(byKey) {
+			ops := byKey[k].FilterByOp(typ)
+			if k == "" {
+				k = unknown
+			}
+			if line, ok := resultsLine(typ, k, ops); ok {
+				console.Println(line)
+			}
+		}
+	}
+}
+
+// resultsLine returns a line with the throughput, latency and errors of ops.
+// False is returned if ops don't cover any time.
+func resultsLine(typ, k string, ops bench.Operations) (string, bool) {
+	start, end := ops.ActiveTimeRange(false)
+	dur := end.Sub(start)
+	if len(ops) == 0 || dur <= 0 {
+		return "", false
+	}
+	var bytes int64
+	for _, op := range ops {
+		bytes += op.Size
+	}
+	line := fmt.Sprintf(" * %s, %s: %d requests, %.02f obj/s, %v", typ, k, len(ops), float64(len(ops))/dur.Seconds(), bench.Throughput(float64(bytes)/dur.Seconds()))
+	if lat := summaryLatencies(ops.FilterSuccessful()); lat != nil {
+		line += fmt.Sprintf(", avg: %.01fms, 99%%: %.01fms", lat.Average, lat.P99)
+	}
+	if errs := ops.NErrors(); errs > 0 {
+		line += fmt.Sprintf(", errors: %d", errs)
+	}
+	return line, true
+}
+
+// printPrepareAnalysis prints the results of operations made when preparing
+// next to the results of the same operation types in the benchmark.
+func printPrepareAnalysis(o, prepare bench.Operations) {
+	console.SetColor("Print", color.New(color.FgHiWhite))
+	console.Println("\nResults by phase:")
+	console.SetColor("Print", color.New(color.FgWhite))
+	for _, typ := range prepare.OpTypes() {
+		typ = strings.TrimPrefix(typ, bench.PreparePrefix)
+		if line, ok := resultsLine(typ, "prepare", prepare.FilterByOp(bench.PreparePrefix+typ)); ok {
+			console.Println(line)
+		}
+		if line, ok := resultsLine(typ, "benchmark", o.FilterByOp(typ)); ok {
+			console.Println(line)
+		}
+	}
+}
+
+// printContentTypeAnalysis prints the results per object extension for each operation type,
+// if objects with a mix of extensions were generated with --obj.content-types.
+func printContentTypeAnalysis(o bench.Operations) {
+	exts := make(map[string]bool)
+	withExt := make(bench.Operations, 0, len(o))
+	for _, op := range o {
+		if ext := path.Ext(op.File); ext != "" {
+			exts[ext] = true
+			withExt = append(withExt, op)
+		}
+	}
+	if len(exts) < 2 || exts[".rnd"] {
+		return
+	}
+	printResultsBy(withExt, "extension", "none", func(op bench.Operation) string { return path.Ext(op.File) })
+}
+
+// printConnAnalysis prints the connection reuse ratio per operation type
+// and the latency of requests on new and reused connections.
+func printConnAnalysis(o bench.Operations) {
+	header := false
+	for _, typ := range o.OpTypes() {
+		reused, newConn := o.FilterByOp(typ).FilterSuccessful().SplitByConnReuse()
+		total := len(reused) + len(newConn)
+		if total == 0 {
+			continue
+		}
+		if !header {
+			console.SetColor("Print", color.New(color.FgHiWhite))
+			console.Println("\nConnection reuse:")
+			console.SetColor("Print", color.New(color.FgWhite))
+			header = true
+		}
+		line := fmt.Sprintf(" * %s: %.1f%% of %d requests reused a connection", typ, 100*float64(len(reused))/float64(total), total)
+		if lat := summaryLatencies(newConn); lat != nil {
+			line += fmt.Sprintf(". New: avg: %.01fms, 99%%: %.01fms", lat.Average, lat.P99)
+		}
+		if lat := summaryLatencies(reused); lat != nil {
+			line += fmt.Sprintf(". Reused: avg: %.01fms, 99%%: %.01fms", lat.Average, lat.P99)
+		}
+		console.Println(line)
+	}
+}
+
+// printWireAnalysis prints the bytes sent and received on the wire for each operation type,
+// including request and response headers.
+func printWireAnalysis(o bench.Operations) {
+	header := false
+	for _, typ := range o.OpTypes() {
+		ops := o.FilterByOp(typ)
+		var sent, recv, body int64
+		for _, op := range ops {
+			sent += op.WireSent
+			recv += op.WireRecv
+			body += op.Size
+		}
+		if sent+recv == 0 {
+			continue
+		}
+		if !header {
+			console.SetColor("Print", color.New(color.FgHiWhite))
+			console.Println("\nNetwork usage, including headers:")
+			console.SetColor("Print", color.New(color.FgWhite))
+			header = true
+		}
+		line := fmt.Sprintf(" * %s: Sent %s (%s/op), received %s (%s/op)", typ,
+			humanize.IBytes(uint64(sent)), humanize.IBytes(uint64(sent)/uint64(len(ops))),
+			humanize.IBytes(uint64(recv)), humanize.IBytes(uint64(recv)/uint64(len(ops))))
+		if dur := ops.Duration(); dur > 0 {
+			line += fmt.Sprintf(". %s/s", humanize.IBytes(uint64(float64(sent+recv)/dur.Seconds())))
+		}
+		if overhead := sent + recv - body; overhead > 0 {
+			line += fmt.Sprintf(". Overhead: %s (%.1f%%)", humanize.IBytes(uint64(overhead)), 100*float64(overhead)/float64(sent+recv))
+		}
+		console.Println(line)
+	}
+}
+
+// omissionRate returns the requests per second of each client used for coordinated omission correction.
+// Zero is returned if the rate is unknown.
+func omissionRate(ctx *cli.Context, o bench.Operations) float64 {
+	if rate := ctx.Float64("analyze.co-rate"); rate > 0 {
+		return rate
+	}
+	if total := ctx.Float64("rps-limit.cluster"); total > 0 {
+		if clients := o.Clients(); clients > 0 {
+			return total / float64(clients)
+		}
+	}
+	rate, perOp, err := parseRpsLimit(ctx.String("rps-limit"))
+	if err != nil || len(perOp) > 0 {
+		// Per operation limits do not give a fixed rate per thread.
+		return 0
+	}
+	return rate
+}
+
+// printOmissionAnalysis prints the measured and corrected latency percentiles of each operation type,
+// if the requests were sent at a known rate.
+func printOmissionAnalysis(ctx *cli.Context, o bench.Operations) {
+	rate := omissionRate(ctx, o)
+	if rate <= 0 {
+		return
+	}
+	corrected := o.CorrectOmission(rate)
+	console.SetColor("Print", color.New(color.FgHiWhite))
+	console.Printf("\nLatency corrected for coordinated omission at %.01f requests/s per client:\n", rate)
+	console.SetColor("Print", color.New(color.FgWhite))
+	for _, typ := range o.OpTypes() {
+		measured := summaryLatencies(o.FilterByOp(typ).FilterSuccessful())
+		corr := summaryLatencies(corrected.FilterByOp(typ).FilterSuccessful())
+		if measured == nil || corr == nil {
+			continue
+		}
+		console.Printf(" * %s: measured 50%%: %.01fms, 90%%: %.01fms, 99%%: %.01fms, max: %.01fms. Corrected 50%%: %.01fms, 90%%: %.01fms, 99%%: %.01fms, max: %.01fms.\n",
+			typ, measured.P50, measured.P90, measured.P99, measured.Slowest, corr.P50, corr.P90, corr.P99, corr.Slowest)
+	}
+}
+
+// printStallAnalysis prints the download stalls of each operation type, if any were recorded.
+func printStallAnalysis(o bench.Operations) {
+	header := false
+	for _, typ := range o.OpTypes() {
+		ops := o.FilterByOp(typ)
+		var stalls, stalledOps int
+		var stalled, longest time.Duration
+		for _, op := range ops {
+			if op.Stalls == 0 {
+				continue
+			}
+			stalledOps++
+			stalls += op.Stalls
+			stalled += op.StallTime
+			longest = max(longest, op.StallTime)
+		}
+		if stalls == 0 {
+			continue
+		}
+		if !header {
+			console.SetColor("Print", color.New(color.FgHiWhite))
+			console.Println("\nDownload stalls:")
+			console.SetColor("Print", color.New(color.FgWhite))
+			header = true
+		}
+		console.Printf(" * %s: %d stalls in %d of %d requests (%.2f%%). Total stalled: %v, longest in one request: %v.\n",
+			typ, stalls, stalledOps, len(ops), 100*float64(stalledOps)/float64(len(ops)),
+			stalled.Round(time.Millisecond), longest.Round(time.Millisecond))
+	}
+}
+
+// printNameClassAnalysis prints requests and errors by object name class,
+// if objects with other than plain ascii names were used.
+func printNameClassAnalysis(o bench.Operations) {
+	header := false
+	for _, typ := range o.OpTypes() {
+		type classStats struct {
+			requests, errors int
+			firstErr         string
+		}
+		classes := make(map[string]*classStats)
+		for _, op := range o.FilterByOp(typ) {
+			class := generator.NameClass(op.File)
+			cs := classes[class]
+			if cs == nil {
+				cs = &classStats{}
+				classes[class] = cs
+			}
+			cs.requests++
+			if op.Err != "" {
+				cs.errors++
+				if cs.firstErr == "" {
+					cs.firstErr = op.Err
+				}
+			}
+		}
+		if _, ok := classes[generator.NameASCII]; ok && len(classes) == 1 {
+			continue
+		}
+		if !header {
+			console.SetColor("Print", color.New(color.FgHiWhite))
+			console.Println("\nObject name classes:")
+			console.SetColor("Print", color.New(color.FgWhite))
+			header = true
+		}
+		console.Printf(" * %s:\n", typ)
+		for _, class := range generator.NameClasses {
+			cs := classes[class]
+			if cs == nil {
+				continue
+			}
+			console.Printf("   - %s: %d requests, %d errors (%.2f%%).", class, cs.requests, cs.errors, 100*float64(cs.errors)/float64(cs.requests))
+			if cs.firstErr != "" {
+				console.Printf(" First error: %s", cs.firstErr)
+			}
+			console.Println("")
+		}
+	}
+}
+
+// nullBackendOps returns whether all operations were served by the null backend.
+func nullBackendOps(o bench.Operations) bool {
+	for _, op := range o {
+		u, err := url.Parse(op.Endpoint)
+		if err != nil || u.Host != nulls3.Host {
+			return false
+		}
+	}
+	return len(o) > 0
+}
+
+// printNullBackendCalibration prints the rates measured against the null backend,
+// which are the highest rates the client can reach.
+func printNullBackendCalibration(aggr aggregate.Aggregated) {
+	cores := runtime.GOMAXPROCS(0)
+	console.SetColor("Print", color.New(color.FgHiYellow))
+	console.Println("\nWarning: Benchmark was run against the null backend. Results show the overhead of warp itself.")
+	for _, ops := range aggr.Operations {
+		if ops.Skipped || ops.Throughput.AverageOPS == 0 {
+			continue
+		}
+		clients := max(ops.Clients, 1)
+		console.Printf(" * %s: Max %.2f obj/s per client, %.2f obj/s per core (%d cores, %d threads).\n", ops.Type,
+			ops.Throughput.AverageOPS/float64(clients), ops.Throughput.AverageOPS/float64(clients*cores), cores, ops.Concurrency/clients)
+	}
+	console.Println("Benchmarks against a server reaching a significant part of these rates are limited by the client.")
+}
+
+// failoverWindow is the time before and after endpoint events used to show the impact on requests.
+const failoverWindow = 10 * time.Second
+
+// printFailoverAnalysis prints endpoint exclusion and re-inclusion events
+// and the request latency and errors before, during and after each exclusion.
+func printFailoverAnalysis(o, events bench.Operations) {
+	events.SortByStartTime()
+	console.SetColor("Print", color.New(color.FgHiWhite))
+	console.Printf("\nEndpoint events (%d):\n", len(events))
+	console.SetColor("Print", color.New(color.FgWhite))
+	impact := func(name string, start, end time.Time) string {
+		ops := o.FilterInsideRange(start, end)
+		line := fmt.Sprintf("%s: %d requests", name, len(ops))
+		if lat := summaryLatencies(ops.FilterSuccessful()); lat != nil {
+			line += fmt.Sprintf(", avg: %.01fms, 99%%: %.01fms", lat.Average, lat.P99)
+		}
+		if errs := ops.NErrors(); errs > 0 {
+			line += fmt.Sprintf(", errors: %d", errs)
+		}
+		return line
+	}
+	for _, ev := range events {
+		switch ev.OpType {
+		case bench.EndpointDownOp:
+			console.Printf(" * %s: %s excluded, %v after last successful check: %s\n", ev.End.Format(time.RFC3339), ev.Endpoint, ev.Duration().Round(time.Millisecond), ev.Err)
+			console.Println("   -", impact("Before", ev.Start.Add(-failoverWindow), ev.Start))
+			console.Println("   -", impact("Detection", ev.Start, ev.End))
+			console.Println("   -", impact("After", ev.End, ev.End.Add(failoverWindow)))
+		case bench.EndpointUpOp:
+			console.Printf(" * %s: %s included again after %v\n", ev.End.Format(time.RFC3339), ev.Endpoint, ev.Duration().Round(time.Second))
+		}
+	}
+}
+
+// printAdjustAnalysis prints the requests, throughput and latency of each operation type
+// between changes of the concurrency and request rate of the benchmark.
+func printAdjustAnalysis(o, events bench.Operations) {
+	events.SortByStartTime()
+	_, end := o.TimeRange()
+	console.SetColor("Print", color.New(color.FgHiWhite))
+	console.Printf("\nBenchmark adjustments (%d):\n", len(events))
+	console.SetColor("Print", color.New(color.FgWhite))
+	for i, ev := range events {
+		to := end
+		if i+1 < len(events) {
+			to = events[i+1].Start
+		}
+		rps := ev.Headers[bench.AdjustRPS]
+		if rps == "0" {
+			rps = "unlimited"
+		}
+		console.Printf(" * %s: concurrency: %s, rps: %s\n", ev.Start.Format(time.RFC3339), ev.Headers[bench.AdjustConcurrency], rps)
+		ops := o.FilterInsideRange(ev.Start, to)
+		start, stop := ops.TimeRange()
+		dur := stop.Sub(start)
+		if len(ops) == 0 || dur <= 0 {
+			continue
+		}
+		for _, typ := range ops.OpTypes() {
+			ops := ops.FilterByOp(typ)
+			var bytes int64
+			for _, op := range ops {
+				bytes += op.Size
+			}
+			line := fmt.Sprintf("%s: %d requests, %.02f obj/s, %v", typ, len(ops), float64(len(ops))/dur.Seconds(), bench.Throughput(float64(bytes)/dur.Seconds()))
+			if lat := summaryLatencies(ops.FilterSuccessful()); lat != nil {
+				line += fmt.Sprintf(", avg: %.01fms, 99%%: %.01fms", lat.Average, lat.P99)
+			}
+			if errs := ops.NErrors(); errs > 0 {
+				line += fmt.Sprintf(", errors: %d", errs)
+			}
+			console.Println("   -", line)
+		}
+	}
+}
+
+// printPacingAnalysis prints the request rate achieved compared to the rate limit,
+// and whether a shortfall was caused by threads being busy or by warp starting requests late.
+func printPacingAnalysis(events bench.Operations) {
+	var dur time.Duration
+	var requests, waited int
+	var limitSecs, late float64
+	var lateMax time.Duration
+	for _, ev := range events {
+		d := ev.End.Sub(ev.Start)
+		limit, _ := strconv.ParseFloat(ev.Headers[bench.PacingLimit], 64)
+		w, _ := strconv.Atoi(ev.Headers[bench.PacingWaited])
+		l, _ := strconv.ParseInt(ev.Headers[bench.PacingLateNS], 10, 64)
+		lm, _ := strconv.ParseInt(ev.Headers[bench.PacingLateMaxNS], 10, 64)
+		dur += d
+		limitSecs += limit * d.Seconds()
+		requests += ev.ObjPerOp
+		waited += w
+		late += float64(l)
+		lateMax = max(lateMax, time.Duration(lm))
+	}
+	if dur <= 0 || requests == 0 {
+		return
+	}
+	// Clients record events separately, so the rates are per client on average.
+	clients := events.Clients()
+	limit := limitSecs / dur.Seconds() * float64(clients)
+	achieved := float64(requests) / dur.Seconds() * float64(clients)
+	idle := 100 * float64(requests-waited) / float64(requests)
+	console.SetColor("Print", color.New(color.FgHiWhite))
+	console.Println("\nRequest pacing:")
+	console.SetColor("Print", color.New(color.FgWhite))
+	console.Printf(" * Limit: %.02f requests/s, achieved: %.02f requests/s (%.01f%%).\n", limit, achieved, 100*achieved/limit)
+	if waited > 0 {
+		avg := time.Duration(late / float64(waited))
+		console.Printf(" * Requests started after the limiter allowed them: avg %v, max %v.\n", avg.Round(time.Microsecond), lateMax.Round(time.Microsecond))
+	}
+	console.Printf(" * %.01f%% of requests found no thread waiting when the limiter allowed them.\n", idle)
+	if achieved >= 0.95*limit {
+		return
+	}
+	interval := time.Duration(float64(time.Second) / limit * float64(clients))
+	switch {
+	case idle > 10:
+		console.Println(" * The rate limit was not reached because all threads were busy with requests. Increase --concurrent or check the target system.")
+	case waited > 0 && time.Duration(late/float64(waited)) > interval/10:
+		console.Println(" * The rate limit was not reached because warp started requests late. The client machine may be overloaded.")
+	}
+}
+
+// printTCPStatsAnalysis prints retransmits, round trip time and congestion window of the benchmark connections.
+func printTCPStatsAnalysis(events bench.Operations) {
+	var retrans, segsOut uint64
+	var rttSum, cwndSum float64
+	var sampled int
+	var rttMax uint64
+	for _, ev := range events {
+		r, _ := strconv.ParseUint(ev.Headers[bench.TCPStatsRetrans], 10, 64)
+		s, _ := strconv.ParseUint(ev.Headers[bench.TCPStatsSegsOut], 10, 64)
+		retrans += r
+		segsOut += s
+		if ev.ObjPerOp == 0 {
+			continue
+		}
+		rtt, _ := strconv.ParseUint(ev.Headers[bench.TCPStatsRTTAvg], 10, 64)
+		rm, _ := strconv.ParseUint(ev.Headers[bench.TCPStatsRTTMax], 10, 64)
+		cwnd, _ := strconv.ParseUint(ev.Headers[bench.TCPStatsCwndAvg], 10, 64)
+		rttSum += float64(rtt)
+		cwndSum += float64(cwnd)
+		rttMax = max(rttMax, rm)
+		sampled++
+	}
+	if segsOut == 0 {
+		return
+	}
+	rate := 100 * float64(retrans) / float64(segsOut)
+	console.SetColor("Print", color.New(color.FgHiWhite))
+	console.Println("\nTCP statistics:")
+	console.SetColor("Print", color.New(color.FgWhite))
+	console.Printf(" * Segments sent: %d, retransmitted: %d (%.03f%%).\n", segsOut, retrans, rate)
+	if sampled > 0 {
+		rttAvg := time.Duration(rttSum/float64(sampled)) * time.Microsecond
+		console.Printf(" * Round trip time: avg %v, max %v. Congestion window: avg %.0f segments.\n",
+			rttAvg, time.Duration(rttMax)*time.Microsecond, cwndSum/float64(sampled))
+	}
+	if rate > 1 {
+		console.Println(" * More than 1% of segments were retransmitted. Check the network between warp and the target for packet loss.")
+	}
+}
+
 func writeSegs(ctx *cli.Context, wrSegs io.Writer, ops bench.Operations, allThreads, details bool) {
 	if wrSegs == nil {
 		return
@@ -411,6 +1465,7 @@ func writeSegs(ctx *cli.Context, wrSegs io.Writer, ops bench.Operations, allThre
 		From:           time.Time{},
 		PerSegDuration: aDur,
 		AllThreads:     allThreads && !ops.HasError(),
+		Align:          ctx.Bool("analyze.align"),
 	})
 	if len(segs) == 0 {
 		return
@@ -486,6 +1541,9 @@ func printRequestAnalysis(_ *cli.Context, ops aggregate.Operation, details bool)
 		if reqs.FirstByte != nil {
 			console.Println(" * TTFB:", reqs.FirstByte)
 		}
+		if details && reqs.SlowestRequestID != "" {
+			console.Println(" * Slowest request ID:", reqs.SlowestRequestID)
+		}
 
 		if details && reqs.FirstAccess != nil {
 			reqs := reqs.FirstAccess
@@ -644,6 +1702,25 @@ func checkAnalyze(ctx *cli.Context) {
 		err := errors.New("-analyze.dur cannot be 0")
 		fatal(probe.NewError(err), "Invalid -analyze.dur value")
 	}
+	if _, err := sizeBuckets(ctx); err != nil {
+		fatal(probe.NewError(err), "Invalid -analyze.size-buckets value")
+	}
+	if ctx.Float64("analyze.co-rate") < 0 {
+		err := errors.New("-analyze.co-rate cannot be negative")
+		fatal(probe.NewError(err), "Invalid -analyze.co-rate value")
+	}
+	if t := ctx.Float64("analyze.downtime-threshold"); t < 0 || t >= 1 {
+		err := errors.New("-analyze.downtime-threshold must be at least 0 and less than 1")
+		fatal(probe.NewError(err), "Invalid -analyze.downtime-threshold value")
+	}
+	thresholds, err := latencyThresholds(ctx)
+	if err != nil {
+		fatal(probe.NewError(err), "Invalid -analyze.thresholds value")
+	}
+	if ctx.String("analyze.thresholds-out") != "" && len(thresholds) == 0 {
+		err := errors.New("-analyze.thresholds-out requires -analyze.thresholds")
+		fatal(probe.NewError(err), "Invalid -analyze.thresholds-out value")
+	}
 }
 
 // stringKeysSorted returns the keys as a sorted string slice.
@@ -655,3 +1732,79 @@ func stringKeysSorted[K string, V any](m map[K]V) []string {
 	sort.Strings(keys)
 	return keys
 }
+
+// printAvailability prints the availability of all requests.
+// With details every downtime period is printed.
+func printAvailability(a *aggregate.Availability, details bool) {
+	if a == nil {
+		return
+	}
+	millis := func(n int) time.Duration {
+		return time.Duration(n) * time.Millisecond
+	}
+	console.SetColor("Print", color.New(color.FgHiWhite))
+	console.Println("\nAvailability:")
+	console.SetColor("Print", color.New(color.FgWhite))
+	if a.Errors == 0 {
+		console.Printf(" * All %d requests successful.\n", a.Requests)
+		return
+	}
+	console.Printf(" * Requests: %.3f%% successful (%d errors of %d requests). Time available: %.3f%%.\n",
+		100*a.SuccessRatio, a.Errors, a.Requests, 100*a.TimeAvailability)
+	if a.TimeToFirstErrorMillis != nil {
+		console.Printf(" * First error after %v. Longest error free period: %v from %s.\n",
+			millis(*a.TimeToFirstErrorMillis), millis(a.LongestErrorFreeMillis), a.LongestErrorFreeStart.Format("15:04:05"))
+	}
+	console.Printf(" * Lowest %v segment: %.3f%% successful at %s.\n",
+		millis(a.WindowMillis), 100*a.MinWindowSuccessRatio, a.MinWindowStart.Format("15:04:05"))
+	if len(a.Downtime) == 0 {
+		console.Printf(" * No downtime (error rate above %.1f%%).\n", 100*a.DowntimeThreshold)
+		return
+	}
+	console.Printf(" * Downtime (error rate above %.1f%%): %d periods, total %v.\n",
+		100*a.DowntimeThreshold, len(a.Downtime), millis(a.DowntimeMillis))
+	if !details {
+		return
+	}
+	for _, d := range a.Downtime {
+		console.Printf("   - %s-%s (%v): %d errors of %d requests.\n",
+			d.Start.Format("15:04:05"), d.End.Format("15:04:05"), millis(d.DurationMillis), d.Errors, d.Requests)
+	}
+}
+
+// printErrorTimeline prints the segments with errors.
+// Without details only the segment with the highest error rate is printed.
+func printErrorTimeline(t *aggregate.ErrorTimeline, details bool) {
+	if t == nil || globalJSON {
+		return
+	}
+	segLine := func(seg aggregate.ErrorSegment) string {
+		classes := make([]string, 0, len(seg.ByClass))
+		for _, class := range stringKeysSorted(seg.ByClass) {
+			classes = append(classes, fmt.Sprintf("%s: %d", class, seg.ByClass[class]))
+		}
+		return fmt.Sprintf("%s: %d errors of %d requests (%.1f%%) - %s. Avg latency: %.1fms",
+			seg.Start.Round(time.Second).Format("15:04:05"), seg.Errors, seg.Requests, 100*seg.ErrorRate(),
+			strings.Join(classes, ", "), seg.AvgLatencyMillis)
+	}
+	console.SetColor("Print", color.New(color.FgWhite))
+	if !details {
+		var peak *aggregate.ErrorSegment
+		for i := range t.Segments {
+			if seg := &t.Segments[i]; seg.Errors > 0 && (peak == nil || seg.ErrorRate() > peak.ErrorRate()) {
+				peak = seg
+			}
+		}
+		if peak != nil {
+			console.Println("Peak error rate:", segLine(*peak))
+		}
+		return
+	}
+	console.Printf("Errors over time (%v segments):\n", time.Duration(t.SegmentDurationMillis)*time.Millisecond)
+	for _, seg := range t.Segments {
+		if seg.Errors > 0 {
+			console.Println(" *", segLine(seg))
+		}
+	}
+	console.Println("")
+}
@@ -0,0 +1,367 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/aggregate"
+	"github.com/minio/warp/pkg/bench"
+)
+
+var baselineFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "baseline.dir",
+		Value: "warp-baselines",
+		Usage: "Directory where baselines are stored.",
+	},
+	cli.StringFlag{
+		Name:  "baseline.name",
+		Usage: "Name of the baseline. By default baselines are named by a hash of the benchmark parameters.",
+	},
+}
+
+var baselineCompareFlags = []cli.Flag{
+	cli.Float64Flag{
+		Name:  "baseline.max-regression",
+		Value: 10,
+		Usage: "Fail if throughput of any operation is this many percent below the baseline.",
+	},
+}
+
+var baselineCmd = cli.Command{
+	Name:  "baseline",
+	Usage: "save and compare benchmark baselines",
+	Subcommands: []cli.Command{
+		{
+			Name:   "save",
+			Usage:  "save benchmark data as baseline",
+			Action: mainBaselineSave,
+			Before: setGlobalsFromContext,
+			Flags:  combineFlags(globalFlags, analyzeFlags, baselineFlags, inputFlags),
+			CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] benchmark-data-file
+  -> see https://github.com/minio/warp#baselines
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+		},
+		{
+			Name:   "compare",
+			Usage:  "compare benchmark data to the matching baseline",
+			Action: mainBaselineCompare,
+			Before: setGlobalsFromContext,
+			Flags:  combineFlags(globalFlags, analyzeFlags, baselineFlags, baselineCompareFlags, inputFlags),
+			CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] benchmark-data-file
+  -> see https://github.com/minio/warp#baselines
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+		},
+	},
+}
+
+// baselineConfig contains the benchmark parameters that must match
+// for results to be compared.
+// The parameters are the benchmark and its configured flags from the run manifest.
+type baselineConfig struct {
+	Benchmark string              `json:"benchmark"`
+	Flags     map[string]string   `json:"flags"`
+	FlagLists map[string][]string `json:"flag_lists,omitempty"`
+}
+
+// baselineSkipFlags are flags that do not change the workload
+// and are left out of the baseline parameters.
+var baselineSkipFlags = map[string]bool{
+	"benchdata":           true,
+	"syncstart":           true,
+	"serverprof":          true,
+	"noclear":             true,
+	"keep-data":           true,
+	"tag":                 true,
+	"summary-file":        true,
+	"html":                true,
+	"record":              true,
+	"record-headers":      true,
+	"op-sample":           true,
+	"run-id":              true,
+	"run-id.header":       true,
+	"op-id.header":        true,
+	"warp-client.version": true,
+}
+
+// baselineSkipPrefixes are prefixes of flags left out of the baseline parameters.
+var baselineSkipPrefixes = []string{"analyze", "cleanup.", "daemon", "hook.", "kafka", "results"}
+
+// baselineFlag returns whether the flag is part of the baseline parameters.
+func baselineFlag(name string) bool {
+	if baselineSkipFlags[name] {
+		return false
+	}
+	for _, p := range baselineSkipPrefixes {
+		if strings.HasPrefix(name, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// baseline is a stored baseline.
+type baseline struct {
+	Name    string               `json:"name"`
+	Config  baselineConfig       `json:"config"`
+	Saved   time.Time            `json:"saved"`
+	Source  string               `json:"source"`
+	Tags    map[string]string    `json:"tags,omitempty"`
+	Results aggregate.Aggregated `json:"results"`
+}
+
+// newBaselineConfig returns the parameters of a benchmark run from its manifest.
+func newBaselineConfig(m *runManifest) baselineConfig {
+	c := baselineConfig{
+		Benchmark: m.Benchmark,
+		Flags:     make(map[string]string, len(m.Flags)),
+	}
+	for k, v := range m.Flags {
+		if baselineFlag(k) {
+			c.Flags[k] = v
+		}
+	}
+	for k, v := range m.FlagLists {
+		if !baselineFlag(k) {
+			continue
+		}
+		if c.FlagLists == nil {
+			c.FlagLists = make(map[string][]string)
+		}
+		c.FlagLists[k] = v
+	}
+	return c
+}
+
+// hash returns a hash of the configuration.
+func (c baselineConfig) hash() string {
+	b, _ := json.Marshal(c)
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:8])
+}
+
+// baselineFile returns the file name of the baseline.
+func baselineFile(ctx *cli.Context, c baselineConfig) (name, file string) {
+	name = ctx.String("baseline.name")
+	if name == "" {
+		name = c.hash()
+	}
+	return name, filepath.Join(ctx.String("baseline.dir"), name+".json")
+}
+
+// readBaselineInput reads the benchmark data file given as argument
+// and returns the operations, tags and parameters of the run.
+func readBaselineInput(ctx *cli.Context) (bench.Operations, bench.Tags, baselineConfig) {
+	if ctx.NArg() != 1 {
+		console.Fatal("One benchmark data file must be supplied")
+	}
+	checkAnalyze(ctx)
+	m, err := readManifest(ctx, ctx.Args().First())
+	if errors.Is(err, errNoManifest) {
+		console.Fatal("Benchmark data has no run manifest, so the benchmark parameters are unknown")
+	}
+	fatalIf(probe.NewError(err), "Unable to read run manifest")
+	ops, tags, err := readInput(ctx, ctx.Args().First())
+	fatalIf(probe.NewError(err), "Unable to read input")
+	if len(ops) == 0 {
+		console.Fatal("No operations found in input")
+	}
+	return ops, tags, newBaselineConfig(m)
+}
+
+// aggregateBaseline returns the aggregated results of ops.
+func aggregateBaseline(ctx *cli.Context, ops bench.Operations) aggregate.Aggregated {
+	return aggregate.Aggregate(ops, aggregate.Options{
+		DurFunc: func(total time.Duration) time.Duration {
+			if total <= 0 {
+				return 0
+			}
+			return analysisDur(ctx, total)
+		},
+		SkipDur: ctx.Duration("analyze.skip"),
+	})
+}
+
+// mainBaselineSave is the entry point for the baseline save command.
+func mainBaselineSave(ctx *cli.Context) error {
+	ops, tags, cfg := readBaselineInput(ctx)
+	b := baseline{
+		Config: cfg,
+		Saved:  time.Now().UTC(),
+		Source: ctx.Args().First(),
+		Tags:   tags,
+	}
+	var file string
+	b.Name, file = baselineFile(ctx, b.Config)
+	b.Results = aggregateBaseline(ctx, ops)
+	b.Results.Tags = tags
+
+	data, err := json.MarshalIndent(b, "", "  ")
+	fatalIf(probe.NewError(err), "Unable to marshal baseline")
+	err = os.MkdirAll(ctx.String("baseline.dir"), 0o755)
+	fatalIf(probe.NewError(err), "Unable to create baseline directory")
+	err = os.WriteFile(file, data, 0o644)
+	fatalIf(probe.NewError(err), "Unable to write baseline")
+	console.Println("Baseline", b.Name, "saved to", file)
+	return nil
+}
+
+// mainBaselineCompare is the entry point for the baseline compare command.
+func mainBaselineCompare(ctx *cli.Context) error {
+	ops, _, cfg := readBaselineInput(ctx)
+	name, file := baselineFile(ctx, cfg)
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		console.Fatalf("No baseline %q found for the benchmark parameters in %s\n", name, ctx.String("baseline.dir"))
+	}
+	fatalIf(probe.NewError(err), "Unable to read baseline")
+	var b baseline
+	err = json.Unmarshal(data, &b)
+	fatalIf(probe.NewError(err), "Unable to parse baseline")
+	if !reflect.DeepEqual(b.Config, cfg) {
+		console.Errorln("Benchmark parameters do not match baseline", name)
+		printBaselineConfigDiff(b.Config, cfg)
+		console.Fatal("Refusing to compare runs with different parameters")
+	}
+
+	after := aggregateBaseline(ctx, ops)
+	before := make(map[string]aggregate.Operation, len(b.Results.Operations))
+	for _, op := range b.Results.Operations {
+		before[op.Type] = op
+	}
+	console.SetColor("Print", color.New(color.FgWhite))
+	console.Printf("Comparing to baseline %s, saved %s from %s\n", b.Name, b.Saved.Format(time.RFC3339), b.Source)
+	maxRegression := ctx.Float64("baseline.max-regression")
+	var regressions []string
+	for _, a := range after.Operations {
+		bop, ok := before[a.Type]
+		if !ok || bop.Skipped || a.Skipped {
+			console.Println("Operation:", a.Type, "- skipped, not enough data.")
+			continue
+		}
+		console.SetColor("Print", color.New(color.FgHiWhite))
+		console.Println("-------------------")
+		console.Println("Operation:", a.Type)
+		console.SetColor("Print", color.New(color.FgWhite))
+		if bop.Errors+a.Errors > 0 {
+			console.Println("Errors:", bop.Errors, "->", a.Errors)
+		}
+		change := pctChange(bop.Throughput.AverageOPS, a.Throughput.AverageOPS)
+		if bop.Throughput.AverageBPS > 0 {
+			console.Printf("* Average: %.02f MiB/s -> %.02f MiB/s (%+.01f%%)\n", bop.Throughput.AverageBPS/(1<<20), a.Throughput.AverageBPS/(1<<20), pctChange(bop.Throughput.AverageBPS, a.Throughput.AverageBPS))
+		}
+		console.Printf("* Average: %.02f obj/s -> %.02f obj/s (%+.01f%%)\n", bop.Throughput.AverageOPS, a.Throughput.AverageOPS, change)
+		if bop.SingleSizedRequests != nil && a.SingleSizedRequests != nil && !bop.SingleSizedRequests.Skipped && !a.SingleSizedRequests.Skipped {
+			br, ar := bop.SingleSizedRequests, a.SingleSizedRequests
+			console.Printf("* Requests: Avg: %dms -> %dms, 50%%: %dms -> %dms, 90%%: %dms -> %dms, 99%%: %dms -> %dms\n",
+				br.DurAvgMillis, ar.DurAvgMillis, br.DurMedianMillis, ar.DurMedianMillis, br.Dur90Millis, ar.Dur90Millis, br.Dur99Millis, ar.Dur99Millis)
+		}
+		if maxRegression > 0 && -change > maxRegression {
+			regressions = append(regressions, fmt.Sprintf("%s: %.01f%% slower", a.Type, -change))
+		}
+	}
+	for typ := range before {
+		found := false
+		for _, a := range after.Operations {
+			found = found || a.Type == typ
+		}
+		if !found {
+			regressions = append(regressions, fmt.Sprintf("%s: not found", typ))
+		}
+	}
+	if len(regressions) > 0 {
+		console.Errorln("Regressions compared to baseline:")
+		for _, r := range regressions {
+			console.Errorln(" *", r)
+		}
+		console.Fatal("Performance regression detected")
+	}
+	console.SetColor("Print", color.New(color.FgHiGreen))
+	console.Println("\nNo regressions compared to baseline.")
+	return nil
+}
+
+// printBaselineConfigDiff prints the parameters that differ.
+func printBaselineConfigDiff(before, after baselineConfig) {
+	if before.Benchmark != after.Benchmark {
+		console.Errorln(fmt.Sprintf(" * benchmark: %v -> %v", before.Benchmark, after.Benchmark))
+	}
+	names := make(map[string]struct{}, len(after.Flags))
+	for k := range before.Flags {
+		names[k] = struct{}{}
+	}
+	for k := range after.Flags {
+		names[k] = struct{}{}
+	}
+	for k := range before.FlagLists {
+		names[k] = struct{}{}
+	}
+	for k := range after.FlagLists {
+		names[k] = struct{}{}
+	}
+	sorted := make([]string, 0, len(names))
+	for k := range names {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+	for _, k := range sorted {
+		if b, a := before.Flags[k], after.Flags[k]; b != a {
+			console.Errorln(fmt.Sprintf(" * --%s: %q -> %q", k, b, a))
+		}
+		if b, a := before.FlagLists[k], after.FlagLists[k]; !reflect.DeepEqual(b, a) {
+			console.Errorln(fmt.Sprintf(" * --%s: %v -> %v", k, b, a))
+		}
+	}
+}
+
+// pctChange returns the change from before to after in percent.
+func pctChange(before, after float64) float64 {
+	if before == 0 {
+		return 0
+	}
+	return 100 * (after - before) / before
+}
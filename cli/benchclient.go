@@ -23,13 +23,17 @@ import (
 	"flag"
 	"fmt"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
+	"github.com/dustin/go-humanize"
 	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
 	"github.com/minio/pkg/v2/console"
 	"github.com/minio/warp/pkg/bench"
 	"github.com/minio/websocket"
+	"golang.org/x/time/rate"
 )
 
 // clientReplyType indicates the client reply type.
@@ -39,6 +43,8 @@ const (
 	clientRespBenchmarkStarted clientReplyType = "benchmark_started"
 	clientRespStatus           clientReplyType = "benchmark_status"
 	clientRespOps              clientReplyType = "ops"
+	clientRespUpgradeReady     clientReplyType = "upgrade_ready"
+	clientRespUpgraded         clientReplyType = "upgraded"
 )
 
 // clientReply contains the response to a server request.
@@ -49,12 +55,21 @@ type clientReply struct {
 		Progress float64           `json:"progress"`
 		Started  bool              `json:"started"`
 		Finished bool              `json:"finished"`
+		// Requests allowed by the rate limiter.
+		Requests int64 `json:"requests,omitempty"`
+		// Bytes transferred through the bandwidth limiter.
+		Bytes int64 `json:"bytes,omitempty"`
 	} `json:"stage_info"`
 	Type clientReplyType  `json:"type"`
 	Err  string           `json:"err,omitempty"`
 	Ops  bench.Operations `json:"ops,omitempty"`
+	// Build is sent when accepting a connection.
+	Build *warpBuild `json:"build,omitempty"`
 }
 
+// clientSeedStep separates the seeds of clients in a distributed benchmark.
+const clientSeedStep = 0x2545f4914f6cdd1d
+
 // executeBenchmark will execute the benchmark and return any error.
 func (s serverRequest) executeBenchmark(ctx context.Context) (*clientBenchmark, error) {
 	// Reconstruct
@@ -76,6 +91,12 @@ func (s serverRequest) executeBenchmark(ctx context.Context) (*clientBenchmark,
 			return nil, err
 		}
 	}
+	if seed := ctx2.Int64("seed"); seed != 0 && s.ClientIdx > 0 {
+		// Clients must not generate the same objects.
+		if err := ctx2.Set("seed", strconv.FormatInt(seed+int64(s.ClientIdx)*clientSeedStep, 10)); err != nil {
+			return nil, err
+		}
+	}
 	var cb clientBenchmark
 	cb.init(ctx)
 	cb.clientIdx = s.ClientIdx
@@ -160,11 +181,13 @@ func serveWs(w http.ResponseWriter, r *http.Request) {
 	}()
 
 	// Confirm the connection
-	err = ws.WriteJSON(clientReply{Time: time.Now()})
+	build := currentBuild()
+	err = ws.WriteJSON(clientReply{Time: time.Now(), Build: &build})
 	if err != nil {
 		console.Error("Writing response:", err)
 		return
 	}
+	restart := false
 	for {
 		var req serverRequest
 		err := ws.ReadJSON(&req)
@@ -223,6 +246,7 @@ func serveWs(w http.ResponseWriter, r *http.Request) {
 				break
 			}
 			info.startRequested = true
+			info.duration = req.Duration
 			ab.Lock()
 			ab.info[req.Stage] = info
 			ab.Unlock()
@@ -264,12 +288,52 @@ func serveWs(w http.ResponseWriter, r *http.Request) {
 				resp.StageInfo.Started = true
 			default:
 			}
+			ab.Lock()
+			if ab.common != nil && ab.common.RpsRequests != nil {
+				resp.StageInfo.Requests = ab.common.RpsRequests.Load()
+			}
+			if ab.common != nil && ab.common.BwLimiter != nil {
+				resp.StageInfo.Bytes = ab.common.BwLimiter.Bytes()
+			}
+			ab.Unlock()
 			select {
 			case <-info.done:
 				resp.StageInfo.Finished = true
 				resp.StageInfo.Custom = info.custom
 			default:
 			}
+		case serverReqSetRate:
+			activeBenchmarkMu.Lock()
+			ab := activeBenchmark
+			activeBenchmarkMu.Unlock()
+			if ab == nil {
+				resp.Err = "no benchmark running"
+				break
+			}
+			resp.Type = clientRespStatus
+			ab.Lock()
+			common := ab.common
+			ab.Unlock()
+			if req.RpsLimit > 0 {
+				if common == nil || common.RpsLimiter == nil {
+					resp.Err = "benchmark is not rate limited"
+					break
+				}
+				if globalDebug {
+					console.Infof("Setting rate limit to %.02f requests/s\n", req.RpsLimit)
+				}
+				common.RpsLimiter.SetLimit(rate.Limit(req.RpsLimit))
+			}
+			if req.BwLimit > 0 {
+				if common == nil || common.BwLimiter == nil {
+					resp.Err = "benchmark is not bandwidth limited"
+					break
+				}
+				if globalDebug {
+					console.Infof("Setting bandwidth limit to %s/s\n", humanize.IBytes(uint64(req.BwLimit)))
+				}
+				common.BwLimiter.SetLimit(req.BwLimit)
+			}
 		case serverReqSendOps:
 			activeBenchmarkMu.Lock()
 			ab := activeBenchmark
@@ -282,6 +346,20 @@ func serveWs(w http.ResponseWriter, r *http.Request) {
 			ab.Lock()
 			resp.Ops = ab.results
 			ab.Unlock()
+		case serverReqUpgrade:
+			activeBenchmarkMu.Lock()
+			ab := activeBenchmark
+			activeBenchmarkMu.Unlock()
+			if ab != nil {
+				ab.cancel()
+			}
+			if err := receiveUpgrade(ws, req.Upgrade); err != nil {
+				console.Errorln("Upgrade:", err)
+				resp.Err = err.Error()
+				break
+			}
+			resp.Type = clientRespUpgraded
+			restart = true
 		default:
 			resp.Err = "unknown command"
 		}
@@ -294,6 +372,11 @@ func serveWs(w http.ResponseWriter, r *http.Request) {
 			console.Error("Writing response:", err)
 			return
 		}
+		if restart {
+			console.Infoln("Restarting with upgraded binary")
+			ws.Close()
+			fatalIf(probe.NewError(restartClient()), "Unable to restart client")
+		}
 	}
 }
 
@@ -25,12 +25,12 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/cheggaaa/pb"
-	"github.com/klauspost/compress/zstd"
 	"github.com/minio/cli"
 	"github.com/minio/madmin-go/v3"
 	"github.com/minio/mc/pkg/probe"
@@ -78,6 +78,22 @@ var benchFlags = []cli.Flag{
 		Usage:  "Leave benchmark data. Do not run cleanup after benchmark. Bucket will still be cleaned prior to benchmark",
 		Hidden: true,
 	},
+	cli.StringSliceFlag{
+		Name:  "warp-client.group",
+		Usage: "Run a different benchmark on a group of clients. Format: 'host1,host2=command --flag=value'. Can be used multiple times.",
+	},
+	cli.DurationFlag{
+		Name:  "stagger.start",
+		Usage: "When running with --warp-client, start each client this long after the previous one.",
+	},
+	cli.DurationFlag{
+		Name:  "stagger.stop",
+		Usage: "When running with --warp-client, stop clients one by one with this interval, in reverse start order.",
+	},
+	cli.StringFlag{
+		Name:  "spool",
+		Usage: "Write operations to files per thread in a directory inside this directory while running, and merge them when done. Files are kept if warp stops.",
+	},
 	cli.StringFlag{
 		Name:  "syncstart",
 		Usage: "Specify a benchmark start time. Time format is 'hh:mm' where hours are specified in 24h format, server TZ.",
@@ -89,15 +105,132 @@ var benchFlags = []cli.Flag{
 		EnvVar: "",
 		Value:  "",
 	},
+	cli.StringFlag{
+		Name:  "warp-client.version",
+		Usage: "Action when warp clients run a different version. Can be 'warn', 'refuse' or 'upgrade'",
+		Value: clientVersionWarn,
+	},
+	cli.StringSliceFlag{
+		Name:  "tag",
+		Usage: "Add 'key=value' tag to benchmark output. Can be specified multiple times.",
+	},
+	cli.IntFlag{
+		Name:  "cleanup.concurrent",
+		Usage: "Number of concurrent delete requests when clearing the bucket. 0 deletes in a single stream.",
+	},
+	cli.IntFlag{
+		Name:  "cleanup.batch",
+		Value: 1000,
+		Usage: "Number of objects to delete in each request when clearing the bucket. Max 1000.",
+	},
+	cli.Float64Flag{
+		Name:  "cleanup.rate",
+		Usage: "Maximum number of objects deleted per second when clearing the bucket. 0 is unlimited.",
+	},
+	cli.StringFlag{
+		Name:  "cleanup.at",
+		Usage: "Wait until this time before clearing the bucket after the benchmark. Time format is 'hh:mm' where hours are specified in 24h format.",
+	},
+	cli.BoolFlag{
+		Name:  "cleanup.delete-bucket",
+		Usage: "Delete the bucket after cleanup.",
+	},
+	cli.StringFlag{
+		Name:  "results-bucket",
+		Usage: "Upload benchmark data and analysis to this bucket when the benchmark has completed.",
+	},
+	cli.StringFlag{
+		Name:  "results-prefix",
+		Usage: "Prefix of uploaded benchmark data and analysis.",
+	},
+	cli.StringFlag{
+		Name:  "results.compression",
+		Usage: "Compression of benchmark data. Can be zstd, s2 or none",
+		Value: "zstd",
+	},
+	cli.IntFlag{
+		Name:  "results.compression-level",
+		Usage: "Compression level of benchmark data. 1-22 for zstd, 1-3 for s2. Default is a balanced level",
+	},
+	cli.StringFlag{
+		Name:   "results.key",
+		Usage:  "Encrypt benchmark data with this 32 byte key, given as hex, base64 or 'file:path'",
+		EnvVar: appNameUC + "_RESULTS_KEY",
+	},
+	cli.IntFlag{
+		Name:  "results.keep",
+		Usage: "Keep results of this number of runs in the daemon directory and the results bucket. Older results are removed.",
+	},
+	cli.StringFlag{
+		Name:  "results.max-size",
+		Usage: "Remove the oldest results in the daemon directory and the results bucket when their total size exceeds this, eg. 10GiB.",
+	},
+	cli.DurationFlag{
+		Name:  "results.max-age",
+		Usage: "Remove results older than this from the daemon directory and the results bucket, eg. 168h.",
+	},
+	cli.StringFlag{
+		Name:  "summary-file",
+		Usage: "Write a JSON summary of the run to this file. Use '-' to write to stdout and send other output to stderr.",
+	},
+	cli.BoolFlag{
+		Name:  "daemon",
+		Usage: "Run the benchmark repeatedly until stopped and keep track of the results.",
+	},
+	cli.DurationFlag{
+		Name:  "daemon.interval",
+		Usage: "Time between the start of each benchmark run in daemon mode. 0 will start the next run when the previous has completed.",
+	},
+	cli.StringFlag{
+		Name:  "daemon.dir",
+		Value: "warp-daemon",
+		Usage: "Directory where benchmark data and trends are stored in daemon mode.",
+	},
+	cli.IntFlag{
+		Name:  "daemon.keep",
+		Value: 24,
+		Usage: "Number of runs to keep benchmark data and trends for in daemon mode.",
+	},
+	cli.StringFlag{
+		Name:  "daemon.listen",
+		Usage: "Serve trend metrics on this address in daemon mode, eg. ':7762'.",
+	},
+	cli.StringFlag{
+		Name:  "hook.pre-prepare",
+		Usage: "Command to execute before preparing the benchmark.",
+	},
+	cli.StringFlag{
+		Name:  "hook.pre-run",
+		Usage: "Command to execute after preparing, before running the benchmark.",
+	},
+	cli.StringFlag{
+		Name:  "hook.post-run",
+		Usage: "Command to execute after the benchmark has run and data has been saved.",
+	},
+	cli.DurationFlag{
+		Name:  "hook.timeout",
+		Usage: "Maximum time a hook command may run.",
+		Value: 5 * time.Minute,
+	},
 }
 
-// runBench will run the supplied benchmark and save/print the analysis.
-func runBench(ctx *cli.Context, b bench.Benchmark) error {
+// runBench will create the benchmark with newBench, run it and save/print the analysis.
+// newBench may be called again to create the benchmark for later runs,
+// or with another context to create the benchmark for a second endpoint.
+func runBench(ctx *cli.Context, newBench func(ctx *cli.Context) bench.Benchmark) error {
+	create := func() bench.Benchmark {
+		b := newBench(ctx)
+		if ctx.String("host.b") != "" {
+			b = newDualBenchmark(ctx, b, newBench)
+		}
+		b.GetCommon().Error = printError
+		return b
+	}
+	b := create()
 	defer globalWG.Wait()
 	activeBenchmarkMu.Lock()
 	ab := activeBenchmark
 	activeBenchmarkMu.Unlock()
-	b.GetCommon().Error = printError
 	if ab != nil {
 		b.GetCommon().ClientIdx = ab.clientIdx
 		return runClientBenchmark(ctx, b, ab)
@@ -106,14 +239,33 @@ func runBench(ctx *cli.Context, b bench.Benchmark) error {
 		fatalIf(probe.NewError(err), "Error running remote benchmark")
 		return nil
 	}
+	if ctx.Bool("daemon") {
+		return runDaemon(ctx, b, create)
+	}
+	_, _, err := runLocalBench(ctx, b, ctx.String("benchdata"))
+	fatalIf(probe.NewError(err), "Error running benchmark")
+	return nil
+}
 
+// runLocalBench will run the benchmark on this machine and save/print the analysis.
+// If fileName is empty a file name is generated.
+// Returns the operations and the file name used, without extension.
+func runLocalBench(ctx *cli.Context, b bench.Benchmark, fileName string) (bench.Operations, string, error) {
 	monitor := api.NewBenchmarkMonitor(ctx.String(serverFlagName))
 	monitor.SetLnLoggers(printInfo, printError)
 	defer monitor.Done()
+	c := b.GetCommon()
+	defer stopHealth(b)
+
+	err := runHook(ctx, hookPrePrepare)
+	if err != nil {
+		return nil, "", fmt.Errorf("pre-prepare hook failed: %w", err)
+	}
 
+	var stages stageTimes
+	prepareStart := time.Now()
 	monitor.InfoLn("Preparing server.")
 	pgDone := make(chan struct{})
-	c := b.GetCommon()
 	c.Clear = !ctx.Bool("noclear")
 	if ctx.Bool("autoterm") {
 		// TODO: autoterm cannot be used when in client/server mode
@@ -127,7 +279,7 @@ func runBench(ctx *cli.Context, b bench.Benchmark) error {
 		pg.ShowCounters = false
 		pg.ShowElapsedTime = false
 		pg.ShowSpeed = false
-		pg.ShowTimeLeft = false
+		pg.ShowTimeLeft = true
 		pg.ShowFinalTime = true
 		go func() {
 			defer close(pgDone)
@@ -145,7 +297,12 @@ func runBench(ctx *cli.Context, b bench.Benchmark) error {
 						pg.Set64(newVal)
 						pg.Update()
 					}
-					monitor.InfoQuietln(fmt.Sprintf("Preparation: %0.0f%% done...", float64(newVal)/float64(100)))
+					done := float64(newVal) / pgScale
+					if left := timeLeft(time.Since(prepareStart), done); left > 0 {
+						monitor.InfoQuietln(fmt.Sprintf("Preparation: %0.0f%% done, %v left...", 100*done, left.Round(time.Second)))
+					} else {
+						monitor.InfoQuietln(fmt.Sprintf("Preparation: %0.0f%% done...", 100*done))
+					}
 				case pct, ok := <-c.PrepareProgress:
 					if !ok {
 						pg.Set64(pgScale)
@@ -162,16 +319,26 @@ func runBench(ctx *cli.Context, b bench.Benchmark) error {
 		close(pgDone)
 	}
 
-	err := b.Prepare(context.Background())
-	fatalIf(probe.NewError(err), "Error preparing server")
+	err = b.Prepare(context.Background())
 	if c.PrepareProgress != nil {
 		close(c.PrepareProgress)
 		<-pgDone
 	}
+	if err != nil {
+		return nil, "", fmt.Errorf("error preparing server: %w", err)
+	}
 
 	if ap, ok := b.(AfterPreparer); ok {
 		err := ap.AfterPrepare(context.Background())
-		fatalIf(probe.NewError(err), "Error preparing server")
+		if err != nil {
+			return nil, "", fmt.Errorf("error preparing server: %w", err)
+		}
+	}
+	stages.Prepare = time.Since(prepareStart)
+	prepareDone := time.Now()
+	err = runHook(ctx, hookPreRun)
+	if err != nil {
+		return nil, "", fmt.Errorf("pre-run hook failed: %w", err)
 	}
 
 	// Start after waiting a second or until we reached the start time.
@@ -197,14 +364,15 @@ func runBench(ctx *cli.Context, b bench.Benchmark) error {
 		close(start)
 	}()
 
-	fileName := ctx.String("benchdata")
 	cID := pRandASCII(4)
 	if fileName == "" {
 		fileName = fmt.Sprintf("%s-%s-%s-%s", appName, ctx.Command.Name, time.Now().Format("2006-01-02[150405]"), cID)
 	}
 
 	prof, err := startProfiling(ctx2, ctx)
-	fatalIf(probe.NewError(err), "Unable to start profile.")
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to start profile: %w", err)
+	}
 	monitor.InfoLn("Starting benchmark in ", time.Until(tStart).Round(time.Second), "...")
 	pgDone = make(chan struct{})
 	if !globalQuiet && !globalJSON {
@@ -236,43 +404,127 @@ func runBench(ctx *cli.Context, b bench.Benchmark) error {
 	} else {
 		close(pgDone)
 	}
+	stopAdjust := startAdjust(ctx, c, monitor)
 	ops, _ := b.Start(ctx2, start)
+	stopAdjust()
+	stages.Benchmark = time.Since(tStart)
 	cancel()
 	<-pgDone
 
 	// Previous context is canceled, create a new...
 	monitor.InfoLn("Saving benchmark data...")
 	ctx2 = context.Background()
+	ops.MarkPrepare(prepareDone)
 	ops.SortByStartTime()
 	ops.SetClientID(cID)
 	prof.stop(ctx2, ctx, fileName+".profiles.zip")
 
-	if len(ops) > 0 {
-		f, err := os.Create(fileName + ".csv.zst")
+	if c.Collector.Spooled() {
+		// Operations are merged from the spool files into the results
+		// and read back for analysis.
+		ops, err = writeSpooledResults(ctx, c.Collector, fileName+resultsExt(ctx), ops, prepareDone, cID)
+		if err != nil {
+			monitor.Errorln("Unable to write benchmark data, operation files kept in", c.Collector.SpoolDir()+":", err)
+		} else {
+			monitor.InfoLn(fmt.Sprintf("Benchmark data written to %q\n", fileName+resultsExt(ctx)))
+		}
+	} else if len(ops) > 0 {
+		err := writeLocalResults(ctx, fileName+resultsExt(ctx), ops)
 		if err != nil {
 			monitor.Errorln("Unable to write benchmark data:", err)
 		} else {
-			func() {
-				defer f.Close()
-				enc, err := zstd.NewWriter(f, zstd.WithEncoderLevel(zstd.SpeedBetterCompression))
-				fatalIf(probe.NewError(err), "Unable to compress benchmark output")
-
-				defer enc.Close()
-				err = ops.CSV(enc, commandLine(ctx))
-				fatalIf(probe.NewError(err), "Unable to write benchmark output")
-
-				monitor.InfoLn(fmt.Sprintf("Benchmark data written to %q\n", fileName+".csv.zst"))
-			}()
+			monitor.InfoLn(fmt.Sprintf("Benchmark data written to %q\n", fileName+resultsExt(ctx)))
 		}
 	}
 	monitor.OperationsReady(ops, fileName, commandLine(ctx))
-	printAnalysis(ctx, ops)
+	err = runHook(ctx, hookPostRun, hookResultEnv(ctx, fileName, ops)...)
+	errorIf(probe.NewError(err), "Post-run hook failed")
+	printAnalysis(ctx, ops, benchTags(ctx))
+	if ctx.String("host.b") != "" {
+		printDualCompare(ctx, ops)
+	}
+	err = uploadResults(ctx, c, fileName, benchDataFile(ctx, fileName, ops), ops)
+	errorIf(probe.NewError(err), "Unable to upload results")
 	if !ctx.Bool("keep-data") && !ctx.Bool("noclear") {
+		if t := cleanupTime(ctx); time.Until(t) > 0 {
+			monitor.InfoLn(fmt.Sprintf("Waiting until %s to start cleanup...", t.Format(time.DateTime)))
+			time.Sleep(time.Until(t))
+		}
 		monitor.InfoLn("Starting cleanup...")
+		cleanupStart := time.Now()
 		b.Cleanup(context.Background())
+		if ctx.Bool("cleanup.delete-bucket") {
+			err := b.GetCommon().RemoveBucket(context.Background())
+			errorIf(probe.NewError(err), "Unable to delete bucket")
+		}
+		stages.Cleanup = time.Since(cleanupStart)
 	}
 	monitor.InfoLn("Cleanup Done.")
-	return nil
+	monitor.InfoLn("Stage times: " + stages.String())
+	err = writeSummary(ctx, ops, benchDataFile(ctx, fileName, ops), &stages)
+	errorIf(probe.NewError(err), "Unable to write run summary")
+	return ops, fileName, nil
+}
+
+// writeLocalResults writes the operations to a compressed results file.
+func writeLocalResults(ctx *cli.Context, fileName string, ops bench.Operations) error {
+	return writeResults(ctx, fileName, func(w io.Writer) error {
+		return ops.CSV(w, commandLine(ctx))
+	})
+}
+
+// writeSpooledResults merges the operations spooled by the collector and events into a compressed results file.
+// prepareDone and clientID are applied as they are to operations returned by the benchmark.
+// The operations are read back from the file.
+func writeSpooledResults(ctx *cli.Context, col *bench.Collector, fileName string, events bench.Operations, prepareDone time.Time, clientID string) (bench.Operations, error) {
+	one := make(bench.Operations, 1)
+	err := writeResults(ctx, fileName, func(w io.Writer) error {
+		return col.WriteSpoolCSV(w, events, commandLine(ctx), func(op *bench.Operation) {
+			one[0] = *op
+			one.MarkPrepare(prepareDone)
+			one.SetClientID(clientID)
+			*op = one[0]
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	f, err := openInput(ctx, fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	dec, err := newResultsReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer dec.Close()
+	_, rd, err := bench.TagsFromCSV(dec)
+	if err != nil {
+		return nil, err
+	}
+	return bench.OperationsFromCSV(rd, false, 0, 0, nil)
+}
+
+// writeResults writes a compressed results file with the header and the operations written by write.
+func writeResults(ctx *cli.Context, fileName string, write func(w io.Writer) error) error {
+	f, err := createResults(ctx, fileName)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	enc, err := newResultsWriter(ctx, f)
+	if err != nil {
+		return err
+	}
+	err = writeResultsHeader(ctx, enc)
+	if err == nil {
+		err = write(enc)
+	}
+	if cerr := enc.Close(); err == nil {
+		err = cerr
+	}
+	return err
 }
 
 var (
@@ -288,6 +540,8 @@ type clientBenchmark struct {
 	stage     benchmarkStage
 	results   bench.Operations
 	clientIdx int
+	// common is set when the benchmark has been prepared.
+	common *bench.Common
 	sync.Mutex
 }
 
@@ -296,6 +550,8 @@ type stageInfo struct {
 	done           chan struct{}
 	custom         map[string]string
 	startRequested bool
+	// duration overrides the benchmark duration, if set.
+	duration time.Duration
 }
 
 func (c *clientBenchmark) init(ctx context.Context) {
@@ -381,11 +637,15 @@ func runClientBenchmark(ctx *cli.Context, b bench.Benchmark, cb *clientBenchmark
 	defer cancel()
 	cb.Unlock()
 	err = b.Prepare(ctx2)
+	prepareDone := time.Now()
 
 	cb.stageDone(stagePrepare, err, common.Custom)
 	if err != nil {
 		return err
 	}
+	cb.Lock()
+	cb.common = common
+	cb.Unlock()
 
 	// Start after waiting a second or until we reached the start time.
 	benchDur := ctx.Duration("duration")
@@ -398,6 +658,11 @@ func runClientBenchmark(ctx *cli.Context, b bench.Benchmark, cb *clientBenchmark
 			return
 		case <-start:
 		}
+		cb.Lock()
+		if d := cb.info[stageBenchmark].duration; d > 0 {
+			benchDur = d
+		}
+		cb.Unlock()
 		console.Infoln("Starting")
 		// Finish after duration
 		select {
@@ -417,7 +682,10 @@ func runClientBenchmark(ctx *cli.Context, b bench.Benchmark, cb *clientBenchmark
 		fileName = fmt.Sprintf("%s-%s-%s-%s", appName, ctx.Command.Name, time.Now().Format("2006-01-02[150405]"), cID)
 	}
 
+	stopAdjust := startAdjust(ctx, common, nil)
 	ops, err := b.Start(ctx2, start)
+	stopAdjust()
+	ops.MarkPrepare(prepareDone)
 	cb.Lock()
 	cb.results = ops
 	cb.Unlock()
@@ -429,23 +697,28 @@ func runClientBenchmark(ctx *cli.Context, b bench.Benchmark, cb *clientBenchmark
 	ops.SortByStartTime()
 
 	if len(ops) > 0 {
-		f, err := os.Create(fileName + ".csv.zst")
+		f, err := createResults(ctx, fileName+resultsExt(ctx))
 		if err != nil {
 			console.Error("Unable to write benchmark data:", err)
 		} else {
 			func() {
 				defer f.Close()
-				enc, err := zstd.NewWriter(f, zstd.WithEncoderLevel(zstd.SpeedBetterCompression))
+				enc, err := newResultsWriter(ctx, f)
 				fatalIf(probe.NewError(err), "Unable to compress benchmark output")
 
 				defer enc.Close()
+				err = writeResultsHeader(ctx, enc)
+				fatalIf(probe.NewError(err), "Unable to write benchmark output")
 				err = ops.CSV(enc, commandLine(ctx))
 				fatalIf(probe.NewError(err), "Unable to write benchmark output")
 
-				console.Infof("Benchmark data written to %q\n", fileName+".csv.zst")
+				console.Infof("Benchmark data written to %q\n", fileName+resultsExt(ctx))
 			}()
 		}
 	}
+	// The server uploads the combined results, so the name of each client must be unique.
+	err = uploadResults(ctx, common, fmt.Sprintf("%s-client-%d", fileName, common.ClientIdx), benchDataFile(ctx, fileName, ops), ops)
+	errorIf(probe.NewError(err), "Unable to upload results")
 
 	err = cb.waitForStage(stageCleanup)
 	if err != nil {
@@ -521,6 +794,36 @@ func checkBenchmark(ctx *cli.Context) {
 
 	_, err := parseInfluxURL(ctx)
 	fatalIf(probe.NewError(err), "invalid influx config")
+	_, err = parseKafkaURL(ctx)
+	fatalIf(probe.NewError(err), "invalid kafka config")
+	checkDualEndpoint(ctx)
+	checkSignature(ctx)
+	checkRequestIDHeaders(ctx)
+	reserveSummaryStdout(ctx)
+	_, err = opSample(ctx)
+	fatalIf(probe.NewError(err), "Invalid op-sample")
+	if ctx.Bool("tcp-stats") && !bench.TCPStatsSupported() {
+		console.Errorln("--tcp-stats is only supported on Linux and will be ignored")
+	}
+	if ctx.Bool("adjust") {
+		if ctx.Float64("rps-limit.cluster") > 0 {
+			fatalIf(errDummy(), "--adjust cannot be used with --rps-limit.cluster")
+		}
+		if n := ctx.Int("adjust.concurrent"); n < 0 || n > ctx.Int("concurrent") {
+			fatalIf(errDummy(), "--adjust.concurrent must be between 1 and --concurrent")
+		}
+		if ctx.Int("adjust.step") < 1 {
+			fatalIf(errDummy(), "--adjust.step must be at least 1")
+		}
+	}
+	if ctx.Duration("health.interval") > 0 && ctx.Int("health.failures") < 1 {
+		fatalIf(errDummy(), "--health.failures must be at least 1")
+	}
+	switch ctx.String("backend") {
+	case "s3", "null":
+	default:
+		fatalIf(errDummy(), "unknown backend: %q. Can be 's3' or 'null'", ctx.String("backend"))
+	}
 
 	profs := strings.Split(ctx.String("serverprof"), ",")
 	for _, profilerType := range profs {
@@ -545,6 +848,169 @@ func checkBenchmark(ctx *cli.Context) {
 			fatalIf(errDummy(), "syncstart is in the past: %v", t)
 		}
 	}
+	_, err = bench.ParseTags(ctx.StringSlice("tag"))
+	fatalIf(probe.NewError(err), "invalid tag")
+	if ps := ctx.String("prepare.strategy"); ps != "" && !slices.Contains(bench.PrepareStrategies, bench.PrepareStrategy(ps)) {
+		fatalIf(errDummy(), "unknown prepare strategy %q. Possible values are: %v.", ps, bench.PrepareStrategies)
+	}
+	if ctx.Int("prepare.concurrent") < 0 {
+		fatalIf(errDummy(), "prepare.concurrent cannot be negative")
+	}
+	switch bench.PrepareStrategy(ctx.String("prepare.strategy")) {
+	case bench.PrepareStrategyCopy:
+		if ctx.Int("prepare.seeds") < 1 {
+			fatalIf(errDummy(), "prepare.seeds must be at least 1")
+		}
+	case bench.PrepareStrategySnowball:
+		if ctx.Int("prepare.batch") < 1 {
+			fatalIf(errDummy(), "prepare.batch must be at least 1")
+		}
+		if ctx.Int("versions") > 1 {
+			fatalIf(errDummy(), "snowball prepare cannot be used with multiple versions")
+		}
+	}
+	if ctx.String("spool") != "" {
+		// Spooled operations are only written to local results.
+		switch {
+		case ctx.Bool("autoterm"):
+			fatalIf(errDummy(), "spool cannot be combined with --autoterm")
+		case ctx.String("warp-client") != "":
+			fatalIf(errDummy(), "spool cannot be combined with --warp-client")
+		case ctx.String("host.b") != "":
+			fatalIf(errDummy(), "spool cannot be combined with --host.b")
+		}
+	}
+	if ctx.Float64("cleanup.rate") < 0 {
+		fatalIf(errDummy(), "cleanup.rate cannot be negative")
+	}
+	if ctx.String("cleanup.at") != "" {
+		// Fails on invalid times.
+		cleanupTime(ctx)
+	}
+	if n := ctx.Int("cleanup.batch"); n < 1 || n > 1000 {
+		fatalIf(errDummy(), "cleanup.batch must be between 1 and 1000")
+	}
+	if ctx.Bool("daemon") {
+		if ctx.String("warp-client") != "" {
+			fatalIf(errDummy(), "daemon mode cannot be used with --warp-client")
+		}
+		if ctx.Int("daemon.keep") < 1 {
+			fatalIf(errDummy(), "daemon.keep must be at least 1")
+		}
+		if ctx.Duration("daemon.interval") < 0 {
+			fatalIf(errDummy(), "daemon.interval cannot be negative")
+		}
+	}
+	if err := checkResultsCompression(ctx); err != nil {
+		fatalIf(probe.NewError(err), "Invalid results.compression")
+	}
+	if _, err := resultsKey(ctx); err != nil {
+		fatalIf(probe.NewError(err), "Invalid results.key")
+	}
+	if ctx.Int("results.keep") < 0 || ctx.Duration("results.max-age") < 0 {
+		fatalIf(errDummy(), "results.keep and results.max-age cannot be negative")
+	}
+	if s := ctx.String("results.max-size"); s != "" {
+		if _, err := toSize(s); err != nil {
+			fatalIf(probe.NewError(err), "Invalid results.max-size")
+		}
+	}
+	if s := ctx.String("stall.speed"); s != "" {
+		if _, err := toSize(s); err != nil {
+			fatalIf(probe.NewError(err), "Invalid stall.speed value")
+		}
+		if ctx.Duration("stall.dur") <= 0 {
+			fatalIf(errDummy(), "stall.dur must be positive")
+		}
+	}
+	if _, err := dialNetwork(ctx); err != nil {
+		fatalIf(probe.NewError(err), "Invalid ip-version")
+	}
+	if ctx.Int("cleanup.concurrent") < 0 {
+		fatalIf(errDummy(), "cleanup.concurrent cannot be negative")
+	}
+	if f := ctx.Float64("hot.fraction"); f < 0 || f > 1 {
+		fatalIf(errDummy(), "hot.fraction must be between 0 and 1")
+	}
+	if ctx.Float64("hot.fraction") > 0 && ctx.Int("hot.prefixes") < 1 {
+		fatalIf(errDummy(), "hot.prefixes must be at least 1")
+	}
+	if s := ctx.String("names.scheme"); s != nameSchemeRandom && s != nameSchemeLease {
+		fatalIf(errDummy(), "unknown names.scheme %q. Must be %s or %s", s, nameSchemeRandom, nameSchemeLease)
+	}
+	if strings.Contains(ctx.String("names.lease"), "/") {
+		fatalIf(errDummy(), "names.lease cannot contain '/'")
+	}
+	if f := ctx.Float64("names.collide"); f < 0 || f > 1 {
+		fatalIf(errDummy(), "names.collide must be between 0 and 1")
+	}
+	if ctx.Float64("names.collide") > 0 && ctx.Int("names.collide-keys") < 1 {
+		fatalIf(errDummy(), "names.collide-keys must be at least 1")
+	}
+	if ctx.String("record") != "" {
+		if ctx.Bool("stress") {
+			fatalIf(errDummy(), "--record cannot be used with --stress, since object names are not recorded")
+		}
+		if s := ctx.String("prepare.strategy"); s != string(bench.PrepareStrategyPut) {
+			// Batches and copies cannot be replayed as the objects they create.
+			fatalIf(errDummy(), "--record can only be used with --prepare.strategy=%s", bench.PrepareStrategyPut)
+		}
+	}
+	if ctx.Float64("names.collide") > 0 && ctx.Command.Name != "put" && ctx.Command.Name != "mixed" {
+		// Other benchmarks expect the objects they read to have the size they uploaded.
+		fatalIf(errDummy(), "names.collide can only be used with put and mixed")
+	}
+	if ctx.Duration("rps-limit.jitter") < 0 || ctx.Duration("start-jitter") < 0 {
+		fatalIf(errDummy(), "jitter cannot be negative")
+	}
+	rpsLimit, opLimits, err := parseRpsLimit(ctx.String("rps-limit"))
+	fatalIf(probe.NewError(err), "Invalid rps-limit")
+	if opLimits != nil && ctx.Command.Name != "mixed" && ctx.Command.Name != "iam" {
+		fatalIf(errDummy(), "rps-limit per operation type is only supported by the mixed and iam benchmarks")
+	}
+	if len(ctx.StringSlice("warp-client.group")) > 0 && ctx.String("warp-client") == "" {
+		fatalIf(errDummy(), "warp-client.group requires --warp-client")
+	}
+	if ctx.Duration("stagger.start") < 0 || ctx.Duration("stagger.stop") < 0 {
+		fatalIf(errDummy(), "stagger cannot be negative")
+	}
+	if err := checkClientVersionAction(ctx.String("warp-client.version")); err != nil {
+		fatalIf(probe.NewError(err), "Invalid warp-client.version")
+	}
+	if (ctx.Duration("stagger.start") > 0 || ctx.Duration("stagger.stop") > 0) && ctx.String("warp-client") == "" {
+		fatalIf(errDummy(), "stagger.start and stagger.stop require --warp-client")
+	}
+	if ctx.Float64("rps-limit.cluster") < 0 {
+		fatalIf(errDummy(), "rps-limit.cluster cannot be negative")
+	}
+	if ctx.Float64("rps-limit.cluster") > 0 {
+		if ctx.String("warp-client") == "" {
+			fatalIf(errDummy(), "rps-limit.cluster requires --warp-client")
+		}
+		if rpsLimit > 0 || opLimits != nil {
+			fatalIf(errDummy(), "rps-limit.cluster cannot be combined with --rps-limit")
+		}
+		rpsLimit = ctx.Float64("rps-limit.cluster")
+	}
+	bwLimit, err := toSize(ctx.String("bw-limit"))
+	if err != nil {
+		fatalIf(probe.NewError(err), "Invalid bw-limit")
+	}
+	if s := ctx.String("bw-limit.cluster"); s != "" {
+		total, err := toSize(s)
+		if err != nil {
+			fatalIf(probe.NewError(err), "Invalid bw-limit.cluster")
+		}
+		if total > 0 && ctx.String("warp-client") == "" {
+			fatalIf(errDummy(), "bw-limit.cluster requires --warp-client")
+		}
+		if total > 0 && bwLimit > 0 {
+			fatalIf(errDummy(), "bw-limit.cluster cannot be combined with --bw-limit")
+		}
+	}
+	if ctx.Duration("rps-limit.jitter") > 0 && rpsLimit <= 0 {
+		fatalIf(errDummy(), "rps-limit.jitter requires --rps-limit")
+	}
 	if ctx.Bool("autoterm") {
 		// TODO: autoterm cannot be used when in client/server mode
 		if ctx.Duration("autoterm.dur") <= 0 {
@@ -556,6 +1022,22 @@ func checkBenchmark(ctx *cli.Context) {
 	}
 }
 
+// benchDataFile returns the name of the benchmark data file.
+// If there are no operations no file is written and an empty string is returned.
+func benchDataFile(ctx *cli.Context, fileName string, ops bench.Operations) string {
+	if len(ops) == 0 {
+		return ""
+	}
+	return fileName + resultsExt(ctx)
+}
+
+// benchTags returns the tags supplied on the command line.
+func benchTags(ctx *cli.Context) bench.Tags {
+	tags, err := bench.ParseTags(ctx.StringSlice("tag"))
+	fatalIf(probe.NewError(err), "invalid tag")
+	return tags
+}
+
 // time format for start time.
 const timeLayout = "15:04"
 
@@ -568,6 +1050,20 @@ func parseLocalTime(s string) time.Time {
 	return t
 }
 
+// cleanupTime returns when cleanup should start.
+// Times that have passed today refer to tomorrow.
+func cleanupTime(ctx *cli.Context) time.Time {
+	st := ctx.String("cleanup.at")
+	if st == "" {
+		return time.Now()
+	}
+	t := parseLocalTime(st)
+	if t.Before(time.Now()) {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t
+}
+
 // pRandASCII return pseudorandom ASCII string with length n.
 // Should never be considered for true random data generation.
 func pRandASCII(n int) string {
@@ -22,13 +22,11 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
-	"os"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/klauspost/compress/zstd"
 	"github.com/minio/cli"
 	"github.com/minio/mc/pkg/probe"
 	"github.com/minio/warp/api"
@@ -46,6 +44,8 @@ const (
 	serverReqStartStage  serverRequestOp = "start_stage"
 	serverReqStageStatus serverRequestOp = "stage_status"
 	serverReqSendOps     serverRequestOp = "send_ops"
+	serverReqSetRate     serverRequestOp = "set_rate"
+	serverReqUpgrade     serverRequestOp = "upgrade"
 )
 
 const serverFlagName = "serve"
@@ -57,9 +57,9 @@ type serverInfo struct {
 	connected bool
 }
 
-type AfterPreparer interface {
-	AfterPrepare(ctx context.Context) error
-}
+// AfterPreparer is implemented by benchmarks that must
+// do additional work after all clients have prepared.
+type AfterPreparer = bench.AfterPreparer
 
 // validate the serverinfo.
 func (s serverInfo) validate() error {
@@ -83,6 +83,12 @@ type serverRequest struct {
 	Operation serverRequestOp `json:"op"`
 	Stage     benchmarkStage  `json:"stage"`
 	ClientIdx int             `json:"client_idx"`
+	RpsLimit  float64         `json:"rps_limit,omitempty"`
+	BwLimit   float64         `json:"bw_limit,omitempty"`
+	// Duration overrides the benchmark duration of the client.
+	Duration time.Duration `json:"duration,omitempty"`
+	// Upgrade describes the binary sent with an upgrade request.
+	Upgrade *clientUpgrade `json:"upgrade,omitempty"`
 }
 
 // runServerBenchmark will run a benchmark server if requested.
@@ -92,13 +98,10 @@ func runServerBenchmark(ctx *cli.Context, b bench.Benchmark) (bool, error) {
 		return false, nil
 	}
 
-	conns := newConnections(parseHosts(ctx.String("warp-client"), false))
-	if len(conns.hosts) == 0 {
+	hosts := parseHosts(ctx.String("warp-client"), false)
+	if len(hosts) == 0 {
 		return true, errors.New("no hosts")
 	}
-	conns.info = printInfo
-	conns.errLn = printError
-	defer conns.closeAll()
 	monitor := api.NewBenchmarkMonitor(ctx.String(serverFlagName))
 	defer monitor.Done()
 	monitor.SetLnLoggers(printInfo, printError)
@@ -109,13 +112,27 @@ func runServerBenchmark(ctx *cli.Context, b bench.Benchmark) (bool, error) {
 
 	// Serialize parameters
 	excludeFlags := map[string]struct{}{
-		"warp-client":        {},
-		"warp-client-server": {},
-		"serverprof":         {},
-		"autocompletion":     {},
-		"help":               {},
-		"syncstart":          {},
-		"analyze.out":        {},
+		"warp-client":         {},
+		"warp-client-server":  {},
+		"serverprof":          {},
+		"autocompletion":      {},
+		"help":                {},
+		"syncstart":           {},
+		"cleanup.at":          {},
+		"analyze.out":         {},
+		"html":                {},
+		"summary-file":        {},
+		"hook.pre-prepare":    {},
+		"hook.pre-run":        {},
+		"hook.post-run":       {},
+		"hook.timeout":        {},
+		"rps-limit.cluster":   {},
+		"bw-limit.cluster":    {},
+		"stagger.start":       {},
+		"stagger.stop":        {},
+		"warp-client.group":   {},
+		"warp-client.version": {},
+		"names.lease":         {},
 	}
 	transformFlags := map[string]func(flag cli.Flag) (string, error){
 		// Special handling for hosts, we read files and expand it.
@@ -154,25 +171,94 @@ func runServerBenchmark(ctx *cli.Context, b bench.Benchmark) (bool, error) {
 			}
 		}
 	}
+	groups, groupErr := parseClientGroups(ctx, req.Benchmark.Flags, excludeFlags)
+	if groupErr != nil {
+		return true, groupErr
+	}
 	for k, v := range b.GetCommon().ExtraFlags {
 		req.Benchmark.Flags[k] = v
 	}
 
-	// Connect to hosts, send benchmark requests.
+	// Clients in groups run their own benchmark.
+	reqs := make([]serverRequest, len(hosts))
+	for i := range reqs {
+		reqs[i] = req
+	}
+	for _, g := range groups {
+		greq := serverRequest{Operation: serverReqBenchmark}
+		greq.Benchmark.Command = g.command
+		greq.Benchmark.Args = g.args
+		greq.Benchmark.Flags = g.flags
+		for range g.hosts {
+			reqs = append(reqs, greq)
+		}
+		hosts = append(hosts, g.hosts...)
+	}
+	// Assign each client its own name prefix.
+	for i := range reqs {
+		if reqs[i].Benchmark.Flags["names.scheme"] != nameSchemeLease {
+			continue
+		}
+		flags := make(map[string]string, len(reqs[i].Benchmark.Flags)+1)
+		for k, v := range reqs[i].Benchmark.Flags {
+			flags[k] = v
+		}
+		flags["names.lease"] = nameLease(i)
+		reqs[i].Benchmark.Flags = flags
+	}
+	conns := newConnections(hosts)
+	conns.info = printInfo
+	conns.errLn = printError
+	defer conns.closeAll()
+
+	conns.staggerStart = ctx.Duration("stagger.start")
+	conns.staggerStop = ctx.Duration("stagger.stop")
+	conns.duration = ctx.Duration("duration")
+	if total := ctx.Float64("rps-limit.cluster"); total > 0 {
+		// Clients start with an equal share.
+		conns.rps = newClusterLimiter(total, len(conns.hosts))
+		for _, r := range reqs {
+			r.Benchmark.Flags["rps-limit"] = fmt.Sprint(total / float64(len(conns.hosts)))
+		}
+	}
+	if total, _ := toSize(ctx.String("bw-limit.cluster")); total > 0 {
+		conns.bw = newClusterLimiter(float64(total), len(conns.hosts))
+		for _, r := range reqs {
+			r.Benchmark.Flags["bw-limit"] = fmt.Sprint(total / uint64(len(conns.hosts)))
+		}
+	}
+
+	// Connect to hosts and verify they run the same build.
+	for i := range conns.hosts {
+		err := conns.connect(i)
+		fatalIf(probe.NewError(err), "Unable to connect to warp client")
+	}
+	err := conns.checkVersions(ctx.String("warp-client.version"))
+	fatalIf(probe.NewError(err), "Client version check failed")
+
+	// Send benchmark requests.
 	for i := range conns.hosts {
-		resp, err := conns.roundTrip(i, req)
+		resp, err := conns.roundTrip(i, reqs[i])
 		fatalIf(probe.NewError(err), "Unable to send benchmark info to warp client")
 		if resp.Err != "" {
 			fatalIf(probe.NewError(errors.New(resp.Err)), "Error received from warp client")
 		}
+		if reqs[i].Benchmark.Command != req.Benchmark.Command {
+			infoLn("Client ", conns.hostName(i), " connected, running ", reqs[i].Benchmark.Command, "...")
+			continue
+		}
 		infoLn("Client ", conns.hostName(i), " connected...")
 		// Assume ok.
 	}
 	infoLn("All clients connected...")
 
 	common := b.GetCommon()
+	var stages stageTimes
+	prepareStart := time.Now()
+	err = runHook(ctx, hookPrePrepare)
+	fatalIf(probe.NewError(err), "Pre-prepare hook failed")
 	_ = conns.startStageAll(stagePrepare, time.Now().Add(time.Second), true)
-	err := conns.waitForStage(stagePrepare, true, common)
+	err = conns.waitForStage(stagePrepare, true, common)
 	if err != nil {
 		fatalIf(probe.NewError(err), "Failed to prepare")
 	}
@@ -181,7 +267,10 @@ func runServerBenchmark(ctx *cli.Context, b bench.Benchmark) (bool, error) {
 		fatalIf(probe.NewError(err), "Error preparing server")
 	}
 
+	stages.Prepare = time.Since(prepareStart)
 	infoLn("All clients prepared...")
+	err = runHook(ctx, hookPreRun)
+	fatalIf(probe.NewError(err), "Pre-run hook failed")
 
 	const benchmarkWait = 3 * time.Second
 
@@ -189,7 +278,8 @@ func runServerBenchmark(ctx *cli.Context, b bench.Benchmark) (bool, error) {
 	if err != nil {
 		return true, err
 	}
-	err = conns.startStageAll(stageBenchmark, time.Now().Add(benchmarkWait), false)
+	benchStart := time.Now().Add(benchmarkWait)
+	err = conns.startStageAll(stageBenchmark, benchStart, false)
 	if err != nil {
 		errorLn("Failed to start all clients", err)
 	}
@@ -198,6 +288,7 @@ func runServerBenchmark(ctx *cli.Context, b bench.Benchmark) (bool, error) {
 	if err != nil {
 		errorLn("Failed to keep connection to all clients", err)
 	}
+	stages.Benchmark = time.Since(benchStart)
 
 	fileName := ctx.String("benchdata")
 	if fileName == "" {
@@ -221,27 +312,43 @@ func runServerBenchmark(ctx *cli.Context, b bench.Benchmark) (bool, error) {
 
 	if len(allOps) > 0 {
 		allOps.SortByStartTime()
-		f, err := os.Create(fileName + ".csv.zst")
+		f, err := createResults(ctx, fileName+resultsExt(ctx))
 		if err != nil {
 			errorLn("Unable to write benchmark data:", err)
 		} else {
 			func() {
 				defer f.Close()
-				enc, err := zstd.NewWriter(f, zstd.WithEncoderLevel(zstd.SpeedBetterCompression))
+				enc, err := newResultsWriter(ctx, f)
 				fatalIf(probe.NewError(err), "Unable to compress benchmark output")
 
 				defer enc.Close()
+				err = writeResultsHeader(ctx, enc)
+				fatalIf(probe.NewError(err), "Unable to write benchmark output")
 				err = allOps.CSV(enc, commandLine(ctx))
 				fatalIf(probe.NewError(err), "Unable to write benchmark output")
 
-				infoLn(fmt.Sprintf("Benchmark data written to %q\n", fileName+".csv.zst"))
+				infoLn(fmt.Sprintf("Benchmark data written to %q\n", fileName+resultsExt(ctx)))
 			}()
 		}
 	}
 	monitor.OperationsReady(allOps, fileName, commandLine(ctx))
-	printAnalysis(ctx, allOps)
-
-	err = conns.startStageAll(stageCleanup, time.Now(), false)
+	err = runHook(ctx, hookPostRun, hookResultEnv(ctx, fileName, allOps)...)
+	errorIf(probe.NewError(err), "Post-run hook failed")
+	printAnalysis(ctx, allOps, benchTags(ctx))
+	if ctx.String("host.b") != "" {
+		printDualCompare(ctx, allOps)
+	}
+	err = uploadResults(ctx, common, fileName, benchDataFile(ctx, fileName, allOps), allOps)
+	errorIf(probe.NewError(err), "Unable to upload results")
+
+	cleanupAt := time.Now()
+	if !ctx.Bool("keep-data") && !ctx.Bool("noclear") {
+		cleanupAt = cleanupTime(ctx)
+		if time.Until(cleanupAt) > 0 {
+			infoLn(fmt.Sprintf("Waiting until %s to start cleanup...", cleanupAt.Format(time.DateTime)))
+		}
+	}
+	err = conns.startStageAll(stageCleanup, cleanupAt, false)
 	if err != nil {
 		errorLn("Failed to clean up all clients", err)
 	}
@@ -249,7 +356,16 @@ func runServerBenchmark(ctx *cli.Context, b bench.Benchmark) (bool, error) {
 	if err != nil {
 		errorLn("Failed to keep connection to all clients", err)
 	}
+	// Remove the bucket once all clients have finished cleaning up.
+	if !ctx.Bool("keep-data") && !ctx.Bool("noclear") && ctx.Bool("cleanup.delete-bucket") {
+		err = common.RemoveBucket(context.Background())
+		errorIf(probe.NewError(err), "Unable to delete bucket")
+	}
+	stages.Cleanup = time.Since(cleanupAt)
 	infoLn("Cleanup done.\n")
+	infoLn("Stage times: " + stages.String())
+	err = writeSummary(ctx, allOps, benchDataFile(ctx, fileName, allOps), &stages)
+	errorIf(probe.NewError(err), "Unable to write run summary")
 
 	return true, nil
 }
@@ -261,6 +377,15 @@ type connections struct {
 	hosts []string
 	ws    []*websocket.Conn
 	si    serverInfo
+	// builds reported by clients when connecting. nil if not reported.
+	builds []*warpBuild
+	// rps distributes the cluster rate limit, if set.
+	rps *clusterLimiter
+	bw  *clusterLimiter
+
+	// Stagger client start and stop of the benchmark stage.
+	staggerStart, staggerStop time.Duration
+	duration                  time.Duration
 }
 
 // newConnections creates connections (but does not connect) to clients.
@@ -273,6 +398,7 @@ func newConnections(hosts []string) *connections {
 	}
 	c.hosts = hosts
 	c.ws = make([]*websocket.Conn, len(hosts))
+	c.builds = make([]*warpBuild, len(hosts))
 	return &c
 }
 
@@ -373,6 +499,7 @@ func (c *connections) connect(i int) error {
 			if resp.Err != "" {
 				return errors.New(resp.Err)
 			}
+			c.builds[i] = resp.Build
 
 			roundtrip := time.Since(sent)
 			// Add 50% of the roundtrip.
@@ -405,6 +532,13 @@ func (c *connections) startStage(i int, t time.Time, stage benchmarkStage) error
 		Stage:     stage,
 		StartTime: t,
 	}
+	if stage == stageBenchmark && (c.staggerStart > 0 || c.staggerStop > 0) {
+		// Client i starts i intervals after the first client.
+		// Clients stop in reverse order, so all clients run together for the duration.
+		n := len(c.hosts)
+		req.StartTime = t.Add(time.Duration(i) * c.staggerStart)
+		req.Duration = c.duration + time.Duration(n-1-i)*(c.staggerStart+c.staggerStop)
+	}
 	resp, err := c.roundTrip(i, req)
 	if err != nil {
 		return err
@@ -474,6 +608,8 @@ func (c *connections) downloadOps() []bench.Operations {
 				return
 			}
 			c.info("Client ", c.hostName(i), ": Operations downloaded.")
+			// Identify operations by the client that ran them.
+			resp.Ops.SetClientID(c.hosts[i])
 
 			mu.Lock()
 			res = append(res, resp.Ops)
@@ -484,6 +620,17 @@ func (c *connections) downloadOps() []bench.Operations {
 	return res
 }
 
+// setRate sends a new rate or bandwidth limit to client i.
+func (c *connections) setRate(i int, req serverRequest) {
+	resp, err := c.roundTrip(i, req)
+	if err == nil && resp.Err != "" {
+		err = errors.New(resp.Err)
+	}
+	if err != nil {
+		c.errorF("Client %v: unable to set rate limit: %v\n", c.hostName(i), err)
+	}
+}
+
 // waitForStage will wait for stage completion on all clients.
 func (c *connections) waitForStage(stage benchmarkStage, failOnErr bool, common *bench.Common) error {
 	var wg sync.WaitGroup
@@ -496,6 +643,12 @@ func (c *connections) waitForStage(stage benchmarkStage, failOnErr bool, common
 		wg.Add(1)
 		go func(i int) {
 			defer wg.Done()
+			if stage == stageBenchmark && c.rps != nil {
+				defer c.rps.finished(i)
+			}
+			if stage == stageBenchmark && c.bw != nil {
+				defer c.bw.finished(i)
+			}
 			for {
 				req := serverRequest{
 					Operation: serverReqStageStatus,
@@ -518,6 +671,16 @@ func (c *connections) waitForStage(stage benchmarkStage, failOnErr bool, common
 					c.errorF("Client %v returned error: %v\n", c.hostName(i), resp.Err)
 					return
 				}
+				if stage == stageBenchmark && resp.StageInfo.Started && c.rps != nil {
+					if limit, ok := c.rps.observe(i, resp.StageInfo.Requests, resp.Time); ok {
+						c.setRate(i, serverRequest{Operation: serverReqSetRate, RpsLimit: limit})
+					}
+				}
+				if stage == stageBenchmark && resp.StageInfo.Started && c.bw != nil {
+					if limit, ok := c.bw.observe(i, resp.StageInfo.Bytes, resp.Time); ok {
+						c.setRate(i, serverRequest{Operation: serverReqSetRate, BwLimit: limit})
+					}
+				}
 				if resp.StageInfo.Finished {
 					// Merge custom
 					if len(resp.StageInfo.Custom) > 0 {
@@ -0,0 +1,90 @@
+/*
+ * Warp (C) 2019-2020 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"github.com/minio/cli"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/bench"
+)
+
+var bucketsFlags = []cli.Flag{
+	cli.IntFlag{
+		Name:  "buckets",
+		Value: 1000,
+		Usage: "Number of buckets to create on each client.",
+	},
+	cli.Float64Flag{
+		Name:  "list.fraction",
+		Value: 0.1,
+		Usage: "Fraction of requests, 0 to 1, listing all buckets. Other requests check a random bucket with HeadBucket.",
+	},
+	cli.IntFlag{
+		Name:  "prepare.concurrent",
+		Usage: "Number of threads creating buckets when preparing. Default is --concurrent.",
+	},
+}
+
+var bucketsCmd = cli.Command{
+	Name:   "buckets",
+	Usage:  "benchmark listing and checking buckets when many buckets exist",
+	Action: mainBuckets,
+	Before: setGlobalsFromContext,
+	Flags:  combineFlags(globalFlags, ioFlags, bucketsFlags, benchFlags, analyzeFlags),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+  -> see https://github.com/minio/warp#buckets
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+}
+
+// mainBuckets is the entry point for buckets command.
+func mainBuckets(ctx *cli.Context) error {
+	checkBucketsSyntax(ctx)
+	return runBench(ctx, func(ctx *cli.Context) bench.Benchmark {
+		b := bench.Buckets{
+			Common:        getCommon(ctx, nil),
+			CreateBuckets: ctx.Int("buckets"),
+			ListFraction:  ctx.Float64("list.fraction"),
+		}
+		return &b
+	})
+}
+
+func checkBucketsSyntax(ctx *cli.Context) {
+	if ctx.NArg() > 0 {
+		console.Fatal("Command takes no arguments")
+	}
+	if ctx.Int("buckets") < 1 {
+		console.Fatal("At least one bucket must be created")
+	}
+	if f := ctx.Float64("list.fraction"); f < 0 || f > 1 {
+		console.Fatal("list.fraction must be between 0 and 1")
+	}
+	// Bucket names are limited to 63 characters.
+	if n := len(bench.BucketsPrefix(ctx.String("bucket"), 0)) + 8; n > 63 {
+		console.Fatal("--bucket is too long to be used as prefix of the created bucket names")
+	}
+	checkAnalyze(ctx)
+	checkBenchmark(ctx)
+}
@@ -0,0 +1,99 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"github.com/minio/cli"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/bench"
+)
+
+var cacheFlags = []cli.Flag{
+	cli.IntFlag{
+		Name:  "objects",
+		Value: 2500,
+		Usage: "Number of objects to upload. Each object is read once in each pass.",
+	},
+	cli.StringFlag{
+		Name:  "obj.size",
+		Value: "1MiB",
+		Usage: "Size of each generated object. Can be a number or 10KiB/MiB/GiB. All sizes are base 2 binary.",
+	},
+	cli.IntFlag{
+		Name:  "warm.passes",
+		Value: 1,
+		Usage: "Number of times all objects are read after the cold pass.",
+	},
+	cli.DurationFlag{
+		Name:  "warm.delay",
+		Usage: "Time to wait between the cold pass and the first warm pass.",
+	},
+}
+
+var cacheCmd = cli.Command{
+	Name:   "cache",
+	Usage:  "benchmark first reads of objects compared to repeated reads",
+	Action: mainCache,
+	Before: setGlobalsFromContext,
+	Flags:  combineFlags(globalFlags, ioFlags, cacheFlags, prepareFlags, genFlags, benchFlags, analyzeFlags),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+  -> see https://github.com/minio/warp#cache
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+}
+
+// mainCache is the entry point for cache command.
+func mainCache(ctx *cli.Context) error {
+	checkCacheSyntax(ctx)
+	return runBench(ctx, func(ctx *cli.Context) bench.Benchmark {
+		b := bench.Cache{
+			Common:        getCommon(ctx, newGenSource(ctx, "obj.size")),
+			CreateObjects: ctx.Int("objects"),
+			GetOpts:       getOpts(ctx),
+			WarmPasses:    ctx.Int("warm.passes"),
+			WarmDelay:     ctx.Duration("warm.delay"),
+		}
+		return &b
+	})
+}
+
+func checkCacheSyntax(ctx *cli.Context) {
+	if ctx.NArg() > 0 {
+		console.Fatal("Command takes no arguments")
+	}
+	if ctx.Int("objects") < 1 {
+		console.Fatal("At least one object must be tested")
+	}
+	if ctx.Int("warm.passes") < 1 {
+		console.Fatal("At least one warm pass must be done")
+	}
+	if ctx.Duration("warm.delay") < 0 {
+		console.Fatal("--warm.delay cannot be negative")
+	}
+	if ctx.Bool("autoterm") {
+		console.Fatal("--autoterm cannot be used, the benchmark ends when all passes are done")
+	}
+	checkAnalyze(ctx)
+	checkBenchmark(ctx)
+}
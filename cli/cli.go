@@ -77,6 +77,8 @@ func Main(args []string) {
 	// Set the warp app name.
 	appName := filepath.Base(args[0])
 
+	addPlugins()
+
 	// Run the app - exit on error.
 	if err := registerApp(appName, appCmds).Run(args); err != nil {
 		os.Exit(1)
@@ -93,18 +95,36 @@ func init() {
 		statCmd,
 		selectCmd,
 		versionedCmd,
+		deleteMarkersCmd,
+		rmwCmd,
+		tinyCmd,
 		retentionCmd,
 		multipartCmd,
+		multipartPutCmd,
+		largeCmd,
 		zipCmd,
 		snowballCmd,
 		fanoutCmd,
+		iamCmd,
+		isolationCmd,
+		policyCmd,
+		overwriteCmd,
+		cacheCmd,
+		throttleCmd,
+		bucketsCmd,
+		replayCmd,
+		externalCmd,
 	}
 	b := []cli.Command{
 		analyzeCmd,
 		cmpCmd,
+		baselineCmd,
+		reportCmd,
 		mergeCmd,
 		clientCmd,
 		runCmd,
+		selftestCmd,
+		rerunCmd,
 	}
 	appCmds = append(append(appCmds, a...), b...)
 	benchCmds = a
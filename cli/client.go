@@ -19,6 +19,7 @@ package cli
 
 import (
 	"bufio"
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"errors"
@@ -42,6 +43,8 @@ import (
 	"github.com/minio/pkg/v2/console"
 	"github.com/minio/pkg/v2/ellipses"
 	"github.com/minio/warp/pkg"
+	"github.com/minio/warp/pkg/bench"
+	"github.com/minio/warp/pkg/nulls3"
 	"golang.org/x/net/http2"
 )
 
@@ -52,48 +55,59 @@ const (
 	hostSelectTypeWeighed    hostSelectType = "weighed"
 )
 
-func newClient(ctx *cli.Context) func() (cl *minio.Client, done func()) {
+// newClient returns a function that selects a client for each request.
+// If health checks are enabled with multiple hosts, the health checker is returned
+// and unhealthy hosts are not selected while any host is healthy.
+func newClient(ctx *cli.Context) (func() (cl *minio.Client, done func()), *bench.HealthChecker) {
 	hosts := parseHosts(ctx.String("host"), ctx.Bool("resolve-host"))
+	lookups := bucketLookups(ctx)
 	switch len(hosts) {
 	case 0:
 		fatalIf(probe.NewError(errors.New("no host defined")), "Unable to create MinIO client")
 	case 1:
+		if len(lookups) > 1 {
+			break
+		}
 		cl, err := getClient(ctx, hosts[0])
 		fatalIf(probe.NewError(err), "Unable to create MinIO client")
 
 		return func() (*minio.Client, func()) {
 			return cl, func() {}
+		}, nil
+	}
+	// When alternating addressing styles, a client is created for each style.
+	clients := make([]*minio.Client, 0, len(hosts)*len(lookups))
+	for _, host := range hosts {
+		for _, lookup := range lookups {
+			cl, err := getClientKeys(ctx, host, ctx.String("access-key"), ctx.String("secret-key"), lookup)
+			fatalIf(probe.NewError(err), "Unable to create MinIO client")
+			clients = append(clients, cl)
 		}
 	}
+	health := newHealthChecker(ctx, clients)
 	hostSelect := hostSelectType(ctx.String("host-select"))
 	switch hostSelect {
 	case hostSelectTypeRoundrobin:
 		// Do round-robin.
 		var current int
 		var mu sync.Mutex
-		clients := make([]*minio.Client, len(hosts))
-		for i := range hosts {
-			cl, err := getClient(ctx, hosts[i])
-			fatalIf(probe.NewError(err), "Unable to create MinIO client")
-			clients[i] = cl
-		}
 		return func() (*minio.Client, func()) {
 			mu.Lock()
 			now := current % len(clients)
 			current++
+			if health.AnyHealthy() {
+				for !health.Healthy(now) {
+					now = current % len(clients)
+					current++
+				}
+			}
 			mu.Unlock()
 			return clients[now], func() {}
-		}
+		}, health
 	case hostSelectTypeWeighed:
 		// Keep track of handed out clients.
 		// Select random between the clients that have the fewest handed out.
 		var mu sync.Mutex
-		clients := make([]*minio.Client, len(hosts))
-		for i := range hosts {
-			cl, err := getClient(ctx, hosts[i])
-			fatalIf(probe.NewError(err), "Unable to create MinIO client")
-			clients[i] = cl
-		}
 		running := make([]int, len(hosts))
 		lastFinished := make([]time.Time, len(hosts))
 		{
@@ -105,16 +119,20 @@ func newClient(ctx *cli.Context) func() (cl *minio.Client, done func()) {
 			}
 		}
 		find := func() int {
+			anyHealthy := health.AnyHealthy()
+			usable := func(i int) bool {
+				return !anyHealthy || health.Healthy(i)
+			}
 			min := math.MaxInt32
-			for _, n := range running {
-				if n < min {
+			for i, n := range running {
+				if n < min && usable(i) {
 					min = n
 				}
 			}
 			earliest := time.Now().Add(time.Second)
 			earliestIdx := 0
 			for i, n := range running {
-				if n == min {
+				if n == min && usable(i) {
 					if lastFinished[i].Before(earliest) {
 						earliest = lastFinished[i]
 						earliestIdx = i
@@ -138,33 +156,164 @@ func newClient(ctx *cli.Context) func() (cl *minio.Client, done func()) {
 				}
 				mu.Unlock()
 			}
-		}
+		}, health
 	}
 	console.Fatalln("unknown host-select:", hostSelect)
-	return nil
+	return nil, nil
+}
+
+// newHealthChecker returns a started health checker for the clients,
+// or nil if health checks are disabled.
+// Endpoints are checked by checking if the benchmark bucket exists.
+func newHealthChecker(ctx *cli.Context, clients []*minio.Client) *bench.HealthChecker {
+	interval := ctx.Duration("health.interval")
+	if interval <= 0 {
+		return nil
+	}
+	bucket := ctx.String("bucket")
+	h := bench.HealthChecker{
+		Endpoints: make([]string, len(clients)),
+		Interval:  interval,
+		Failures:  ctx.Int("health.failures"),
+		Check: func(ctx context.Context, idx int) error {
+			_, err := clients[idx].BucketExists(ctx, bucket)
+			return err
+		},
+	}
+	for i, cl := range clients {
+		h.Endpoints[i] = cl.EndpointURL().String()
+	}
+	h.Start()
+	return &h
 }
 
 // getClient creates a client with the specified host and the options set in the context.
 func getClient(ctx *cli.Context, host string) (*minio.Client, error) {
+	return getClientKeys(ctx, host, ctx.String("access-key"), ctx.String("secret-key"), bucketLookups(ctx)[0])
+}
+
+// bucketLookups returns the bucket addressing styles selected by the "lookup" parameter.
+// When alternating, both virtual-host and path style are returned.
+func bucketLookups(ctx *cli.Context) []minio.BucketLookupType {
+	switch ctx.String("lookup") {
+	case "", "auto":
+		return []minio.BucketLookupType{minio.BucketLookupAuto}
+	case "dns":
+		return []minio.BucketLookupType{minio.BucketLookupDNS}
+	case "path":
+		return []minio.BucketLookupType{minio.BucketLookupPath}
+	case "alternate":
+		return []minio.BucketLookupType{minio.BucketLookupDNS, minio.BucketLookupPath}
+	}
+	console.Fatalln("unknown lookup:", ctx.String("lookup"))
+	return nil
+}
+
+// Signature methods that can be selected with --signature.
+const (
+	signatureV2 = "S3V2"
+	// signatureV4 uses streaming signatures for uploads without TLS
+	// and unsigned payloads for uploads with TLS.
+	signatureV4          = "S3V4"
+	signatureV4Streaming = "S3V4-STREAMING"
+	signatureV4Unsigned  = "S3V4-UNSIGNED"
+)
+
+// signatureMethod returns the signature method selected in ctx.
+func signatureMethod(ctx *cli.Context) string {
+	return strings.ToUpper(ctx.String("signature"))
+}
+
+// unsignedPayload returns whether the payload of uploads should not be signed.
+func unsignedPayload(ctx *cli.Context) bool {
+	return ctx.Bool("disable-sha256-payload") || signatureMethod(ctx) == signatureV4Unsigned
+}
+
+// effectiveSignature returns the signature method used for uploads.
+func effectiveSignature(ctx *cli.Context) string {
+	switch {
+	case signatureMethod(ctx) == signatureV2:
+		return signatureV2
+	case unsignedPayload(ctx), ctx.Bool("tls"):
+		return signatureV4Unsigned
+	}
+	return signatureV4Streaming
+}
+
+// checkSignature verifies the selected signature method.
+func checkSignature(ctx *cli.Context) {
+	switch signatureMethod(ctx) {
+	case signatureV2, signatureV4, signatureV4Unsigned:
+	case signatureV4Streaming:
+		if ctx.Bool("tls") {
+			fatalIf(errDummy(), "--signature=%s cannot be used with --tls, uploads over TLS are not signed", signatureV4Streaming)
+		}
+		if ctx.Bool("disable-sha256-payload") {
+			fatalIf(errDummy(), "--signature=%s cannot be used with --disable-sha256-payload", signatureV4Streaming)
+		}
+	default:
+		fatalIf(errDummy(), "unknown signature method %q. Can be %s, %s, %s or %s", ctx.String("signature"),
+			signatureV2, signatureV4, signatureV4Streaming, signatureV4Unsigned)
+	}
+}
+
+// getClientKeys creates a client with the specified host, keys and bucket addressing style
+// and the remaining options set in the context.
+// If the addressing style is not automatic, it is recorded with each operation.
+func getClientKeys(ctx *cli.Context, host, accessKey, secretKey string, lookup minio.BucketLookupType) (*minio.Client, error) {
 	var creds *credentials.Credentials
-	switch strings.ToUpper(ctx.String("signature")) {
-	case "S3V4":
+	switch signatureMethod(ctx) {
+	case signatureV4, signatureV4Streaming, signatureV4Unsigned:
 		// if Signature version '4' use NewV4 directly.
-		creds = credentials.NewStaticV4(ctx.String("access-key"), ctx.String("secret-key"), "")
-	case "S3V2":
+		// Payload signing is selected with the upload options.
+		creds = credentials.NewStaticV4(accessKey, secretKey, "")
+	case signatureV2:
 		// if Signature version '2' use NewV2 directly.
-		creds = credentials.NewStaticV2(ctx.String("access-key"), ctx.String("secret-key"), "")
+		creds = credentials.NewStaticV2(accessKey, secretKey, "")
 	default:
-		fatal(probe.NewError(errors.New("unknown signature method. S3V2 and S3V4 is available")), strings.ToUpper(ctx.String("signature")))
+		fatal(probe.NewError(errors.New("unknown signature method. S3V2, S3V4, S3V4-STREAMING and S3V4-UNSIGNED are available")), signatureMethod(ctx))
 	}
 
-	cl, err := minio.New(host, &minio.Options{
+	secure := ctx.Bool("tls")
+	if nullBackend(ctx) {
+		host, secure = nulls3.Host, false
+	}
+	endpoint, dialAddr := host, ""
+	if hh := ctx.String("host-header"); hh != "" && !nullBackend(ctx) {
+		// Requests are addressed and signed for the host header,
+		// but connect to the host.
+		endpoint, dialAddr = hh, host
+		if _, _, err := net.SplitHostPort(host); err != nil {
+			port := "80"
+			if secure {
+				port = "443"
+			}
+			dialAddr = net.JoinHostPort(strings.Trim(host, "[]"), port)
+		}
+	}
+	tr := clientTransport(ctx, dialAddr)
+	if rec, ok := tr.(*bench.ResponseRecorder); ok {
+		if dialAddr != "" {
+			scheme := "http://"
+			if secure {
+				scheme = "https://"
+			}
+			rec.Endpoint = scheme + host
+		}
+		switch lookup {
+		case minio.BucketLookupDNS:
+			rec.Addressing = bench.AddressingVirtualHost
+		case minio.BucketLookupPath:
+			rec.Addressing = bench.AddressingPath
+		}
+	}
+	cl, err := minio.New(endpoint, &minio.Options{
 		Creds:        creds,
-		Secure:       ctx.Bool("tls"),
+		Secure:       secure,
 		Region:       ctx.String("region"),
-		BucketLookup: minio.BucketLookupAuto,
+		BucketLookup: lookup,
 		CustomMD5:    md5simd.NewServer().NewHash,
-		Transport:    clientTransport(ctx),
+		Transport:    tr,
 	})
 	if err != nil {
 		return nil, err
@@ -178,13 +327,86 @@ func getClient(ctx *cli.Context, host string) (*minio.Client, error) {
 	return cl, nil
 }
 
-func clientTransport(ctx *cli.Context) http.RoundTripper {
+var (
+	nullServerOnce sync.Once
+	nullServer     *nulls3.Server
+)
+
+var (
+	tcpStatsOnce sync.Once
+	tcpStatsConn *bench.TCPStats
+)
+
+// tcpStats returns the sampler of connections if --tcp-stats is set and supported.
+// All clients share the same sampler.
+func tcpStats(ctx *cli.Context) *bench.TCPStats {
+	if !ctx.Bool("tcp-stats") || !bench.TCPStatsSupported() {
+		return nil
+	}
+	tcpStatsOnce.Do(func() {
+		tcpStatsConn = bench.NewTCPStats()
+	})
+	return tcpStatsConn
+}
+
+var (
+	bwLimiterMu sync.Mutex
+	bwLimiter   *bench.BandwidthLimiter
+)
+
+// bandwidthLimiter returns the bandwidth limiter if --bw-limit is set.
+// All clients share the same limiter.
+func bandwidthLimiter(ctx *cli.Context) *bench.BandwidthLimiter {
+	limit, err := toSize(ctx.String("bw-limit"))
+	if err != nil || limit == 0 {
+		return nil
+	}
+	bwLimiterMu.Lock()
+	defer bwLimiterMu.Unlock()
+	if bwLimiter == nil {
+		bwLimiter = bench.NewBandwidthLimiter(float64(limit))
+	} else {
+		bwLimiter.SetLimit(float64(limit))
+	}
+	return bwLimiter
+}
+
+// nullBackend returns whether requests are served by the in-process null backend.
+func nullBackend(ctx *cli.Context) bool {
+	return ctx.String("backend") == "null"
+}
+
+// clientTransport returns the transport used by clients.
+// If dialAddr is set, all connections are made to it.
+func clientTransport(ctx *cli.Context, dialAddr string) http.RoundTripper {
+	if nullBackend(ctx) {
+		// All clients share the same server, so objects are visible to all of them.
+		nullServerOnce.Do(func() {
+			nullServer = nulls3.New()
+		})
+		return responseRecorder(ctx, bandwidthLimiter(ctx).Wrap(nulls3.Transport{Server: nullServer}))
+	}
+	network, err := dialNetwork(ctx)
+	fatalIf(probe.NewError(err), "Invalid ip-version")
+	stats := tcpStats(ctx)
+	dialer := &net.Dialer{
+		Timeout:       10 * time.Second,
+		KeepAlive:     10 * time.Second,
+		FallbackDelay: ctx.Duration("ip.fallback-delay"),
+	}
 	tr := &http.Transport{
 		Proxy: http.ProxyFromEnvironment,
-		DialContext: (&net.Dialer{
-			Timeout:   10 * time.Second,
-			KeepAlive: 10 * time.Second,
-		}).DialContext,
+		DialContext: func(ctx context.Context, _, addr string) (net.Conn, error) {
+			if dialAddr != "" {
+				// Connect to dialAddr regardless of the requested host.
+				addr = dialAddr
+			}
+			conn, err := dialer.DialContext(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			return stats.Wrap(conn), nil
+		},
 		MaxIdleConnsPerHost:   ctx.Int("concurrent"),
 		WriteBufferSize:       ctx.Int("sndbuf"), // Configure beyond 4KiB default buffer size.
 		ReadBufferSize:        ctx.Int("rcvbuf"), // Configure beyond 4KiB default buffer size.
@@ -210,6 +432,7 @@ func clientTransport(ctx *cli.Context) http.RoundTripper {
 			// Can't use TLSv1.1 because of RC4 cipher usage
 			MinVersion:         tls.VersionTLS12,
 			InsecureSkipVerify: ctx.Bool("insecure"),
+			ServerName:         ctx.String("sni"),
 		}
 
 		// Because we create a custom TLSClientConfig, we have to opt-in to HTTP/2.
@@ -218,7 +441,64 @@ func clientTransport(ctx *cli.Context) http.RoundTripper {
 			http2.ConfigureTransport(tr)
 		}
 	}
-	return tr
+	return responseRecorder(ctx, bandwidthLimiter(ctx).Wrap(tr))
+}
+
+// dialNetwork returns the network used to connect to hosts selected by the "ip-version" parameter.
+func dialNetwork(ctx *cli.Context) (string, error) {
+	switch ctx.String("ip-version") {
+	case "4":
+		return "tcp4", nil
+	case "6":
+		return "tcp6", nil
+	case "", "dual":
+		return "tcp", nil
+	}
+	return "", fmt.Errorf("unknown ip-version %q, must be 4, 6 or dual", ctx.String("ip-version"))
+}
+
+// responseRecorder returns a ResponseRecorder for rt configured from the command line.
+func responseRecorder(ctx *cli.Context, rt http.RoundTripper) *bench.ResponseRecorder {
+	rec := &bench.ResponseRecorder{
+		RoundTripper:   rt,
+		TracePhases:    ctx.Bool("trace-phases") || ctx.Bool("expect-continue"),
+		ExpectContinue: ctx.Bool("expect-continue"),
+		OpIDHeader:     ctx.String("op-id.header"),
+
+		HonorRetryAfter: ctx.Bool("retry-after"),
+	}
+	if h := ctx.String("run-id.header"); h != "" {
+		rec.RunIDHeader, rec.RunID = h, runID(ctx)
+	}
+	if s := ctx.String("stall.speed"); s != "" {
+		speed, err := toSize(s)
+		fatalIf(probe.NewError(err), "Invalid stall.speed value")
+		rec.StallSpeed = int64(speed)
+		rec.StallDuration = ctx.Duration("stall.dur")
+	}
+	return rec
+}
+
+// runID returns the ID of the run.
+// If no ID is set, a random ID is selected and stored in the context,
+// so it is recorded with the run and sent to clients of distributed benchmarks.
+func runID(ctx *cli.Context) string {
+	id := ctx.String("run-id")
+	if id == "" {
+		id = fmt.Sprintf("%016x", rand.Uint64())
+		_ = ctx.Set("run-id", id)
+	}
+	return id
+}
+
+// checkRequestIDHeaders checks the headers used to send run and operation IDs.
+// Unsigned 'x-amz-' headers are rejected by S3, so they cannot be used.
+func checkRequestIDHeaders(ctx *cli.Context) {
+	for _, name := range []string{"run-id.header", "op-id.header"} {
+		if strings.HasPrefix(strings.ToLower(ctx.String(name)), "x-amz-") {
+			fatalIf(errDummy(), "--%s cannot be an 'x-amz-' header", name)
+		}
+	}
 }
 
 // parseHosts will parse the host parameter given.
@@ -315,7 +595,7 @@ func newAdminClient(ctx *cli.Context) *madmin.AdminClient {
 	cl, err := madmin.NewWithOptions(hosts[0], &madmin.Options{
 		Creds:     credentials.NewStaticV4(ctx.String("access-key"), ctx.String("secret-key"), ""),
 		Secure:    ctx.Bool("tls"),
-		Transport: clientTransport(ctx),
+		Transport: clientTransport(ctx, ""),
 	})
 	fatalIf(probe.NewError(err), "Unable to create MinIO admin client")
 	cl.SetAppInfo(appName, pkg.Version)
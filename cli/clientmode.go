@@ -27,7 +27,12 @@ import (
 	"github.com/minio/pkg/v2/console"
 )
 
-var clientFlags = []cli.Flag{}
+var clientFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "allow-upgrade",
+		Usage: "Accept warp binaries sent by a server using '--warp-client.version=upgrade' and restart with them",
+	},
+}
 
 // Put command.
 var clientCmd = cli.Command{
@@ -69,6 +74,7 @@ func mainClient(ctx *cli.Context) error {
 	default:
 		fatal(errInvalidArgument(), "Too many parameters")
 	}
+	clientAllowUpgrade = ctx.Bool("allow-upgrade")
 	http.HandleFunc("/ws", serveWs)
 	console.Infoln("Listening on", addr)
 	fatalIf(probe.NewError(http.ListenAndServe(addr, nil)), "Unable to start client")
@@ -23,7 +23,6 @@ import (
 	"time"
 
 	"github.com/fatih/color"
-	"github.com/klauspost/compress/zstd"
 	"github.com/minio/cli"
 	"github.com/minio/mc/pkg/probe"
 	"github.com/minio/pkg/v2/console"
@@ -37,7 +36,7 @@ var cmpCmd = cli.Command{
 	Usage:  "compare existing benchmark data",
 	Action: mainCmp,
 	Before: setGlobalsFromContext,
-	Flags:  combineFlags(globalFlags, analyzeFlags, cmpFlags),
+	Flags:  combineFlags(globalFlags, analyzeFlags, cmpFlags, inputFlags),
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
@@ -55,23 +54,27 @@ func mainCmp(ctx *cli.Context) error {
 	checkAnalyze(ctx)
 	checkCmp(ctx)
 	args := ctx.Args()
-	zstdDec, _ := zstd.NewReader(nil)
-	defer zstdDec.Close()
 	log := console.Printf
 	if globalQuiet {
 		log = nil
 	}
-	readOps := func(s string) bench.Operations {
-		f, err := os.Open(s)
+	readOps := func(s string) (bench.Operations, bench.Tags) {
+		f, err := openInput(ctx, s)
 		fatalIf(probe.NewError(err), "Unable to open input file")
 		defer f.Close()
-		err = zstdDec.Reset(f)
+		dec, err := newResultsReader(f)
 		fatalIf(probe.NewError(err), "Unable to read input")
-		ops, err := bench.OperationsFromCSV(zstdDec, true, ctx.Int("analyze.offset"), ctx.Int("analyze.limit"), log)
+		defer dec.Close()
+		tags, rd, err := bench.TagsFromCSV(dec)
+		fatalIf(probe.NewError(err), "Unable to read input")
+		ops, err := bench.OperationsFromCSV(rd, true, ctx.Int("analyze.offset"), ctx.Int("analyze.limit"), log)
 		fatalIf(probe.NewError(err), "Unable to parse input")
-		return ops
+		return ops, tags
 	}
-	printCompare(ctx, readOps(args[0]), readOps(args[1]))
+	before, beforeTags := readOps(args[0])
+	after, afterTags := readOps(args[1])
+	printTagsCompare(beforeTags, afterTags)
+	printCompare(ctx, before, after)
 	return nil
 }
 
@@ -157,6 +160,23 @@ func printCompare(ctx *cli.Context, before, after bench.Operations) {
 	}
 }
 
+func printTagsCompare(before, after bench.Tags) {
+	if len(before)+len(after) == 0 {
+		return
+	}
+	keys := bench.Tags(nil).Merge(before).Merge(after).Keys()
+	console.SetColor("Print", color.New(color.FgWhite))
+	console.Println("Tags:")
+	for _, k := range keys {
+		b, a := before[k], after[k]
+		if a == b {
+			console.Printf(" * %s: %s\n", k, b)
+			continue
+		}
+		console.Printf(" * %s: %q -> %q\n", k, b, a)
+	}
+}
+
 func checkCmp(ctx *cli.Context) {
 	if ctx.NArg() != 2 {
 		console.Fatal("Two data sources must be supplied")
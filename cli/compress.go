@@ -0,0 +1,271 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/minio/cli"
+	"github.com/minio/pkg/v2/console"
+)
+
+// resultsSegmentSize is the amount of uncompressed data in each segment of benchmark data.
+// Each segment can be decompressed independently,
+// so only the last segment is lost when writing is interrupted.
+const resultsSegmentSize = 4 << 20
+
+var (
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	s2Magic   = []byte("\xff\x06\x00\x00S2sTwO")
+)
+
+// resultsExts are the extensions of benchmark data files, longest first.
+// Encrypted files have encryptedExt added.
+var resultsExts = []string{".csv.zst", ".csv.s2", ".csv"}
+
+// encryptedExt is added to the extension of encrypted benchmark data.
+const encryptedExt = ".enc"
+
+// resultsExt returns the extension of benchmark data written
+// with the compression and encryption selected by the command line.
+func resultsExt(ctx *cli.Context) string {
+	ext := ".csv.zst"
+	switch ctx.String("results.compression") {
+	case "s2":
+		ext = ".csv.s2"
+	case "none":
+		ext = ".csv"
+	}
+	if ctx.String("results.key") != "" {
+		ext += encryptedExt
+	}
+	return ext
+}
+
+// trimResultsExt removes the extension of benchmark data from name.
+func trimResultsExt(name string) string {
+	base := strings.TrimSuffix(name, encryptedExt)
+	for _, ext := range resultsExts {
+		if strings.HasSuffix(base, ext) {
+			return strings.TrimSuffix(base, ext)
+		}
+	}
+	return name
+}
+
+// isResultsFile returns whether name has the extension of benchmark data.
+func isResultsFile(name string) bool {
+	return trimResultsExt(name) != name
+}
+
+// checkResultsCompression validates the compression flags.
+func checkResultsCompression(ctx *cli.Context) error {
+	level := ctx.Int("results.compression-level")
+	switch ctx.String("results.compression") {
+	case "zstd":
+		if level < 0 || level > 22 {
+			return errors.New("zstd compression level must be between 1 and 22")
+		}
+	case "s2":
+		if level < 0 || level > 3 {
+			return errors.New("s2 compression level must be between 1 and 3")
+		}
+	case "none":
+	default:
+		return fmt.Errorf("unknown compression %q, must be zstd, s2 or none", ctx.String("results.compression"))
+	}
+	return nil
+}
+
+// newResultsWriter returns a writer compressing benchmark data to w
+// with the codec selected by the command line.
+// The data is written in segments that end at line boundaries.
+// Closing the returned writer does not close w.
+func newResultsWriter(ctx *cli.Context, w io.Writer) (io.WriteCloser, error) {
+	if err := checkResultsCompression(ctx); err != nil {
+		return nil, err
+	}
+	level := ctx.Int("results.compression-level")
+	switch ctx.String("results.compression") {
+	case "s2":
+		var opts []s2.WriterOption
+		switch level {
+		case 2:
+			opts = append(opts, s2.WriterBetterCompression())
+		case 3:
+			opts = append(opts, s2.WriterBestCompression())
+		}
+		enc := s2.NewWriter(w, opts...)
+		return &segmentWriter{w: enc, end: enc.Flush, close: enc.Close}, nil
+	case "none":
+		return &segmentWriter{w: w, end: func() error { return nil }, close: func() error { return nil }}, nil
+	}
+	encLevel := zstd.SpeedBetterCompression
+	if level > 0 {
+		encLevel = zstd.EncoderLevelFromZstd(level)
+	}
+	enc, err := zstd.NewWriter(w, zstd.WithEncoderLevel(encLevel))
+	if err != nil {
+		return nil, err
+	}
+	return &segmentWriter{
+		w: enc,
+		end: func() error {
+			// Finish the frame and start a new one.
+			err := enc.Close()
+			enc.Reset(w)
+			return err
+		},
+		close: enc.Close,
+	}, nil
+}
+
+// segmentWriter ends a segment at the first line end after every resultsSegmentSize bytes.
+type segmentWriter struct {
+	w     io.Writer
+	n     int
+	end   func() error
+	close func() error
+}
+
+func (s *segmentWriter) Write(p []byte) (int, error) {
+	var written int
+	for len(p) > 0 {
+		if s.n < resultsSegmentSize {
+			n, err := s.w.Write(p[:min(len(p), resultsSegmentSize-s.n)])
+			s.n += n
+			written += n
+			if err != nil {
+				return written, err
+			}
+			p = p[n:]
+			continue
+		}
+		idx := bytes.IndexByte(p, '\n')
+		if idx < 0 {
+			n, err := s.w.Write(p)
+			s.n += n
+			return written + n, err
+		}
+		n, err := s.w.Write(p[:idx+1])
+		written += n
+		if err != nil {
+			return written, err
+		}
+		if err := s.end(); err != nil {
+			return written, err
+		}
+		s.n = 0
+		p = p[idx+1:]
+	}
+	return written, nil
+}
+
+func (s *segmentWriter) Close() error {
+	return s.close()
+}
+
+// newResultsReader returns a reader decompressing benchmark data from r.
+// The codec is detected from the content.
+// If the data ends in an incomplete segment, for example from an interrupted run,
+// the complete lines are returned and a warning is printed.
+// Encrypted data that fails authentication is always an error.
+func newResultsReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	hdr, _ := br.Peek(len(s2Magic))
+	var dec io.Reader
+	closer := func() {}
+	switch {
+	case bytes.HasPrefix(hdr, zstdMagic):
+		zdec, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		dec, closer = zdec, zdec.Close
+	case bytes.HasPrefix(hdr, s2Magic):
+		dec = s2.NewReader(br, s2.ReaderMaxBlockSize(4<<20))
+	default:
+		dec = br
+	}
+	return &truncatedReader{r: dec, close: closer}, nil
+}
+
+// truncatedReader returns data up to the last complete line
+// if the underlying reader fails or the data ends inside a line.
+type truncatedReader struct {
+	r     io.Reader
+	close func()
+	store []byte
+	buf   []byte
+	safe  int
+	err   error
+}
+
+func (t *truncatedReader) Read(p []byte) (int, error) {
+	for {
+		if t.safe > 0 {
+			n := copy(p, t.buf[:t.safe])
+			t.buf = t.buf[n:]
+			t.safe -= n
+			return n, nil
+		}
+		if t.err != nil {
+			return 0, t.err
+		}
+		if t.store == nil {
+			t.store = make([]byte, 64<<10)
+		}
+		// Move the incomplete line to the front and grow if it fills the buffer.
+		kept := copy(t.store, t.buf)
+		if kept == len(t.store) {
+			t.store = append(t.store, make([]byte, len(t.store))...)
+		}
+		n, err := t.r.Read(t.store[kept:])
+		t.buf = t.store[:kept+n]
+		t.safe = bytes.LastIndexByte(t.buf, '\n') + 1
+		switch {
+		case err == nil:
+		case err == io.EOF && t.safe == len(t.buf):
+			t.err = io.EOF
+		case errors.Is(err, errResultsModified):
+			// Never use data that could not be authenticated.
+			t.err = err
+		default:
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			if !globalQuiet {
+				console.Errorf("Benchmark data is incomplete (%v). Using the data before the error.\n", err)
+			}
+			t.buf = t.buf[:t.safe]
+			t.err = io.EOF
+		}
+	}
+}
+
+func (t *truncatedReader) Close() error {
+	t.close()
+	return nil
+}
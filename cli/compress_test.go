@@ -0,0 +1,157 @@
+/*
+ * Warp (C) 2019-2020 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/minio/cli"
+)
+
+func TestResultsSegments(t *testing.T) {
+	globalQuiet = true
+	// Random lines, so the data does not compress too well.
+	rng := rand.New(rand.NewSource(0))
+	var input []byte
+	for i := 0; len(input) < 4*resultsSegmentSize+resultsSegmentSize/2; i++ {
+		input = fmt.Appendf(input, "%d,%x\n", i, rng.Uint64()>>rng.Intn(64))
+	}
+
+	for _, codec := range []string{"zstd", "s2", "none"} {
+		t.Run(codec, func(t *testing.T) {
+			set := flag.NewFlagSet(codec, flag.ContinueOnError)
+			set.String("results.compression", codec, "")
+			set.Int("results.compression-level", 0, "")
+			ctx := cli.NewContext(nil, set, nil)
+
+			var out bytes.Buffer
+			w, err := newResultsWriter(ctx, &out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Write in odd sized pieces, so segments end inside writes.
+			for in := input; len(in) > 0; {
+				n := min(len(in), 100003)
+				if _, err := w.Write(in[:n]); err != nil {
+					t.Fatal(err)
+				}
+				in = in[n:]
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			got := readResults(t, out.Bytes())
+			if !bytes.Equal(got, input) {
+				t.Fatalf("complete data: got %d bytes, want %d", len(got), len(input))
+			}
+
+			if codec == "zstd" && bytes.Count(out.Bytes(), zstdMagic) < 5 {
+				t.Fatal("data was not written in independent segments")
+			}
+
+			// Cut the data inside the last segment.
+			// Exactly the complete lines that can be decompressed must be returned.
+			cut := out.Bytes()[:out.Len()*9/10]
+			got = readResults(t, cut)
+			raw := decodeRaw(t, codec, cut)
+			want := raw[:bytes.LastIndexByte(raw, '\n')+1]
+			if !bytes.Equal(got, want) {
+				t.Fatalf("truncated data: got %d bytes, want %d", len(got), len(want))
+			}
+			if !bytes.HasPrefix(input, got) {
+				t.Fatal("truncated data is not a prefix of the input")
+			}
+			if len(got) < 3*resultsSegmentSize {
+				t.Fatalf("truncated data: got %d bytes, want at least the first %d bytes", len(got), 3*resultsSegmentSize)
+			}
+		})
+	}
+}
+
+// decodeRaw returns everything that can be decompressed from b.
+func decodeRaw(t *testing.T, codec string, b []byte) []byte {
+	t.Helper()
+	var r io.Reader
+	switch codec {
+	case "zstd":
+		dec, err := zstd.NewReader(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer dec.Close()
+		r = dec
+	case "s2":
+		r = s2.NewReader(bytes.NewReader(b))
+	default:
+		return b
+	}
+	got, _ := io.ReadAll(r)
+	return got
+}
+
+func readResults(t *testing.T, b []byte) []byte {
+	t.Helper()
+	r, err := newResultsReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestResultsExt(t *testing.T) {
+	tests := []struct {
+		compression, key string
+		want             string
+	}{
+		{compression: "zstd", want: ".csv.zst"},
+		{compression: "s2", want: ".csv.s2"},
+		{compression: "none", want: ".csv"},
+		{compression: "zstd", key: "file:key", want: ".csv.zst.enc"},
+		{compression: "none", key: "file:key", want: ".csv.enc"},
+	}
+	for _, test := range tests {
+		set := flag.NewFlagSet(test.compression, flag.ContinueOnError)
+		set.String("results.compression", test.compression, "")
+		set.String("results.key", test.key, "")
+		ctx := cli.NewContext(nil, set, nil)
+		got := resultsExt(ctx)
+		if got != test.want {
+			t.Errorf("%s, key %q: got %q, want %q", test.compression, test.key, got, test.want)
+		}
+		name := "warp-get-2024-01-01[120000]-abcd" + got
+		if !isResultsFile(name) || trimResultsExt(name) != "warp-get-2024-01-01[120000]-abcd" {
+			t.Errorf("%s: not recognized as benchmark data", name)
+		}
+	}
+	if isResultsFile("report.html") {
+		t.Error("report.html recognized as benchmark data")
+	}
+}
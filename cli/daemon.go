@@ -0,0 +1,220 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/warp/pkg/bench"
+)
+
+// trendFile is the name of the file containing the trend in the daemon directory.
+const trendFile = "trend.json"
+
+// daemonTrend contains the summaries of the most recent runs.
+type daemonTrend struct {
+	mu   sync.Mutex
+	Runs []runSummary `json:"runs"`
+}
+
+// runDaemon will run benchmarks repeatedly until the process is stopped.
+// b is used for the first run, after that newBench creates the benchmark for each run.
+// Failed runs are logged and skipped.
+func runDaemon(ctx *cli.Context, b bench.Benchmark, newBench func() bench.Benchmark) error {
+	dir := ctx.String("daemon.dir")
+	err := os.MkdirAll(dir, 0o755)
+	fatalIf(probe.NewError(err), "Unable to create daemon directory")
+	trend, err := loadTrend(filepath.Join(dir, trendFile))
+	fatalIf(probe.NewError(err), "Unable to read trend")
+	if addr := ctx.String("daemon.listen"); addr != "" {
+		go func() {
+			err := http.ListenAndServe(addr, trend.handler())
+			fatalIf(probe.NewError(err), "Unable to serve trend metrics")
+		}()
+	}
+
+	interval := ctx.Duration("daemon.interval")
+	for run := 0; ; run++ {
+		if run > 0 {
+			b = newBench()
+		}
+		started := time.Now()
+		printInfo(fmt.Sprintf("Starting run %d\n", run+1))
+		fileName := filepath.Join(dir, fmt.Sprintf("%s-%s-%s", appName, ctx.Command.Name, started.Format("2006-01-02[150405]")))
+		ops, fileName, err := runLocalBench(ctx, b, fileName)
+		if err != nil {
+			printError(fmt.Sprintf("Run %d failed, skipping:", run+1), err)
+			if !ctx.Bool("keep-data") && !ctx.Bool("noclear") {
+				// Remove objects prepared before the failure.
+				b.Cleanup(context.Background())
+			}
+			// Don't retry a failing setup in a tight loop.
+			daemonWait(started, max(interval, time.Minute))
+			continue
+		}
+		removed := trend.add(newRunSummary(ctx, ops, benchDataFile(ctx, fileName, ops)), ctx.Int("daemon.keep"))
+		for _, s := range removed {
+			if s.BenchData != "" {
+				err := os.Remove(s.BenchData)
+				if err != nil && !errors.Is(err, os.ErrNotExist) {
+					printError("Unable to remove old benchmark data:", err)
+				}
+			}
+		}
+		err = trend.save(filepath.Join(dir, trendFile))
+		errorIf(probe.NewError(err), "Unable to save trend")
+		pruneLocalResults(ctx, dir)
+		daemonWait(started, interval)
+	}
+}
+
+// daemonWait waits until interval has passed since started.
+func daemonWait(started time.Time, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	if wait := time.Until(started.Add(interval)); wait > 0 {
+		printInfo(fmt.Sprintf("Next run in %v\n", wait.Round(time.Second)))
+		time.Sleep(wait)
+	}
+}
+
+// loadTrend loads the trend file if it exists.
+func loadTrend(file string) (*daemonTrend, error) {
+	var t daemonTrend
+	b, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return &t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, json.Unmarshal(b, &t)
+}
+
+// add a run to the trend and keep the most recent runs.
+// Removed runs are returned.
+func (t *daemonTrend) add(s runSummary, keep int) []runSummary {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Runs = append(t.Runs, s)
+	if len(t.Runs) <= keep {
+		return nil
+	}
+	n := len(t.Runs) - keep
+	removed := append([]runSummary{}, t.Runs[:n]...)
+	t.Runs = append(t.Runs[:0], t.Runs[n:]...)
+	return removed
+}
+
+// save the trend to file.
+func (t *daemonTrend) save(file string) error {
+	t.mu.Lock()
+	b, err := json.MarshalIndent(t, "", "  ")
+	t.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// handler returns a handler serving the trend.
+// '/trend' returns the runs as JSON and '/metrics' returns metrics in Prometheus text format.
+func (t *daemonTrend) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/trend", func(w http.ResponseWriter, r *http.Request) {
+		t.mu.Lock()
+		b, err := json.Marshal(t)
+		t.mu.Unlock()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(b)
+	})
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		t.writeMetrics(w)
+	})
+	return mux
+}
+
+// writeMetrics writes metrics for the latest run and the trend over the kept runs.
+func (t *daemonTrend) writeMetrics(w io.Writer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fmt.Fprintf(w, "# HELP warp_daemon_runs Number of runs in the trend window.\n# TYPE warp_daemon_runs gauge\nwarp_daemon_runs %d\n", len(t.Runs))
+	if len(t.Runs) == 0 {
+		return
+	}
+	latest := t.Runs[len(t.Runs)-1]
+	fmt.Fprintf(w, "# TYPE warp_daemon_last_run_timestamp_seconds gauge\nwarp_daemon_last_run_timestamp_seconds %d\n", latest.EndTime.Unix())
+
+	// Average over the window, excluding the latest run.
+	type avg struct {
+		bps, ops float64
+		n        int
+	}
+	window := make(map[string]avg)
+	for _, run := range t.Runs[:len(t.Runs)-1] {
+		for _, op := range run.Operations {
+			if op.Skipped {
+				continue
+			}
+			a := window[op.Type]
+			a.bps += op.AverageBPS
+			a.ops += op.AverageOPS
+			a.n++
+			window[op.Type] = a
+		}
+	}
+	ops := append([]summaryOpStats{}, latest.Operations...)
+	sort.Slice(ops, func(i, j int) bool { return ops[i].Type < ops[j].Type })
+	for _, op := range ops {
+		fmt.Fprintf(w, "warp_daemon_bytes_per_second{op=%q} %g\n", op.Type, op.AverageBPS)
+		fmt.Fprintf(w, "warp_daemon_objects_per_second{op=%q} %g\n", op.Type, op.AverageOPS)
+		fmt.Fprintf(w, "warp_daemon_errors{op=%q} %d\n", op.Type, op.Errors)
+		if l := op.Latency; l != nil {
+			fmt.Fprintf(w, "warp_daemon_latency_ms{op=%q,quantile=\"0.5\"} %g\n", op.Type, l.P50)
+			fmt.Fprintf(w, "warp_daemon_latency_ms{op=%q,quantile=\"0.9\"} %g\n", op.Type, l.P90)
+			fmt.Fprintf(w, "warp_daemon_latency_ms{op=%q,quantile=\"0.99\"} %g\n", op.Type, l.P99)
+		}
+		if a := window[op.Type]; a.n > 0 {
+			fmt.Fprintf(w, "warp_daemon_window_bytes_per_second{op=%q} %g\n", op.Type, a.bps/float64(a.n))
+			fmt.Fprintf(w, "warp_daemon_window_objects_per_second{op=%q} %g\n", op.Type, a.ops/float64(a.n))
+			fmt.Fprintf(w, "warp_daemon_objects_per_second_change_pct{op=%q} %g\n", op.Type, pctChange(a.ops/float64(a.n), op.AverageOPS))
+		}
+	}
+}
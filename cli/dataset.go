@@ -0,0 +1,140 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/bench"
+)
+
+// datasetBuckets is the number of dataset size ranges latency is displayed for.
+const datasetBuckets = 5
+
+// datasetInitialObjects returns the number of objects present when the benchmark started.
+// If --analyze.objects is not set, the number of objects uploaded by the benchmark is used.
+func datasetInitialObjects(ctx *cli.Context) int {
+	if ctx.IsSet("analyze.objects") {
+		return ctx.Int("analyze.objects")
+	}
+	return ctx.Int("objects")
+}
+
+// datasetReadOps returns the operations that do not change the dataset.
+func datasetReadOps(o bench.Operations) map[string]bench.Operations {
+	res := make(map[string]bench.Operations)
+	for _, typ := range o.OpTypes() {
+		if typ == http.MethodPut || typ == http.MethodDelete {
+			continue
+		}
+		res[typ] = o.FilterByOp(typ)
+	}
+	return res
+}
+
+// printDatasetAnalysis prints the latency of operations that do not change the dataset
+// by the number of live objects when they started, if objects were both added and removed.
+func printDatasetAnalysis(ctx *cli.Context, o bench.Operations) {
+	ds := o.Dataset(datasetInitialObjects(ctx))
+	reads := datasetReadOps(o)
+	if !ds.Changes() || len(reads) == 0 {
+		return
+	}
+	start, end := o.TimeRange()
+	lo, hi := ds.MinMax()
+	console.SetColor("Print", color.New(color.FgHiWhite))
+	console.Printf("\nLatency by live objects (start: %d, end: %d, min: %d, max: %d):\n", ds.At(start), ds.At(end), lo, hi)
+	console.SetColor("Print", color.New(color.FgWhite))
+	n := min(datasetBuckets, hi-lo+1)
+	size := (hi - lo + n) / n
+	for _, typ := range stringKeysSorted(reads) {
+		buckets := make([]bench.Operations, n)
+		for _, op := range reads[typ] {
+			i := (ds.At(op.Start) - lo) / size
+			buckets[i] = append(buckets[i], op)
+		}
+		for i, ops := range buckets {
+			if len(ops) == 0 {
+				continue
+			}
+			from := lo + i*size
+			line := fmt.Sprintf(" * %s, %d -> %d objects: %d requests", typ, from, min(from+size-1, hi), len(ops))
+			if lat := summaryLatencies(ops.FilterSuccessful()); lat != nil {
+				line += fmt.Sprintf(", avg: %.01fms, 50%%: %.01fms, 99%%: %.01fms", lat.Average, lat.P50, lat.P99)
+			}
+			if errs := ops.NErrors(); errs > 0 {
+				line += fmt.Sprintf(", errors: %d", errs)
+			}
+			console.Println(line)
+		}
+	}
+}
+
+// writeDatasetSegments writes the number of live objects at the start of each analysis segment
+// and the latency of operations that do not change the dataset to fn.
+// Requests are included in the segment they start in.
+func writeDatasetSegments(ctx *cli.Context, fn string, o bench.Operations) error {
+	var w io.Writer = os.Stdout
+	if fn != "-" {
+		f, err := os.Create(fn)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	cw := csv.NewWriter(w)
+	cw.Comma = '\t'
+	if err := cw.Write([]string{"index", "op", "start_time", "end_time", "objects", "requests", "errors", "avg_ms", "p50_ms", "p99_ms"}); err != nil {
+		return err
+	}
+	ds := o.Dataset(datasetInitialObjects(ctx))
+	reads := datasetReadOps(o)
+	starts, dur := segmentStarts(ctx, o)
+	for _, typ := range stringKeysSorted(reads) {
+		ops := reads[typ]
+		ops.SortByStartTime()
+		for i, start := range starts {
+			end := start.Add(dur)
+			from := sort.Search(len(ops), func(i int) bool { return !ops[i].Start.Before(start) })
+			to := sort.Search(len(ops), func(i int) bool { return !ops[i].Start.Before(end) })
+			seg := ops[from:to]
+			row := []string{fmt.Sprint(i), typ, start.Format(time.RFC3339Nano), end.Format(time.RFC3339Nano),
+				fmt.Sprint(ds.At(start)), fmt.Sprint(len(seg)), fmt.Sprint(seg.NErrors())}
+			if lat := summaryLatencies(seg.FilterSuccessful()); lat != nil {
+				row = append(row, fmt.Sprint(lat.Average), fmt.Sprint(lat.P50), fmt.Sprint(lat.P99))
+			} else {
+				row = append(row, "", "", "")
+			}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -72,18 +72,20 @@ FLAGS:
 func mainDelete(ctx *cli.Context) error {
 	checkDeleteSyntax(ctx)
 
-	b := bench.Delete{
-		Common:        getCommon(ctx, newGenSource(ctx, "obj.size")),
-		CreateObjects: ctx.Int("objects"),
-		BatchSize:     ctx.Int("batch"),
-		ListExisting:  ctx.Bool("list-existing"),
-		ListFlat:      ctx.Bool("list-flat"),
-		ListPrefix:    ctx.String("prefix"),
-	}
-	if b.ListExisting && !ctx.IsSet("objects") {
-		b.CreateObjects = 0
-	}
-	return runBench(ctx, &b)
+	return runBench(ctx, func(ctx *cli.Context) bench.Benchmark {
+		b := bench.Delete{
+			Common:        getCommon(ctx, newGenSource(ctx, "obj.size")),
+			CreateObjects: ctx.Int("objects"),
+			BatchSize:     ctx.Int("batch"),
+			ListExisting:  ctx.Bool("list-existing"),
+			ListFlat:      ctx.Bool("list-flat"),
+			ListPrefix:    ctx.String("prefix"),
+		}
+		if b.ListExisting && !ctx.IsSet("objects") {
+			b.CreateObjects = 0
+		}
+		return &b
+	})
 }
 
 func checkDeleteSyntax(ctx *cli.Context) {
@@ -0,0 +1,116 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"github.com/minio/cli"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/bench"
+)
+
+var deleteMarkersFlags = []cli.Flag{
+	cli.IntFlag{
+		Name:  "objects",
+		Value: 1000,
+		Usage: "Number of objects to upload. Rounded up to have equal concurrent objects.",
+	},
+	cli.StringFlag{
+		Name:  "obj.size",
+		Value: "10KiB",
+		Usage: "Size of each generated object. Can be a number or 10KiB/MiB/GiB. All sizes are base 2 binary.",
+	},
+	cli.Float64Flag{
+		Name:  "delete-distrib",
+		Usage: "The amount of DELETE operations. Each delete adds a delete marker.",
+		Value: 30,
+	},
+	cli.Float64Flag{
+		Name:  "get-distrib",
+		Usage: "The amount of GET operations.",
+		Value: 50,
+	},
+	cli.Float64Flag{
+		Name:  "list-distrib",
+		Usage: "The amount of LIST operations.",
+		Value: 20,
+	},
+	cli.BoolFlag{
+		Name:  "list-versions",
+		Usage: "List all versions including delete markers. By default only the latest versions are listed.",
+	},
+}
+
+var deleteMarkersCmd = cli.Command{
+	Name:   "delete-markers",
+	Usage:  "benchmark reads and listing while delete markers accumulate",
+	Action: mainDeleteMarkers,
+	Before: setGlobalsFromContext,
+	Flags:  combineFlags(globalFlags, ioFlags, deleteMarkersFlags, genFlags, benchFlags, analyzeFlags),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+  -> see https://github.com/minio/warp#delete-markers
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+}
+
+// mainDeleteMarkers is the entry point for delete-markers command.
+func mainDeleteMarkers(ctx *cli.Context) error {
+	checkDeleteMarkersSyntax(ctx)
+	return runBench(ctx, func(ctx *cli.Context) bench.Benchmark {
+		b := bench.DeleteMarkers{
+			Common:        getCommon(ctx, newGenSource(ctx, "obj.size")),
+			CreateObjects: ctx.Int("objects"),
+			GetOpts:       getOpts(ctx),
+			DeleteDist:    ctx.Float64("delete-distrib"),
+			GetDist:       ctx.Float64("get-distrib"),
+			ListDist:      ctx.Float64("list-distrib"),
+			ListVersions:  ctx.Bool("list-versions"),
+		}
+		return &b
+	})
+}
+
+func checkDeleteMarkersSyntax(ctx *cli.Context) {
+	if ctx.NArg() > 0 {
+		console.Fatal("Command takes no arguments")
+	}
+	if ctx.Int("objects") < 1 {
+		console.Fatal("At least one object must be tested")
+	}
+	if ctx.Bool("noprefix") {
+		console.Fatal("--noprefix cannot be used, each thread must use a separate prefix")
+	}
+	if ctx.Float64("hot.fraction") > 0 {
+		console.Fatal("--hot.fraction cannot be used, each thread must use a separate prefix")
+	}
+	for _, flag := range []string{"delete-distrib", "get-distrib", "list-distrib"} {
+		if ctx.Float64(flag) < 0 {
+			console.Fatalf("--%s cannot be negative", flag)
+		}
+	}
+	if ctx.Float64("delete-distrib")+ctx.Float64("get-distrib")+ctx.Float64("list-distrib") <= 0 {
+		console.Fatal("No operations selected, total distribution is 0")
+	}
+	checkAnalyze(ctx)
+	checkBenchmark(ctx)
+}
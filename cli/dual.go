@@ -0,0 +1,216 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/bench"
+)
+
+// dualBenchmark runs the same benchmark against two endpoints at the same time.
+// Each endpoint gets half of the threads, so both sides see the same
+// client load and network conditions.
+type dualBenchmark struct {
+	a, b bench.Benchmark
+}
+
+// newDualBenchmark creates a second benchmark using newBench
+// with the host and credentials replaced by the ".b" flags.
+// The threads are divided between the benchmarks.
+func newDualBenchmark(ctx *cli.Context, a bench.Benchmark, newBench func(ctx *cli.Context) bench.Benchmark) bench.Benchmark {
+	bctx, err := dualContext(ctx)
+	fatalIf(probe.NewError(err), "Unable to create benchmark for --host.b")
+	b := newBench(bctx)
+	ca, cb := a.GetCommon(), b.GetCommon()
+	n := ca.Concurrency
+	ca.Concurrency = n / 2
+	cb.Concurrency = n - n/2
+	return &dualBenchmark{a: a, b: b}
+}
+
+// dualContext returns a copy of ctx with the host and credentials replaced by the ".b" flags.
+// ctx is not modified.
+func dualContext(ctx *cli.Context) (*cli.Context, error) {
+	fs, err := flagSet(ctx.Command.Name, ctx.Command.Flags, ctx.Args())
+	if err != nil {
+		return nil, err
+	}
+	bctx := cli.NewContext(ctx.App, fs, nil)
+	bctx.Command = ctx.Command
+	for _, flag := range ctx.Command.Flags {
+		if !ctx.IsSet(flag.GetName()) {
+			continue
+		}
+		v, err := flagToJSON(ctx, flag)
+		if err != nil {
+			return nil, err
+		}
+		if err := bctx.Set(flag.GetName(), v); err != nil {
+			return nil, fmt.Errorf("setting %s: %w", flag.GetName(), err)
+		}
+	}
+	for _, name := range []string{"host", "access-key", "secret-key"} {
+		if v := ctx.String(name + ".b"); v != "" {
+			if err := bctx.Set(name, v); err != nil {
+				return nil, fmt.Errorf("setting %s: %w", name, err)
+			}
+		}
+	}
+	return bctx, nil
+}
+
+// Prepare both benchmarks concurrently.
+// Settings applied to the first benchmark are copied to the second.
+func (d *dualBenchmark) Prepare(ctx context.Context) error {
+	ca, cb := d.a.GetCommon(), d.b.GetCommon()
+	cb.Clear = ca.Clear
+	cb.AutoTermDur = ca.AutoTermDur
+	cb.AutoTermScale = ca.AutoTermScale
+	cb.Error = ca.Error
+	cb.ClientIdx = ca.ClientIdx
+	return d.both(func(b bench.Benchmark) error {
+		return b.Prepare(ctx)
+	})
+}
+
+// AfterPrepare is forwarded to both benchmarks if they implement it.
+func (d *dualBenchmark) AfterPrepare(ctx context.Context) error {
+	return d.both(func(b bench.Benchmark) error {
+		if ap, ok := b.(AfterPreparer); ok {
+			return ap.AfterPrepare(ctx)
+		}
+		return nil
+	})
+}
+
+// Start both benchmarks with the same start signal.
+// Threads of the second endpoint are numbered after the first.
+func (d *dualBenchmark) Start(ctx context.Context, wait chan struct{}) (bench.Operations, error) {
+	var opsA, opsB bench.Operations
+	var errA, errB error
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		opsA, errA = d.a.Start(ctx, wait)
+	}()
+	go func() {
+		defer wg.Done()
+		opsB, errB = d.b.Start(ctx, wait)
+	}()
+	wg.Wait()
+	opsB.OffsetThreads(opsA.OffsetThreads(0))
+	return append(opsA, opsB...), errors.Join(errA, errB)
+}
+
+// Cleanup both benchmarks.
+func (d *dualBenchmark) Cleanup(ctx context.Context) {
+	d.both(func(b bench.Benchmark) error {
+		b.Cleanup(ctx)
+		return nil
+	})
+}
+
+// GetCommon returns the common parameters of the first benchmark.
+func (d *dualBenchmark) GetCommon() *bench.Common {
+	return d.a.GetCommon()
+}
+
+// stopHealth stops the health checkers of b.
+// Dual benchmarks have a health checker for each endpoint.
+func stopHealth(b bench.Benchmark) {
+	if d, ok := b.(*dualBenchmark); ok {
+		d.b.GetCommon().Health.Stop()
+	}
+	b.GetCommon().Health.Stop()
+}
+
+// both runs fn on both benchmarks concurrently.
+func (d *dualBenchmark) both(fn func(b bench.Benchmark) error) error {
+	var errB error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		errB = fn(d.b)
+	}()
+	errA := fn(d.a)
+	wg.Wait()
+	return errors.Join(errA, errB)
+}
+
+// dualEndpointHosts returns the hosts of the second endpoint.
+func dualEndpointHosts(ctx *cli.Context) map[string]struct{} {
+	hosts := parseHosts(ctx.String("host.b"), ctx.Bool("resolve-host"))
+	res := make(map[string]struct{}, len(hosts))
+	for _, h := range hosts {
+		res[h] = struct{}{}
+	}
+	return res
+}
+
+// checkDualEndpoint validates the --host.b parameters.
+func checkDualEndpoint(ctx *cli.Context) {
+	if ctx.String("host.b") == "" {
+		if ctx.String("access-key.b") != "" || ctx.String("secret-key.b") != "" {
+			fatalIf(errDummy(), "--access-key.b and --secret-key.b require --host.b")
+		}
+		return
+	}
+	if ctx.Int("concurrent") < 2 {
+		fatalIf(errDummy(), "--host.b requires --concurrent of at least 2")
+	}
+	b := dualEndpointHosts(ctx)
+	for _, h := range parseHosts(ctx.String("host"), ctx.Bool("resolve-host")) {
+		if _, ok := b[h]; ok {
+			fatalIf(errDummy(), "--host.b cannot contain hosts from --host: "+h)
+		}
+	}
+}
+
+// printDualCompare splits operations by endpoint and prints
+// a comparison of the first endpoint to the second.
+func printDualCompare(ctx *cli.Context, ops bench.Operations) {
+	hostsB := dualEndpointHosts(ctx)
+	var opsA, opsB bench.Operations
+	for _, op := range ops {
+		if u, err := url.Parse(op.Endpoint); err == nil {
+			if _, ok := hostsB[u.Host]; ok {
+				opsB = append(opsB, op)
+				continue
+			}
+		}
+		opsA = append(opsA, op)
+	}
+	if len(opsA) == 0 || len(opsB) == 0 {
+		console.Errorln("Unable to compare endpoints: no operations recorded for one of the endpoints")
+		return
+	}
+	console.SetColor("Print", color.New(color.FgHiWhite))
+	console.Println("\nEndpoint comparison:", ctx.String("host"), "->", ctx.String("host.b"))
+	printCompare(ctx, opsA, opsB)
+}
@@ -0,0 +1,86 @@
+/*
+ * Warp (C) 2019-2020 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/minio/cli"
+	"github.com/minio/warp/pkg/bench"
+)
+
+type dualTestBench struct {
+	bench.Common
+}
+
+func (d *dualTestBench) Prepare(context.Context) error { return nil }
+
+func (d *dualTestBench) Start(context.Context, chan struct{}) (bench.Operations, error) {
+	return nil, nil
+}
+
+func (d *dualTestBench) Cleanup(context.Context) {}
+
+func TestNewDualBenchmark(t *testing.T) {
+	flags := []cli.Flag{
+		cli.StringFlag{Name: "host"},
+		cli.StringFlag{Name: "access-key"},
+		cli.StringFlag{Name: "secret-key"},
+		cli.StringFlag{Name: "host.b"},
+		cli.StringFlag{Name: "access-key.b"},
+		cli.StringFlag{Name: "secret-key.b"},
+		cli.IntFlag{Name: "concurrent"},
+	}
+	set, err := flagSet("put", flags, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := cli.NewContext(nil, set, nil)
+	ctx.Command = cli.Command{Name: "put", Flags: flags}
+	for k, v := range map[string]string{
+		"host":         "a:9000",
+		"access-key":   "keyA",
+		"secret-key":   "secretA",
+		"host.b":       "b:9000",
+		"access-key.b": "keyB",
+		"concurrent":   "5",
+	} {
+		if err := ctx.Set(k, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	type endpoint struct{ host, accessKey, secretKey string }
+	var created []endpoint
+	newBench := func(ctx *cli.Context) bench.Benchmark {
+		created = append(created, endpoint{ctx.String("host"), ctx.String("access-key"), ctx.String("secret-key")})
+		return &dualTestBench{Common: bench.Common{Concurrency: ctx.Int("concurrent")}}
+	}
+	d := newDualBenchmark(ctx, newBench(ctx), newBench).(*dualBenchmark)
+
+	want := []endpoint{{"a:9000", "keyA", "secretA"}, {"b:9000", "keyB", "secretA"}}
+	if len(created) != 2 || created[0] != want[0] || created[1] != want[1] {
+		t.Errorf("got endpoints %v, want %v", created, want)
+	}
+	if got := ctx.String("host"); got != "a:9000" {
+		t.Errorf("host was changed to %q", got)
+	}
+	if a, b := d.a.GetCommon().Concurrency, d.b.GetCommon().Concurrency; a != 2 || b != 3 {
+		t.Errorf("got concurrency %d and %d, want 2 and 3", a, b)
+	}
+}
@@ -0,0 +1,159 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/minio/cli"
+	"github.com/secure-io/sio-go"
+)
+
+// resultsMagic is the header of encrypted benchmark data files.
+// It is followed by the stream nonce and the AES-256-GCM encrypted compressed stream.
+// Each encrypted fragment is authenticated and the final fragment is marked,
+// so modified and truncated files are detected when reading.
+var resultsMagic = []byte("WARPENC1")
+
+var (
+	// errResultsKeyRequired is returned when reading an encrypted file without a key.
+	errResultsKeyRequired = errors.New("benchmark data is encrypted, supply the key with --results.key")
+
+	// errResultsModified is returned when encrypted data cannot be authenticated.
+	errResultsModified = errors.New("benchmark data has been modified or truncated, or the key is wrong")
+)
+
+// resultsKey returns the key used to encrypt and decrypt benchmark data files.
+// A nil key is returned if no key is specified.
+// The key must be 32 bytes, given as hex or base64, or be read from a file with 'file:path'.
+func resultsKey(ctx *cli.Context) ([]byte, error) {
+	s := ctx.String("results.key")
+	if s == "" {
+		return nil, nil
+	}
+	if fn, ok := strings.CutPrefix(s, "file:"); ok {
+		b, err := os.ReadFile(fn)
+		if err != nil {
+			return nil, err
+		}
+		s = strings.TrimSpace(string(b))
+	}
+	if key, err := hex.DecodeString(s); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(s); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	return nil, errors.New("results.key must be 32 bytes encoded as hex or base64")
+}
+
+// createResults creates a benchmark data file.
+// If a key is specified the content is encrypted.
+// The returned writer must be closed to finish the file.
+func createResults(ctx *cli.Context, name string) (io.WriteCloser, error) {
+	key, err := resultsKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return f, nil
+	}
+	w, err := encryptResults(f, key)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// encryptResults writes the encryption header to f and returns a writer encrypting to f.
+// Closing the writer closes f.
+func encryptResults(f io.WriteCloser, key []byte) (io.WriteCloser, error) {
+	stream, err := sio.AES_256_GCM.Stream(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, stream.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(append(append([]byte{}, resultsMagic...), nonce...)); err != nil {
+		return nil, err
+	}
+	return stream.EncryptWriter(f, nonce, resultsMagic), nil
+}
+
+// decryptResults returns a reader that decrypts r if it is encrypted.
+// Unencrypted input is returned unmodified.
+func decryptResults(ctx *cli.Context, r io.ReadCloser) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	hdr, err := br.Peek(len(resultsMagic))
+	if err != nil || !bytes.Equal(hdr, resultsMagic) {
+		// Too short or unencrypted. Let the decompressor report errors.
+		return readCloser{Reader: br, Closer: r}, nil
+	}
+	key, err := resultsKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, errResultsKeyRequired
+	}
+	stream, err := sio.AES_256_GCM.Stream(key)
+	if err != nil {
+		return nil, err
+	}
+	hdr = make([]byte, len(resultsMagic)+stream.NonceSize())
+	if _, err := io.ReadFull(br, hdr); err != nil {
+		return nil, fmt.Errorf("reading encryption header: %w", err)
+	}
+	dec := stream.DecryptReader(br, hdr[len(resultsMagic):], resultsMagic)
+	return readCloser{Reader: authReader{dec}, Closer: r}, nil
+}
+
+// authReader replaces authentication errors with a descriptive error.
+type authReader struct {
+	r io.Reader
+}
+
+func (a authReader) Read(p []byte) (int, error) {
+	n, err := a.r.Read(p)
+	if errors.Is(err, sio.NotAuthentic) {
+		err = errResultsModified
+	}
+	return n, err
+}
+
+// readCloser combines a reader with a separate closer.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
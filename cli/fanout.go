@@ -59,11 +59,13 @@ FLAGS:
 // mainFanout is the entry point for cp command.
 func mainFanout(ctx *cli.Context) error {
 	checkFanoutSyntax(ctx)
-	b := bench.Fanout{
-		Copies: ctx.Int("copies"),
-		Common: getCommon(ctx, newGenSource(ctx, "obj.size")),
-	}
-	return runBench(ctx, &b)
+	return runBench(ctx, func(ctx *cli.Context) bench.Benchmark {
+		b := bench.Fanout{
+			Copies: ctx.Int("copies"),
+			Common: getCommon(ctx, newGenSource(ctx, "obj.size")),
+		}
+		return &b
+	})
 }
 
 func checkFanoutSyntax(ctx *cli.Context) {
@@ -19,8 +19,13 @@ package cli
 
 import (
 	"fmt"
+	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/minio/cli"
 	"github.com/minio/mc/pkg/probe"
@@ -134,9 +139,7 @@ func commandLine(ctx *cli.Context) string {
 		if err != nil || val == "" {
 			continue
 		}
-		name := flag.GetName()
-		switch name {
-		case "access-key", "secret-key", "influxdb":
+		if secretFlag(flag.GetName()) {
 			val = "*REDACTED*"
 		}
 		s += " --" + flag.GetName() + "=" + val
@@ -144,6 +147,33 @@ func commandLine(ctx *cli.Context) string {
 	return s
 }
 
+// secretFlag returns whether the value of the flag must not be stored or displayed.
+func secretFlag(name string) bool {
+	switch name {
+	case "access-key", "secret-key", "access-key.b", "secret-key.b", "shadow.access-key", "shadow.secret-key",
+		"influxdb", "kafka", "results.key":
+		return true
+	}
+	return false
+}
+
+// commandConfig returns the flags set for the command with secrets redacted.
+func commandConfig(ctx *cli.Context) map[string]string {
+	res := make(map[string]string, len(ctx.Command.Flags))
+	for _, flag := range ctx.Command.Flags {
+		val, err := flagToJSON(ctx, flag)
+		if err != nil || val == "" {
+			continue
+		}
+		name := flag.GetName()
+		if secretFlag(name) {
+			val = "*REDACTED*"
+		}
+		res[name] = val
+	}
+	return res
+}
+
 // Flags common across all I/O commands such as cp, mirror, stat, pipe etc.
 var ioFlags = []cli.Flag{
 	cli.StringFlag{
@@ -164,6 +194,18 @@ var ioFlags = []cli.Flag{
 		EnvVar: appNameUC + "_SECRET_KEY",
 		Value:  "",
 	},
+	cli.StringFlag{
+		Name:  "host.b",
+		Usage: "Run the same benchmark against these hosts at the same time and compare. Threads are split between --host and --host.b",
+	},
+	cli.StringFlag{
+		Name:  "access-key.b",
+		Usage: "Access key for --host.b. Defaults to --access-key",
+	},
+	cli.StringFlag{
+		Name:  "secret-key.b",
+		Usage: "Secret key for --host.b. Defaults to --secret-key",
+	},
 	cli.BoolFlag{
 		Name:   "tls",
 		Usage:  "Use TLS (HTTPS) for transport",
@@ -175,10 +217,9 @@ var ioFlags = []cli.Flag{
 		EnvVar: appNameUC + "_REGION",
 	},
 	cli.StringFlag{
-		Name:   "signature",
-		Usage:  "Specify a signature method. Available values are S3V2, S3V4",
-		Value:  "S3V4",
-		Hidden: true,
+		Name:  "signature",
+		Usage: "Specify a signature method. Available values are S3V2, S3V4, S3V4-STREAMING and S3V4-UNSIGNED",
+		Value: "S3V4",
 	},
 	cli.BoolFlag{
 		Name:  "encrypt",
@@ -198,6 +239,33 @@ var ioFlags = []cli.Flag{
 		Value: string(hostSelectTypeWeighed),
 		Usage: fmt.Sprintf("Host selection algorithm. Can be %q or %q", hostSelectTypeWeighed, hostSelectTypeRoundrobin),
 	},
+	cli.StringFlag{
+		Name:  "lookup",
+		Value: "auto",
+		Usage: "Bucket addressing style. Can be 'auto', 'dns' for virtual-host style, 'path' or 'alternate' to alternate between 'dns' and 'path'",
+	},
+	cli.StringFlag{
+		Name:  "backend",
+		Value: "s3",
+		Usage: "Backend to benchmark. Use 'null' to serve requests in-process and measure the maximum rate of the client itself",
+	},
+	cli.DurationFlag{
+		Name:  "health.interval",
+		Usage: "Check health of each host at this interval and stop sending requests to unhealthy hosts. Requires multiple hosts",
+	},
+	cli.IntFlag{
+		Name:  "health.failures",
+		Value: 2,
+		Usage: "Number of consecutive failed health checks before a host is excluded",
+	},
+	cli.StringFlag{
+		Name:  "host-header",
+		Usage: "Address requests to this host name while connecting to the hosts given with --host. Requests are signed for this host",
+	},
+	cli.StringFlag{
+		Name:  "sni",
+		Usage: "Server name sent in the TLS handshake and used to verify certificates. Defaults to the requested host",
+	},
 	cli.BoolFlag{
 		Name:   "resolve-host",
 		Usage:  "Resolve the host(s) ip(s) (including multiple A/AAAA records). This can break SSL certificates, use --insecure if so",
@@ -245,6 +313,10 @@ var ioFlags = []cli.Flag{
 		Value: "",
 		Usage: "Specify custom storage class, for instance 'STANDARD' or 'REDUCED_REDUNDANCY'.",
 	},
+	cli.BoolFlag{
+		Name:  "requester-pays",
+		Usage: "Send 'x-amz-request-payer: requester' with downloads and stat requests to buckets with requester pays enabled.",
+	},
 	cli.BoolFlag{
 		Name:   "disable-http-keepalive",
 		Usage:  "Disable HTTP Keep-Alive",
@@ -259,15 +331,146 @@ var ioFlags = []cli.Flag{
 		Name:  "stress",
 		Usage: "stress test only and discard output",
 	},
+	cli.StringFlag{
+		Name:  "op-sample",
+		Usage: "Only record a sample of the benchmark operations, for example '1/10'. Exact counts and latency percentiles of all operations are recorded",
+	},
 	cli.StringFlag{
 		Name:   "influxdb",
 		EnvVar: appNameUC + "_INFLUXDB_CONNECT",
 		Usage:  "Send operations to InfluxDB. Specify as 'http://<token>@<hostname>:<port>/<bucket>/<org>'",
 	},
-	cli.Float64Flag{
+	cli.StringFlag{
+		Name:   "kafka",
+		EnvVar: appNameUC + "_KAFKA_CONNECT",
+		Usage:  "Send operations to a Kafka topic using a Kafka REST proxy. Specify as 'http://[<user>:<password>@]<hostname>:<port>/<topic>'",
+	},
+	cli.StringFlag{
+		Name:  "kafka.format",
+		Value: "json",
+		Usage: "Serialization of operations sent to Kafka. Can be 'json' or 'avro'",
+	},
+	cli.IntFlag{
+		Name:  "kafka.batch",
+		Value: 500,
+		Usage: "Maximum number of operations sent to Kafka in each request",
+	},
+	cli.StringFlag{
+		Name:  "record",
+		Usage: "Record the sequence of operations to a workload file that can be run again with 'warp replay'",
+	},
+	cli.StringFlag{
 		Name:  "rps-limit",
-		Value: 0,
-		Usage: "Rate limit each instance to this number of requests per second (0 to disable)",
+		Value: "0",
+		Usage: "Rate limit each instance to this number of requests per second (0 to disable). Mixed benchmarks accept limits per operation, eg. 'put=100,get=2000'",
+	},
+	cli.Float64Flag{
+		Name:  "rps-limit.cluster",
+		Usage: "Limit all clients of a distributed benchmark to this total number of requests per second. Shares are rebalanced between clients",
+	},
+	cli.StringFlag{
+		Name:  "bw-limit",
+		Value: "0",
+		Usage: "Limit the bytes sent and received by each instance to this number per second. Can be a number or 10KiB/MiB/GiB (0 to disable)",
+	},
+	cli.StringFlag{
+		Name:  "bw-limit.cluster",
+		Usage: "Limit all clients of a distributed benchmark to this total number of bytes per second. Shares are rebalanced between clients",
+	},
+	cli.BoolFlag{
+		Name:  "adjust",
+		Usage: "Allow changing the concurrency and request rate while the benchmark is running, using SIGUSR1/SIGUSR2 or the --serve API",
+	},
+	cli.IntFlag{
+		Name:  "adjust.concurrent",
+		Usage: "Number of active threads when starting with --adjust. --concurrent is the maximum. Defaults to --concurrent",
+	},
+	cli.IntFlag{
+		Name:  "adjust.step",
+		Value: 1,
+		Usage: "Number of threads added or removed by each SIGUSR1 and SIGUSR2 signal with --adjust",
+	},
+	cli.DurationFlag{
+		Name:  "rps-limit.jitter",
+		Usage: "Add a random delay up to this duration to each rate limited request",
+	},
+	cli.DurationFlag{
+		Name:  "start-jitter",
+		Usage: "Delay the start of each thread by a random duration up to this value",
+	},
+	cli.StringFlag{
+		Name:  "record-headers",
+		Value: "x-amz-version-id,x-amz-storage-class,x-amz-request-charged,x-amz-server-side-encryption,x-amz-server-side-encryption-customer-algorithm,x-cache,cf-cache-status,cache-status",
+		Usage: "Comma separated list of response headers to record with each operation. The request ID is always recorded.",
+	},
+	cli.StringFlag{
+		Name:  "run-id",
+		Usage: "ID of the run sent with --run-id.header. A random ID is used if not set.",
+	},
+	cli.StringFlag{
+		Name:  "run-id.header",
+		Usage: "Send the run ID in this header with every request, eg. 'X-Warp-Run-ID'.",
+	},
+	cli.StringFlag{
+		Name:  "op-id.header",
+		Usage: "Send a random ID of each operation in this header with its requests, eg. 'X-Warp-Op-ID'. The ID is recorded with the operation.",
+	},
+	cli.BoolFlag{
+		Name:  "tcp-stats",
+		Usage: "Record retransmits, round trip time and congestion window of connections every second. Linux only.",
+	},
+	cli.BoolFlag{
+		Name:  "trace-phases",
+		Usage: "Record the time spent in DNS, connect, TLS, send, time to first byte and transfer for each operation.",
+	},
+	cli.BoolFlag{
+		Name:  "retry-after",
+		Usage: "Wait as advised by Retry-After before retrying requests throttled with 429 or 503, up to 1m. The time waited is recorded separately.",
+	},
+	cli.BoolFlag{
+		Name:  "expect-continue",
+		Usage: "Send uploads with 'Expect: 100-continue' and record the time until the server accepts the request separately. Implies --trace-phases.",
+	},
+	cli.StringFlag{
+		Name:  "stall.speed",
+		Usage: "Record a stall when the speed of a download stays below this number of bytes per second for --stall.dur, eg. 100KiB",
+	},
+	cli.DurationFlag{
+		Name:  "stall.dur",
+		Value: 5 * time.Second,
+		Usage: "Duration the download speed must stay below --stall.speed to be recorded as a stall",
+	},
+	cli.StringFlag{
+		Name:  "ip-version",
+		Value: "dual",
+		Usage: "IP version used to connect to hosts. Can be '4', '6' or 'dual' to use both",
+	},
+	cli.DurationFlag{
+		Name:  "ip.fallback-delay",
+		Usage: "With --ip-version=dual, time to wait for the preferred address family before also trying the other. Default 300ms, negative disables fallback",
+	},
+}
+
+// prepareFlags are flags for benchmarks that upload objects before running.
+var prepareFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "prepare.strategy",
+		Value: string(bench.PrepareStrategyPut),
+		Usage: "How to create objects when preparing. 'put' uploads all objects, 'copy' uploads seed objects and uses server side copy for the rest, 'snowball' uploads objects in batches.",
+	},
+	cli.IntFlag{
+		Name:  "prepare.concurrent",
+		Usage: "Number of threads uploading objects when preparing. Default is --concurrent.",
+	},
+	cli.IntFlag{
+		Name:  "prepare.seeds",
+		Value: 10,
+		Usage: "Number of objects to upload per thread before copying when using '--prepare.strategy=copy'.",
+	},
+	cli.IntFlag{
+		Name:  "prepare.batch",
+		Value: 1000,
+		Usage: "Maximum number of objects in each upload when using '--prepare.strategy=snowball'.",
 	},
 }
 
@@ -282,24 +485,103 @@ func getCommon(ctx *cli.Context, src func() generator.Source) bench.Common {
 			extra = append(extra, in)
 		}
 	}
+	if ctx.String("kafka") != "" {
+		extra = append(extra, newKafka(ctx, &globalWG))
+	}
+	if ctx.String("record") != "" {
+		extra = append(extra, newWorkloadRecorder(ctx, &globalWG))
+	}
 
-	rpsLimit := ctx.Float64("rps-limit")
+	rpsLimit, _, err := parseRpsLimit(ctx.String("rps-limit"))
+	fatalIf(probe.NewError(err), "Invalid rps-limit")
 	var rpsLimiter *rate.Limiter
+	var rpsRequests *atomic.Int64
 	if rpsLimit > 0 {
 		// set burst to 1 as limiter will always be called to wait for 1 token
 		rpsLimiter = rate.NewLimiter(rate.Limit(rpsLimit), 1)
+		rpsRequests = new(atomic.Int64)
 	}
 
+	sample, err := opSample(ctx)
+	fatalIf(probe.NewError(err), "Invalid op-sample")
+
+	adjust, rpsLimiter := newAdjuster(ctx, rpsLimiter)
+	client, health := newClient(ctx)
 	return bench.Common{
-		Client:        newClient(ctx),
+		Client:        client,
+		Health:        health,
 		Concurrency:   ctx.Int("concurrent"),
 		Source:        src,
 		Bucket:        ctx.String("bucket"),
 		Location:      ctx.String("region"),
 		PutOpts:       putOpts(ctx),
 		DiscardOutput: ctx.Bool("stress"),
+		Quiet:         globalQuiet || globalJSON,
 		ExtraOut:      extra,
 		RpsLimiter:    rpsLimiter,
-		Transport:     clientTransport(ctx),
+		Adjust:        adjust,
+		RpsRequests:   rpsRequests,
+		BwLimiter:     bandwidthLimiter(ctx),
+		RpsJitter:     ctx.Duration("rps-limit.jitter"),
+		StartJitter:   ctx.Duration("start-jitter"),
+		Transport:     clientTransport(ctx, ""),
+		TCPStats:      tcpStats(ctx),
+		RecordHeaders: recordHeaders(ctx),
+		OpSample:      sample,
+
+		PrepareStrategy: bench.PrepareStrategy(ctx.String("prepare.strategy")),
+		PrepareSeeds:    ctx.Int("prepare.seeds"),
+		PrepareBatch:    ctx.Int("prepare.batch"),
+
+		PrepareConcurrency: ctx.Int("prepare.concurrent"),
+
+		CleanupConcurrency: ctx.Int("cleanup.concurrent"),
+		CleanupBatch:       ctx.Int("cleanup.batch"),
+		CleanupRate:        ctx.Float64("cleanup.rate"),
+		SpoolDir:           ctx.String("spool"),
+	}
+}
+
+// recordHeaders returns the response headers to record.
+func recordHeaders(ctx *cli.Context) []string {
+	var headers []string
+	for _, h := range strings.Split(ctx.String("record-headers"), ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			headers = append(headers, http.CanonicalHeaderKey(h))
+		}
+	}
+	return headers
+}
+
+// parseRpsLimit parses the --rps-limit value.
+// It is either a single limit for all requests, or limits
+// per operation type, eg. "put=100,get=2000".
+func parseRpsLimit(s string) (all float64, perOp map[string]float64, err error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil, nil
+	}
+	if !strings.Contains(s, "=") {
+		all, err = strconv.ParseFloat(s, 64)
+		if err == nil && all < 0 {
+			err = fmt.Errorf("negative limit: %v", s)
+		}
+		return all, nil, err
+	}
+	perOp = make(map[string]float64)
+	for _, kv := range strings.Split(s, ",") {
+		op, limit, ok := strings.Cut(strings.TrimSpace(kv), "=")
+		if !ok {
+			return 0, nil, fmt.Errorf("expected op=limit, got %q", kv)
+		}
+		v, err := strconv.ParseFloat(limit, 64)
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid limit for %s: %w", op, err)
+		}
+		if v <= 0 {
+			return 0, nil, fmt.Errorf("limit for %s must be > 0", op)
+		}
+		perOp[strings.ToUpper(strings.TrimSpace(op))] = v
 	}
+	return 0, perOp, nil
 }
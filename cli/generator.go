@@ -20,6 +20,8 @@ package cli
 import (
 	"errors"
 	"fmt"
+	"math/rand"
+	"strconv"
 	"strings"
 
 	"github.com/dustin/go-humanize"
@@ -33,12 +35,55 @@ var genFlags = []cli.Flag{
 	cli.StringFlag{
 		Name:  "obj.generator",
 		Value: "random",
-		Usage: "Use specific data generator",
+		Usage: "Use specific data generator: 'random', 'csv' or 'file'",
+	},
+	cli.StringFlag{
+		Name:  "obj.path",
+		Usage: "Read object content from this directory, file or block device. Implies --obj.generator=file",
 	},
 	cli.BoolFlag{
 		Name:  "obj.randsize",
 		Usage: "Randomize size of objects so they will be up to the specified size",
 	},
+	cli.Float64Flag{
+		Name:  "hot.fraction",
+		Usage: "Fraction of objects, 0 to 1, placed in shared hot prefixes instead of the per thread prefix",
+	},
+	cli.IntFlag{
+		Name:  "hot.prefixes",
+		Value: 1,
+		Usage: "Number of shared hot prefixes used with --hot.fraction",
+	},
+	cli.Int64Flag{
+		Name:  "seed",
+		Usage: "Seed for generated object names, sizes and data. Runs with the same seed generate the same objects. Default is a random seed",
+	},
+	cli.StringFlag{
+		Name:  "names.scheme",
+		Value: "random",
+		Usage: "How object names are kept apart on distributed clients. 'random' uses random prefixes, 'lease' assigns each client its own prefix",
+	},
+	cli.StringFlag{
+		Name:  "names.lease",
+		Usage: "Place all objects under this prefix. Set for each client by the server with --names.scheme=lease",
+	},
+	cli.Float64Flag{
+		Name:  "names.collide",
+		Usage: "Fraction of objects, 0 to 1, given a name shared by all clients and threads, to test overwrite contention",
+	},
+	cli.IntFlag{
+		Name:  "names.collide-keys",
+		Value: 100,
+		Usage: "Number of shared names used with --names.collide",
+	},
+	cli.StringFlag{
+		Name:  "obj.name-classes",
+		Usage: "Comma separated object name classes to pick from for each object: " + strings.Join(generator.NameClasses, ", "),
+	},
+	cli.StringFlag{
+		Name:  "obj.content-types",
+		Usage: "Comma separated extensions with weights to pick from for each object, eg. 'jpg:60,json:30,parquet:10'. Sets the content type and text formats get text content. Random generator only",
+	},
 }
 
 func newGenSourceCSV(ctx *cli.Context) func() generator.Source {
@@ -56,24 +101,84 @@ func newGenSourceCSV(ctx *cli.Context) func() generator.Source {
 		generator.WithPrefixSize(prefixSize),
 		generator.WithSize(int64(size)),
 		generator.WithRandomSize(ctx.Bool("obj.randsize")),
+		generator.WithHotPrefixes(ctx.Int("hot.prefixes"), ctx.Float64("hot.fraction")),
+		generator.WithNameClasses(nameClasses(ctx)...),
+		generator.WithSeed(genSeed(ctx)),
+		generator.WithNameLease(ctx.String("names.lease")),
+		generator.WithCollisions(ctx.Int("names.collide-keys"), ctx.Float64("names.collide")),
 	)
 	fatalIf(probe.NewError(err), "Unable to create data generator")
 	return src
 }
 
+// Object name schemes of distributed clients.
+const (
+	nameSchemeRandom = "random"
+	nameSchemeLease  = "lease"
+)
+
+// nameLease returns the name lease of the client with the specified index.
+func nameLease(clientIdx int) string {
+	return fmt.Sprintf("client-%d", clientIdx)
+}
+
+// nameClasses returns the object name classes selected.
+func nameClasses(ctx *cli.Context) []string {
+	s := ctx.String("obj.name-classes")
+	if s == "" {
+		return nil
+	}
+	classes := strings.Split(s, ",")
+	for i := range classes {
+		classes[i] = strings.TrimSpace(classes[i])
+	}
+	return classes
+}
+
+// genSeed returns the seed for generated objects.
+// If no seed is set, a random seed is selected and stored in the context,
+// so it is recorded with the run.
+func genSeed(ctx *cli.Context) int64 {
+	seed := ctx.Int64("seed")
+	for seed == 0 {
+		seed = rand.Int63()
+		// Commands without the flag will not record the seed.
+		_ = ctx.Set("seed", strconv.FormatInt(seed, 10))
+	}
+	return seed
+}
+
 // newGenSource returns a new generator
 func newGenSource(ctx *cli.Context, sizeField string) func() generator.Source {
+	return newGenSourceSize(ctx, ctx.String(sizeField))
+}
+
+// newGenSourceSize returns a new generator with the specified object size.
+// The size can be a single size or a "min,max" range.
+func newGenSourceSize(ctx *cli.Context, sizeSpec string) func() generator.Source {
 	prefixSize := 8
 	if ctx.Bool("noprefix") {
 		prefixSize = 0
 	}
 
+	gen := ctx.String("obj.generator")
+	if ctx.String("obj.path") != "" && !ctx.IsSet("obj.generator") {
+		gen = "file"
+	}
+	if gen != "random" && ctx.String("obj.content-types") != "" {
+		fatal(probe.NewError(errors.New("--obj.content-types can only be used with the random generator")), "Invalid -generator parameter")
+	}
 	var g generator.OptionApplier
-	switch ctx.String("obj.generator") {
+	switch gen {
 	case "random":
 		g = generator.WithRandomData()
 	case "csv":
 		g = generator.WithCSV().Size(25, 1000)
+	case "file":
+		if ctx.String("obj.path") == "" {
+			fatal(probe.NewError(errors.New("--obj.path must be specified")), "Invalid -generator parameter")
+		}
+		g = generator.WithFiles(ctx.String("obj.path"))
 	default:
 		err := errors.New("unknown generator type:" + ctx.String("obj.generator"))
 		fatal(probe.NewError(err), "Invalid -generator parameter")
@@ -83,7 +188,7 @@ func newGenSource(ctx *cli.Context, sizeField string) func() generator.Source {
 		generator.WithCustomPrefix(ctx.String("prefix")),
 		generator.WithPrefixSize(prefixSize),
 	}
-	tokens := strings.Split(ctx.String(sizeField), ",")
+	tokens := strings.Split(sizeSpec, ",")
 	switch len(tokens) {
 	case 1:
 		size, err := toSize(tokens[0])
@@ -102,8 +207,16 @@ func newGenSource(ctx *cli.Context, sizeField string) func() generator.Source {
 		}
 		opts = append(opts, generator.WithMinMaxSize(int64(minSize), int64(maxSize)))
 	default:
-		fatalIf(probe.NewError(fmt.Errorf("unexpected obj.size specified: %s", ctx.String(sizeField))), "Invalid obj.size parameter")
+		fatalIf(probe.NewError(fmt.Errorf("unexpected obj.size specified: %s", sizeSpec)), "Invalid obj.size parameter")
 	}
+	opts = append(opts, generator.WithHotPrefixes(ctx.Int("hot.prefixes"), ctx.Float64("hot.fraction")))
+	opts = append(opts, generator.WithNameClasses(nameClasses(ctx)...))
+	contentTypes, err := generator.ParseContentTypes(ctx.String("obj.content-types"))
+	fatalIf(probe.NewError(err), "Invalid obj.content-types specified")
+	opts = append(opts, generator.WithContentTypes(contentTypes...))
+	opts = append(opts, generator.WithSeed(genSeed(ctx)))
+	opts = append(opts, generator.WithNameLease(ctx.String("names.lease")))
+	opts = append(opts, generator.WithCollisions(ctx.Int("names.collide-keys"), ctx.Float64("names.collide")))
 	opts = append([]generator.Option{g.Apply()}, append(opts, generator.WithRandomSize(ctx.Bool("obj.randsize")))...)
 	src, err := generator.NewFn(opts...)
 	fatalIf(probe.NewError(err), "Unable to create data generator")
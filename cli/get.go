@@ -18,6 +18,9 @@
 package cli
 
 import (
+	"path"
+	"slices"
+
 	"github.com/minio/cli"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/pkg/v2/console"
@@ -33,6 +36,10 @@ var getFlags = []cli.Flag{
 		Name:  "list-flat",
 		Usage: "When using --list-existing, do not use recursive listing",
 	},
+	cli.BoolFlag{
+		Name:  "resume",
+		Usage: "Resume an interrupted prepare. Objects already in the bucket are kept and only missing objects and versions are uploaded",
+	},
 	cli.IntFlag{
 		Name:  "objects",
 		Value: 2500,
@@ -62,6 +69,42 @@ var getFlags = []cli.Flag{
 		Value: 1,
 		Usage: "Number of versions to upload. If more than 1, versioned listing will be benchmarked",
 	},
+	cli.StringFlag{
+		Name:  "download-dir",
+		Usage: "Write downloaded objects to files in this directory instead of discarding them.",
+	},
+	cli.BoolFlag{
+		Name:  "download.direct",
+		Usage: "Write downloaded files with O_DIRECT, bypassing the page cache. Linux only.",
+	},
+	cli.BoolFlag{
+		Name:  "download.sync",
+		Usage: "Fsync each downloaded file before the download is considered complete.",
+	},
+	cli.StringFlag{
+		Name:  "content-encoding",
+		Usage: "Compress uploaded objects and store them with this content encoding. Only 'gzip' is supported.",
+	},
+	cli.BoolFlag{
+		Name:  "decompress",
+		Usage: "Decompress downloaded objects. Requires --content-encoding.",
+	},
+	cli.Float64Flag{
+		Name:  "missing",
+		Usage: "Percentage of requests for objects that don't exist. These are reported separately as GET-MISSING and must return 404",
+	},
+	cli.BoolFlag{
+		Name:  "missing.deleted",
+		Usage: "Delete the --missing percentage of objects when preparing and request those instead of objects never created",
+	},
+	accessFlag,
+}
+
+// accessFlag selects the order objects are read in.
+var accessFlag = cli.StringFlag{
+	Name:  "access",
+	Value: string(bench.AccessRandom),
+	Usage: "Order objects are read in. 'random', 'sequential' reads a range of objects per thread in name order, 'global' reads all objects in name order, 'strided' reads every n'th object per thread",
 }
 
 var getCmd = cli.Command{
@@ -69,7 +112,7 @@ var getCmd = cli.Command{
 	Usage:  "benchmark get objects",
 	Action: mainGet,
 	Before: setGlobalsFromContext,
-	Flags:  combineFlags(globalFlags, ioFlags, getFlags, genFlags, benchFlags, analyzeFlags),
+	Flags:  combineFlags(globalFlags, ioFlags, getFlags, prepareFlags, genFlags, benchFlags, shadowFlags, analyzeFlags),
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
@@ -95,22 +138,46 @@ func mainGet(ctx *cli.Context) error {
 		rangeSize = int64(s)
 	}
 
-	sse := newSSE(ctx)
-	b := bench.Get{
-		Common:        getCommon(ctx, newGenSource(ctx, "obj.size")),
-		Versions:      ctx.Int("versions"),
-		RandomRanges:  ctx.Bool("range") || ctx.IsSet("range-size"),
-		RangeSize:     rangeSize,
-		CreateObjects: ctx.Int("objects"),
-		GetOpts:       minio.GetObjectOptions{ServerSideEncryption: sse},
-		ListExisting:  ctx.Bool("list-existing"),
-		ListFlat:      ctx.Bool("list-flat"),
-		ListPrefix:    ctx.String("prefix"),
-	}
-	if b.ListExisting && !ctx.IsSet("objects") {
-		b.CreateObjects = 0
+	return runBench(ctx, func(ctx *cli.Context) bench.Benchmark {
+		b := bench.Get{
+			Common:          getCommon(ctx, newGenSource(ctx, "obj.size")),
+			Versions:        ctx.Int("versions"),
+			RandomRanges:    ctx.Bool("range") || ctx.IsSet("range-size"),
+			RangeSize:       rangeSize,
+			CreateObjects:   ctx.Int("objects"),
+			GetOpts:         getOpts(ctx),
+			ListExisting:    ctx.Bool("list-existing"),
+			ListFlat:        ctx.Bool("list-flat"),
+			ListPrefix:      ctx.String("prefix"),
+			Resume:          ctx.Bool("resume"),
+			ResumePrefix:    path.Join(ctx.String("prefix"), ctx.String("names.lease")),
+			DownloadDir:     ctx.String("download-dir"),
+			DownloadDirect:  ctx.Bool("download.direct"),
+			DownloadSync:    ctx.Bool("download.sync"),
+			ContentEncoding: ctx.String("content-encoding"),
+			Decompress:      ctx.Bool("decompress"),
+			Missing:         ctx.Float64("missing") / 100,
+			MissingDeleted:  ctx.Bool("missing.deleted"),
+			AccessOrder:     bench.AccessOrder(ctx.String("access")),
+		}
+		if b.ListExisting && !ctx.IsSet("objects") {
+			b.CreateObjects = 0
+		}
+		b.Shadow = newShadow(ctx)
+		return &b
+	})
+}
+
+// requestPayerHeader is sent to buckets with requester pays enabled.
+const requestPayerHeader = "x-amz-request-payer"
+
+// getOpts returns the download options set in the context.
+func getOpts(ctx *cli.Context) minio.GetObjectOptions {
+	opts := minio.GetObjectOptions{ServerSideEncryption: newSSE(ctx)}
+	if ctx.Bool("requester-pays") {
+		opts.Set(requestPayerHeader, "requester")
 	}
-	return runBench(ctx, &b)
+	return opts
 }
 
 func checkGetSyntax(ctx *cli.Context) {
@@ -123,6 +190,50 @@ func checkGetSyntax(ctx *cli.Context) {
 	if ctx.Int("objects") < 1 {
 		console.Fatal("At least one object must be tested")
 	}
+	if ctx.String("download-dir") == "" && (ctx.Bool("download.direct") || ctx.Bool("download.sync")) {
+		console.Fatal("--download.direct and --download.sync require --download-dir")
+	}
+	if ctx.Bool("resume") && ctx.Bool("list-existing") {
+		console.Fatal("--resume cannot be used with --list-existing")
+	}
+	if m := ctx.Float64("missing"); m < 0 || m > 100 {
+		console.Fatal("--missing must be a percentage between 0 and 100")
+	}
+	checkAccess(ctx)
+	if ctx.Bool("missing.deleted") {
+		if !ctx.IsSet("missing") {
+			console.Fatal("--missing.deleted requires --missing")
+		}
+		if ctx.Bool("list-existing") {
+			console.Fatal("--missing.deleted cannot be used with --list-existing")
+		}
+	}
+	if ce := ctx.String("content-encoding"); ce != "" {
+		if ce != "gzip" {
+			console.Fatal("Only 'gzip' content encoding is supported")
+		}
+		if ctx.Bool("list-existing") {
+			console.Fatal("--content-encoding cannot be used with --list-existing")
+		}
+		if ctx.String("prepare.strategy") != string(bench.PrepareStrategyPut) {
+			console.Fatal("--content-encoding requires --prepare.strategy=" + string(bench.PrepareStrategyPut))
+		}
+	}
+	if ctx.Bool("decompress") {
+		if ctx.String("content-encoding") == "" {
+			console.Fatal("--decompress requires --content-encoding")
+		}
+		if ctx.Bool("range") || ctx.IsSet("range-size") || ctx.String("download-dir") != "" {
+			console.Fatal("--decompress cannot be used with ranged gets or --download-dir")
+		}
+	}
 	checkAnalyze(ctx)
 	checkBenchmark(ctx)
 }
+
+// checkAccess verifies the --access order.
+func checkAccess(ctx *cli.Context) {
+	if a := ctx.String("access"); !slices.Contains(bench.AccessOrders, bench.AccessOrder(a)) {
+		console.Fatalf("unknown access order %q. Possible values are: %v.\n", a, bench.AccessOrders)
+	}
+}
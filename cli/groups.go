@@ -0,0 +1,97 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/minio/cli"
+)
+
+// clientGroup is a group of clients running a different benchmark than the main command.
+type clientGroup struct {
+	hosts   []string
+	command string
+	args    cli.Args
+	flags   map[string]string
+}
+
+// parseClientGroups parses the --warp-client.group values.
+// Each value has the format "host1,host2=command --flag=value ...".
+// Flags of the main command are inherited if the group command has them,
+// except flags in exclude.
+func parseClientGroups(ctx *cli.Context, mainFlags map[string]string, exclude map[string]struct{}) ([]clientGroup, error) {
+	var groups []clientGroup
+	for _, def := range ctx.StringSlice("warp-client.group") {
+		hosts, cmdLine, ok := strings.Cut(def, "=")
+		if !ok {
+			return nil, fmt.Errorf("group %q: expected 'hosts=command [flags]'", def)
+		}
+		g := clientGroup{hosts: parseHosts(hosts, false)}
+		if len(g.hosts) == 0 {
+			return nil, fmt.Errorf("group %q: no hosts", def)
+		}
+		fields := strings.Fields(cmdLine)
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("group %q: no command", def)
+		}
+		var cmd *cli.Command
+		for i := range benchCmds {
+			if benchCmds[i].Name == fields[0] {
+				cmd = &benchCmds[i]
+				break
+			}
+		}
+		if cmd == nil {
+			return nil, fmt.Errorf("group %q: unknown benchmark %q", def, fields[0])
+		}
+		g.command = cmd.Name
+
+		set := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+		for _, f := range cmd.Flags {
+			f.Apply(set)
+		}
+		if err := set.Parse(fields[1:]); err != nil {
+			return nil, fmt.Errorf("group %q: %w", def, err)
+		}
+		gctx := cli.NewContext(ctx.App, set, nil)
+		gctx.Command = *cmd
+		g.args = gctx.Args()
+
+		g.flags = make(map[string]string, len(mainFlags))
+		for _, f := range cmd.Flags {
+			name := f.GetName()
+			if _, ok := exclude[name]; ok {
+				continue
+			}
+			if gctx.IsSet(name) {
+				v, err := flagToJSON(gctx, f)
+				if err != nil {
+					return nil, fmt.Errorf("group %q: %w", def, err)
+				}
+				g.flags[name] = v
+			} else if v, ok := mainFlags[name]; ok {
+				g.flags[name] = v
+			}
+		}
+		groups = append(groups, g)
+	}
+	return groups, nil
+}
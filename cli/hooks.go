@@ -0,0 +1,143 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/warp/pkg/bench"
+)
+
+// Benchmark phases where hooks can be executed.
+const (
+	hookPrePrepare = "pre-prepare"
+	hookPreRun     = "pre-run"
+	hookPostRun    = "post-run"
+)
+
+// runHook will execute the hook command for the phase, if any.
+// The command is executed using the system shell with environment variables describing the run.
+// Output is written to stderr so it doesn't interfere with the benchmark output.
+// If the command is an http or https URL, the variables are posted to it instead.
+func runHook(ctx *cli.Context, phase string, env ...string) error {
+	command := ctx.String("hook." + phase)
+	if command == "" {
+		return nil
+	}
+	hctx := context.Background()
+	if timeout := ctx.Duration("hook.timeout"); timeout > 0 {
+		var cancel context.CancelFunc
+		hctx, cancel = context.WithTimeout(hctx, timeout)
+		defer cancel()
+	}
+	if strings.HasPrefix(command, "http://") || strings.HasPrefix(command, "https://") {
+		return runHTTPHook(hctx, phase, command, append(hookEnv(ctx, phase), env...))
+	}
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(hctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(hctx, "sh", "-c", command)
+	}
+	cmd.Env = append(append(os.Environ(), hookEnv(ctx, phase)...), env...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	printInfo(fmt.Sprintf("Running %s hook: %s", phase, command))
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s hook %q: %w", phase, command, err)
+	}
+	return nil
+}
+
+// runHTTPHook posts the variables describing the run as a JSON object to url.
+// The hook fails unless a 2xx status is returned.
+func runHTTPHook(ctx context.Context, phase, url string, env []string) error {
+	vars := make(map[string]string, len(env))
+	for _, kv := range env {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			vars[k] = v
+		}
+	}
+	body, err := json.Marshal(vars)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%s hook %q: %w", phase, url, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	printInfo(fmt.Sprintf("Calling %s hook: %s", phase, url))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s hook %q: %w", phase, url, err)
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s hook %q: %s: %s", phase, url, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// hookEnv returns the environment variables describing the run.
+func hookEnv(ctx *cli.Context, phase string) []string {
+	env := []string{
+		"WARP_HOOK=" + phase,
+		"WARP_BENCHMARK=" + ctx.Command.Name,
+		"WARP_HOST=" + ctx.String("host"),
+		"WARP_BUCKET=" + ctx.String("bucket"),
+		"WARP_CONCURRENT=" + strconv.Itoa(ctx.Int("concurrent")),
+		"WARP_DURATION=" + ctx.Duration("duration").String(),
+	}
+	if clients := ctx.String("warp-client"); clients != "" {
+		env = append(env, "WARP_CLIENTS="+clients)
+	}
+	if tags := benchTags(ctx); len(tags) > 0 {
+		env = append(env, "WARP_TAGS="+tags.String())
+	}
+	return env
+}
+
+// hookResultEnv returns the environment variables describing the result of a run.
+func hookResultEnv(ctx *cli.Context, fileName string, ops bench.Operations) []string {
+	env := []string{
+		"WARP_OPERATIONS=" + strconv.Itoa(len(ops)),
+		"WARP_ERRORS=" + strconv.Itoa(ops.NErrors()),
+	}
+	if fn := benchDataFile(ctx, fileName, ops); fn != "" {
+		env = append(env, "WARP_BENCHDATA="+fn)
+	}
+	start, end := ops.TimeRange()
+	if !start.IsZero() {
+		env = append(env, "WARP_START="+start.UTC().Format(time.RFC3339Nano), "WARP_END="+end.UTC().Format(time.RFC3339Nano))
+	}
+	return env
+}
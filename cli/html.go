@@ -0,0 +1,309 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/warp/pkg"
+	"github.com/minio/warp/pkg/aggregate"
+	"github.com/minio/warp/pkg/bench"
+)
+
+// htmlReport contains the data of a HTML report.
+type htmlReport struct {
+	Title      string
+	Version    string
+	Generated  string
+	Start, End string
+	Duration   time.Duration
+	Tags       string
+	N, Errors  int
+	Operations []htmlOp
+	Charts     []template.HTML
+}
+
+// htmlOp contains the results of an operation type.
+type htmlOp struct {
+	aggregate.Operation
+	Latency *summaryLatency
+	MiBps   float64
+}
+
+var htmlReportTmpl = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em auto; max-width: 1000px; color: #222; }
+h1 { font-size: 1.6em; }
+h2 { font-size: 1.2em; margin-top: 2em; border-bottom: 1px solid #ddd; }
+table { border-collapse: collapse; width: 100%; font-size: 0.9em; }
+th, td { padding: 4px 8px; border-bottom: 1px solid #eee; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+.meta td { text-align: left; }
+.err { color: #c0392b; }
+svg { display: block; margin: 1em 0; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<table class="meta">
+<tr><td>Start</td><td>{{.Start}}</td></tr>
+<tr><td>End</td><td>{{.End}}</td></tr>
+<tr><td>Duration</td><td>{{.Duration}}</td></tr>
+<tr><td>Operations</td><td>{{.N}}{{if .Errors}} <span class="err">({{.Errors}} errors)</span>{{end}}</td></tr>
+{{if .Tags}}<tr><td>Tags</td><td>{{.Tags}}</td></tr>{{end}}
+</table>
+<h2>Operations</h2>
+<table>
+<tr><th>Operation</th><th>Requests</th><th>Errors</th><th>Concurrency</th><th>MiB/s</th><th>obj/s</th><th>Avg</th><th>50%</th><th>90%</th><th>99%</th><th>Slowest</th></tr>
+{{range .Operations}}<tr><td>{{.Type}}</td><td>{{.N}}</td><td{{if .Errors}} class="err"{{end}}>{{.Errors}}</td><td>{{.Concurrency}}</td>
+{{if .Skipped}}<td colspan="7">Skipped, too few samples</td>
+{{else}}<td>{{printf "%.2f" .MiBps}}</td><td>{{printf "%.2f" .Throughput.AverageOPS}}</td>
+{{with .Latency}}<td>{{.Average}}ms</td><td>{{.P50}}ms</td><td>{{.P90}}ms</td><td>{{.P99}}ms</td><td>{{.Slowest}}ms</td>{{else}}<td colspan="5"></td>{{end}}{{end}}</tr>
+{{end}}</table>
+{{range .Operations}}{{if .FirstErrors}}<h2 class="err">{{.Type}} errors</h2>
+<ul>{{range .FirstErrors}}<li>{{.}}</li>{{end}}</ul>
+{{end}}{{end}}
+{{range .Charts}}{{.}}
+{{end}}
+<p><small>Generated by warp {{.Version}} at {{.Generated}}</small></p>
+</body>
+</html>
+`))
+
+// writeHTMLReport writes a self-contained HTML report of the operations to the file given by --html.
+func writeHTMLReport(ctx *cli.Context, fn string, ops bench.Operations, aggr aggregate.Aggregated, tags bench.Tags) error {
+	start, end := ops.TimeRange()
+	r := htmlReport{
+		Title:     "Warp benchmark report",
+		Version:   pkg.Version,
+		Generated: time.Now().Format(time.RFC3339),
+		Start:     start.Format(time.RFC3339),
+		End:       end.Format(time.RFC3339),
+		Duration:  end.Sub(start).Round(time.Second),
+		Tags:      tags.String(),
+		N:         len(ops),
+		Errors:    ops.NErrors(),
+	}
+	if len(tags) == 0 {
+		r.Tags = ""
+	}
+	segDur := analysisDur(ctx, end.Sub(start))
+	if segDur <= 0 {
+		segDur = time.Second
+	}
+	var tputMiB, tputObjs, reqs, errs []svgSeries
+	hasBytes := false
+	var latLabels []string
+	var latValues [][]float64
+	for _, op := range aggr.Operations {
+		typOps := ops.FilterByOp(op.Type)
+		hop := htmlOp{Operation: op, MiBps: op.Throughput.AverageBPS / (1 << 20)}
+		hop.Latency = summaryLatencies(typOps.FilterSuccessful())
+		r.Operations = append(r.Operations, hop)
+		if hop.Latency != nil {
+			latLabels = append(latLabels, op.Type)
+			latValues = append(latValues, []float64{hop.Latency.P50, hop.Latency.P90, hop.Latency.P99})
+		}
+
+		ok := typOps.FilterSuccessful()
+		ok.SortByStartTime()
+		segs := ok.Segment(bench.SegmentOptions{
+			From:           start,
+			PerSegDuration: segDur,
+			AllThreads:     !aggr.Mixed,
+		})
+		segs.SortByTime()
+		var mib, objs, avg svgSeries
+		mib.Name, objs.Name, avg.Name = op.Type, op.Type, op.Type
+		for _, s := range segs {
+			x := s.Start.Sub(start).Seconds()
+			m, _, o := s.SpeedPerSec()
+			mib.add(x, m)
+			objs.add(x, o)
+			if s.OpsEnded > 0 {
+				avg.add(x, s.ReqAvg)
+			}
+			hasBytes = hasBytes || s.TotalBytes > 0
+		}
+		tputMiB = append(tputMiB, mib)
+		tputObjs = append(tputObjs, objs)
+		reqs = append(reqs, avg)
+
+		errSeries := svgSeries{Name: op.Type}
+		counts := make(map[int64]int)
+		for _, o := range typOps {
+			if o.Err != "" {
+				counts[int64(o.End.Sub(start)/segDur)]++
+			}
+		}
+		if len(counts) > 0 {
+			for i := int64(0); i <= int64(end.Sub(start)/segDur); i++ {
+				errSeries.add((time.Duration(i) * segDur).Seconds(), float64(counts[i]))
+			}
+			errs = append(errs, errSeries)
+		}
+	}
+	tput := svgLineChart("Throughput over time", "obj/s", tputObjs)
+	if hasBytes {
+		tput = svgLineChart("Throughput over time", "MiB/s", tputMiB)
+	}
+	r.Charts = append(r.Charts,
+		tput,
+		svgLineChart("Average request time over time", "ms", reqs),
+		svgBarChart("Request time percentiles", "ms", []string{"50%", "90%", "99%"}, latLabels, latValues),
+	)
+	if len(errs) > 0 {
+		r.Charts = append(r.Charts, svgLineChart("Errors over time", "errors per "+segDur.String(), errs))
+	}
+
+	f, err := os.Create(fn)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return htmlReportTmpl.Execute(f, r)
+}
+
+// svgSeries is a series of points in a chart.
+type svgSeries struct {
+	Name string
+	X, Y []float64
+}
+
+func (s *svgSeries) add(x, y float64) {
+	s.X = append(s.X, x)
+	s.Y = append(s.Y, y)
+}
+
+var svgColors = []string{"#2980b9", "#27ae60", "#e67e22", "#8e44ad", "#c0392b", "#16a085", "#d35400", "#7f8c8d"}
+
+const (
+	svgWidth, svgHeight = 960, 320
+	svgLeft, svgRight   = 70, 20
+	svgTop, svgBottom   = 40, 50
+)
+
+// svgNiceMax returns a rounded up maximum for an axis.
+func svgNiceMax(v float64) float64 {
+	if v <= 0 {
+		return 1
+	}
+	mag := math.Pow(10, math.Floor(math.Log10(v)))
+	for _, m := range []float64{1, 2, 2.5, 5, 10} {
+		if v <= m*mag {
+			return m * mag
+		}
+	}
+	return 10 * mag
+}
+
+// svgAxes writes the title, y axis grid and legend of a chart.
+func svgAxes(sb *strings.Builder, title, unit string, maxY float64, names []string) {
+	fmt.Fprintf(sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-size="12" font-family="sans-serif">`, svgWidth, svgHeight)
+	fmt.Fprintf(sb, `<text x="%d" y="20" font-size="15" font-weight="bold">%s</text>`, svgLeft, html.EscapeString(title))
+	plotH := float64(svgHeight - svgTop - svgBottom)
+	for i := 0; i <= 4; i++ {
+		y := float64(svgTop) + plotH*float64(4-i)/4
+		fmt.Fprintf(sb, `<line x1="%d" x2="%d" y1="%.1f" y2="%.1f" stroke="#eee"/>`, svgLeft, svgWidth-svgRight, y, y)
+		fmt.Fprintf(sb, `<text x="%d" y="%.1f" text-anchor="end">%s</text>`, svgLeft-6, y+4, strings.TrimSuffix(fmt.Sprintf("%.4g", maxY*float64(i)/4), ".0"))
+	}
+	fmt.Fprintf(sb, `<text x="12" y="%d" transform="rotate(-90 12 %d)" text-anchor="middle">%s</text>`, svgTop+int(plotH/2), svgTop+int(plotH/2), html.EscapeString(unit))
+	x := svgLeft
+	for i, name := range names {
+		fmt.Fprintf(sb, `<rect x="%d" y="%d" width="10" height="10" fill="%s"/>`, x, svgHeight-16, svgColors[i%len(svgColors)])
+		fmt.Fprintf(sb, `<text x="%d" y="%d">%s</text>`, x+14, svgHeight-7, html.EscapeString(name))
+		x += 24 + 7*len(name)
+	}
+}
+
+// svgLineChart returns a line chart of the series.
+// X values are seconds from the start of the benchmark.
+func svgLineChart(title, unit string, series []svgSeries) template.HTML {
+	var maxX, maxY float64
+	names := make([]string, 0, len(series))
+	for _, s := range series {
+		names = append(names, s.Name)
+		for i := range s.X {
+			maxX = math.Max(maxX, s.X[i])
+			maxY = math.Max(maxY, s.Y[i])
+		}
+	}
+	maxY = svgNiceMax(maxY)
+	if maxX <= 0 {
+		maxX = 1
+	}
+	var sb strings.Builder
+	svgAxes(&sb, title, unit, maxY, names)
+	plotW := float64(svgWidth - svgLeft - svgRight)
+	plotH := float64(svgHeight - svgTop - svgBottom)
+	for i := 0; i <= 4; i++ {
+		x := float64(svgLeft) + plotW*float64(i)/4
+		fmt.Fprintf(&sb, `<text x="%.1f" y="%d" text-anchor="middle">%v</text>`, x, svgHeight-svgBottom+16, (time.Duration(maxX*float64(i)/4) * time.Second).Round(time.Second))
+	}
+	for i, s := range series {
+		var pts strings.Builder
+		for j := range s.X {
+			fmt.Fprintf(&pts, "%.1f,%.1f ", float64(svgLeft)+plotW*s.X[j]/maxX, float64(svgTop)+plotH*(1-s.Y[j]/maxY))
+		}
+		fmt.Fprintf(&sb, `<polyline fill="none" stroke="%s" stroke-width="2" points="%s"/>`, svgColors[i%len(svgColors)], strings.TrimSpace(pts.String()))
+	}
+	sb.WriteString(`</svg>`)
+	return template.HTML(sb.String())
+}
+
+// svgBarChart returns a grouped bar chart.
+// values contains a value for each series for each group.
+func svgBarChart(title, unit string, series, groups []string, values [][]float64) template.HTML {
+	var maxY float64
+	for _, v := range values {
+		for _, y := range v {
+			maxY = math.Max(maxY, y)
+		}
+	}
+	maxY = svgNiceMax(maxY)
+	var sb strings.Builder
+	svgAxes(&sb, title, unit, maxY, series)
+	plotW := float64(svgWidth - svgLeft - svgRight)
+	plotH := float64(svgHeight - svgTop - svgBottom)
+	if len(groups) > 0 {
+		groupW := plotW / float64(len(groups))
+		barW := groupW * 0.8 / float64(len(series))
+		for i, g := range groups {
+			x0 := float64(svgLeft) + groupW*float64(i) + groupW*0.1
+			fmt.Fprintf(&sb, `<text x="%.1f" y="%d" text-anchor="middle">%s</text>`, x0+groupW*0.4, svgHeight-svgBottom+16, html.EscapeString(g))
+			for j, y := range values[i] {
+				h := plotH * y / maxY
+				fmt.Fprintf(&sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"><title>%s %s: %.1f %s</title></rect>`,
+					x0+barW*float64(j), float64(svgTop)+plotH-h, barW, h, svgColors[j%len(svgColors)], html.EscapeString(g), html.EscapeString(series[j]), y, html.EscapeString(unit))
+			}
+		}
+	}
+	sb.WriteString(`</svg>`)
+	return template.HTML(sb.String())
+}
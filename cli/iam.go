@@ -0,0 +1,134 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/bench"
+	"golang.org/x/time/rate"
+)
+
+var iamFlags = []cli.Flag{
+	cli.IntFlag{
+		Name:  "users",
+		Value: 100,
+		Usage: "Number of users to create before the benchmark. Service accounts and policies are added to these users.",
+	},
+	cli.IntFlag{
+		Name:  "keep",
+		Value: 10,
+		Usage: "Number of service accounts and users each thread creates before removing the oldest.",
+	},
+	cli.Float64Flag{
+		Name:  "svcacct-distrib",
+		Usage: "The amount of service account add and delete operations.",
+		Value: 40,
+	},
+	cli.Float64Flag{
+		Name:  "policy-distrib",
+		Usage: "The amount of policy attach and detach operations.",
+		Value: 30,
+	},
+	cli.Float64Flag{
+		Name:  "user-distrib",
+		Usage: "The amount of user add and remove operations.",
+		Value: 10,
+	},
+	cli.Float64Flag{
+		Name:  "list-distrib",
+		Usage: "The amount of list users operations.",
+		Value: 20,
+	},
+}
+
+var iamCmd = cli.Command{
+	Name:   "iam",
+	Usage:  "benchmark MinIO IAM admin operations",
+	Action: mainIAM,
+	Before: setGlobalsFromContext,
+	Flags:  combineFlags(globalFlags, ioFlags, iamFlags, benchFlags, analyzeFlags),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+  -> see https://github.com/minio/warp#iam
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+}
+
+// mainIAM is the entry point for iam command.
+func mainIAM(ctx *cli.Context) error {
+	checkIAMSyntax(ctx)
+	return runBench(ctx, func(ctx *cli.Context) bench.Benchmark {
+		dist := bench.MixedDistribution{
+			Distribution: map[string]float64{
+				bench.IAMServiceAccount: ctx.Float64("svcacct-distrib"),
+				bench.IAMPolicy:         ctx.Float64("policy-distrib"),
+				bench.IAMUser:           ctx.Float64("user-distrib"),
+				bench.IAMListUsers:      ctx.Float64("list-distrib"),
+			},
+		}
+		err := dist.Generate(0)
+		fatalIf(probe.NewError(err), "Invalid distribution")
+		b := bench.IAM{
+			// No objects are uploaded.
+			Common: getCommon(ctx, nil),
+			Admin:  newAdminClient(ctx),
+			Dist:   &dist,
+			Users:  ctx.Int("users"),
+			Keep:   ctx.Int("keep"),
+		}
+		_, opLimits, err := parseRpsLimit(ctx.String("rps-limit"))
+		fatalIf(probe.NewError(err), "Invalid rps-limit")
+		if len(opLimits) > 0 {
+			b.OpRpsLimits = make(map[string]*rate.Limiter, len(opLimits))
+			for op, limit := range opLimits {
+				b.OpRpsLimits[op] = rate.NewLimiter(rate.Limit(limit), 1)
+			}
+		}
+		return &b
+	})
+}
+
+func checkIAMSyntax(ctx *cli.Context) {
+	if ctx.NArg() > 0 {
+		console.Fatal("Command takes no arguments")
+	}
+	if ctx.Int("users") < ctx.Int("concurrent") {
+		console.Fatal("At least one user per thread must be created")
+	}
+	if ctx.Int("keep") < 1 {
+		console.Fatal("--keep must be at least 1")
+	}
+	if _, opLimits, err := parseRpsLimit(ctx.String("rps-limit")); err == nil {
+		for op := range opLimits {
+			switch op {
+			case bench.IAMServiceAccount, bench.IAMPolicy, bench.IAMUser, bench.IAMListUsers:
+			default:
+				console.Fatal("Unknown operation type in --rps-limit: ", op)
+			}
+		}
+	}
+	checkAnalyze(ctx)
+	checkBenchmark(ctx)
+}
@@ -48,7 +48,11 @@ func newInfluxDB(ctx *cli.Context, wg *sync.WaitGroup) chan<- bench.Operation {
 		tagValues, err = url.ParseQuery(u.RawQuery)
 		errorIf(probe.NewError(err), "unable to parse tags")
 	}
-	tags := make(map[string]string, len(tagValues)+1)
+	runTags := benchTags(ctx)
+	tags := make(map[string]string, len(tagValues)+len(runTags)+1)
+	for key, tag := range runTags {
+		tags[key] = tag
+	}
 	for key, tag := range tagValues {
 		if len(tag) > 0 && len(key) > 0 {
 			tags[key] = tag[0]
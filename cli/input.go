@@ -0,0 +1,172 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/minio/cli"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/bench"
+)
+
+// inputFlags contains the flags used to read benchmark data from S3
+// and to decrypt encrypted benchmark data.
+var inputFlags = combineFlags(pickFlags(ioFlags, "host", "access-key", "secret-key", "tls", "region", "signature"), pickFlags(benchFlags, "results.key"))
+
+// pickFlags returns the flags with the specified names.
+func pickFlags(flags []cli.Flag, names ...string) []cli.Flag {
+	var dst []cli.Flag
+	for _, fl := range flags {
+		for _, name := range names {
+			if fl.GetName() == name {
+				dst = append(dst, fl)
+			}
+		}
+	}
+	return dst
+}
+
+// openInput opens a benchmark data file.
+// Files can be local files, '-' for stdin, or 's3://bucket/object',
+// which is read from the first host using the supplied credentials.
+// Encrypted files are decrypted with the key given by --results.key.
+func openInput(ctx *cli.Context, name string) (io.ReadCloser, error) {
+	rc, err := openRawInput(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	dec, err := decryptResults(ctx, rc)
+	if err != nil {
+		rc.Close()
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return dec, nil
+}
+
+// openRawInput opens a benchmark data file without decrypting it.
+func openRawInput(ctx *cli.Context, name string) (io.ReadCloser, error) {
+	if name == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	if !strings.HasPrefix(name, "s3://") {
+		return os.Open(name)
+	}
+	bucket, object, _ := strings.Cut(strings.TrimPrefix(name, "s3://"), "/")
+	if bucket == "" || object == "" {
+		return nil, fmt.Errorf("invalid S3 URL %q, must be s3://bucket/object", name)
+	}
+	cl, err := inputClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := cl.GetObject(context.Background(), bucket, object, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	// Check that the object exists.
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return obj, nil
+}
+
+// inputClient returns a client for the first host.
+func inputClient(ctx *cli.Context) (*minio.Client, error) {
+	hosts := parseHosts(ctx.String("host"), false)
+	if len(hosts) == 0 {
+		return nil, errors.New("no host defined")
+	}
+	return getClient(ctx, hosts[0])
+}
+
+// readInput reads the tags and operations of a benchmark data file.
+// See openInput for supported names.
+func readInput(ctx *cli.Context, name string) (bench.Operations, bench.Tags, error) {
+	f, err := openInput(ctx, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	dec, err := newResultsReader(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer dec.Close()
+	tags, rd, err := bench.TagsFromCSV(dec)
+	if err != nil {
+		return nil, nil, err
+	}
+	log := console.Printf
+	if globalQuiet {
+		log = nil
+	}
+	ops, err := bench.OperationsFromCSV(rd, true, ctx.Int("analyze.offset"), ctx.Int("analyze.limit"), log)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ops, tags, nil
+}
+
+// listInputs returns all benchmark data files found in a directory
+// or below an S3 prefix given as 's3://bucket/prefix'.
+// Names are returned sorted.
+func listInputs(ctx *cli.Context, location string) ([]string, error) {
+	var names []string
+	if !strings.HasPrefix(location, "s3://") {
+		err := filepath.WalkDir(location, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && isResultsFile(d.Name()) {
+				names = append(names, path)
+			}
+			return nil
+		})
+		sort.Strings(names)
+		return names, err
+	}
+	bucket, prefix, _ := strings.Cut(strings.TrimPrefix(location, "s3://"), "/")
+	if bucket == "" {
+		return nil, fmt.Errorf("invalid S3 URL %q, must be s3://bucket/prefix", location)
+	}
+	cl, err := inputClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for obj := range cl.ListObjects(context.Background(), bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if obj.Err != nil {
+			return nil, obj.Err
+		}
+		if isResultsFile(obj.Key) {
+			names = append(names, "s3://"+bucket+"/"+obj.Key)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
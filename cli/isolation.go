@@ -0,0 +1,217 @@
+/*
+ * Warp (C) 2019-2020 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/bench"
+)
+
+var isolationFlags = []cli.Flag{
+	cli.IntFlag{
+		Name:  "tenants",
+		Value: 4,
+		Usage: "Number of tenant prefixes. The first tenant receives the burst.",
+	},
+	cli.IntFlag{
+		Name:  "objects",
+		Value: 1000,
+		Usage: "Number of objects to upload to each tenant.",
+	},
+	cli.StringFlag{
+		Name:  "obj.size",
+		Value: "1MiB",
+		Usage: "Size of each generated object. Can be a number or 10KB/MB/GB. All sizes are base 2 binary.",
+	},
+	cli.IntFlag{
+		Name:  "burst.concurrent",
+		Usage: "Number of threads running the burst. Default is 4 times --concurrent.",
+	},
+	cli.StringFlag{
+		Name:  "burst.op",
+		Value: "put",
+		Usage: "Operation of the burst. Can be 'put' or 'get'.",
+	},
+	cli.DurationFlag{
+		Name:  "burst.delay",
+		Value: time.Minute,
+		Usage: "Time from the start of the benchmark until the burst begins.",
+	},
+	cli.DurationFlag{
+		Name:  "burst.dur",
+		Value: time.Minute,
+		Usage: "Duration of the burst.",
+	},
+}
+
+var isolationCmd = cli.Command{
+	Name:   "isolation",
+	Usage:  "benchmark isolation of tenant prefixes during a burst on one prefix",
+	Action: mainIsolation,
+	Before: setGlobalsFromContext,
+	Flags:  combineFlags(globalFlags, ioFlags, isolationFlags, prepareFlags, genFlags, benchFlags, analyzeFlags),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+  -> see https://github.com/minio/warp#isolation
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+}
+
+// mainIsolation is the entry point for isolation command.
+func mainIsolation(ctx *cli.Context) error {
+	checkIsolationSyntax(ctx)
+	return runBench(ctx, func(ctx *cli.Context) bench.Benchmark {
+		burstConcurrent := ctx.Int("burst.concurrent")
+		if burstConcurrent == 0 {
+			burstConcurrent = 4 * ctx.Int("concurrent")
+		}
+		b := bench.Isolation{
+			Common:           getCommon(ctx, newGenSource(ctx, "obj.size")),
+			Tenants:          ctx.Int("tenants"),
+			CreateObjects:    ctx.Int("objects"),
+			BurstConcurrency: burstConcurrent,
+			BurstOp:          strings.ToUpper(ctx.String("burst.op")),
+			BurstDelay:       ctx.Duration("burst.delay"),
+			BurstDuration:    ctx.Duration("burst.dur"),
+			GetOpts:          getOpts(ctx),
+		}
+		return &b
+	})
+}
+
+func checkIsolationSyntax(ctx *cli.Context) {
+	if ctx.NArg() > 0 {
+		console.Fatal("Command takes no arguments")
+	}
+	if ctx.Int("tenants") < 2 {
+		console.Fatal("At least two tenants are required")
+	}
+	if ctx.Int("objects") < 1 {
+		console.Fatal("At least one object must be uploaded to each tenant")
+	}
+	if ctx.Int("burst.concurrent") < 0 {
+		console.Fatal("burst.concurrent cannot be negative")
+	}
+	switch strings.ToUpper(ctx.String("burst.op")) {
+	case http.MethodGet, http.MethodPut:
+	default:
+		console.Fatal("burst.op must be 'put' or 'get'")
+	}
+	if ctx.Duration("burst.dur") <= 0 || ctx.Duration("burst.delay") < 0 {
+		console.Fatal("burst.dur must be positive and burst.delay cannot be negative")
+	}
+	if ctx.Bool("autoterm") {
+		console.Fatal("autoterm cannot be used with isolation benchmark")
+	}
+	if ctx.Duration("burst.delay")+ctx.Duration("burst.dur") >= ctx.Duration("duration") {
+		console.Fatal("The burst must end before the benchmark duration")
+	}
+	checkAnalyze(ctx)
+	checkBenchmark(ctx)
+}
+
+// isolationPhase contains the latency of successful requests of a tenant in a phase of the benchmark.
+type isolationPhase struct {
+	name string
+	lat  *summaryLatency
+}
+
+// printIsolationAnalysis prints the latency of each tenant before, during and after the burst.
+// Nothing is printed if the operations contain no burst.
+func printIsolationAnalysis(o bench.Operations) {
+	var burstStart, burstEnd time.Time
+	var victims bench.Operations
+	for _, op := range o {
+		if !strings.HasPrefix(op.OpType, bench.IsolationBurstPrefix) {
+			victims = append(victims, op)
+			continue
+		}
+		if burstStart.IsZero() || op.Start.Before(burstStart) {
+			burstStart = op.Start
+		}
+		if op.End.After(burstEnd) {
+			burstEnd = op.End
+		}
+	}
+	if burstStart.IsZero() || len(victims) == 0 {
+		return
+	}
+	byTenant := make(map[string]bench.Operations)
+	for _, op := range victims.FilterSuccessful() {
+		tenant, _, ok := strings.Cut(path.Clean(op.File), "/")
+		if !ok || op.OpType != http.MethodGet {
+			continue
+		}
+		byTenant[tenant] = append(byTenant[tenant], op)
+	}
+	if len(byTenant) == 0 {
+		return
+	}
+	console.SetColor("Print", color.New(color.FgHiWhite))
+	console.Printf("\nTenant isolation, burst on %s from %s to %s (%v):\n", bench.IsolationTenant(0),
+		burstStart.Format("15:04:05"), burstEnd.Format("15:04:05"), burstEnd.Sub(burstStart).Round(time.Second))
+	console.SetColor("Print", color.New(color.FgWhite))
+	for _, tenant := range stringKeysSorted(byTenant) {
+		ops := byTenant[tenant]
+		var before, during, after bench.Operations
+		for _, op := range ops {
+			switch {
+			case op.End.Before(burstStart):
+				before = append(before, op)
+			case op.Start.After(burstEnd):
+				after = append(after, op)
+			case !op.Start.Before(burstStart) && !op.End.After(burstEnd):
+				during = append(during, op)
+			}
+		}
+		phases := []isolationPhase{
+			{name: "before", lat: summaryLatencies(before)},
+			{name: "during", lat: summaryLatencies(during)},
+			{name: "after", lat: summaryLatencies(after)},
+		}
+		role := "victim"
+		if tenant == bench.IsolationTenant(0) {
+			role = "aggressor"
+		}
+		line := fmt.Sprintf(" * %s (%s):", tenant, role)
+		for _, p := range phases {
+			if p.lat == nil {
+				line += fmt.Sprintf(" %s: no requests.", p.name)
+				continue
+			}
+			line += fmt.Sprintf(" %s: median %.1fms, 99%%: %.1fms.", p.name, p.lat.P50, p.lat.P99)
+		}
+		if b, d := phases[0].lat, phases[1].lat; b != nil && d != nil && b.P50 > 0 && b.P99 > 0 {
+			line += fmt.Sprintf(" Impact: median %+.1f%%, 99%% %+.1f%%", 100*(d.P50/b.P50-1), 100*(d.P99/b.P99-1))
+		}
+		console.Println(line)
+	}
+}
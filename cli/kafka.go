@@ -0,0 +1,276 @@
+/*
+ * Warp (C) 2019-2020 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/warp/pkg/bench"
+)
+
+const (
+	// kafkaBatchInterval is the longest time operations are held before being sent.
+	kafkaBatchInterval = time.Second
+
+	kafkaAcceptType = "application/vnd.kafka.v2+json"
+)
+
+// kafkaSchema is the Avro schema of kafkaOp.
+const kafkaSchema = `{
+  "type": "record",
+  "name": "Operation",
+  "namespace": "io.minio.warp",
+  "fields": [
+    {"name": "warp_id", "type": "string"},
+    {"name": "client_id", "type": "string"},
+    {"name": "op", "type": "string"},
+    {"name": "thread", "type": "int"},
+    {"name": "endpoint", "type": "string"},
+    {"name": "file", "type": "string"},
+    {"name": "error", "type": "string"},
+    {"name": "start_ns", "type": "long"},
+    {"name": "end_ns", "type": "long"},
+    {"name": "duration_ns", "type": "long"},
+    {"name": "ttfb_ns", "type": "long"},
+    {"name": "objects", "type": "int"},
+    {"name": "bytes", "type": "long"},
+    {"name": "request_id", "type": "string"},
+    {"name": "tags", "type": {"type": "map", "values": "string"}}
+  ]
+}`
+
+// kafkaOp is an operation as published to Kafka.
+// Times are nanoseconds since the Unix epoch.
+// The time to first byte is 0 if not recorded.
+type kafkaOp struct {
+	WarpID    string            `json:"warp_id"`
+	ClientID  string            `json:"client_id"`
+	OpType    string            `json:"op"`
+	Thread    int               `json:"thread"`
+	Endpoint  string            `json:"endpoint"`
+	File      string            `json:"file"`
+	Err       string            `json:"error"`
+	Start     int64             `json:"start_ns"`
+	End       int64             `json:"end_ns"`
+	Duration  int64             `json:"duration_ns"`
+	TTFB      int64             `json:"ttfb_ns"`
+	ObjPerOp  int               `json:"objects"`
+	Size      int64             `json:"bytes"`
+	RequestID string            `json:"request_id"`
+	Tags      map[string]string `json:"tags"`
+}
+
+type kafkaRecord struct {
+	Key   string  `json:"key"`
+	Value kafkaOp `json:"value"`
+}
+
+type kafkaRequest struct {
+	ValueSchema   string        `json:"value_schema,omitempty"`
+	ValueSchemaID int           `json:"value_schema_id,omitempty"`
+	Records       []kafkaRecord `json:"records"`
+}
+
+type kafkaResponse struct {
+	ValueSchemaID int `json:"value_schema_id"`
+	Offsets       []struct {
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+// kafkaSink publishes operations to a Kafka topic through a Kafka REST proxy.
+type kafkaSink struct {
+	url      string
+	user     *url.Userinfo
+	avro     bool
+	schemaID int
+	client   http.Client
+}
+
+func newKafka(ctx *cli.Context, wg *sync.WaitGroup) chan<- bench.Operation {
+	u, err := parseKafkaURL(ctx)
+	fatalIf(probe.NewError(err), "unable to parse kafka parameter")
+	topic := strings.Trim(u.Path, "/")
+	k := kafkaSink{
+		url:    u.Scheme + "://" + u.Host + "/topics/" + url.PathEscape(topic),
+		user:   u.User,
+		avro:   ctx.String("kafka.format") == "avro",
+		client: http.Client{Timeout: 30 * time.Second},
+	}
+	tags := benchTags(ctx)
+	if tags == nil {
+		tags = bench.Tags{}
+	}
+	warpID := pRandASCII(8)
+	batchSize := ctx.Int("kafka.batch")
+
+	ch := make(chan bench.Operation, 10000)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(kafkaBatchInterval)
+		defer ticker.Stop()
+		batch := make([]kafkaRecord, 0, batchSize)
+		send := func() {
+			if len(batch) == 0 {
+				return
+			}
+			errorIf(probe.NewError(k.send(batch)), "unable to write to kafka")
+			batch = batch[:0]
+		}
+		for {
+			select {
+			case op, ok := <-ch:
+				if !ok {
+					send()
+					return
+				}
+				v := kafkaOp{
+					WarpID:    warpID,
+					ClientID:  op.ClientID,
+					OpType:    op.OpType,
+					Thread:    int(op.Thread),
+					Endpoint:  op.Endpoint,
+					File:      op.File,
+					Err:       op.Err,
+					Start:     op.Start.UnixNano(),
+					End:       op.End.UnixNano(),
+					Duration:  int64(op.Duration()),
+					ObjPerOp:  op.ObjPerOp,
+					Size:      op.Size,
+					RequestID: op.RequestID,
+					Tags:      tags,
+				}
+				if op.FirstByte != nil {
+					v.TTFB = int64(op.FirstByte.Sub(op.Start))
+				}
+				batch = append(batch, kafkaRecord{Key: warpID, Value: v})
+				if len(batch) >= batchSize {
+					send()
+				}
+			case <-ticker.C:
+				send()
+			}
+		}
+	}()
+	return ch
+}
+
+// send publishes the records.
+func (k *kafkaSink) send(records []kafkaRecord) error {
+	req := kafkaRequest{Records: records}
+	contentType := "application/vnd.kafka.json.v2+json"
+	if k.avro {
+		contentType = "application/vnd.kafka.avro.v2+json"
+		if k.schemaID != 0 {
+			req.ValueSchemaID = k.schemaID
+		} else {
+			req.ValueSchema = kafkaSchema
+		}
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	hreq, err := http.NewRequest(http.MethodPost, k.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	hreq.Header.Set("Content-Type", contentType)
+	hreq.Header.Set("Accept", kafkaAcceptType)
+	if k.user != nil {
+		pass, _ := k.user.Password()
+		hreq.SetBasicAuth(k.user.Username(), pass)
+	}
+	resp, err := k.client.Do(hreq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("kafka: %s: %s", resp.Status, strings.TrimSpace(string(b)))
+	}
+	var res kafkaResponse
+	if err := json.Unmarshal(b, &res); err != nil {
+		return fmt.Errorf("kafka: unable to parse response: %w", err)
+	}
+	if res.ValueSchemaID != 0 {
+		k.schemaID = res.ValueSchemaID
+	}
+	var failed int
+	var firstErr string
+	for _, o := range res.Offsets {
+		if o.ErrorCode != nil || o.Error != "" {
+			if failed == 0 {
+				firstErr = o.Error
+			}
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("kafka: %d of %d records were not written: %s", failed, len(records), firstErr)
+	}
+	return nil
+}
+
+func parseKafkaURL(ctx *cli.Context) (*url.URL, error) {
+	s := ctx.String("kafka")
+	if s == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "":
+		return nil, errors.New("kafka: no scheme specified (http/https)")
+	case "http", "https":
+	default:
+		return nil, fmt.Errorf("kafka: unknown scheme %s - must be http/https", u.Scheme)
+	}
+	topic := strings.Trim(u.Path, "/")
+	if topic == "" || strings.Contains(topic, "/") {
+		return nil, fmt.Errorf("kafka: unexpected path. Want 'topic', got '%s'", topic)
+	}
+	switch ctx.String("kafka.format") {
+	case "json", "avro":
+	default:
+		return nil, fmt.Errorf("kafka: unknown format %q - must be json or avro", ctx.String("kafka.format"))
+	}
+	if ctx.Int("kafka.batch") < 1 {
+		return nil, errors.New("kafka: batch size must be at least 1")
+	}
+	return u, nil
+}
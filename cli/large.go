@@ -0,0 +1,123 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/bench"
+)
+
+var largeFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "obj.size",
+		Value: "10GiB",
+		Usage: "Size of each object. Can be a number or 10KiB/MiB/GiB/TiB. All sizes are base 2 binary.",
+	},
+	cli.StringFlag{
+		Name:  "part.size",
+		Value: "",
+		Usage: "Multipart part size. Can be a number or 10KiB/MiB/GiB. Calculated from the object size if not set.",
+	},
+	cli.DurationFlag{
+		Name:  "progress",
+		Value: 10 * time.Second,
+		Usage: "Interval of progress operations emitted during each upload and download. 0 disables progress.",
+	},
+	cli.IntFlag{
+		Name:  "verify.samples",
+		Value: 16,
+		Usage: "Number of byte ranges compared on each download. 0 only checks the size.",
+	},
+	cli.StringFlag{
+		Name:  "verify.size",
+		Value: "1MiB",
+		Usage: "Size of each compared byte range.",
+	},
+	cli.BoolFlag{
+		Name:  "skip-download",
+		Usage: "Only upload and delete objects.",
+	},
+}
+
+var largeCmd = cli.Command{
+	Name:   "large",
+	Usage:  "benchmark streaming of very large objects",
+	Action: mainLarge,
+	Before: setGlobalsFromContext,
+	Flags:  combineFlags(globalFlags, ioFlags, largeFlags, genFlags, benchFlags, analyzeFlags),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+  -> see https://github.com/minio/warp#large
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+}
+
+// mainLarge is the entry point for large command.
+func mainLarge(ctx *cli.Context) error {
+	checkLargeSyntax(ctx)
+	return runBench(ctx, func(ctx *cli.Context) bench.Benchmark {
+		objSize, _ := toSize(ctx.String("obj.size"))
+		verifySize, _ := toSize(ctx.String("verify.size"))
+		b := bench.Large{
+			Common:           getCommon(ctx, newGenSource(ctx, "obj.size")),
+			GetOpts:          getOpts(ctx),
+			ObjectSize:       int64(objSize),
+			ProgressInterval: ctx.Duration("progress"),
+			VerifySamples:    ctx.Int("verify.samples"),
+			VerifySize:       int64(verifySize),
+			SkipDownload:     ctx.Bool("skip-download"),
+		}
+		return &b
+	})
+}
+
+func checkLargeSyntax(ctx *cli.Context) {
+	if ctx.NArg() > 0 {
+		console.Fatal("Command takes no arguments")
+	}
+	if sz, err := toSize(ctx.String("obj.size")); err != nil || sz == 0 {
+		console.Fatal("Invalid obj.size specified")
+	}
+	if ctx.String("part.size") != "" {
+		if sz, err := toSize(ctx.String("part.size")); err != nil || sz < 5<<20 {
+			console.Fatal("part.size must be >= 5MiB")
+		}
+	}
+	if sz, err := toSize(ctx.String("verify.size")); err != nil || sz == 0 {
+		console.Fatal("Invalid verify.size specified")
+	}
+	if ctx.Int("verify.samples") < 0 {
+		console.Fatal("verify.samples cannot be negative")
+	}
+	if ctx.Duration("progress") < 0 {
+		console.Fatal("progress interval cannot be negative")
+	}
+	if ctx.Bool("obj.randsize") {
+		console.Fatal("--obj.randsize cannot be used with large objects")
+	}
+	checkAnalyze(ctx)
+	checkBenchmark(ctx)
+}
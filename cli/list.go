@@ -18,6 +18,9 @@
 package cli
 
 import (
+	"slices"
+	"strings"
+
 	"github.com/minio/cli"
 	"github.com/minio/pkg/v2/console"
 	"github.com/minio/warp/pkg/bench"
@@ -43,6 +46,34 @@ var listFlags = []cli.Flag{
 		Name:  "metadata",
 		Usage: "Enable extended MinIO ListObjects with metadata, by default this benchmarking uses ListObjectsV2 API.",
 	},
+	cli.StringFlag{
+		Name:  "api",
+		Value: "",
+		Usage: "List API to use. Can be 'v1', 'v2' or 'metadata'. Specify multiple as comma separated list or 'all' to compare.",
+	},
+	cli.IntFlag{
+		Name:  "prefix-depth",
+		Value: 0,
+		Usage: "Place objects in a directory tree of this depth below each prefix.",
+	},
+	cli.IntFlag{
+		Name:  "prefix-fanout",
+		Value: 10,
+		Usage: "Number of directories on each level of the directory tree.",
+	},
+	cli.IntFlag{
+		Name:  "max-keys",
+		Value: 100,
+		Usage: "Number of objects to request per list page.",
+	},
+	cli.BoolFlag{
+		Name:  "delimiter",
+		Usage: "List with '/' delimiter and traverse all prefixes. Each page is recorded as a LIST-PAGE operation.",
+	},
+	cli.IntFlag{
+		Name:  "mutate",
+		Usage: "Number of additional threads uploading and deleting objects in the listed prefixes while listing.",
+	},
 }
 
 var listCmd = cli.Command{
@@ -50,7 +81,7 @@ var listCmd = cli.Command{
 	Usage:  "benchmark list objects",
 	Action: mainList,
 	Before: setGlobalsFromContext,
-	Flags:  combineFlags(globalFlags, ioFlags, listFlags, genFlags, benchFlags, analyzeFlags),
+	Flags:  combineFlags(globalFlags, ioFlags, listFlags, prepareFlags, genFlags, benchFlags, analyzeFlags),
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
@@ -67,14 +98,42 @@ FLAGS:
 func mainList(ctx *cli.Context) error {
 	checkListSyntax(ctx)
 
-	b := bench.List{
-		Common:        getCommon(ctx, newGenSource(ctx, "obj.size")),
-		Versions:      ctx.Int("versions"),
-		Metadata:      ctx.Bool("metadata"),
-		CreateObjects: ctx.Int("objects"),
-		NoPrefix:      ctx.Bool("noprefix"),
+	return runBench(ctx, func(ctx *cli.Context) bench.Benchmark {
+		b := bench.List{
+			Common:        getCommon(ctx, newGenSource(ctx, "obj.size")),
+			Versions:      ctx.Int("versions"),
+			Metadata:      ctx.Bool("metadata"),
+			APIs:          listAPIs(ctx),
+			CreateObjects: ctx.Int("objects"),
+			NoPrefix:      ctx.Bool("noprefix"),
+			Depth:         ctx.Int("prefix-depth"),
+			FanOut:        ctx.Int("prefix-fanout"),
+			MaxKeys:       ctx.Int("max-keys"),
+			Delimiter:     ctx.Bool("delimiter"),
+			Mutate:        ctx.Int("mutate"),
+		}
+		return &b
+	})
+}
+
+// listAPIs returns the list API variants to benchmark.
+func listAPIs(ctx *cli.Context) []bench.ListAPI {
+	s := ctx.String("api")
+	if s == "" {
+		return nil
+	}
+	if s == "all" {
+		return bench.ListAPIs
 	}
-	return runBench(ctx, &b)
+	var apis []bench.ListAPI
+	for _, api := range strings.Split(s, ",") {
+		api := bench.ListAPI(strings.ToLower(strings.TrimSpace(api)))
+		if !slices.Contains(bench.ListAPIs, api) {
+			console.Fatalf("Unknown list API %q. Valid values are %v", api, bench.ListAPIs)
+		}
+		apis = append(apis, api)
+	}
+	return apis
 }
 
 func checkListSyntax(ctx *cli.Context) {
@@ -87,6 +146,42 @@ func checkListSyntax(ctx *cli.Context) {
 	if ctx.Int("objects") < 1 {
 		console.Fatal("At least one object must be tested")
 	}
+	if ctx.IsSet("prepare.concurrent") {
+		console.Fatal("--prepare.concurrent cannot be used with list, objects are prepared by the threads listing them")
+	}
+	if apis := listAPIs(ctx); len(apis) > 0 {
+		if ctx.Bool("metadata") {
+			console.Fatal("--metadata cannot be combined with --api")
+		}
+		if len(apis) > 1 && ctx.Int("versions") > 1 {
+			console.Fatal("List APIs cannot be compared when listing versions")
+		}
+	}
+	if ctx.Int("prefix-depth") < 0 {
+		console.Fatal("--prefix-depth cannot be negative")
+	}
+	if ctx.Float64("hot.fraction") > 0 {
+		console.Fatal("--hot.fraction cannot be used, each thread lists its own prefix")
+	}
+	if m := ctx.Int("mutate"); m < 0 {
+		console.Fatal("--mutate cannot be negative")
+	} else if m > 0 && (ctx.Int("versions") > 1 || ctx.Bool("delimiter")) {
+		console.Fatal("--mutate cannot be used with --versions or --delimiter")
+	}
+	if ctx.Int("prefix-fanout") < 1 {
+		console.Fatal("--prefix-fanout must be at least 1")
+	}
+	if ctx.Int("max-keys") < 1 || ctx.Int("max-keys") > 1000 {
+		console.Fatal("--max-keys must be between 1 and 1000")
+	}
+	if ctx.Bool("delimiter") {
+		if ctx.Int("versions") > 1 {
+			console.Fatal("--delimiter cannot be used when listing versions")
+		}
+		if ctx.Bool("metadata") || slices.Contains(listAPIs(ctx), bench.ListAPIMetadata) {
+			console.Fatal("--delimiter cannot be used with metadata listing")
+		}
+	}
 
 	checkAnalyze(ctx)
 	checkBenchmark(ctx)
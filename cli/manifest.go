@@ -0,0 +1,270 @@
+/*
+ * Warp (C) 2019-2020 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg"
+)
+
+// manifestPrefix is the prefix of the manifest line in the benchmark data header.
+const manifestPrefix = "# manifest: "
+
+// runManifest contains everything needed to reproduce a benchmark run.
+type runManifest struct {
+	Version   string    `json:"warp_version"`
+	Commit    string    `json:"commit"`
+	GoVersion string    `json:"go_version"`
+	Platform  string    `json:"platform"`
+	Created   time.Time `json:"created"`
+	Benchmark string    `json:"benchmark"`
+	// Flags contains the effective value of every flag of the benchmark, including defaults.
+	// Secrets are not included.
+	Flags map[string]string `json:"flags"`
+	// FlagLists contains the values of flags that can be given multiple times.
+	FlagLists map[string][]string `json:"flag_lists,omitempty"`
+	// Signature is the signature method used for uploads.
+	Signature string   `json:"signature,omitempty"`
+	Hosts     []string `json:"hosts,omitempty"`
+	Clients   []string `json:"clients,omitempty"`
+	Seed      int64    `json:"seed,omitempty"`
+}
+
+// newRunManifest returns the manifest of the benchmark run configured by ctx.
+func newRunManifest(ctx *cli.Context) runManifest {
+	m := runManifest{
+		Version:   pkg.Version,
+		Commit:    pkg.CommitID,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		Created:   time.Now().UTC(),
+		Benchmark: ctx.Command.Name,
+		Flags:     make(map[string]string, len(ctx.Command.Flags)),
+		Seed:      ctx.Int64("seed"),
+		Signature: effectiveSignature(ctx),
+	}
+	for _, flag := range ctx.Command.Flags {
+		name := flag.GetName()
+		if secretFlag(name) {
+			continue
+		}
+		switch flag.(type) {
+		case cli.StringFlag:
+			m.Flags[name] = ctx.String(name)
+		case cli.BoolFlag:
+			m.Flags[name] = fmt.Sprint(ctx.Bool(name))
+		case cli.Int64Flag:
+			m.Flags[name] = fmt.Sprint(ctx.Int64(name))
+		case cli.IntFlag:
+			m.Flags[name] = fmt.Sprint(ctx.Int(name))
+		case cli.DurationFlag:
+			m.Flags[name] = ctx.Duration(name).String()
+		case cli.UintFlag:
+			m.Flags[name] = fmt.Sprint(ctx.Uint(name))
+		case cli.Uint64Flag:
+			m.Flags[name] = fmt.Sprint(ctx.Uint64(name))
+		case cli.Float64Flag:
+			m.Flags[name] = fmt.Sprint(ctx.Float64(name))
+		case cli.StringSliceFlag:
+			if v := ctx.StringSlice(name); len(v) > 0 {
+				if m.FlagLists == nil {
+					m.FlagLists = make(map[string][]string)
+				}
+				m.FlagLists[name] = v
+			}
+		}
+	}
+	if h := ctx.String("host"); h != "" {
+		m.Hosts = parseHosts(h, ctx.Bool("resolve-host"))
+	}
+	if c := ctx.String("warp-client"); c != "" {
+		m.Clients = parseHosts(c, false)
+	}
+	return m
+}
+
+// writeResultsHeader writes the tags and the run manifest to the beginning of benchmark data.
+func writeResultsHeader(ctx *cli.Context, w io.Writer) error {
+	if err := benchTags(ctx).CSV(w); err != nil {
+		return err
+	}
+	b, err := json.Marshal(newRunManifest(ctx))
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, manifestPrefix+string(b)+"\n")
+	return err
+}
+
+// readManifest reads a run manifest from a JSON file
+// or from the header of a benchmark data file.
+func readManifest(ctx *cli.Context, name string) (*runManifest, error) {
+	input, err := openInput(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer input.Close()
+	br := bufio.NewReader(input)
+	if b, _ := br.Peek(1); bytes.Equal(b, []byte("{")) {
+		var m runManifest
+		if err := json.NewDecoder(br).Decode(&m); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return &m, nil
+	}
+	dec, err := newResultsReader(br)
+	if err != nil {
+		return nil, err
+	}
+	defer dec.Close()
+	lines := bufio.NewReader(dec)
+	for {
+		line, err := lines.ReadString('\n')
+		if strings.HasPrefix(line, manifestPrefix) {
+			var m runManifest
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, manifestPrefix)), &m); err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			return &m, nil
+		}
+		if err != nil || !strings.HasPrefix(line, "#") {
+			// The manifest is written before the operations.
+			return nil, fmt.Errorf("%s: %w", name, errNoManifest)
+		}
+	}
+}
+
+// errNoManifest is returned when benchmark data has no run manifest.
+var errNoManifest = errors.New("no run manifest found")
+
+// rerunSkipFlags are flags of the manifest that are not reused,
+// since they only apply to the original run.
+var rerunSkipFlags = map[string]bool{
+	"benchdata":  true,
+	"syncstart":  true,
+	"cleanup.at": true,
+}
+
+var rerunCmd = cli.Command{
+	Name:   "rerun",
+	Usage:  "run a benchmark again using the manifest of a previous run",
+	Action: mainRerun,
+	Before: setGlobalsFromContext,
+	Flags: combineFlags(globalFlags, pickFlags(ioFlags, "access-key", "secret-key", "access-key.b", "secret-key.b"), pickFlags(shadowFlags, "shadow.access-key", "shadow.secret-key"), pickFlags(benchFlags, "results.key"), []cli.Flag{
+		cli.BoolFlag{
+			Name:  "print",
+			Usage: "Print the manifest as JSON instead of running the benchmark",
+		},
+	}),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] <manifest.json|benchmark-data-file>
+  -> see https://github.com/minio/warp#rerun
+
+Credentials are not stored in the manifest and must be supplied again.
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+}
+
+// mainRerun is the entry point for rerun command.
+func mainRerun(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		console.Fatal("A manifest or benchmark data file must be given")
+	}
+	m, err := readManifest(ctx, ctx.Args()[0])
+	fatalIf(probe.NewError(err), "Unable to read run manifest")
+	if ctx.Bool("print") {
+		b, err := json.MarshalIndent(m, "", "  ")
+		fatalIf(probe.NewError(err), "Unable to encode run manifest")
+		_, err = os.Stdout.Write(append(b, '\n'))
+		return err
+	}
+
+	var benchCmd *cli.Command
+	for i, cmd := range benchCmds {
+		if cmd.Name == m.Benchmark {
+			benchCmd = &benchCmds[i]
+			break
+		}
+	}
+	if benchCmd == nil {
+		fatal(probe.NewError(fmt.Errorf("unknown benchmark: %s", m.Benchmark)), "Unknown benchmark")
+	}
+	if m.Version != pkg.Version {
+		console.Errorf("Warning: The run was made with warp %s, this is warp %s. Results may differ.\n", m.Version, pkg.Version)
+	}
+
+	app := registerApp("warp", benchCmds)
+	fs, err := flagSet(benchCmd.Name, benchCmd.Flags, nil)
+	fatalIf(probe.NewError(err), "error setting flags")
+	ctx2 := cli.NewContext(app, fs, ctx)
+	ctx2.Command = *benchCmd
+	known := make(map[string]bool, len(benchCmd.Flags))
+	for _, flag := range benchCmd.Flags {
+		known[flag.GetName()] = true
+	}
+	set := func(k, v string) {
+		if !known[k] {
+			console.Errorf("Warning: Ignoring flag %q, which is not supported by this version.\n", k)
+			return
+		}
+		if err := ctx2.Set(k, v); err != nil {
+			err := fmt.Errorf("parsing parameters (%v:%v): %w", k, v, err)
+			fatal(probe.NewError(err), "error setting flags")
+		}
+	}
+	for _, k := range stringKeysSorted(m.Flags) {
+		if !rerunSkipFlags[k] {
+			set(k, m.Flags[k])
+		}
+	}
+	for k, values := range m.FlagLists {
+		for _, v := range values {
+			set(k, v)
+		}
+	}
+	if m.Seed != 0 {
+		set("seed", fmt.Sprint(m.Seed))
+	}
+	for _, k := range []string{"access-key", "secret-key", "access-key.b", "secret-key.b", "shadow.access-key", "shadow.secret-key", "results.key"} {
+		if ctx.IsSet(k) {
+			set(k, ctx.String(k))
+		}
+	}
+	if !globalQuiet {
+		console.Infoln("Rerunning", m.Benchmark, "benchmark recorded", m.Created.Local().Format(time.RFC1123))
+	}
+	return runCommand(ctx2, benchCmd)
+}
@@ -20,10 +20,8 @@ package cli
 import (
 	"errors"
 	"fmt"
-	"os"
 	"time"
 
-	"github.com/klauspost/compress/zstd"
 	"github.com/minio/cli"
 	"github.com/minio/mc/pkg/probe"
 	"github.com/minio/pkg/v2/console"
@@ -36,6 +34,29 @@ var mergeFlags = []cli.Flag{
 		Value: "",
 		Usage: "Output combined data to this file. By default unique filename is generated.",
 	},
+	cli.BoolFlag{
+		Name:  "dedup",
+		Usage: "Drop operations that are present in more than one input file. By default duplicates are only reported.",
+	},
+}
+
+// mergeKey identifies an operation when detecting duplicates between files.
+// The thread is the thread in the input file.
+type mergeKey struct {
+	client, op, file, endpoint string
+	thread                     uint16
+	start, end                 int64
+}
+
+// newMergeKey returns the key of an operation read from an input file.
+func newMergeKey(op bench.Operation) mergeKey {
+	return mergeKey{client: op.ClientID, op: op.OpType, file: op.File, endpoint: op.Endpoint, thread: op.Thread, start: op.Start.UnixNano(), end: op.End.UnixNano()}
+}
+
+// mergeFile is the time range of an input file.
+type mergeFile struct {
+	name       string
+	start, end time.Time
 }
 
 var mergeCmd = cli.Command{
@@ -43,7 +64,7 @@ var mergeCmd = cli.Command{
 	Usage:  "merge existing benchmark data",
 	Action: mainMerge,
 	Before: setGlobalsFromContext,
-	Flags:  combineFlags(globalFlags, mergeFlags),
+	Flags:  combineFlags(globalFlags, mergeFlags, pickFlags(benchFlags, "results.compression", "results.compression-level"), inputFlags),
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
@@ -63,22 +84,57 @@ func mainMerge(ctx *cli.Context) error {
 	if len(args) <= 1 {
 		console.Fatal("Two or more benchmark data files must be supplied")
 	}
-	zstdDec, _ := zstd.NewReader(nil)
-	defer zstdDec.Close()
 	var allOps bench.Operations
+	var allTags bench.Tags
+	var files []mergeFile
+	seen := make(map[mergeKey]struct{})
+	dedup := ctx.Bool("dedup")
+	duplicates := 0
 	threads := uint16(0)
 	log := console.Printf
 	if globalQuiet {
 		log = nil
 	}
 	for _, arg := range args {
-		f, err := os.Open(arg)
+		f, err := openInput(ctx, arg)
 		fatalIf(probe.NewError(err), "Unable to open input file")
 		defer f.Close()
-		err = zstdDec.Reset(f)
+		dec, err := newResultsReader(f)
 		fatalIf(probe.NewError(err), "Unable to decompress input")
-		ops, err := bench.OperationsFromCSV(zstdDec, false, ctx.Int("analyze.offset"), ctx.Int("analyze.limit"), log)
+		defer dec.Close()
+		tags, rd, err := bench.TagsFromCSV(dec)
+		fatalIf(probe.NewError(err), "Unable to read input")
+		ops, err := bench.OperationsFromCSV(rd, false, ctx.Int("analyze.offset"), ctx.Int("analyze.limit"), log)
 		fatalIf(probe.NewError(err), "Unable to parse input")
+		allTags = allTags.Merge(tags)
+
+		// Duplicates within a file are not checked.
+		var dupes map[mergeKey]struct{}
+		for _, op := range ops {
+			k := newMergeKey(op)
+			if _, ok := seen[k]; ok {
+				if dupes == nil {
+					dupes = make(map[mergeKey]struct{})
+				}
+				dupes[k] = struct{}{}
+				duplicates++
+			}
+		}
+		for _, op := range ops {
+			seen[newMergeKey(op)] = struct{}{}
+		}
+		if dedup && len(dupes) > 0 {
+			kept := ops[:0]
+			for _, op := range ops {
+				if _, ok := dupes[newMergeKey(op)]; !ok {
+					kept = append(kept, op)
+				}
+			}
+			ops = kept
+		}
+		if start, end := ops.TimeRange(); len(ops) > 0 {
+			files = append(files, mergeFile{name: arg, start: start, end: end})
+		}
 
 		threads = ops.OffsetThreads(threads)
 		allOps = append(allOps, ops...)
@@ -86,26 +142,35 @@ func mainMerge(ctx *cli.Context) error {
 	if len(allOps) == 0 {
 		return errors.New("benchmark files contains no data")
 	}
+	switch {
+	case duplicates > 0 && dedup:
+		console.Errorf("Warning: Dropped %d operations present in more than one file.\n", duplicates)
+	case duplicates > 0:
+		console.Errorf("Warning: %d operations are present in more than one file. Use --dedup to drop them.\n", duplicates)
+	}
+	checkMergeOverlap(files)
 	fileName := ctx.String("benchdata")
 	if fileName == "" {
 		fileName = fmt.Sprintf("%s-%s-%s", appName, ctx.Command.Name, time.Now().Format("2006-01-02[150405]"))
 	}
 	if len(allOps) > 0 {
 		allOps.SortByStartTime()
-		f, err := os.Create(fileName + ".csv.zst")
+		f, err := createResults(ctx, fileName+resultsExt(ctx))
 		if err != nil {
 			console.Error("Unable to write benchmark data:", err)
 		} else {
 			func() {
 				defer f.Close()
-				enc, err := zstd.NewWriter(f, zstd.WithEncoderLevel(zstd.SpeedBetterCompression))
+				enc, err := newResultsWriter(ctx, f)
 				fatalIf(probe.NewError(err), "Unable to compress benchmark output")
 
 				defer enc.Close()
+				err = allTags.CSV(enc)
+				fatalIf(probe.NewError(err), "Unable to write benchmark output")
 				err = allOps.CSV(enc, commandLine(ctx))
 				fatalIf(probe.NewError(err), "Unable to write benchmark output")
 
-				console.Infof("Benchmark data written to %q\n", fileName+".csv.zst")
+				console.Infof("Benchmark data written to %q\n", fileName+resultsExt(ctx))
 			}()
 		}
 	}
@@ -118,5 +183,29 @@ func mainMerge(ctx *cli.Context) error {
 	return nil
 }
 
+// checkMergeOverlap warns if the time ranges of the files don't overlap.
+// Throughput of the combined data is then lower than the throughput of any of the runs.
+func checkMergeOverlap(files []mergeFile) {
+	if len(files) < 2 {
+		return
+	}
+	start, end := files[0].start, files[0].end
+	for _, f := range files[1:] {
+		if f.start.After(start) {
+			start = f.start
+		}
+		if f.end.Before(end) {
+			end = f.end
+		}
+	}
+	if start.Before(end) {
+		return
+	}
+	console.Errorln("Warning: The benchmark files don't overlap in time, so the combined throughput will be wrong:")
+	for _, f := range files {
+		console.Errorf(" * %s: %s to %s\n", f.name, f.start.Format(time.RFC3339), f.end.Format(time.RFC3339))
+	}
+}
+
 func checkMerge(_ *cli.Context) {
 }
@@ -22,9 +22,9 @@ import (
 
 	"github.com/minio/cli"
 	"github.com/minio/mc/pkg/probe"
-	"github.com/minio/minio-go/v7"
 	"github.com/minio/pkg/v2/console"
 	"github.com/minio/warp/pkg/bench"
+	"golang.org/x/time/rate"
 )
 
 var mixedFlags = []cli.Flag{
@@ -58,6 +58,19 @@ var mixedFlags = []cli.Flag{
 		Usage: "The amount of DELETE operations. Must be same or lower than -put-distrib",
 		Value: 10,
 	},
+	cli.Float64Flag{
+		Name:  "recency",
+		Usage: "Read recently uploaded objects more often. Mean age of read objects as a fraction of all objects, eg. 0.1. 0 reads all objects equally often.",
+	},
+	cli.StringFlag{
+		Name:  "chain",
+		Usage: "Operation chain executed on new objects, eg. 'PUT,GET:100ms,DELETE'. Steps can have a delay.",
+	},
+	cli.Float64Flag{
+		Name:  "chain-distrib",
+		Usage: "The amount of operation chains. Requires --chain.",
+		Value: 0,
+	},
 }
 
 var mixedCmd = cli.Command{
@@ -65,7 +78,7 @@ var mixedCmd = cli.Command{
 	Usage:  "benchmark mixed objects",
 	Action: mainMixed,
 	Before: setGlobalsFromContext,
-	Flags:  combineFlags(globalFlags, ioFlags, mixedFlags, genFlags, benchFlags, analyzeFlags),
+	Flags:  combineFlags(globalFlags, ioFlags, mixedFlags, genFlags, benchFlags, shadowFlags, soakFlags, analyzeFlags),
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
@@ -81,27 +94,51 @@ FLAGS:
 // mainMixed is the entry point for mixed command.
 func mainMixed(ctx *cli.Context) error {
 	checkMixedSyntax(ctx)
-	sse := newSSE(ctx)
-	dist := bench.MixedDistribution{
-		Distribution: map[string]float64{
-			http.MethodGet:    ctx.Float64("get-distrib"),
-			"STAT":            ctx.Float64("stat-distrib"),
-			http.MethodPut:    ctx.Float64("put-distrib"),
-			http.MethodDelete: ctx.Float64("delete-distrib"),
-		},
-	}
-	err := dist.Generate(ctx.Int("objects") * 2)
-	fatalIf(probe.NewError(err), "Invalid distribution")
-	b := bench.Mixed{
-		Common:        getCommon(ctx, newGenSource(ctx, "obj.size")),
-		CreateObjects: ctx.Int("objects"),
-		GetOpts:       minio.GetObjectOptions{ServerSideEncryption: sse},
-		StatOpts: minio.StatObjectOptions{
-			ServerSideEncryption: sse,
-		},
-		Dist: &dist,
-	}
-	return runBench(ctx, &b)
+	return runBench(ctx, func(ctx *cli.Context) bench.Benchmark {
+		dist := bench.MixedDistribution{
+			Distribution: map[string]float64{
+				http.MethodGet:    ctx.Float64("get-distrib"),
+				"STAT":            ctx.Float64("stat-distrib"),
+				http.MethodPut:    ctx.Float64("put-distrib"),
+				http.MethodDelete: ctx.Float64("delete-distrib"),
+			},
+			Recency: ctx.Float64("recency"),
+		}
+		var chain []bench.ChainStep
+		if c := ctx.String("chain"); c != "" {
+			var err error
+			chain, err = bench.ParseChain(c)
+			fatalIf(probe.NewError(err), "Invalid operation chain")
+			dist.Distribution[bench.MixedChainOp] = ctx.Float64("chain-distrib")
+		}
+		err := dist.Generate(ctx.Int("objects") * 2)
+		fatalIf(probe.NewError(err), "Invalid distribution")
+		b := bench.Mixed{
+			Common:        getCommon(ctx, newGenSource(ctx, "obj.size")),
+			CreateObjects: ctx.Int("objects"),
+			GetOpts:       getOpts(ctx),
+			StatOpts:      statOpts(ctx),
+			Dist:          &dist,
+			Chain:         chain,
+		}
+		_, opLimits, err := parseRpsLimit(ctx.String("rps-limit"))
+		fatalIf(probe.NewError(err), "Invalid rps-limit")
+		if len(opLimits) > 0 {
+			b.OpRpsLimits = make(map[string]*rate.Limiter, len(opLimits))
+			for op, limit := range opLimits {
+				b.OpRpsLimits[op] = rate.NewLimiter(rate.Limit(limit), 1)
+			}
+		}
+		b.Shadow = newShadow(ctx)
+		if ctx.Bool("soak") {
+			// Operations are written by the soak test instead of being collected.
+			s, rcv := newSoak(ctx, &dist)
+			b.Source = s.Source
+			b.DiscardOutput = true
+			b.ExtraOut = append(b.ExtraOut, rcv)
+		}
+		return &b
+	})
 }
 
 func checkMixedSyntax(ctx *cli.Context) {
@@ -111,6 +148,25 @@ func checkMixedSyntax(ctx *cli.Context) {
 	if ctx.Int("objects") < 1 {
 		console.Fatal("At least one object must be tested")
 	}
+	if r := ctx.Float64("recency"); r < 0 || r > 1 {
+		console.Fatal("--recency must be between 0 and 1")
+	}
+	if ctx.String("chain") != "" && ctx.Float64("chain-distrib") <= 0 {
+		console.Fatal("--chain-distrib must be set when using --chain")
+	}
+	if _, opLimits, err := parseRpsLimit(ctx.String("rps-limit")); err == nil {
+		for op := range opLimits {
+			switch op {
+			case http.MethodGet, "STAT", http.MethodPut, http.MethodDelete, bench.MixedChainOp:
+			default:
+				console.Fatal("Unknown operation type in --rps-limit: ", op)
+			}
+		}
+	}
+	if ctx.String("chain") == "" && ctx.Float64("chain-distrib") > 0 {
+		console.Fatal("--chain-distrib requires --chain")
+	}
+	checkSoak(ctx)
 	checkAnalyze(ctx)
 	checkBenchmark(ctx)
 }
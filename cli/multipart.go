@@ -78,22 +78,24 @@ FLAGS:
 // mainPut is the entry point for cp command.
 func mainMultipart(ctx *cli.Context) error {
 	checkMultipartSyntax(ctx)
-	b := bench.Multipart{
-		Common:      getCommon(ctx, newGenSource(ctx, "part.size")),
-		ObjName:     ctx.String("obj.name"),
-		PartStart:   ctx.Int("_part-start"),
-		UploadID:    ctx.String("_upload-id"),
-		CreateParts: ctx.Int("parts"),
-	}
-	b.PutOpts = multipartOpts(ctx)
-	if b.UploadID == "" {
-		err := b.InitOnce(context.Background())
-		if err != nil {
-			console.Fatal(err)
+	return runBench(ctx, func(ctx *cli.Context) bench.Benchmark {
+		b := bench.Multipart{
+			Common:      getCommon(ctx, newGenSource(ctx, "part.size")),
+			ObjName:     ctx.String("obj.name"),
+			PartStart:   ctx.Int("_part-start"),
+			UploadID:    ctx.String("_upload-id"),
+			CreateParts: ctx.Int("parts"),
 		}
-		b.ExtraFlags = map[string]string{"_upload-id": b.UploadID, "noprefix": "true"}
-	}
-	return runBench(ctx, &b)
+		b.PutOpts = multipartOpts(ctx)
+		if b.UploadID == "" {
+			err := b.InitOnce(context.Background())
+			if err != nil {
+				console.Fatal(err)
+			}
+			b.ExtraFlags = map[string]string{"_upload-id": b.UploadID, "noprefix": "true"}
+		}
+		return &b
+	})
 }
 
 // putOpts retrieves put options from the context.
@@ -101,7 +103,7 @@ func multipartOpts(ctx *cli.Context) minio.PutObjectOptions {
 	return minio.PutObjectOptions{
 		ServerSideEncryption: newSSE(ctx),
 		DisableMultipart:     false,
-		DisableContentSha256: ctx.Bool("disable-sha256-payload"),
+		DisableContentSha256: unsignedPayload(ctx),
 		SendContentMd5:       ctx.Bool("md5"),
 		StorageClass:         ctx.String("storage-class"),
 	}
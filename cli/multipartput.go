@@ -0,0 +1,98 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"github.com/minio/cli"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/bench"
+)
+
+var multipartPutFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "part.size",
+		Value: "5MiB",
+		Usage: "Size of each part. Can be a number or MiB/GiB. Must be >= 5MiB",
+	},
+	cli.IntFlag{
+		Name:  "parts",
+		Value: 100,
+		Usage: "Number of parts of each object",
+	},
+	cli.IntFlag{
+		Name:  "part.concurrent",
+		Value: 4,
+		Usage: "Number of parts uploaded concurrently for each object",
+	},
+}
+
+var multipartPutCmd = cli.Command{
+	Name:   "multipart-put",
+	Usage:  "benchmark multipart upload of objects",
+	Action: mainMultipartPut,
+	Before: setGlobalsFromContext,
+	Flags:  combineFlags(globalFlags, ioFlags, multipartPutFlags, genFlags, benchFlags, analyzeFlags),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+  -> see https://github.com/minio/warp#multipart-put
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+}
+
+// mainMultipartPut is the entry point for multipart-put command.
+func mainMultipartPut(ctx *cli.Context) error {
+	checkMultipartPutSyntax(ctx)
+	return runBench(ctx, func(ctx *cli.Context) bench.Benchmark {
+		b := bench.MultipartPut{
+			Common:          getCommon(ctx, newGenSource(ctx, "part.size")),
+			Parts:           ctx.Int("parts"),
+			PartConcurrency: ctx.Int("part.concurrent"),
+		}
+		b.PutOpts = multipartOpts(ctx)
+		return &b
+	})
+}
+
+func checkMultipartPutSyntax(ctx *cli.Context) {
+	if ctx.NArg() > 0 {
+		console.Fatal("Command takes no arguments")
+	}
+	if ctx.Bool("disable-multipart") {
+		console.Fatal("Cannot disable multipart for multipart test")
+	}
+	if ctx.Int("parts") <= 0 || ctx.Int("parts") > 10000 {
+		console.Fatal("--parts must be between 1 and 10000")
+	}
+	if ctx.Int("part.concurrent") <= 0 {
+		console.Fatal("--part.concurrent must be > 0")
+	}
+	if sz, err := toSize(ctx.String("part.size")); err != nil || sz < 5<<20 {
+		console.Fatal("part.size must be >= 5MiB")
+	}
+	if ctx.Bool("obj.randsize") {
+		console.Fatal("--obj.randsize cannot be used, all parts except the last must be the same size")
+	}
+
+	checkAnalyze(ctx)
+	checkBenchmark(ctx)
+}
@@ -0,0 +1,110 @@
+/*
+ * Warp (C) 2019-2020 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/bench"
+)
+
+// opSample returns n when 1 in n operations should be recorded, as set by --op-sample.
+func opSample(ctx *cli.Context) (int, error) {
+	s := strings.TrimSpace(ctx.String("op-sample"))
+	if s == "" {
+		return 1, nil
+	}
+	num, den, ok := strings.Cut(s, "/")
+	if !ok {
+		num, den = "1", s
+	}
+	if strings.TrimSpace(num) != "1" {
+		return 0, fmt.Errorf("invalid op-sample %q, must be '1/n'", s)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(den))
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid op-sample %q, must be '1/n' with n at least 1", s)
+	}
+	return n, nil
+}
+
+// printOpSampleAnalysis prints the exact results of each operation type
+// when only a sample of the operations was recorded.
+// Events of all clients are combined.
+func printOpSampleAnalysis(events bench.Operations) {
+	type result struct {
+		rate, requests, errors uint64
+		bytes                  int64
+		start, end             time.Time
+		latency                bench.LatencyHistogram
+	}
+	byType := make(map[string]*result)
+	for _, ev := range events {
+		r := byType[ev.File]
+		if r == nil {
+			r = &result{start: ev.Start, end: ev.End}
+			byType[ev.File] = r
+		}
+		rate, _ := strconv.ParseUint(ev.Headers[bench.OpSampleRate], 10, 64)
+		requests, _ := strconv.ParseUint(ev.Headers[bench.OpSampleRequests], 10, 64)
+		errs, _ := strconv.ParseUint(ev.Headers[bench.OpSampleErrors], 10, 64)
+		r.rate = max(r.rate, rate)
+		r.requests += requests
+		r.errors += errs
+		r.bytes += ev.Size
+		if ev.Start.Before(r.start) {
+			r.start = ev.Start
+		}
+		if ev.End.After(r.end) {
+			r.end = ev.End
+		}
+		if h, err := bench.ParseLatencyHistogram(ev.Headers[bench.OpSampleLatency]); err == nil {
+			r.latency.Merge(h)
+		}
+	}
+	if len(byType) == 0 {
+		return
+	}
+	console.SetColor("Print", color.New(color.FgHiWhite))
+	console.Println("\nExact results of sampled operations:")
+	console.SetColor("Print", color.New(color.FgWhite))
+	for _, typ := range stringKeysSorted(byType) {
+		r := byType[typ]
+		dur := r.end.Sub(r.start)
+		if dur <= 0 {
+			continue
+		}
+		line := fmt.Sprintf(" * %s: 1 in %d recorded. %d requests, %.02f obj/s, %v", typ, r.rate, r.requests,
+			float64(r.requests)/dur.Seconds(), bench.Throughput(float64(r.bytes)/dur.Seconds()))
+		if h := &r.latency; h.N() > 0 {
+			ms := func(d time.Duration) float64 { return float64(d.Microseconds()) / 1000 }
+			line += fmt.Sprintf(", avg: %.01fms, 50%%: %.01fms, 90%%: %.01fms, 99%%: %.01fms, 99.9%%: %.01fms, max: %.01fms",
+				ms(h.Average()), ms(h.Quantile(0.5)), ms(h.Quantile(0.9)), ms(h.Quantile(0.99)), ms(h.Quantile(0.999)), ms(h.Max()))
+		}
+		if r.errors > 0 {
+			line += fmt.Sprintf(", errors: %d", r.errors)
+		}
+		console.Println(line)
+	}
+}
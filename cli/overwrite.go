@@ -0,0 +1,104 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"path"
+
+	"github.com/minio/cli"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/bench"
+)
+
+var overwriteFlags = []cli.Flag{
+	cli.IntFlag{
+		Name:  "keys",
+		Value: 10,
+		Usage: "Number of keys all threads upload to. Fewer keys will increase contention.",
+	},
+	cli.StringFlag{
+		Name:  "obj.size",
+		Value: "1MiB",
+		Usage: "Size of each generated object. Can be a number or 10KiB/MiB/GiB. All sizes are base 2 binary.",
+	},
+	cli.StringFlag{
+		Name:  "condition",
+		Usage: "Upload condition. Can be 'if-match' to upload with the ETag read by a HEAD request, or 'if-none-match' to only create keys.",
+	},
+	cli.BoolFlag{
+		Name:  "versioned",
+		Usage: "Enable versioning on the bucket.",
+	},
+	cli.IntFlag{
+		Name:  "readers",
+		Usage: "Number of threads downloading with If-Match and the last seen ETag instead of uploading.",
+	},
+}
+
+var overwriteCmd = cli.Command{
+	Name:   "overwrite",
+	Usage:  "benchmark concurrent uploads to the same keys",
+	Action: mainOverwrite,
+	Before: setGlobalsFromContext,
+	Flags:  combineFlags(globalFlags, ioFlags, overwriteFlags, genFlags, benchFlags, analyzeFlags),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+  -> see https://github.com/minio/warp#overwrite
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+}
+
+// mainOverwrite is the entry point for overwrite command.
+func mainOverwrite(ctx *cli.Context) error {
+	checkOverwriteSyntax(ctx)
+	return runBench(ctx, func(ctx *cli.Context) bench.Benchmark {
+		b := bench.Overwrite{
+			Common:           getCommon(ctx, newGenSource(ctx, "obj.size")),
+			Keys:             ctx.Int("keys"),
+			KeyPrefix:        path.Join(ctx.String("prefix"), "overwrite"),
+			Condition:        ctx.String("condition"),
+			EnableVersioning: ctx.Bool("versioned"),
+			Readers:          ctx.Int("readers"),
+		}
+		return &b
+	})
+}
+
+func checkOverwriteSyntax(ctx *cli.Context) {
+	if ctx.NArg() > 0 {
+		console.Fatal("Command takes no arguments")
+	}
+	if ctx.Int("keys") < 1 {
+		console.Fatal("At least one key must be used")
+	}
+	if n := ctx.Int("readers"); n < 0 || n >= ctx.Int("concurrent") {
+		console.Fatal("--readers must be less than --concurrent")
+	}
+	switch ctx.String("condition") {
+	case bench.OverwriteUnconditional, bench.OverwriteIfMatch, bench.OverwriteIfNoneMatch:
+	default:
+		console.Fatalf("Unknown condition %q. Must be %s or %s", ctx.String("condition"), bench.OverwriteIfMatch, bench.OverwriteIfNoneMatch)
+	}
+	checkAnalyze(ctx)
+	checkBenchmark(ctx)
+}
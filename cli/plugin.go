@@ -0,0 +1,182 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/bench"
+)
+
+// addPlugins will add commands for all registered plugin benchmarks.
+// Plugins with names that collide with existing commands or flags are rejected.
+func addPlugins() {
+	cmds := make(map[string]struct{}, len(appCmds))
+	for _, cmd := range appCmds {
+		for _, name := range cmd.Names() {
+			cmds[name] = struct{}{}
+		}
+	}
+	for _, p := range bench.Plugins() {
+		if _, ok := cmds[p.Name]; ok {
+			fatalIf(errDummy(), "Plugin %q collides with an existing command", p.Name)
+		}
+		cmds[p.Name] = struct{}{}
+		cmd := pluginCmd(p)
+		appCmds = append(appCmds, cmd)
+		benchCmds = append(benchCmds, cmd)
+	}
+}
+
+// pluginCmd returns the command for a plugin benchmark.
+func pluginCmd(p bench.Plugin) cli.Command {
+	flags := []cli.Flag{
+		cli.StringFlag{
+			Name:  "obj.size",
+			Value: "10MiB",
+			Usage: "Size of each generated object. Can be a number or 10KiB/MiB/GiB. All sizes are base 2 binary.",
+		},
+	}
+	common := combineFlags(globalFlags, ioFlags, flags, genFlags, benchFlags, analyzeFlags)
+	existing := make(map[string]struct{}, len(common))
+	for _, f := range common {
+		for _, name := range strings.Split(f.GetName(), ",") {
+			existing[strings.TrimSpace(name)] = struct{}{}
+		}
+	}
+	for _, o := range p.Options {
+		if _, ok := existing[o.Name]; ok {
+			fatalIf(errDummy(), "Option %q of plugin %q collides with an existing flag", o.Name, p.Name)
+		}
+		flags = append(flags, cli.StringFlag{
+			Name:  o.Name,
+			Value: o.Default,
+			Usage: o.Usage,
+		})
+	}
+	return cli.Command{
+		Name:   p.Name,
+		Usage:  p.Usage,
+		Action: func(ctx *cli.Context) error { return mainPlugin(ctx, p) },
+		Before: setGlobalsFromContext,
+		Flags:  combineFlags(globalFlags, ioFlags, flags, genFlags, benchFlags, analyzeFlags),
+		CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+	}
+}
+
+// mainPlugin is the entry point for plugin commands.
+func mainPlugin(ctx *cli.Context, p bench.Plugin) error {
+	if ctx.NArg() > 0 {
+		console.Fatal("Command takes no arguments")
+	}
+	checkAnalyze(ctx)
+	checkBenchmark(ctx)
+
+	return runBench(ctx, func(ctx *cli.Context) bench.Benchmark {
+		opts := make(bench.PluginOptions, len(p.Options))
+		for _, o := range p.Options {
+			opts[o.Name] = ctx.String(o.Name)
+		}
+		b, err := p.New(getCommon(ctx, newGenSource(ctx, "obj.size")), opts)
+		fatalIf(probe.NewError(err), "Invalid "+p.Name+" options")
+		return b
+	})
+}
+
+var externalFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "plugin",
+		Usage: "Executable implementing the benchmark. One process is started per thread",
+	},
+	cli.StringFlag{
+		Name:  "plugin.args",
+		Usage: "Space separated arguments passed to the plugin",
+	},
+}
+
+// External command.
+var externalCmd = cli.Command{
+	Name:   "external",
+	Usage:  "benchmark using an external plugin process",
+	Action: mainExternal,
+	Before: setGlobalsFromContext,
+	Flags:  combineFlags(globalFlags, ioFlags, externalFlags, benchFlags, analyzeFlags),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} --plugin=<executable> [FLAGS]
+  -> see https://github.com/minio/warp#external-plugins
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+}
+
+// mainExternal is the entry point for the external command.
+func mainExternal(ctx *cli.Context) error {
+	checkExternalSyntax(ctx)
+	hosts := parseHosts(ctx.String("host"), ctx.Bool("resolve-host"))
+	return runBench(ctx, func(ctx *cli.Context) bench.Benchmark {
+		b := bench.External{
+			Common:  getCommon(ctx, nil),
+			Command: ctx.String("plugin"),
+			Args:    strings.Fields(ctx.String("plugin.args")),
+			Env: []string{
+				"WARP_ACCESS_KEY=" + ctx.String("access-key"),
+				"WARP_SECRET_KEY=" + ctx.String("secret-key"),
+				"WARP_TLS=" + strconv.FormatBool(ctx.Bool("tls")),
+				"WARP_INSECURE=" + strconv.FormatBool(ctx.Bool("insecure")),
+				"WARP_REGION=" + ctx.String("region"),
+				"WARP_BUCKET=" + ctx.String("bucket"),
+			},
+			// Spread threads over hosts.
+			ThreadEnv: func(thread int) []string {
+				return []string{"WARP_HOST=" + hosts[thread%len(hosts)]}
+			},
+		}
+		return &b
+	})
+}
+
+func checkExternalSyntax(ctx *cli.Context) {
+	if ctx.NArg() > 0 {
+		console.Fatal("Command takes no arguments")
+	}
+	if ctx.String("plugin") == "" {
+		console.Fatal("--plugin must be specified")
+	}
+	if len(parseHosts(ctx.String("host"), ctx.Bool("resolve-host"))) == 0 {
+		console.Fatal("no host defined")
+	}
+
+	checkAnalyze(ctx)
+	checkBenchmark(ctx)
+}
@@ -0,0 +1,116 @@
+/*
+ * Warp (C) 2019-2020 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"sync/atomic"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/bench"
+)
+
+var policyFlags = []cli.Flag{
+	cli.IntFlag{
+		Name:  "objects",
+		Value: 1000,
+		Usage: "Number of objects to upload. Half are uploaded to each of the allowed and denied prefixes.",
+	},
+	cli.StringFlag{
+		Name:  "obj.size",
+		Value: "1KiB",
+		Usage: "Size of each generated object. Can be a number or 10KB/MB/GB. All sizes are base 2 binary.",
+	},
+	cli.Float64Flag{
+		Name:  "denied.fraction",
+		Value: 0.5,
+		Usage: "Fraction of requests sent to the prefix denied by the bucket policy.",
+	},
+	cli.BoolFlag{
+		Name:  "policy.signed",
+		Usage: "Sign requests with the access keys instead of sending anonymous requests. Keys must not belong to an administrator.",
+	},
+}
+
+var policyCmd = cli.Command{
+	Name:   "policy",
+	Usage:  "benchmark requests allowed and denied by a bucket policy",
+	Action: mainPolicy,
+	Before: setGlobalsFromContext,
+	Flags:  combineFlags(globalFlags, ioFlags, policyFlags, prepareFlags, genFlags, benchFlags, analyzeFlags),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+  -> see https://github.com/minio/warp#policy
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+}
+
+// mainPolicy is the entry point for policy command.
+func mainPolicy(ctx *cli.Context) error {
+	checkPolicySyntax(ctx)
+	return runBench(ctx, func(ctx *cli.Context) bench.Benchmark {
+		b := bench.Policy{
+			Common:         getCommon(ctx, newGenSource(ctx, "obj.size")),
+			CreateObjects:  ctx.Int("objects"),
+			DeniedFraction: ctx.Float64("denied.fraction"),
+			GetOpts:        getOpts(ctx),
+		}
+		b.Requester = b.Client
+		if !ctx.Bool("policy.signed") {
+			b.Requester = newAnonymousClient(ctx)
+		}
+		return &b
+	})
+}
+
+// newAnonymousClient returns a function that selects an anonymous client for each request.
+// Hosts are selected round-robin.
+func newAnonymousClient(ctx *cli.Context) func() (*minio.Client, func()) {
+	hosts := parseHosts(ctx.String("host"), ctx.Bool("resolve-host"))
+	clients := make([]*minio.Client, 0, len(hosts))
+	for _, host := range hosts {
+		cl, err := getClientKeys(ctx, host, "", "", bucketLookups(ctx)[0])
+		fatalIf(probe.NewError(err), "Unable to create MinIO client")
+		clients = append(clients, cl)
+	}
+	var current atomic.Uint64
+	return func() (*minio.Client, func()) {
+		return clients[(current.Add(1)-1)%uint64(len(clients))], func() {}
+	}
+}
+
+func checkPolicySyntax(ctx *cli.Context) {
+	if ctx.NArg() > 0 {
+		console.Fatal("Command takes no arguments")
+	}
+	if ctx.Int("objects") < 2 {
+		console.Fatal("At least two objects must be uploaded")
+	}
+	if f := ctx.Float64("denied.fraction"); f < 0 || f > 1 {
+		console.Fatal("denied.fraction must be between 0 and 1")
+	}
+	checkAnalyze(ctx)
+	checkBenchmark(ctx)
+}
@@ -0,0 +1,171 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/minio-go/v7"
+)
+
+// resultRun contains the files of a single run.
+type resultRun struct {
+	name    string
+	files   []string
+	size    int64
+	modTime time.Time
+}
+
+// resultRetention contains the limits of kept result files.
+// Zero values are not limited.
+type resultRetention struct {
+	keep    int
+	maxSize int64
+	maxAge  time.Duration
+}
+
+func getResultRetention(ctx *cli.Context) (r resultRetention) {
+	r.keep = ctx.Int("results.keep")
+	r.maxAge = ctx.Duration("results.max-age")
+	if s := ctx.String("results.max-size"); s != "" {
+		size, err := toSize(s)
+		if err == nil {
+			r.maxSize = int64(size)
+		}
+	}
+	return r
+}
+
+func (r resultRetention) enabled() bool {
+	return r.keep > 0 || r.maxSize > 0 || r.maxAge > 0
+}
+
+// prune returns the runs that should be removed.
+// The newest run is always kept.
+func (r resultRetention) prune(runs []resultRun, now time.Time) []resultRun {
+	sort.Slice(runs, func(i, j int) bool { return runs[i].modTime.After(runs[j].modTime) })
+	var remove []resultRun
+	var total int64
+	for i, run := range runs {
+		total += run.size
+		switch {
+		case i == 0:
+		case r.keep > 0 && i >= r.keep,
+			r.maxAge > 0 && now.Sub(run.modTime) > r.maxAge,
+			r.maxSize > 0 && total > r.maxSize:
+			remove = append(remove, run)
+		}
+	}
+	return remove
+}
+
+// addResultFile adds a file to the run it belongs to.
+// Files belong to the same run if the name is the same up to the first dot.
+// Only files created by warp are added.
+func addResultFile(runs map[string]*resultRun, name string, size int64, modTime time.Time) {
+	base := path.Base(name)
+	if !strings.HasPrefix(base, appName+"-") {
+		return
+	}
+	runName, _, _ := strings.Cut(base, ".")
+	run := runs[runName]
+	if run == nil {
+		run = &resultRun{name: runName}
+		runs[runName] = run
+	}
+	run.files = append(run.files, name)
+	run.size += size
+	if modTime.After(run.modTime) {
+		run.modTime = modTime
+	}
+}
+
+func runsOf(m map[string]*resultRun) []resultRun {
+	runs := make([]resultRun, 0, len(m))
+	for _, run := range m {
+		runs = append(runs, *run)
+	}
+	return runs
+}
+
+// pruneLocalResults removes result files of old runs in dir.
+func pruneLocalResults(ctx *cli.Context, dir string) {
+	r := getResultRetention(ctx)
+	if !r.enabled() {
+		return
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		printError("Unable to list results:", err)
+		return
+	}
+	m := make(map[string]*resultRun)
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		addResultFile(m, filepath.Join(dir, e.Name()), info.Size(), info.ModTime())
+	}
+	for _, run := range r.prune(runsOf(m), time.Now()) {
+		for _, f := range run.files {
+			if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+				printError("Unable to remove old result:", err)
+			}
+		}
+		printInfo("Removed old results of " + run.name + "\n")
+	}
+}
+
+// pruneUploadedResults removes uploaded result files of old runs in the results bucket.
+func pruneUploadedResults(ctx context.Context, cliCtx *cli.Context, cl *minio.Client, bucket string) error {
+	r := getResultRetention(cliCtx)
+	if !r.enabled() {
+		return nil
+	}
+	prefix := cliCtx.String("results-prefix")
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	m := make(map[string]*resultRun)
+	for obj := range cl.ListObjects(ctx, bucket, minio.ListObjectsOptions{Prefix: prefix}) {
+		if obj.Err != nil {
+			return obj.Err
+		}
+		addResultFile(m, obj.Key, obj.Size, obj.LastModified)
+	}
+	for _, run := range r.prune(runsOf(m), time.Now()) {
+		for _, key := range run.files {
+			if err := cl.RemoveObject(ctx, bucket, key, minio.RemoveObjectOptions{}); err != nil {
+				return err
+			}
+		}
+		printInfo("Removed old uploaded results of " + run.name + "\n")
+	}
+	return nil
+}
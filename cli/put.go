@@ -64,11 +64,13 @@ FLAGS:
 // mainPut is the entry point for cp command.
 func mainPut(ctx *cli.Context) error {
 	checkPutSyntax(ctx)
-	b := bench.Put{
-		Common:     getCommon(ctx, newGenSource(ctx, "obj.size")),
-		PostObject: ctx.Bool("post"),
-	}
-	return runBench(ctx, &b)
+	return runBench(ctx, func(ctx *cli.Context) bench.Benchmark {
+		b := bench.Put{
+			Common:     getCommon(ctx, newGenSource(ctx, "obj.size")),
+			PostObject: ctx.Bool("post"),
+		}
+		return &b
+	})
 }
 
 // putOpts retrieves put options from the context.
@@ -77,7 +79,7 @@ func putOpts(ctx *cli.Context) minio.PutObjectOptions {
 	return minio.PutObjectOptions{
 		ServerSideEncryption: newSSE(ctx),
 		DisableMultipart:     ctx.Bool("disable-multipart"),
-		DisableContentSha256: ctx.Bool("disable-sha256-payload"),
+		DisableContentSha256: unsignedPayload(ctx),
 		SendContentMd5:       ctx.Bool("md5"),
 		StorageClass:         ctx.String("storage-class"),
 		PartSize:             pSize,
@@ -0,0 +1,128 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// clusterRebalanceInterval is the minimum interval between rebalancing client shares.
+	clusterRebalanceInterval = 5 * time.Second
+
+	// clusterUnderuse is the fraction of its share below which a client is underusing it.
+	clusterUnderuse = 0.9
+
+	// clusterHeadroom is added to the measured rate of underusing clients.
+	clusterHeadroom = 1.2
+)
+
+// clusterLimiter distributes a cluster wide rate limit between clients.
+// The rate is either requests or bytes per second.
+// Clients that do not use their share keep a little more than they use,
+// and the remainder is divided between the clients that are limited.
+type clusterLimiter struct {
+	mu      sync.Mutex
+	total   float64
+	clients []clusterClient
+	updated time.Time
+}
+
+type clusterClient struct {
+	// Share of the total limit.
+	share float64
+	// Share last sent to the client.
+	sent float64
+	// Count and time of the last observation.
+	count int64
+	t     time.Time
+	// Measured rate per second since the last rebalance.
+	rate float64
+	done bool
+}
+
+func newClusterLimiter(total float64, clients int) *clusterLimiter {
+	c := clusterLimiter{
+		total:   total,
+		clients: make([]clusterClient, clients),
+		updated: time.Now(),
+	}
+	for i := range c.clients {
+		c.clients[i].share = total / float64(clients)
+		c.clients[i].sent = c.clients[i].share
+	}
+	return &c
+}
+
+// observe records the number of requests or bytes done by client i at time t.
+// If the share of the client has changed, it is returned.
+func (c *clusterLimiter) observe(i int, count int64, t time.Time) (float64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cl := &c.clients[i]
+	if !cl.t.IsZero() && t.Sub(cl.t) >= clusterRebalanceInterval {
+		cl.rate = float64(count-cl.count) / t.Sub(cl.t).Seconds()
+		cl.count, cl.t = count, t
+	} else if cl.t.IsZero() {
+		cl.count, cl.t = count, t
+	}
+	if time.Since(c.updated) >= clusterRebalanceInterval {
+		c.rebalance()
+		c.updated = time.Now()
+	}
+	// Only send changes above 1%.
+	if cl.done || math.Abs(cl.share-cl.sent) < cl.sent/100 {
+		return 0, false
+	}
+	cl.sent = cl.share
+	return cl.share, true
+}
+
+// finished removes client i, so its share is given to other clients.
+func (c *clusterLimiter) finished(i int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clients[i].done = true
+	c.clients[i].share = 0
+}
+
+// rebalance calculates new shares. Must be called with the lock held.
+func (c *clusterLimiter) rebalance() {
+	var limited []int
+	remaining := c.total
+	for i := range c.clients {
+		cl := &c.clients[i]
+		switch {
+		case cl.done:
+		case cl.rate <= 0:
+			// Not measured yet, keep share.
+			remaining -= cl.share
+		case cl.rate < cl.share*clusterUnderuse:
+			cl.share = math.Max(1, cl.rate*clusterHeadroom)
+			remaining -= cl.share
+		default:
+			limited = append(limited, i)
+		}
+	}
+	remaining = math.Max(remaining, 0)
+	for _, i := range limited {
+		c.clients[i].share = math.Max(1, remaining/float64(len(limited)))
+	}
+}
@@ -0,0 +1,102 @@
+/*
+ * Warp (C) 2019-2020 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"math"
+	"testing"
+)
+
+func TestClusterLimiterRebalance(t *testing.T) {
+	c := newClusterLimiter(100, 3)
+	shares := func() []float64 {
+		dst := make([]float64, len(c.clients))
+		for i, cl := range c.clients {
+			dst[i] = math.Round(cl.share*100) / 100
+		}
+		return dst
+	}
+	check := func(name string, want ...float64) {
+		t.Helper()
+		got := shares()
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%s: got shares %v, want %v", name, got, want)
+				return
+			}
+		}
+	}
+	check("start", 33.33, 33.33, 33.33)
+
+	// Clients that are not measured keep their share.
+	c.clients[0].rate = 33
+	c.rebalance()
+	check("unmeasured", 33.33, 33.33, 33.33)
+
+	// Client 1 only uses 10, so it keeps 12 and the rest goes to the limited clients.
+	c.clients[1].rate = 10
+	c.clients[2].rate = 33
+	c.rebalance()
+	check("underuse", 44, 12, 44)
+
+	// Client 1 uses its share again, so all clients are limited and share equally.
+	c.clients[0].rate = 44
+	c.clients[1].rate = 12
+	c.clients[2].rate = 44
+	c.rebalance()
+	check("recovery", 33.33, 33.33, 33.33)
+
+	// Shares of finished clients are given to the remaining clients.
+	c.clients[0].rate = 33.33
+	c.clients[1].rate = 33.33
+	c.finished(2)
+	c.rebalance()
+	check("finished", 50, 50, 0)
+
+	// Underusing clients keep at least 1.
+	c.clients[0].rate = 0.1
+	c.clients[1].rate = 50
+	c.rebalance()
+	check("minimum", 1, 99, 0)
+}
+
+func TestClusterLimiterObserve(t *testing.T) {
+	c := newClusterLimiter(100, 2)
+	c.updated = c.updated.Add(-clusterRebalanceInterval)
+	start := c.updated
+	if _, ok := c.observe(0, 0, start); ok {
+		t.Fatal("unexpected change on first observation")
+	}
+	c.updated = c.updated.Add(-clusterRebalanceInterval)
+	c.observe(1, 0, start)
+	c.observe(1, 250, start.Add(clusterRebalanceInterval))
+	c.updated = c.updated.Add(-clusterRebalanceInterval)
+	// Client 0 used 10/s. Client 1 is limited and gets the rest.
+	share, ok := c.observe(0, 50, start.Add(clusterRebalanceInterval))
+	if !ok || share != 12 {
+		t.Fatalf("got share %v (changed %v), want 12", share, ok)
+	}
+	if _, ok := c.observe(0, 50, start.Add(clusterRebalanceInterval)); ok {
+		t.Fatal("unchanged share was sent again")
+	}
+	// Finished clients are not sent updates.
+	c.finished(1)
+	if _, ok := c.observe(1, 300, start.Add(2*clusterRebalanceInterval)); ok {
+		t.Fatal("finished client was sent a share")
+	}
+}
@@ -0,0 +1,57 @@
+/*
+ * Warp (C) 2019-2020 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"os"
+	"sync"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/bench"
+)
+
+// newWorkloadRecorder returns a channel that records all operations.
+// The workload is written to the file given by --record when the channel is closed.
+func newWorkloadRecorder(ctx *cli.Context, wg *sync.WaitGroup) chan<- bench.Operation {
+	fileName := ctx.String("record")
+	// Create the file before starting, so errors are reported early.
+	f, err := os.Create(fileName)
+	fatalIf(probe.NewError(err), "unable to create workload file")
+
+	ch := make(chan bench.Operation, 10000)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		var ops bench.Operations
+		for op := range ch {
+			ops = append(ops, op)
+		}
+		err := bench.WriteWorkload(f, ops)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			errorIf(probe.NewError(err), "unable to write workload")
+			return
+		}
+		console.Infof("Recorded %d operations to %s\n", len(ops), fileName)
+	}()
+	return ch
+}
@@ -0,0 +1,104 @@
+/*
+ * Warp (C) 2019-2020 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/bench"
+)
+
+var replayFlags = []cli.Flag{
+	cli.Float64Flag{
+		Name:  "speed",
+		Value: 1,
+		Usage: "Replay speed relative to the recording. 2 replays twice as fast. 0 sends operations as fast as possible in the recorded order.",
+	},
+}
+
+var replayCmd = cli.Command{
+	Name:   "replay",
+	Usage:  "replay a workload recorded with --record",
+	Action: mainReplay,
+	Before: setGlobalsFromContext,
+	Flags:  combineFlags(globalFlags, ioFlags, replayFlags, benchFlags, analyzeFlags),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] workload.bin
+  -> see https://github.com/minio/warp#replay
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+}
+
+// mainReplay is the entry point for replay command.
+func mainReplay(ctx *cli.Context) error {
+	checkReplaySyntax(ctx)
+	f, err := os.Open(ctx.Args().First())
+	fatalIf(probe.NewError(err), "Unable to open workload file")
+	ops, err := bench.ReadWorkload(f)
+	f.Close()
+	fatalIf(probe.NewError(err), "Unable to read workload file")
+
+	threads := make(map[uint16]struct{})
+	skipped := make(map[string]int)
+	for _, op := range ops {
+		if !bench.ReplaySupported(op.OpType) {
+			skipped[op.OpType]++
+			continue
+		}
+		threads[op.Thread] = struct{}{}
+	}
+	for opType, n := range skipped {
+		console.Errorln(fmt.Sprintf("Skipping %d %s operations that cannot be replayed", n, opType))
+	}
+	if len(threads) == 0 {
+		console.Fatal("Workload has no operations that can be replayed")
+	}
+
+	return runBench(ctx, func(ctx *cli.Context) bench.Benchmark {
+		b := bench.Replay{
+			Common: getCommon(ctx, nil),
+			Ops:    ops,
+			Speed:  ctx.Float64("speed"),
+		}
+		b.Concurrency = len(threads)
+		return &b
+	})
+}
+
+func checkReplaySyntax(ctx *cli.Context) {
+	if ctx.NArg() != 1 {
+		console.Fatal("Command takes exactly one workload file as argument")
+	}
+	if ctx.Float64("speed") < 0 {
+		console.Fatal("--speed cannot be negative")
+	}
+	if ctx.String("warp-client") != "" {
+		console.Fatal("replay cannot be run on remote clients")
+	}
+	checkAnalyze(ctx)
+	checkBenchmark(ctx)
+}
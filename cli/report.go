@@ -0,0 +1,261 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v2/console"
+)
+
+var reportFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "dir",
+		Usage: "Directory or s3://bucket/prefix containing benchmark data files.",
+	},
+	cli.StringFlag{
+		Name:  "report.group",
+		Value: "day",
+		Usage: "Group runs by 'day' or by the value of a tag, eg. 'build'.",
+	},
+	cli.Float64Flag{
+		Name:  "report.max-regression",
+		Value: 10,
+		Usage: "Mark regressions where throughput drops or p99 request time rises more than this many percent compared to the previous group.",
+	},
+	cli.BoolFlag{
+		Name:  "report.fail",
+		Usage: "Exit with an error if a regression is found in the last group.",
+	},
+}
+
+var reportCmd = cli.Command{
+	Name:   "report",
+	Usage:  "trend report of many benchmark runs",
+	Action: mainReport,
+	Before: setGlobalsFromContext,
+	Flags:  combineFlags(globalFlags, analyzeFlags, reportFlags, inputFlags),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] --dir=results/
+  -> see https://github.com/minio/warp#trend-reports
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+}
+
+// reportGroup contains the combined results of all runs in a group.
+type reportGroup struct {
+	Name       string          `json:"name"`
+	Start      time.Time       `json:"start"`
+	Runs       int             `json:"runs"`
+	Operations []reportOpStats `json:"operations"`
+}
+
+// reportOpStats contains the average results of an operation type in a group.
+type reportOpStats struct {
+	Type       string  `json:"type"`
+	Runs       int     `json:"runs"`
+	AverageBPS float64 `json:"average_bps"`
+	AverageOPS float64 `json:"average_ops"`
+	P99Millis  float64 `json:"p99_millis"`
+	// Changes compared to the previous group with the operation type.
+	ThroughputChange *float64 `json:"throughput_change_pct,omitempty"`
+	P99Change        *float64 `json:"p99_change_pct,omitempty"`
+	Regression       bool     `json:"regression,omitempty"`
+}
+
+// mainReport is the entry point for the report command.
+func mainReport(ctx *cli.Context) error {
+	checkAnalyze(ctx)
+	location := ctx.String("dir")
+	if location == "" && ctx.NArg() == 1 {
+		location = ctx.Args().First()
+	}
+	if location == "" {
+		console.Fatal("No directory supplied, use --dir")
+	}
+	files, err := listInputs(ctx, location)
+	fatalIf(probe.NewError(err), "Unable to list benchmark data")
+	if len(files) == 0 {
+		console.Fatal("No benchmark data files found in", location)
+	}
+
+	groups := make(map[string]*reportGroup)
+	for _, file := range files {
+		if !globalQuiet && !globalJSON {
+			console.Println("Reading", file)
+		}
+		ops, tags, err := readInput(ctx, file)
+		if err != nil {
+			errorIf(probe.NewError(err), "Unable to read "+file+", skipping")
+			continue
+		}
+		if len(ops) == 0 {
+			continue
+		}
+		start, _ := ops.TimeRange()
+		name := start.Local().Format("2006-01-02")
+		if key := ctx.String("report.group"); key != "day" {
+			name = tags[key]
+			if name == "" {
+				name = "(no " + key + ")"
+			}
+		}
+		g := groups[name]
+		if g == nil {
+			g = &reportGroup{Name: name, Start: start}
+			groups[name] = g
+		}
+		if start.Before(g.Start) {
+			g.Start = start
+		}
+		g.Runs++
+		for _, op := range aggregateBaseline(ctx, ops).Operations {
+			if op.Skipped {
+				continue
+			}
+			var p99 float64
+			if lat := summaryLatencies(ops.FilterByOp(op.Type).FilterSuccessful()); lat != nil {
+				p99 = lat.P99
+			}
+			g.add(op.Type, op.Throughput.AverageBPS, op.Throughput.AverageOPS, p99)
+		}
+	}
+
+	sorted := make([]*reportGroup, 0, len(groups))
+	for _, g := range groups {
+		sorted = append(sorted, g)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start.Before(sorted[j].Start)
+	})
+	regressions := reportTrend(sorted, ctx.Float64("report.max-regression"))
+
+	if globalJSON {
+		b, err := json.MarshalIndent(sorted, "", "  ")
+		fatalIf(probe.NewError(err), "Unable to marshal data.")
+		console.Println(string(b))
+	} else {
+		printReport(sorted)
+	}
+	if ctx.Bool("report.fail") && len(regressions) > 0 {
+		console.Fatal("Performance regression detected in", sorted[len(sorted)-1].Name)
+	}
+	return nil
+}
+
+// add the results of a run to the group.
+// Values are summed until reportTrend is called.
+func (g *reportGroup) add(opType string, bps, ops, p99 float64) {
+	for i := range g.Operations {
+		op := &g.Operations[i]
+		if op.Type == opType {
+			op.Runs++
+			op.AverageBPS += bps
+			op.AverageOPS += ops
+			op.P99Millis += p99
+			return
+		}
+	}
+	g.Operations = append(g.Operations, reportOpStats{Type: opType, Runs: 1, AverageBPS: bps, AverageOPS: ops, P99Millis: p99})
+}
+
+// reportTrend averages the runs of each group and calculates the change
+// compared to the previous group with the same operation type.
+// Groups must be sorted by time.
+// The regressed operation types of the last group are returned.
+func reportTrend(groups []*reportGroup, maxRegression float64) []string {
+	prev := make(map[string]reportOpStats)
+	var regressions []string
+	for gi, g := range groups {
+		sort.Slice(g.Operations, func(i, j int) bool {
+			return g.Operations[i].Type < g.Operations[j].Type
+		})
+		for i := range g.Operations {
+			op := &g.Operations[i]
+			n := float64(op.Runs)
+			op.AverageBPS /= n
+			op.AverageOPS /= n
+			op.P99Millis /= n
+			if p, ok := prev[op.Type]; ok {
+				tp := pctChange(p.AverageOPS, op.AverageOPS)
+				lat := pctChange(p.P99Millis, op.P99Millis)
+				op.ThroughputChange, op.P99Change = &tp, &lat
+				op.Regression = maxRegression > 0 && (-tp > maxRegression || lat > maxRegression)
+				if op.Regression && gi == len(groups)-1 {
+					regressions = append(regressions, op.Type)
+				}
+			}
+			prev[op.Type] = *op
+		}
+	}
+	return regressions
+}
+
+// printReport prints the trend of each operation type.
+func printReport(groups []*reportGroup) {
+	var opTypes []string
+	seen := make(map[string]struct{})
+	for _, g := range groups {
+		for _, op := range g.Operations {
+			if _, ok := seen[op.Type]; !ok {
+				seen[op.Type] = struct{}{}
+				opTypes = append(opTypes, op.Type)
+			}
+		}
+	}
+	sort.Strings(opTypes)
+	for _, typ := range opTypes {
+		console.SetColor("Print", color.New(color.FgHiWhite))
+		console.Println("-------------------")
+		console.Println("Operation:", typ)
+		for _, g := range groups {
+			for _, op := range g.Operations {
+				if op.Type != typ {
+					continue
+				}
+				console.SetColor("Print", color.New(color.FgWhite))
+				if op.Regression {
+					console.SetColor("Print", color.New(color.FgHiRed))
+				}
+				line := fmt.Sprintf("* %s: %d run(s). ", g.Name, op.Runs)
+				if op.AverageBPS > 0 {
+					line += fmt.Sprintf("%.02f MiB/s, ", op.AverageBPS/(1<<20))
+				}
+				line += fmt.Sprintf("%.02f obj/s, p99: %.01fms", op.AverageOPS, op.P99Millis)
+				if op.ThroughputChange != nil {
+					line += fmt.Sprintf(" (throughput %+.01f%%, p99 %+.01f%%)", *op.ThroughputChange, *op.P99Change)
+				}
+				if op.Regression {
+					line += " REGRESSION"
+				}
+				console.Println(line)
+			}
+		}
+	}
+}
@@ -0,0 +1,92 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/warp/pkg/aggregate"
+	"github.com/minio/warp/pkg/bench"
+)
+
+// uploadResults will upload the benchmark data file and the analysis as JSON to the results bucket, if specified.
+// The files are uploaded using the benchmark clients.
+// benchData is the benchmark data file, and is only uploaded if not empty.
+func uploadResults(ctx *cli.Context, c *bench.Common, fileName, benchData string, ops bench.Operations) error {
+	bucket := ctx.String("results-bucket")
+	if bucket == "" || len(ops) == 0 {
+		return nil
+	}
+	bgCtx := context.Background()
+	cl, done := c.Client()
+	defer done()
+	exists, err := cl.BucketExists(bgCtx, bucket)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		err := cl.MakeBucket(bgCtx, bucket, minio.MakeBucketOptions{Region: c.Location})
+		if err != nil && minio.ToErrorResponse(err).Code != "BucketAlreadyOwnedByYou" {
+			return err
+		}
+	}
+
+	name := path.Join(ctx.String("results-prefix"), filepath.Base(fileName))
+	if benchData != "" {
+		contentType := "application/octet-stream"
+		switch {
+		case ctx.String("results.key") != "":
+		case ctx.String("results.compression") == "zstd":
+			contentType = "application/zstd"
+		case ctx.String("results.compression") == "none":
+			contentType = "text/csv"
+		}
+		_, err := cl.FPutObject(bgCtx, bucket, name+resultsExt(ctx), benchData, minio.PutObjectOptions{ContentType: contentType})
+		if err != nil {
+			return err
+		}
+	}
+
+	aggr := aggregate.Aggregate(ops, aggregate.Options{
+		DurFunc: func(total time.Duration) time.Duration {
+			if total <= 0 {
+				return 0
+			}
+			return analysisDur(ctx, total)
+		},
+		SkipDur: ctx.Duration("analyze.skip"),
+	})
+	aggr.Tags = benchTags(ctx)
+	b, err := json.MarshalIndent(aggr, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = cl.PutObject(bgCtx, bucket, name+".json", bytes.NewReader(b), int64(len(b)), minio.PutObjectOptions{ContentType: "application/json"})
+	if err != nil {
+		return err
+	}
+	printInfo("Results uploaded to " + bucket + "/" + name + "\n")
+	return pruneUploadedResults(bgCtx, ctx, cl, bucket)
+}
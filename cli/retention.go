@@ -62,13 +62,15 @@ FLAGS:
 // mainGet is the entry point for get command.
 func mainRetention(ctx *cli.Context) error {
 	checkRetentionSyntax(ctx)
-	b := bench.Retention{
-		Common:        getCommon(ctx, newGenSource(ctx, "obj.size")),
-		CreateObjects: ctx.Int("objects"),
-		Versions:      ctx.Int("versions"),
-	}
-	b.Locking = true
-	return runBench(ctx, &b)
+	return runBench(ctx, func(ctx *cli.Context) bench.Benchmark {
+		b := bench.Retention{
+			Common:        getCommon(ctx, newGenSource(ctx, "obj.size")),
+			CreateObjects: ctx.Int("objects"),
+			Versions:      ctx.Int("versions"),
+		}
+		b.Locking = true
+		return &b
+	})
 }
 
 func checkRetentionSyntax(ctx *cli.Context) {
@@ -0,0 +1,92 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"github.com/minio/cli"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/bench"
+)
+
+var rmwFlags = []cli.Flag{
+	cli.IntFlag{
+		Name:  "objects",
+		Value: 100,
+		Usage: "Number of objects to upload. Fewer objects will increase contention.",
+	},
+	cli.StringFlag{
+		Name:  "obj.size",
+		Value: "1MiB",
+		Usage: "Size of each generated object. Can be a number or 10KiB/MiB/GiB. All sizes are base 2 binary.",
+	},
+	cli.BoolFlag{
+		Name:  "if-match",
+		Usage: "Upload with the ETag of the downloaded object as precondition.",
+	},
+	cli.BoolFlag{
+		Name:  "versioned",
+		Usage: "Enable versioning on the bucket.",
+	},
+}
+
+var rmwCmd = cli.Command{
+	Name:   "rmw",
+	Usage:  "benchmark read-modify-write of objects",
+	Action: mainRMW,
+	Before: setGlobalsFromContext,
+	Flags:  combineFlags(globalFlags, ioFlags, rmwFlags, genFlags, benchFlags, analyzeFlags),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+  -> see https://github.com/minio/warp#rmw
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+}
+
+// mainRMW is the entry point for rmw command.
+func mainRMW(ctx *cli.Context) error {
+	checkRMWSyntax(ctx)
+	return runBench(ctx, func(ctx *cli.Context) bench.Benchmark {
+		b := bench.ReadModifyWrite{
+			Common:           getCommon(ctx, newGenSource(ctx, "obj.size")),
+			CreateObjects:    ctx.Int("objects"),
+			GetOpts:          getOpts(ctx),
+			IfMatch:          ctx.Bool("if-match"),
+			EnableVersioning: ctx.Bool("versioned"),
+		}
+		return &b
+	})
+}
+
+func checkRMWSyntax(ctx *cli.Context) {
+	if ctx.NArg() > 0 {
+		console.Fatal("Command takes no arguments")
+	}
+	if ctx.Int("objects") < 1 {
+		console.Fatal("At least one object must be tested")
+	}
+	if ctx.Bool("obj.randsize") {
+		console.Fatal("--obj.randsize cannot be used, objects are overwritten with the same size")
+	}
+	checkAnalyze(ctx)
+	checkBenchmark(ctx)
+}
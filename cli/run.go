@@ -144,6 +144,8 @@ func mainExec(ctx *cli.Context) error {
 		"distribution.stat":        "stat-distrib",
 		"distribution.put":         "put-distrib",
 		"distribution.delete":      "delete-distrib",
+		"distribution.list":        "list-distrib",
+		"distribution.chain":       "chain-distrib",
 		"obj.parts":                "parts",
 	}
 
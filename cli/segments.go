@@ -0,0 +1,211 @@
+/*
+ * Warp (C) 2019-2020 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/warp/pkg/bench"
+)
+
+// cdfPercentiles are the percentiles written for each segment by --analyze.cdf-out.
+var cdfPercentiles = func() []float64 {
+	p := make([]float64, 0, 102)
+	for i := 1; i <= 100; i++ {
+		p = append(p, float64(i))
+		if i == 99 {
+			p = append(p, 99.9)
+		}
+	}
+	return p
+}()
+
+// segmentStarts returns the start of each full analysis segment of the operations
+// and the segment duration.
+func segmentStarts(ctx *cli.Context, o bench.Operations) ([]time.Time, time.Duration) {
+	start, end := o.TimeRange()
+	dur := analysisDur(ctx, end.Sub(start))
+	if dur <= 0 {
+		return nil, 0
+	}
+	if ctx.Bool("analyze.align") {
+		if t := start.Truncate(dur); t.Before(start) {
+			start = t.Add(dur)
+		}
+	}
+	var starts []time.Time
+	for t := start; !t.Add(dur).After(end); t = t.Add(dur) {
+		starts = append(starts, t)
+	}
+	return starts, dur
+}
+
+// writeSegmentLatencies writes the request latency of each operation type in each analysis segment to fn.
+// Requests are included in the segment they end in. Failed requests are only counted.
+// If cdf is set, the latency at every percentile is written, otherwise a summary of each segment.
+func writeSegmentLatencies(ctx *cli.Context, fn string, o bench.Operations, cdf bool) error {
+	var w io.Writer = os.Stdout
+	if fn != "-" {
+		f, err := os.Create(fn)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	cw := csv.NewWriter(w)
+	cw.Comma = '\t'
+	header := []string{"index", "op", "start_time", "end_time", "requests", "errors", "avg_ms", "p50_ms", "p90_ms", "p99_ms", "max_ms"}
+	if cdf {
+		header = []string{"index", "op", "start_time", "end_time", "percentile", "latency_ms"}
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	ms := func(d time.Duration) string {
+		return fmt.Sprint(float64(d.Microseconds()) / 1000)
+	}
+	starts, dur := segmentStarts(ctx, o)
+	for _, typ := range o.OpTypes() {
+		ops := o.FilterByOp(typ)
+		ops.SortByEndTime()
+		for i, start := range starts {
+			end := start.Add(dur)
+			from := sort.Search(len(ops), func(i int) bool { return !ops[i].End.Before(start) })
+			to := sort.Search(len(ops), func(i int) bool { return !ops[i].End.Before(end) })
+			seg := ops[from:to]
+			// Copy, so sorting does not modify the order of ops.
+			ok := append(bench.Operations(nil), seg.FilterSuccessful()...)
+			ok.SortByDuration()
+			prefix := []string{fmt.Sprint(i), typ, start.Format(time.RFC3339Nano), end.Format(time.RFC3339Nano)}
+			if cdf {
+				if len(ok) == 0 {
+					continue
+				}
+				for _, p := range cdfPercentiles {
+					if err := cw.Write(append(prefix, fmt.Sprint(p), ms(ok.Median(p/100).Duration()))); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			row := append(prefix, fmt.Sprint(len(seg)), fmt.Sprint(len(seg)-len(ok)))
+			if len(ok) > 0 {
+				row = append(row, ms(ok.AvgDuration()), ms(ok.Median(0.5).Duration()), ms(ok.Median(0.9).Duration()),
+					ms(ok.Median(0.99).Duration()), ms(ok[len(ok)-1].Duration()))
+			} else {
+				row = append(row, "", "", "", "", "")
+			}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// latencyThresholds returns the sorted thresholds of --analyze.thresholds.
+func latencyThresholds(ctx *cli.Context) ([]time.Duration, error) {
+	var thresholds []time.Duration
+	for _, s := range strings.Split(ctx.String("analyze.thresholds"), ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, err
+		}
+		if d <= 0 {
+			return nil, errors.New("thresholds must be positive")
+		}
+		thresholds = append(thresholds, d)
+	}
+	sort.Slice(thresholds, func(i, j int) bool { return thresholds[i] < thresholds[j] })
+	return thresholds, nil
+}
+
+// writeSegmentThresholds writes the fraction of requests of each operation type in each analysis segment
+// that completed successfully within each threshold to fn.
+// Requests are included in the segment they end in. Failed requests are never within a threshold.
+func writeSegmentThresholds(ctx *cli.Context, fn string, o bench.Operations, thresholds []time.Duration) error {
+	var w io.Writer = os.Stdout
+	if fn != "-" {
+		f, err := os.Create(fn)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	cw := csv.NewWriter(w)
+	cw.Comma = '\t'
+	header := []string{"index", "op", "start_time", "end_time", "requests", "errors"}
+	for _, t := range thresholds {
+		header = append(header, "within_"+t.String())
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	starts, dur := segmentStarts(ctx, o)
+	within := make([]int, len(thresholds))
+	for _, typ := range o.OpTypes() {
+		ops := o.FilterByOp(typ)
+		ops.SortByEndTime()
+		for i, start := range starts {
+			end := start.Add(dur)
+			from := sort.Search(len(ops), func(i int) bool { return !ops[i].End.Before(start) })
+			to := sort.Search(len(ops), func(i int) bool { return !ops[i].End.Before(end) })
+			seg := ops[from:to]
+			if len(seg) == 0 {
+				continue
+			}
+			clear(within)
+			var errs int
+			for _, op := range seg {
+				if op.Err != "" {
+					errs++
+					continue
+				}
+				d := op.Duration()
+				for j, t := range thresholds {
+					if d <= t {
+						within[j]++
+					}
+				}
+			}
+			row := []string{fmt.Sprint(i), typ, start.Format(time.RFC3339Nano), end.Format(time.RFC3339Nano), fmt.Sprint(len(seg)), fmt.Sprint(errs)}
+			for _, n := range within {
+				row = append(row, fmt.Sprint(float64(n)/float64(len(seg))))
+			}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -63,32 +63,34 @@ FLAGS:
 // mainSelect is the entry point for select command.
 func mainSelect(ctx *cli.Context) error {
 	checkSelectSyntax(ctx)
-	sse := newSSE(ctx)
-	b := bench.Select{
-		Common:        getCommon(ctx, newGenSourceCSV(ctx)),
-		CreateObjects: ctx.Int("objects"),
-		SelectOpts: minio.SelectObjectOptions{
-			Expression:     ctx.String("query"),
-			ExpressionType: minio.QueryExpressionTypeSQL,
-			// Set any encryption headers
-			ServerSideEncryption: sse,
-			// TODO: support all variations including, json/parquet
-			InputSerialization: minio.SelectObjectInputSerialization{
-				CSV: &minio.CSVInputOptions{
-					RecordDelimiter: "\n",
-					FieldDelimiter:  ",",
-					FileHeaderInfo:  minio.CSVFileHeaderInfoUse,
+	return runBench(ctx, func(ctx *cli.Context) bench.Benchmark {
+		sse := newSSE(ctx)
+		b := bench.Select{
+			Common:        getCommon(ctx, newGenSourceCSV(ctx)),
+			CreateObjects: ctx.Int("objects"),
+			SelectOpts: minio.SelectObjectOptions{
+				Expression:     ctx.String("query"),
+				ExpressionType: minio.QueryExpressionTypeSQL,
+				// Set any encryption headers
+				ServerSideEncryption: sse,
+				// TODO: support all variations including, json/parquet
+				InputSerialization: minio.SelectObjectInputSerialization{
+					CSV: &minio.CSVInputOptions{
+						RecordDelimiter: "\n",
+						FieldDelimiter:  ",",
+						FileHeaderInfo:  minio.CSVFileHeaderInfoUse,
+					},
 				},
-			},
-			OutputSerialization: minio.SelectObjectOutputSerialization{
-				CSV: &minio.CSVOutputOptions{
-					RecordDelimiter: "\n",
-					FieldDelimiter:  ",",
+				OutputSerialization: minio.SelectObjectOutputSerialization{
+					CSV: &minio.CSVOutputOptions{
+						RecordDelimiter: "\n",
+						FieldDelimiter:  ",",
+					},
 				},
 			},
-		},
-	}
-	return runBench(ctx, &b)
+		}
+		return &b
+	})
 }
 
 func checkSelectSyntax(ctx *cli.Context) {
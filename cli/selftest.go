@@ -0,0 +1,252 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/bench"
+	"github.com/minio/warp/pkg/nulls3"
+)
+
+var selftestFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "host",
+		Usage: "Run against this MinIO test server instead of the embedded server. All data in the benchmark bucket will be deleted",
+	},
+	cli.StringFlag{
+		Name:  "access-key",
+		Usage: "Access key of --host",
+	},
+	cli.StringFlag{
+		Name:  "secret-key",
+		Usage: "Secret key of --host",
+	},
+	cli.BoolFlag{
+		Name:  "tls",
+		Usage: "Use TLS (HTTPS) for --host",
+	},
+	cli.DurationFlag{
+		Name:  "duration",
+		Value: 5 * time.Second,
+		Usage: "Duration of each benchmark",
+	},
+	cli.StringFlag{
+		Name:  "benchmarks",
+		Usage: "Comma separated list of benchmarks to run. By default all benchmarks supported by the server are run",
+	},
+	cli.BoolFlag{
+		Name:  "keep-data",
+		Usage: "Keep the benchmark data files in the current directory",
+	},
+}
+
+var selftestCmd = cli.Command{
+	Name:   "selftest",
+	Usage:  "run all benchmarks against an embedded server and verify the results",
+	Action: mainSelftest,
+	Before: setGlobalsFromContext,
+	Flags:  combineFlags(globalFlags, selftestFlags),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+  -> see https://github.com/minio/warp#self-test
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+}
+
+// selftestBench is a benchmark run by the self test.
+type selftestBench struct {
+	name string
+	args []string
+	// Extra arguments when running against the embedded server.
+	embeddedArgs []string
+	// minioOnly benchmarks require features not available in the embedded server.
+	minioOnly bool
+}
+
+var selftestBenchmarks = []selftestBench{
+	{name: "put", args: []string{"--obj.size=4KiB"}},
+	{name: "get", args: []string{"--objects=200", "--obj.size=4KiB"}},
+	{name: "delete", args: []string{"--objects=5000", "--batch=10", "--obj.size=1KiB"}},
+	{name: "list", args: []string{"--objects=200", "--obj.size=1KiB"}},
+	{name: "stat", args: []string{"--objects=200", "--obj.size=1KiB"}},
+	{name: "mixed", args: []string{"--objects=200", "--obj.size=4KiB"}},
+	{name: "tiny", args: []string{"--objects=200"}},
+	{name: "rmw", args: []string{"--objects=200", "--obj.size=4KiB"}},
+	{name: "multipart", args: []string{"--parts=20", "--part.size=5MiB"}},
+	{name: "multipart-put", args: []string{"--parts=4", "--part.size=5MiB"}},
+	// The embedded server returns zeros, so downloaded content cannot be verified.
+	{name: "large", args: []string{"--obj.size=20MiB", "--part.size=5MiB"}, embeddedArgs: []string{"--verify.samples=0"}},
+	{name: "versioned", args: []string{"--objects=200", "--obj.size=4KiB"}, minioOnly: true},
+	{name: "delete-markers", args: []string{"--objects=200", "--obj.size=1KiB"}, minioOnly: true},
+	{name: "retention", args: []string{"--objects=200", "--versions=2"}, minioOnly: true},
+	{name: "fanout", args: []string{"--copies=10", "--obj.size=4KiB"}, minioOnly: true},
+	{name: "zip", args: []string{"--files=100"}, minioOnly: true},
+	{name: "snowball", args: []string{"--obj.size=4KiB"}, minioOnly: true},
+}
+
+// mainSelftest is the entry point for selftest command.
+func mainSelftest(ctx *cli.Context) error {
+	if ctx.NArg() > 0 {
+		console.Fatal("Command takes no arguments")
+	}
+	exe, err := os.Executable()
+	fatalIf(probe.NewError(err), "Unable to find warp executable")
+
+	host, accessKey, secretKey := ctx.String("host"), ctx.String("access-key"), ctx.String("secret-key")
+	embedded := host == ""
+	if embedded {
+		srv := httptest.NewServer(nulls3.New())
+		defer srv.Close()
+		host, accessKey, secretKey = srv.Listener.Addr().String(), "selftest", "selftest"
+	}
+
+	benches := selftestBenchmarks
+	if names := ctx.String("benchmarks"); names != "" {
+		benches = nil
+		for _, name := range strings.Split(names, ",") {
+			found := false
+			for _, b := range selftestBenchmarks {
+				if b.name == name {
+					benches = append(benches, b)
+					found = true
+				}
+			}
+			if !found {
+				console.Fatalf("Unknown benchmark %q\n", name)
+			}
+		}
+	}
+
+	dir := "."
+	if !ctx.Bool("keep-data") {
+		dir, err = os.MkdirTemp("", "warp-selftest")
+		fatalIf(probe.NewError(err), "Unable to create temporary directory")
+		defer os.RemoveAll(dir)
+	}
+
+	var failed int
+	for _, b := range benches {
+		if embedded && b.minioOnly {
+			console.Printf("%-16s SKIP  not supported by the embedded server\n", b.name)
+			continue
+		}
+		fileName := filepath.Join(dir, "warp-selftest-"+b.name)
+		args := []string{
+			b.name, "--no-color",
+			"--host=" + host, "--access-key=" + accessKey, "--secret-key=" + secretKey,
+			"--duration=" + ctx.Duration("duration").String(), "--concurrent=4", "--benchdata=" + fileName,
+		}
+		if ctx.Bool("tls") && !embedded {
+			args = append(args, "--tls")
+		}
+		args = append(args, b.args...)
+		if embedded {
+			args = append(args, b.embeddedArgs...)
+		}
+		start := time.Now()
+		out, err := exec.Command(exe, args...).CombinedOutput()
+		var n int
+		if err == nil {
+			n, err = verifySelftestData(fileName + ".csv.zst")
+		}
+		if err != nil {
+			failed++
+			console.Printf("%-16s FAIL  %v\n", b.name, err)
+			if globalDebug {
+				console.Println(string(out))
+			}
+			continue
+		}
+		console.Printf("%-16s PASS  %d operations in %v\n", b.name, n, time.Since(start).Round(time.Second))
+	}
+	if failed > 0 {
+		fatalIf(errDummy(), fmt.Sprintf("%d of %d benchmarks failed. Use --debug to see their output", failed, len(benches)))
+	}
+	return nil
+}
+
+// verifySelftestData checks the integrity of a benchmark data file and returns the number of operations.
+// All operations must be valid and succeed, and the data must be unchanged when written and read again.
+func verifySelftestData(fileName string) (int, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	dec, err := newResultsReader(f)
+	if err != nil {
+		return 0, err
+	}
+	defer dec.Close()
+	_, rd, err := bench.TagsFromCSV(dec)
+	if err != nil {
+		return 0, err
+	}
+	ops, err := bench.OperationsFromCSV(rd, false, 0, 0, nil)
+	if err != nil {
+		return 0, err
+	}
+	if len(ops) == 0 {
+		return 0, errors.New("no operations recorded")
+	}
+	if errs := ops.FilterErrors(); len(errs) > 0 {
+		return 0, fmt.Errorf("%d operations failed, first: %s", len(errs), errs[0].Err)
+	}
+	for i, op := range ops {
+		switch {
+		case op.OpType == "":
+			return 0, fmt.Errorf("operation %d has no type", i)
+		case op.End.Before(op.Start):
+			return 0, fmt.Errorf("operation %d ended before it started", i)
+		case op.FirstByte != nil && (op.FirstByte.Before(op.Start) || op.FirstByte.After(op.End)):
+			return 0, fmt.Errorf("operation %d has first byte outside the request", i)
+		}
+	}
+	var written, rewritten bytes.Buffer
+	if err := ops.CSV(&written, ""); err != nil {
+		return 0, err
+	}
+	again, err := bench.OperationsFromCSV(bytes.NewReader(written.Bytes()), false, 0, 0, nil)
+	if err != nil {
+		return 0, err
+	}
+	if err := again.CSV(&rewritten, ""); err != nil {
+		return 0, err
+	}
+	if !bytes.Equal(written.Bytes(), rewritten.Bytes()) {
+		return 0, errors.New("data changed when written and read again")
+	}
+	return len(ops), nil
+}
@@ -0,0 +1,84 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/warp/pkg/bench"
+)
+
+var shadowFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "shadow.host",
+		Usage: "Mirror each download to these hosts and compare the content. Multiple hosts can be specified as a comma separated list.",
+	},
+	cli.StringFlag{
+		Name:  "shadow.access-key",
+		Usage: "Access key for --shadow.host. Defaults to --access-key",
+	},
+	cli.StringFlag{
+		Name:  "shadow.secret-key",
+		Usage: "Secret key for --shadow.host. Defaults to --secret-key",
+	},
+	cli.StringFlag{
+		Name:  "shadow.bucket",
+		Usage: "Bucket on --shadow.host. Defaults to --bucket",
+	},
+	cli.BoolFlag{
+		Name:  "shadow.skip-etag",
+		Usage: "Do not compare ETags of shadow downloads",
+	},
+}
+
+// newShadow returns the shadow download configuration or nil if not enabled.
+// Requests are distributed round-robin between the shadow hosts.
+func newShadow(ctx *cli.Context) *bench.Shadow {
+	if ctx.String("shadow.host") == "" {
+		return nil
+	}
+	accessKey, secretKey := ctx.String("access-key"), ctx.String("secret-key")
+	if ctx.IsSet("shadow.access-key") {
+		accessKey = ctx.String("shadow.access-key")
+	}
+	if ctx.IsSet("shadow.secret-key") {
+		secretKey = ctx.String("shadow.secret-key")
+	}
+	hosts := parseHosts(ctx.String("shadow.host"), ctx.Bool("resolve-host"))
+	if len(hosts) == 0 {
+		fatalIf(probe.NewError(errors.New("no host defined")), "Unable to create shadow client")
+	}
+	clients := make([]*minio.Client, len(hosts))
+	for i, host := range hosts {
+		cl, err := getClientKeys(ctx, host, accessKey, secretKey, bucketLookups(ctx)[0])
+		fatalIf(probe.NewError(err), "Unable to create shadow client")
+		clients[i] = cl
+	}
+	var current atomic.Uint64
+	return &bench.Shadow{
+		Client: func() (*minio.Client, func()) {
+			return clients[(current.Add(1)-1)%uint64(len(clients))], func() {}
+		},
+		Bucket:   ctx.String("shadow.bucket"),
+		SkipETag: ctx.Bool("shadow.skip-etag"),
+	}
+}
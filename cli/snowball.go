@@ -19,6 +19,7 @@ package cli
 
 import (
 	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/pkg/v2/console"
 	"github.com/minio/warp/pkg/bench"
@@ -63,27 +64,27 @@ FLAGS:
 // mainPut is the entry point for cp command.
 func mainSnowball(ctx *cli.Context) error {
 	checkSnowballSyntax(ctx)
-	b := bench.Snowball{
-		Common:    getCommon(ctx, newGenSource(ctx, "obj.size")),
-		Compress:  ctx.Bool("compress"),
-		Duplicate: ctx.Bool("compress"),
-		NumObjs:   ctx.Int("objs.per"),
-	}
-	b.PutOpts = snowballOpts(ctx)
-	if b.Compress {
-		sz, err := toSize(ctx.String("obj.size"))
-		if err != nil {
-			return err
-		}
-		b.WindowSize = int(sz) * 2
-		if b.WindowSize < 128<<10 {
-			b.WindowSize = 128 << 10
+	return runBench(ctx, func(ctx *cli.Context) bench.Benchmark {
+		b := bench.Snowball{
+			Common:    getCommon(ctx, newGenSource(ctx, "obj.size")),
+			Compress:  ctx.Bool("compress"),
+			Duplicate: ctx.Bool("compress"),
+			NumObjs:   ctx.Int("objs.per"),
 		}
-		if b.WindowSize > 16<<20 {
-			b.WindowSize = 16 << 20
+		b.PutOpts = snowballOpts(ctx)
+		if b.Compress {
+			sz, err := toSize(ctx.String("obj.size"))
+			fatalIf(probe.NewError(err), "Invalid obj.size specified")
+			b.WindowSize = int(sz) * 2
+			if b.WindowSize < 128<<10 {
+				b.WindowSize = 128 << 10
+			}
+			if b.WindowSize > 16<<20 {
+				b.WindowSize = 16 << 20
+			}
 		}
-	}
-	return runBench(ctx, &b)
+		return &b
+	})
 }
 
 // putOpts retrieves put options from the context.
@@ -91,7 +92,7 @@ func snowballOpts(ctx *cli.Context) minio.PutObjectOptions {
 	return minio.PutObjectOptions{
 		ServerSideEncryption: newSSE(ctx),
 		DisableMultipart:     ctx.Bool("disable-multipart"),
-		DisableContentSha256: ctx.Bool("disable-sha256-payload"),
+		DisableContentSha256: unsignedPayload(ctx),
 		SendContentMd5:       ctx.Bool("md5"),
 		StorageClass:         ctx.String("storage-class"),
 	}
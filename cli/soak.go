@@ -0,0 +1,428 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/bench"
+	"github.com/minio/warp/pkg/generator"
+)
+
+var soakFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "soak",
+		Usage: "Run as a soak test. The operation mix and object size are changed periodically, benchmark data is rotated and a stability report is written. Use with a long --duration",
+	},
+	cli.DurationFlag{
+		Name:  "soak.mutate",
+		Value: time.Hour,
+		Usage: "Interval for changing the operation mix and object size",
+	},
+	cli.Float64Flag{
+		Name:  "soak.vary",
+		Value: 0.5,
+		Usage: "Maximum relative change of each operation distribution, 0 to 1",
+	},
+	cli.StringFlag{
+		Name:  "soak.size",
+		Usage: "Range of object sizes for uploads as 'min,max', eg. '1KiB,64MiB'. By default --obj.size is used for all uploads",
+	},
+	cli.DurationFlag{
+		Name:  "soak.rotate",
+		Value: time.Hour,
+		Usage: "Interval for writing benchmark data to a new file",
+	},
+}
+
+func checkSoak(ctx *cli.Context) {
+	if !ctx.Bool("soak") {
+		return
+	}
+	if ctx.Duration("soak.mutate") <= 0 || ctx.Duration("soak.rotate") <= 0 {
+		console.Fatal("--soak.mutate and --soak.rotate must be positive")
+	}
+	if v := ctx.Float64("soak.vary"); v < 0 || v > 1 {
+		console.Fatal("--soak.vary must be between 0 and 1")
+	}
+	if ctx.String("soak.size") != "" {
+		if _, _, err := soakSizes(ctx); err != nil {
+			console.Fatal("Invalid --soak.size: ", err)
+		}
+	}
+	if ctx.Bool("stress") {
+		console.Fatal("--soak cannot be used with --stress")
+	}
+	if ctx.String("warp-client") != "" || ctx.String("host.b") != "" || ctx.Bool("daemon") {
+		console.Fatal("--soak can only be used with local benchmarks")
+	}
+}
+
+// soakSizes returns the range of object sizes.
+func soakSizes(ctx *cli.Context) (minSize, maxSize uint64, err error) {
+	lo, hi, ok := strings.Cut(ctx.String("soak.size"), ",")
+	if !ok {
+		return 0, 0, fmt.Errorf("expected 'min,max', got %q", ctx.String("soak.size"))
+	}
+	if minSize, err = toSize(lo); err != nil {
+		return 0, 0, err
+	}
+	if maxSize, err = toSize(hi); err != nil {
+		return 0, 0, err
+	}
+	if minSize == 0 || maxSize < minSize {
+		return 0, 0, fmt.Errorf("invalid size range %q", ctx.String("soak.size"))
+	}
+	return minSize, maxSize, nil
+}
+
+// soakPeriod contains the stability measurements of a rotation period.
+type soakPeriod struct {
+	File         string             `json:"file"`
+	Start        time.Time          `json:"start"`
+	End          time.Time          `json:"end"`
+	Operations   int                `json:"operations"`
+	Errors       int                `json:"errors"`
+	ObjsPerSec   float64            `json:"objs_per_sec"`
+	BytesPerSec  float64            `json:"bytes_per_sec"`
+	AvgLatencyMs float64            `json:"avg_latency_millis"`
+	P99LatencyMs float64            `json:"p99_latency_millis"`
+	Distribution map[string]float64 `json:"distribution"`
+	ObjSize      string             `json:"obj_size"`
+	// Memory of the warp process at the end of the period.
+	HeapBytes uint64 `json:"heap_bytes"`
+	SysBytes  uint64 `json:"sys_bytes"`
+}
+
+// soak changes the operation mix and object sizes of a mixed benchmark
+// and writes the operations to a new file for each rotation period.
+type soak struct {
+	ctx      *cli.Context
+	dist     *bench.MixedDistribution
+	base     map[string]float64
+	rng      *rand.Rand
+	prefix   string
+	rcv      chan bench.Operation
+	started  chan struct{}
+	finished chan struct{}
+
+	// Current object size and source. Threads pick up changes on their next upload.
+	mu       sync.Mutex
+	sizeSpec string
+	src      func() generator.Source
+	version  atomic.Int64
+
+	periods []soakPeriod
+}
+
+// newSoak returns a soak controller for dist.
+// The operations must be sent to the returned channel, which is closed when the benchmark ends.
+func newSoak(ctx *cli.Context, dist *bench.MixedDistribution) (*soak, chan<- bench.Operation) {
+	s := soak{
+		ctx:      ctx,
+		dist:     dist,
+		base:     make(map[string]float64, len(dist.Distribution)),
+		rng:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		prefix:   ctx.String("benchdata"),
+		rcv:      make(chan bench.Operation, 1000),
+		started:  make(chan struct{}),
+		finished: make(chan struct{}),
+		sizeSpec: ctx.String("obj.size"),
+		src:      newGenSource(ctx, "obj.size"),
+	}
+	if s.prefix == "" {
+		s.prefix = fmt.Sprintf("%s-soak-%s", appName, time.Now().Format("2006-01-02[150405]"))
+	}
+	for op, v := range dist.Current() {
+		s.base[op] = v
+	}
+	// collect, mutate and the writer started by collect.
+	globalWG.Add(3)
+	go s.collect()
+	go s.mutate()
+	return &s, s.rcv
+}
+
+// Source returns a generator that uses the current object size.
+func (s *soak) Source() generator.Source {
+	return &soakSource{s: s, version: -1}
+}
+
+// soakSource is the generator of a single thread.
+type soakSource struct {
+	s       *soak
+	version int64
+	src     generator.Source
+}
+
+func (t *soakSource) current() generator.Source {
+	if v := t.s.version.Load(); v != t.version {
+		t.s.mu.Lock()
+		t.src, t.version = t.s.src(), v
+		t.s.mu.Unlock()
+	}
+	return t.src
+}
+
+func (t *soakSource) Object() *generator.Object {
+	return t.current().Object()
+}
+
+func (t *soakSource) String() string {
+	return t.current().String()
+}
+
+func (t *soakSource) Prefix() string {
+	return t.current().Prefix()
+}
+
+// mutate changes the distribution and object size at each interval after the benchmark has started.
+func (s *soak) mutate() {
+	defer globalWG.Done()
+	select {
+	case <-s.started:
+	case <-s.finished:
+		return
+	}
+	minSize, maxSize, _ := soakSizes(s.ctx)
+	vary := s.ctx.Float64("soak.vary")
+	ticker := time.NewTicker(s.ctx.Duration("soak.mutate"))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.finished:
+			return
+		case <-ticker.C:
+		}
+		dist := make(map[string]float64, len(s.base))
+		for op, v := range s.base {
+			dist[op] = v * (1 + vary*(2*s.rng.Float64()-1))
+		}
+		if dist[http.MethodDelete] > dist[http.MethodPut] {
+			dist[http.MethodDelete] = dist[http.MethodPut]
+		}
+		if err := s.dist.Update(dist); err != nil {
+			printError("Unable to change distribution:", err)
+			continue
+		}
+		if maxSize > 0 {
+			// Pick sizes evenly on a logarithmic scale.
+			size := uint64(math.Exp(math.Log(float64(minSize)) + s.rng.Float64()*(math.Log(float64(maxSize))-math.Log(float64(minSize)))))
+			s.mu.Lock()
+			s.sizeSpec = humanize.IBytes(size)
+			s.src = newGenSourceSize(s.ctx, strconv.FormatUint(size, 10))
+			s.mu.Unlock()
+			s.version.Add(1)
+		}
+		if !globalQuiet {
+			console.Eraseline()
+			console.Infof("\rSoak: changed operation mix to %s, object size %s\n", soakDistString(s.dist.Current()), s.currentSize())
+		}
+	}
+}
+
+func (s *soak) currentSize() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sizeSpec
+}
+
+// soakBatch contains the operations of a rotation period.
+// The distribution and object size are recorded when the period starts.
+type soakBatch struct {
+	ops        bench.Operations
+	start, end time.Time
+	dist       map[string]float64
+	size       string
+}
+
+// collect receives operations and hands the operations of each rotation period to the writer.
+// Files are written by the writer, so operations keep being received while they are written.
+func (s *soak) collect() {
+	defer globalWG.Done()
+	batches := make(chan soakBatch, 16)
+	go s.writer(batches)
+	var cur soakBatch
+	newBatch := func(start time.Time) soakBatch {
+		return soakBatch{start: start, dist: s.dist.Current(), size: s.currentSize()}
+	}
+	ticker := time.NewTicker(s.ctx.Duration("soak.rotate"))
+	defer ticker.Stop()
+	for {
+		select {
+		case op, ok := <-s.rcv:
+			if !ok {
+				close(s.finished)
+				cur.end = time.Now()
+				batches <- cur
+				close(batches)
+				return
+			}
+			if cur.start.IsZero() {
+				cur = newBatch(time.Now())
+				close(s.started)
+				ticker.Reset(s.ctx.Duration("soak.rotate"))
+			}
+			cur.ops = append(cur.ops, op)
+		case now := <-ticker.C:
+			if cur.start.IsZero() {
+				continue
+			}
+			cur.end = now
+			batches <- cur
+			cur = newBatch(now)
+		}
+	}
+}
+
+// writer writes the periods it receives and the stability report when all periods have been written.
+func (s *soak) writer(batches <-chan soakBatch) {
+	defer globalWG.Done()
+	for b := range batches {
+		s.rotate(b)
+	}
+	s.report()
+}
+
+// rotate writes the operations of a period to a new file and records the measurements.
+func (s *soak) rotate(b soakBatch) {
+	ops := b.ops
+	if len(ops) == 0 {
+		return
+	}
+	start, end := b.start, b.end
+	ops.SortByStartTime()
+	p := soakPeriod{
+		File:         fmt.Sprintf("%s-%04d%s", s.prefix, len(s.periods)+1, resultsExt(s.ctx)),
+		Start:        start,
+		End:          end,
+		Operations:   len(ops),
+		Errors:       ops.NErrors(),
+		Distribution: b.dist,
+		ObjSize:      b.size,
+	}
+	if secs := end.Sub(start).Seconds(); secs > 0 {
+		var bytes int64
+		for _, op := range ops {
+			if op.Err == "" {
+				bytes += op.Size
+			}
+		}
+		p.ObjsPerSec = float64(len(ops)-p.Errors) / secs
+		p.BytesPerSec = float64(bytes) / secs
+	}
+	if lat := summaryLatencies(ops.FilterSuccessful()); lat != nil {
+		p.AvgLatencyMs, p.P99LatencyMs = lat.Average, lat.P99
+	}
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	p.HeapBytes, p.SysBytes = mem.HeapAlloc, mem.Sys
+
+	if err := writeSoakData(s.ctx, p.File, ops); err != nil {
+		printError("Unable to write benchmark data:", err)
+	} else if !globalQuiet {
+		console.Eraseline()
+		console.Infof("\rSoak: benchmark data written to %q\n", p.File)
+	}
+	s.periods = append(s.periods, p)
+}
+
+func writeSoakData(ctx *cli.Context, fileName string, ops bench.Operations) error {
+	f, err := createResults(ctx, fileName)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	enc, err := newResultsWriter(ctx, f)
+	if err != nil {
+		return err
+	}
+	if err := benchTags(ctx).CSV(enc); err != nil {
+		enc.Close()
+		return err
+	}
+	if err := ops.CSV(enc, commandLine(ctx)); err != nil {
+		enc.Close()
+		return err
+	}
+	return enc.Close()
+}
+
+func soakDistString(dist map[string]float64) string {
+	ops := make([]string, 0, len(dist))
+	for op := range dist {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+	for i, op := range ops {
+		ops[i] = fmt.Sprintf("%s %.0f%%", op, 100*dist[op])
+	}
+	return strings.Join(ops, ", ")
+}
+
+// report writes and prints the stability report.
+func (s *soak) report() {
+	if len(s.periods) == 0 {
+		return
+	}
+	b, err := json.MarshalIndent(s.periods, "", "  ")
+	fatalIf(probe.NewError(err), "Unable to marshal soak report")
+	fileName := s.prefix + "-report.json"
+	if err := os.WriteFile(fileName, b, 0o644); err != nil {
+		printError("Unable to write soak report:", err)
+	}
+	if globalQuiet || globalJSON {
+		return
+	}
+	errRate := func(p soakPeriod) float64 {
+		return 100 * float64(p.Errors) / float64(p.Operations)
+	}
+	first, last := s.periods[0], s.periods[len(s.periods)-1]
+	console.SetColor("Print", color.New(color.FgHiWhite))
+	console.Printf("\nSoak report, %d periods. Written to %q:\n", len(s.periods), fileName)
+	console.SetColor("Print", color.New(color.FgWhite))
+	minOps, maxOps := first.ObjsPerSec, first.ObjsPerSec
+	for _, p := range s.periods {
+		minOps, maxOps = min(minOps, p.ObjsPerSec), max(maxOps, p.ObjsPerSec)
+		console.Printf(" * %s: %.2f obj/s, %s/s, avg: %.1fms, 99%%: %.1fms, errors: %.3f%%, memory: %s. Mix: %s. Size: %s\n",
+			p.Start.Format(time.RFC3339), p.ObjsPerSec, humanize.IBytes(uint64(p.BytesPerSec)), p.AvgLatencyMs, p.P99LatencyMs,
+			errRate(p), humanize.IBytes(p.SysBytes), soakDistString(p.Distribution), p.ObjSize)
+	}
+	if first.ObjsPerSec > 0 {
+		console.Printf("Throughput drift: %+.1f%% from first to last period, range %.2f-%.2f obj/s.\n", 100*(last.ObjsPerSec-first.ObjsPerSec)/first.ObjsPerSec, minOps, maxOps)
+	}
+	console.Printf("Error rate: %.3f%% in first period, %.3f%% in last period.\n", errRate(first), errRate(last))
+	console.Printf("Client memory: %s in first period, %s in last period.\n", humanize.IBytes(first.SysBytes), humanize.IBytes(last.SysBytes))
+}
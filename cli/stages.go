@@ -0,0 +1,65 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"time"
+)
+
+// stageTimes contains the wall clock time spent in each stage of a benchmark run.
+// Time spent waiting for a scheduled cleanup is not included.
+type stageTimes struct {
+	Prepare   time.Duration
+	Benchmark time.Duration
+	Cleanup   time.Duration
+}
+
+// String returns the stage times in human readable form.
+func (s stageTimes) String() string {
+	return fmt.Sprintf("Prepare: %v, Benchmark: %v, Cleanup: %v",
+		s.Prepare.Round(time.Millisecond), s.Benchmark.Round(time.Millisecond), s.Cleanup.Round(time.Millisecond))
+}
+
+// summaryStages contains the stage times of a run in seconds.
+type summaryStages struct {
+	PrepareSecs   float64 `json:"prepare_secs"`
+	BenchmarkSecs float64 `json:"benchmark_secs"`
+	CleanupSecs   float64 `json:"cleanup_secs"`
+}
+
+// summary returns the stage times for the run summary.
+func (s *stageTimes) summary() *summaryStages {
+	if s == nil {
+		return nil
+	}
+	return &summaryStages{
+		PrepareSecs:   s.Prepare.Seconds(),
+		BenchmarkSecs: s.Benchmark.Seconds(),
+		CleanupSecs:   s.Cleanup.Seconds(),
+	}
+}
+
+// timeLeft estimates the remaining time of a stage that is done (0->1) complete
+// after running for elapsed. Returns 0 if no estimate can be made.
+func timeLeft(elapsed time.Duration, done float64) time.Duration {
+	if done <= 0 || done >= 1 || elapsed <= 0 {
+		return 0
+	}
+	return time.Duration(float64(elapsed) * (1 - done) / done)
+}
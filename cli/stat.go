@@ -40,6 +40,11 @@ var statFlags = []cli.Flag{
 		Value: 1,
 		Usage: "Number of versions to upload. If more than 1, versioned listing will be benchmarked",
 	},
+	cli.Float64Flag{
+		Name:  "missing",
+		Usage: "Percentage of requests for objects that don't exist. These are reported separately as STAT-MISSING and must return 404",
+	},
+	accessFlag,
 }
 
 var statCmd = cli.Command{
@@ -47,7 +52,7 @@ var statCmd = cli.Command{
 	Usage:  "benchmark stat objects (get file info)",
 	Action: mainStat,
 	Before: setGlobalsFromContext,
-	Flags:  combineFlags(globalFlags, ioFlags, statFlags, genFlags, benchFlags, analyzeFlags),
+	Flags:  combineFlags(globalFlags, ioFlags, statFlags, prepareFlags, genFlags, benchFlags, analyzeFlags),
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
@@ -63,17 +68,23 @@ FLAGS:
 // mainDelete is the entry point for get command.
 func mainStat(ctx *cli.Context) error {
 	checkStatSyntax(ctx)
-	sse := newSSE(ctx)
 
-	b := bench.Stat{
-		Common:        getCommon(ctx, newGenSource(ctx, "obj.size")),
-		Versions:      ctx.Int("versions"),
-		CreateObjects: ctx.Int("objects"),
-		StatOpts: minio.StatObjectOptions{
-			ServerSideEncryption: sse,
-		},
-	}
-	return runBench(ctx, &b)
+	return runBench(ctx, func(ctx *cli.Context) bench.Benchmark {
+		b := bench.Stat{
+			Common:        getCommon(ctx, newGenSource(ctx, "obj.size")),
+			Versions:      ctx.Int("versions"),
+			CreateObjects: ctx.Int("objects"),
+			StatOpts:      statOpts(ctx),
+			Missing:       ctx.Float64("missing") / 100,
+			AccessOrder:   bench.AccessOrder(ctx.String("access")),
+		}
+		return &b
+	})
+}
+
+// statOpts returns the stat options set in the context.
+func statOpts(ctx *cli.Context) minio.StatObjectOptions {
+	return getOpts(ctx)
 }
 
 func checkStatSyntax(ctx *cli.Context) {
@@ -86,6 +97,10 @@ func checkStatSyntax(ctx *cli.Context) {
 	if ctx.Int("objects") < 1 {
 		console.Fatal("At least one object must be tested")
 	}
+	if m := ctx.Float64("missing"); m < 0 || m > 100 {
+		console.Fatal("--missing must be a percentage between 0 and 100")
+	}
+	checkAccess(ctx)
 	checkAnalyze(ctx)
 	checkBenchmark(ctx)
 }
@@ -0,0 +1,180 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg"
+	"github.com/minio/warp/pkg/aggregate"
+	"github.com/minio/warp/pkg/bench"
+)
+
+// runSummary is a machine readable summary of a benchmark run.
+type runSummary struct {
+	Version     string            `json:"warp_version"`
+	Benchmark   string            `json:"benchmark"`
+	CommandLine string            `json:"command_line"`
+	Config      map[string]string `json:"config"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	// Signature is the signature method used for uploads.
+	Signature string `json:"signature"`
+	// BenchData is the path of the benchmark data file, if written.
+	BenchData string    `json:"bench_data,omitempty"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	N         int       `json:"n"`
+	Errors    int       `json:"errors"`
+	// Stages contains the time spent in each stage, if known.
+	Stages     *summaryStages   `json:"stages,omitempty"`
+	Operations []summaryOpStats `json:"operations"`
+}
+
+// summaryOpStats contains the summary of a single operation type.
+type summaryOpStats struct {
+	Type                string          `json:"type"`
+	N                   int             `json:"n"`
+	Errors              int             `json:"errors"`
+	Concurrency         int             `json:"concurrency"`
+	Hosts               int             `json:"hosts"`
+	Clients             int             `json:"clients"`
+	ObjectsPerOperation int             `json:"objects_per_operation"`
+	AverageBPS          float64         `json:"average_bps"`
+	AverageOPS          float64         `json:"average_ops"`
+	Latency             *summaryLatency `json:"latency_millis,omitempty"`
+	FirstErrors         []string        `json:"first_errors,omitempty"`
+	Skipped             bool            `json:"skipped,omitempty"`
+}
+
+// summaryLatency contains request latency percentiles in milliseconds.
+type summaryLatency struct {
+	Average float64 `json:"avg"`
+	Fastest float64 `json:"fastest"`
+	P50     float64 `json:"p50"`
+	P90     float64 `json:"p90"`
+	P99     float64 `json:"p99"`
+	Slowest float64 `json:"slowest"`
+}
+
+// writeSummary will write a JSON summary of the run to the file specified by --summary-file.
+// If the file name is '-' the summary is written to stdout.
+func writeSummary(ctx *cli.Context, ops bench.Operations, benchData string, stages *stageTimes) error {
+	fn := ctx.String("summary-file")
+	if fn == "" {
+		return nil
+	}
+	s := newRunSummary(ctx, ops, benchData)
+	s.Stages = stages.summary()
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	if fn == "-" {
+		_, err = summaryStdout.Write(b)
+		return err
+	}
+	err = os.WriteFile(fn, b, 0o666)
+	if err == nil {
+		console.Println("Run summary written to", fn)
+	}
+	return err
+}
+
+// summaryStdout is the standard output reserved for the summary
+// when it is written to '-'.
+var summaryStdout *os.File
+
+// reserveSummaryStdout keeps standard output for the summary when --summary-file=- is given.
+// All other output is sent to stderr, so stdout only contains the JSON summary.
+func reserveSummaryStdout(ctx *cli.Context) {
+	if ctx.String("summary-file") != "-" || summaryStdout != nil {
+		return
+	}
+	summaryStdout = os.Stdout
+	os.Stdout = os.Stderr
+	color.Output = os.Stderr
+}
+
+// newRunSummary returns the summary of a benchmark run.
+func newRunSummary(ctx *cli.Context, ops bench.Operations, benchData string) runSummary {
+	aggr := aggregate.Aggregate(ops, aggregate.Options{
+		DurFunc: func(total time.Duration) time.Duration {
+			if total <= 0 {
+				return 0
+			}
+			return analysisDur(ctx, total)
+		},
+		SkipDur: ctx.Duration("analyze.skip"),
+	})
+	s := runSummary{
+		Version:     pkg.Version,
+		Benchmark:   ctx.Command.Name,
+		CommandLine: commandLine(ctx),
+		Config:      commandConfig(ctx),
+		Tags:        benchTags(ctx),
+		Signature:   effectiveSignature(ctx),
+		BenchData:   benchData,
+		N:           len(ops),
+		Errors:      ops.NErrors(),
+	}
+	s.StartTime, s.EndTime = ops.TimeRange()
+	for _, op := range aggr.Operations {
+		stats := summaryOpStats{
+			Type:                op.Type,
+			N:                   op.N,
+			Errors:              op.Errors,
+			Concurrency:         op.Concurrency,
+			Hosts:               op.Hosts,
+			Clients:             op.Clients,
+			ObjectsPerOperation: op.ObjectsPerOperation,
+			AverageBPS:          op.Throughput.AverageBPS,
+			AverageOPS:          op.Throughput.AverageOPS,
+			FirstErrors:         op.FirstErrors,
+			Skipped:             op.Skipped,
+		}
+		stats.Latency = summaryLatencies(ops.FilterByOp(op.Type).FilterSuccessful())
+		s.Operations = append(s.Operations, stats)
+	}
+	return s
+}
+
+// summaryLatencies returns latency percentiles of the operations.
+// The operations will be sorted by duration.
+func summaryLatencies(ops bench.Operations) *summaryLatency {
+	if len(ops) == 0 {
+		return nil
+	}
+	ms := func(d time.Duration) float64 {
+		return float64(d.Microseconds()) / 1000
+	}
+	ops.SortByDuration()
+	return &summaryLatency{
+		Average: ms(ops.AvgDuration()),
+		Fastest: ms(ops[0].Duration()),
+		P50:     ms(ops.Median(0.5).Duration()),
+		P90:     ms(ops.Median(0.9).Duration()),
+		P99:     ms(ops.Median(0.99).Duration()),
+		Slowest: ms(ops[len(ops)-1].Duration()),
+	}
+}
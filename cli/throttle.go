@@ -0,0 +1,189 @@
+/*
+ * Warp (C) 2019-2020 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/bench"
+)
+
+var throttleFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "obj.size",
+		Value: "1MiB",
+		Usage: "Size of each generated object. Can be a number or 10KiB/MiB/GiB. All sizes are base 2 binary.",
+	},
+	cli.DurationFlag{
+		Name:  "overload",
+		Value: 2 * time.Minute,
+		Usage: "Duration of uploads without a rate limit. The load is then reduced to --recovery.rps for the rest of the benchmark.",
+	},
+	cli.Float64Flag{
+		Name:  "recovery.rps",
+		Value: 10,
+		Usage: "Requests per second of all threads after the overload.",
+	},
+}
+
+var throttleCmd = cli.Command{
+	Name:   "throttle",
+	Usage:  "benchmark throttling of requests beyond the limits of the server and the recovery",
+	Action: mainThrottle,
+	Before: setGlobalsFromContext,
+	Flags:  combineFlags(globalFlags, ioFlags, throttleFlags, genFlags, benchFlags, analyzeFlags),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+  -> see https://github.com/minio/warp#throttle
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+}
+
+// mainThrottle is the entry point for throttle command.
+func mainThrottle(ctx *cli.Context) error {
+	checkThrottleSyntax(ctx)
+	return runBench(ctx, func(ctx *cli.Context) bench.Benchmark {
+		b := bench.Throttle{
+			Common:      getCommon(ctx, newGenSource(ctx, "obj.size")),
+			Overload:    ctx.Duration("overload"),
+			RecoveryRps: ctx.Float64("recovery.rps"),
+		}
+		return &b
+	})
+}
+
+func checkThrottleSyntax(ctx *cli.Context) {
+	if ctx.NArg() > 0 {
+		console.Fatal("Command takes no arguments")
+	}
+	if ctx.Duration("overload") <= 0 || ctx.Duration("overload") >= ctx.Duration("duration") {
+		console.Fatal("overload must be positive and end before the benchmark duration")
+	}
+	if ctx.Float64("recovery.rps") <= 0 {
+		console.Fatal("recovery.rps must be positive")
+	}
+	if ctx.Bool("autoterm") {
+		console.Fatal("autoterm cannot be used with throttle benchmark")
+	}
+	checkAnalyze(ctx)
+	checkBenchmark(ctx)
+}
+
+// throttleStats contains the throttled responses of operations.
+type throttleStats struct {
+	requests, throttledOps, failed int
+	responses, withHint, early     int
+	advised, waited, duration      time.Duration
+}
+
+// add the throttled responses of op.
+func (t *throttleStats) add(op bench.Operation) {
+	t.requests++
+	t.duration += op.Duration()
+	if op.Throttled == 0 {
+		return
+	}
+	t.throttledOps++
+	t.responses += op.Throttled
+	t.early += op.RetryEarly
+	t.advised += op.RetryAfter
+	t.waited += op.ThrottledTime
+	if op.RetryAfter > 0 {
+		t.withHint++
+	}
+	if op.Err != "" {
+		t.failed++
+	}
+}
+
+// printThrottleAnalysis prints the throttled responses of each operation type,
+// whether the server advised a wait with Retry-After and whether the client waited before retrying.
+// With --retry-after the time waited is printed as throttled time.
+// If the operations contain a recovery phase, the time until requests were no longer throttled is printed.
+// Nothing is printed if no requests were throttled.
+func printThrottleAnalysis(o bench.Operations) {
+	byType := make(map[string]*throttleStats)
+	var throttled bool
+	var recoveryStart, recoveryEnd, lastThrottled time.Time
+	for _, op := range o {
+		t := byType[op.OpType]
+		if t == nil {
+			t = &throttleStats{}
+			byType[op.OpType] = t
+		}
+		t.add(op)
+		throttled = throttled || op.Throttled > 0
+		if !strings.HasPrefix(op.OpType, bench.ThrottleRecoveryPrefix) {
+			continue
+		}
+		if recoveryStart.IsZero() || op.Start.Before(recoveryStart) {
+			recoveryStart = op.Start
+		}
+		if op.End.After(recoveryEnd) {
+			recoveryEnd = op.End
+		}
+		if op.Throttled > 0 && op.End.After(lastThrottled) {
+			lastThrottled = op.End
+		}
+	}
+	if !throttled {
+		return
+	}
+	console.SetColor("Print", color.New(color.FgHiWhite))
+	console.Println("\nThrottling (429 and 503 responses):")
+	console.SetColor("Print", color.New(color.FgWhite))
+	for _, typ := range stringKeysSorted(byType) {
+		t := byType[typ]
+		if t.throttledOps == 0 {
+			console.Printf(" * %s: %d requests, none throttled.\n", typ, t.requests)
+			continue
+		}
+		console.Printf(" * %s: %d of %d requests throttled (%.02f%%), %d throttled responses, %d requests failed.\n",
+			typ, t.throttledOps, t.requests, 100*float64(t.throttledOps)/float64(t.requests), t.responses, t.failed)
+		if t.withHint == 0 {
+			console.Println("   - No throttled responses contained Retry-After.")
+			continue
+		}
+		console.Printf("   - %d requests advised a wait with Retry-After, avg %v per request. %d retries were sent before the advised wait.\n",
+			t.withHint, (t.advised / time.Duration(t.withHint)).Round(time.Millisecond), t.early)
+		if t.waited > 0 {
+			console.Printf("   - Throttled time: %v waited as advised, %.02f%% of the request time.\n",
+				t.waited.Round(time.Millisecond), 100*t.waited.Seconds()/t.duration.Seconds())
+		}
+	}
+	if recoveryStart.IsZero() {
+		return
+	}
+	switch {
+	case lastThrottled.IsZero():
+		console.Println(" * No requests were throttled after the load was reduced.")
+	case recoveryEnd.Sub(lastThrottled) < time.Second:
+		console.Printf(" * Requests were still throttled at the end of the benchmark, %v after the load was reduced.\n", recoveryEnd.Sub(recoveryStart).Round(time.Millisecond))
+	default:
+		console.Printf(" * Requests were throttled until %v after the load was reduced.\n", lastThrottled.Sub(recoveryStart).Round(time.Millisecond))
+	}
+}
@@ -0,0 +1,118 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"github.com/minio/cli"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/bench"
+)
+
+var tinyFlags = []cli.Flag{
+	cli.IntFlag{
+		Name:  "objects",
+		Value: 100000,
+		Usage: "Number of objects to upload for GET operations.",
+	},
+	cli.StringFlag{
+		Name:  "obj.size",
+		Value: "1KiB",
+		Usage: "Size of each generated object. Can be a number or 10KiB/MiB/GiB. All sizes are base 2 binary.",
+	},
+	cli.Float64Flag{
+		Name:  "get-distrib",
+		Usage: "The amount of GET operations.",
+		Value: 50,
+	},
+	cli.Float64Flag{
+		Name:  "put-distrib",
+		Usage: "The amount of PUT operations.",
+		Value: 50,
+	},
+	cli.BoolFlag{
+		Name:  "verify",
+		Usage: "Compare downloaded content to the uploaded content. By default only the size is checked.",
+	},
+	cli.IntFlag{
+		Name:   "tiny.batch",
+		Value:  256,
+		Usage:  "Number of operations each thread collects before recording them.",
+		Hidden: true,
+	},
+}
+
+var tinyCmd = cli.Command{
+	Name:   "tiny",
+	Usage:  "benchmark very small objects with minimal client overhead",
+	Action: mainTiny,
+	Before: setGlobalsFromContext,
+	Flags:  combineFlags(globalFlags, ioFlags, tinyFlags, genFlags, benchFlags, analyzeFlags),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+  -> see https://github.com/minio/warp#tiny
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}`,
+}
+
+// mainTiny is the entry point for tiny command.
+func mainTiny(ctx *cli.Context) error {
+	checkTinySyntax(ctx)
+	return runBench(ctx, func(ctx *cli.Context) bench.Benchmark {
+		b := bench.Tiny{
+			Common:        getCommon(ctx, newGenSource(ctx, "obj.size")),
+			CreateObjects: ctx.Int("objects"),
+			GetOpts:       getOpts(ctx),
+			GetDist:       ctx.Float64("get-distrib"),
+			PutDist:       ctx.Float64("put-distrib"),
+			Verify:        ctx.Bool("verify"),
+			BatchSize:     ctx.Int("tiny.batch"),
+		}
+		return &b
+	})
+}
+
+func checkTinySyntax(ctx *cli.Context) {
+	if ctx.NArg() > 0 {
+		console.Fatal("Command takes no arguments")
+	}
+	if ctx.Float64("get-distrib") < 0 || ctx.Float64("put-distrib") < 0 {
+		console.Fatal("Distributions cannot be negative")
+	}
+	if ctx.Float64("get-distrib")+ctx.Float64("put-distrib") <= 0 {
+		console.Fatal("No operations selected, total distribution is 0")
+	}
+	if ctx.Float64("get-distrib") > 0 && ctx.Int("objects") < ctx.Int("concurrent") {
+		console.Fatal("At least one object per thread must be uploaded")
+	}
+	if ctx.Bool("obj.randsize") {
+		console.Fatal("--obj.randsize cannot be used, all objects have the same size")
+	}
+	if ctx.Float64("hot.fraction") > 0 {
+		console.Fatal("--hot.fraction cannot be used with tiny objects")
+	}
+	if ctx.Int("tiny.batch") < 1 {
+		console.Fatal("--tiny.batch must be at least 1")
+	}
+	checkAnalyze(ctx)
+	checkBenchmark(ctx)
+}
@@ -0,0 +1,256 @@
+/*
+ * Warp (C) 2019-2020 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg"
+	"github.com/minio/websocket"
+)
+
+// Actions taken when a warp client runs a different build than the server.
+const (
+	clientVersionWarn    = "warn"
+	clientVersionRefuse  = "refuse"
+	clientVersionUpgrade = "upgrade"
+)
+
+// clientUpgradeWait is the longest time to wait for an upgraded client to restart.
+const clientUpgradeWait = 30 * time.Second
+
+// clientAllowUpgrade is set when the client accepts binaries from the server.
+var clientAllowUpgrade bool
+
+// warpBuild identifies a warp build.
+type warpBuild struct {
+	Version  string `json:"version"`
+	Commit   string `json:"commit"`
+	Platform string `json:"platform"`
+}
+
+// currentBuild returns the build of the running binary.
+func currentBuild() warpBuild {
+	return warpBuild{
+		Version:  pkg.Version,
+		Commit:   pkg.CommitID,
+		Platform: runtime.GOOS + "/" + runtime.GOARCH,
+	}
+}
+
+func (b warpBuild) String() string {
+	commit := b.Commit
+	if len(commit) > 12 {
+		commit = commit[:12]
+	}
+	return fmt.Sprintf("%s - %s (%s)", b.Version, commit, b.Platform)
+}
+
+// compatible returns whether b runs the same version and commit as other.
+func (b warpBuild) compatible(other warpBuild) bool {
+	return b.Version == other.Version && b.Commit == other.Commit
+}
+
+// clientUpgrade describes a binary sent to a client.
+type clientUpgrade struct {
+	Build  warpBuild `json:"build"`
+	Size   int       `json:"size"`
+	SHA256 string    `json:"sha256"`
+}
+
+func checkClientVersionAction(s string) error {
+	switch s {
+	case clientVersionWarn, clientVersionRefuse, clientVersionUpgrade:
+		return nil
+	}
+	return fmt.Errorf("unknown warp-client.version %q. Must be %s, %s or %s", s, clientVersionWarn, clientVersionRefuse, clientVersionUpgrade)
+}
+
+// checkVersions compares the builds of all connected clients to the server build.
+// Depending on action mismatches are reported, returned as an error or upgraded.
+func (c *connections) checkVersions(action string) error {
+	server := currentBuild()
+	var mismatch []string
+	for i := range c.hosts {
+		b := c.builds[i]
+		if b != nil && b.compatible(server) {
+			continue
+		}
+		desc := "unknown (older than server)"
+		if b != nil {
+			desc = b.String()
+		}
+		if action == clientVersionUpgrade && b != nil {
+			c.info("Client ", c.hostName(i), " runs ", desc, ", upgrading to ", server.String())
+			if err := c.upgrade(i); err != nil {
+				return fmt.Errorf("upgrading client %v: %w", c.hostName(i), err)
+			}
+			continue
+		}
+		mismatch = append(mismatch, fmt.Sprintf("%v runs %v", c.hostName(i), desc))
+	}
+	if len(mismatch) == 0 {
+		return nil
+	}
+	msg := fmt.Sprintf("warp clients do not run the server version %v: %v", server, strings.Join(mismatch, ", "))
+	if action == clientVersionWarn {
+		c.errLn(msg)
+		return nil
+	}
+	return errors.New(msg)
+}
+
+// upgrade sends the running binary to client i and waits for it to restart.
+func (c *connections) upgrade(i int) error {
+	server := currentBuild()
+	if b := c.builds[i]; b != nil && b.Platform != server.Platform {
+		return fmt.Errorf("client platform %s does not match server platform %s", b.Platform, server.Platform)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	bin, err := os.ReadFile(exe)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(bin)
+	resp, err := c.roundTrip(i, serverRequest{
+		Operation: serverReqUpgrade,
+		Upgrade: &clientUpgrade{
+			Build:  server,
+			Size:   len(bin),
+			SHA256: hex.EncodeToString(sum[:]),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if resp.Err != "" {
+		return errors.New(resp.Err)
+	}
+	conn := c.ws[i]
+	if err := conn.WriteMessage(websocket.BinaryMessage, bin); err != nil {
+		return err
+	}
+	var done clientReply
+	if err := conn.ReadJSON(&done); err != nil {
+		return err
+	}
+	if done.Err != "" {
+		return errors.New(done.Err)
+	}
+	conn.Close()
+	c.ws[i] = nil
+	c.builds[i] = nil
+
+	// Wait for the client to restart with the new binary.
+	deadline := time.Now().Add(clientUpgradeWait)
+	for {
+		time.Sleep(time.Second)
+		err = c.connect(i)
+		if err == nil || time.Now().After(deadline) {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("client did not come back after upgrade: %w", err)
+	}
+	if b := c.builds[i]; b == nil || !b.compatible(server) {
+		return errors.New("client does not run the new version after upgrade")
+	}
+	c.info("Client ", c.hostName(i), " upgraded")
+	return nil
+}
+
+// receiveUpgrade receives a binary from the server and replaces the running executable.
+// The caller should restart the client when no error is returned.
+func receiveUpgrade(ws *websocket.Conn, u *clientUpgrade) error {
+	if !clientAllowUpgrade {
+		return errors.New("client does not accept upgrades. Start it with --allow-upgrade")
+	}
+	if !clientRestartSupported {
+		return fmt.Errorf("upgrading is not supported on %s", runtime.GOOS)
+	}
+	if u == nil {
+		return errors.New("no upgrade info sent")
+	}
+	if want := currentBuild().Platform; u.Build.Platform != want {
+		return fmt.Errorf("binary platform %s does not match client platform %s", u.Build.Platform, want)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	exe, err = filepath.EvalSymlinks(exe)
+	if err != nil {
+		return err
+	}
+	// Tell the server to send the binary.
+	err = ws.WriteJSON(clientReply{Type: clientRespUpgradeReady, Time: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, bin, err := ws.ReadMessage()
+	if err != nil {
+		return err
+	}
+	if len(bin) != u.Size {
+		return fmt.Errorf("received %d bytes, expected %d", len(bin), u.Size)
+	}
+	sum := sha256.Sum256(bin)
+	if hex.EncodeToString(sum[:]) != u.SHA256 {
+		return errors.New("checksum mismatch on received binary")
+	}
+	if cur, err := os.ReadFile(exe); err == nil && bytes.Equal(cur, bin) {
+		return errors.New("received binary is identical to the running binary")
+	}
+	console.Infoln("Upgrading to", u.Build.String())
+
+	// Write next to the executable and rename, so it is replaced atomically.
+	tmp := exe + ".upgrade"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o755)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(bin)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, exe)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
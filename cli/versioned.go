@@ -22,7 +22,6 @@ import (
 
 	"github.com/minio/cli"
 	"github.com/minio/mc/pkg/probe"
-	"github.com/minio/minio-go/v7"
 	"github.com/minio/pkg/v2/console"
 	"github.com/minio/warp/pkg/bench"
 )
@@ -81,27 +80,26 @@ FLAGS:
 // mainVersioned is the entry point for mixed command.
 func mainVersioned(ctx *cli.Context) error {
 	checkVersionedSyntax(ctx)
-	sse := newSSE(ctx)
-	dist := bench.VersionedDistribution{
-		Distribution: map[string]float64{
-			http.MethodGet:    ctx.Float64("get-distrib"),
-			"STAT":            ctx.Float64("stat-distrib"),
-			http.MethodPut:    ctx.Float64("put-distrib"),
-			http.MethodDelete: ctx.Float64("delete-distrib"),
-		},
-	}
-	err := dist.Generate(ctx.Int("objects") * 2)
-	fatalIf(probe.NewError(err), "Invalid distribution")
-	b := bench.Versioned{
-		Common:        getCommon(ctx, newGenSource(ctx, "obj.size")),
-		CreateObjects: ctx.Int("objects"),
-		GetOpts:       minio.GetObjectOptions{ServerSideEncryption: sse},
-		StatOpts: minio.StatObjectOptions{
-			ServerSideEncryption: sse,
-		},
-		Dist: &dist,
-	}
-	return runBench(ctx, &b)
+	return runBench(ctx, func(ctx *cli.Context) bench.Benchmark {
+		dist := bench.VersionedDistribution{
+			Distribution: map[string]float64{
+				http.MethodGet:    ctx.Float64("get-distrib"),
+				"STAT":            ctx.Float64("stat-distrib"),
+				http.MethodPut:    ctx.Float64("put-distrib"),
+				http.MethodDelete: ctx.Float64("delete-distrib"),
+			},
+		}
+		err := dist.Generate(ctx.Int("objects") * 2)
+		fatalIf(probe.NewError(err), "Invalid distribution")
+		b := bench.Versioned{
+			Common:        getCommon(ctx, newGenSource(ctx, "obj.size")),
+			CreateObjects: ctx.Int("objects"),
+			GetOpts:       getOpts(ctx),
+			StatOpts:      statOpts(ctx),
+			Dist:          &dist,
+		}
+		return &b
+	})
 }
 
 func checkVersionedSyntax(ctx *cli.Context) {
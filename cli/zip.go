@@ -66,14 +66,16 @@ FLAGS:
 // mainGet is the entry point for get command.
 func mainZip(ctx *cli.Context) error {
 	checkZipSyntax(ctx)
-	ctx.Set("noprefix", "true")
-	b := bench.S3Zip{
-		Common:      getCommon(ctx, newGenSource(ctx, "obj.size")),
-		CreateFiles: ctx.Int("files"),
-		ZipObjName:  fmt.Sprintf("%d.zip", time.Now().UnixNano()),
-	}
-	b.Locking = true
-	return runBench(ctx, &b)
+	return runBench(ctx, func(ctx *cli.Context) bench.Benchmark {
+		ctx.Set("noprefix", "true")
+		b := bench.S3Zip{
+			Common:      getCommon(ctx, newGenSource(ctx, "obj.size")),
+			CreateFiles: ctx.Int("files"),
+			ZipObjName:  fmt.Sprintf("%d.zip", time.Now().UnixNano()),
+		}
+		b.Locking = true
+		return &b
+	})
 }
 
 func checkZipSyntax(ctx *cli.Context) {
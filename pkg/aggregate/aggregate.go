@@ -15,24 +15,40 @@
  * along with this program.  If not, see <http://www.gnu.org/licenses/>.
  */
 
+// Package aggregate contains the analysis of benchmark operations.
+//
+// Aggregated is the output of 'warp analyze --json' and can be read using ReadJSON.
+// The JSON names of exported fields are stable: fields may be added,
+// but fields are only removed, renamed or given a different meaning when JSONVersion is increased.
 package aggregate
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"sync"
 	"time"
 
 	"github.com/minio/warp/pkg/bench"
 )
 
+// JSONVersion is the version of the JSON representation of Aggregated.
+const JSONVersion = 1
+
 // Aggregated contains aggregated data for a single benchmark run.
 type Aggregated struct {
+	// Version of the JSON representation. 0 if written before versioning.
+	Version int `json:"version"`
 	// MixedServerStats and MixedThroughputByHost is populated only when data is mixed.
 	MixedServerStats      *Throughput           `json:"mixed_server_stats,omitempty"`
 	MixedThroughputByHost map[string]Throughput `json:"mixed_throughput_by_host,omitempty"`
 	Type                  string                `json:"type"`
 	Operations            []Operation           `json:"operations,omitempty"`
 	Mixed                 bool                  `json:"mixed"`
+	// Tags supplied when the benchmark was run.
+	Tags map[string]string `json:"tags,omitempty"`
+	// Availability of all requests.
+	Availability *Availability `json:"availability,omitempty"`
 }
 
 // Operation returns statistics for a single operation type.
@@ -63,6 +79,8 @@ type Operation struct {
 	Concurrency int `json:"concurrency"`
 	// Total errors recorded.
 	Errors int `json:"errors"`
+	// Errors over time, populated if any errors were recorded.
+	ErrorTimeline *ErrorTimeline `json:"error_timeline,omitempty"`
 	// Objects per operation.
 	ObjectsPerOperation int `json:"objects_per_operation"`
 	// N is the number of operations.
@@ -71,6 +89,19 @@ type Operation struct {
 	Skipped bool `json:"skipped"`
 }
 
+// ReadJSON reads aggregated data written as JSON.
+// Data of all versions up to JSONVersion can be read.
+func ReadJSON(r io.Reader) (*Aggregated, error) {
+	var a Aggregated
+	if err := json.NewDecoder(r).Decode(&a); err != nil {
+		return nil, err
+	}
+	if a.Version > JSONVersion {
+		return nil, fmt.Errorf("aggregated data has version %d, newer than supported version %d", a.Version, JSONVersion)
+	}
+	return &a, nil
+}
+
 // SegmentDurFn accepts a total time and should return the duration used for each segment.
 type SegmentDurFn func(total time.Duration) time.Duration
 
@@ -78,6 +109,11 @@ type Options struct {
 	DurFunc     SegmentDurFn
 	SkipDur     time.Duration
 	Prefiltered bool
+	// Align starts segments at multiples of the segment duration in wall clock time.
+	Align bool
+	// DowntimeThreshold is the error rate above which a segment is counted as downtime.
+	// If 0, DefaultDowntimeThreshold is used.
+	DowntimeThreshold float64
 }
 
 // Aggregate returns statistics when only a single operation was running concurrently.
@@ -85,6 +121,7 @@ func Aggregate(o bench.Operations, opts Options) Aggregated {
 	o.SortByStartTime()
 	types := o.OpTypes()
 	a := Aggregated{
+		Version:               JSONVersion,
 		Type:                  "single",
 		Mixed:                 false,
 		Operations:            nil,
@@ -93,6 +130,9 @@ func Aggregate(o bench.Operations, opts Options) Aggregated {
 	}
 	isMixed := o.IsMixed()
 	opts.Prefiltered = opts.Prefiltered || o.HasError()
+	if start, end := o.TimeRange(); end.After(start) {
+		a.Availability = availability(o, opts.DurFunc(end.Sub(start)), opts.DowntimeThreshold)
+	}
 
 	// Fill mixed only parts...
 	if isMixed {
@@ -123,6 +163,7 @@ func Aggregate(o bench.Operations, opts Options) Aggregated {
 		segs := ops.Segment(bench.SegmentOptions{
 			From:           time.Time{},
 			PerSegDuration: segmentDur,
+			Align:          opts.Align,
 			AllThreads:     false,
 			MultiOp:        true,
 		})
@@ -189,14 +230,22 @@ func Aggregate(o bench.Operations, opts Options) Aggregated {
 					if len(a.FirstErrors) >= 10 {
 						break
 					}
+					if err.RequestID != "" {
+						a.FirstErrors = append(a.FirstErrors, fmt.Sprintf("%s, %s (request id: %s): %v", err.Endpoint, err.End.Round(time.Second), err.RequestID, err.Err))
+						continue
+					}
 					a.FirstErrors = append(a.FirstErrors, fmt.Sprintf("%s, %s: %v", err.Endpoint, err.End.Round(time.Second), err.Err))
 				}
 			}
 
 			segmentDur := opts.DurFunc(ops.Duration())
+			if len(errs) > 0 {
+				a.ErrorTimeline = errorTimeline(ops, segmentDur)
+			}
 			segs := ops.Segment(bench.SegmentOptions{
 				From:           time.Time{},
 				PerSegDuration: segmentDur,
+				Align:          opts.Align,
 				AllThreads:     !opts.Prefiltered,
 				MultiOp:        false,
 			})
@@ -252,6 +301,7 @@ func Aggregate(o bench.Operations, opts Options) Aggregated {
 					segs := ops.Segment(bench.SegmentOptions{
 						From:           time.Time{},
 						PerSegDuration: segmentDur,
+						Align:          opts.Align,
 						AllThreads:     false,
 					})
 
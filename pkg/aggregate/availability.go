@@ -0,0 +1,173 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package aggregate
+
+import (
+	"sort"
+	"time"
+
+	"github.com/minio/warp/pkg/bench"
+)
+
+// DefaultDowntimeThreshold is the error rate of a window above which it is counted as downtime.
+const DefaultDowntimeThreshold = 0.05
+
+// Availability contains availability metrics of all requests.
+type Availability struct {
+	// Duration of each window.
+	WindowMillis int `json:"window_millis"`
+	// Error rate above which a window is counted as downtime.
+	DowntimeThreshold float64 `json:"downtime_threshold"`
+	// Total number of requests.
+	Requests int `json:"requests"`
+	// Total number of failed requests.
+	Errors int `json:"errors"`
+	// Fraction of successful requests.
+	SuccessRatio float64 `json:"success_ratio"`
+	// Fraction of the time that was not downtime.
+	TimeAvailability float64 `json:"time_availability"`
+	// Lowest success ratio of a window with requests.
+	MinWindowSuccessRatio float64 `json:"min_window_success_ratio"`
+	// Start of the window with the lowest success ratio.
+	MinWindowStart time.Time `json:"min_window_start"`
+	// Time from the start of the run to the first error.
+	// Not set if there were no errors.
+	TimeToFirstErrorMillis *int `json:"time_to_first_error_millis,omitempty"`
+	// Longest time without errors.
+	LongestErrorFreeMillis int `json:"longest_error_free_millis"`
+	// Start of the longest time without errors.
+	LongestErrorFreeStart time.Time `json:"longest_error_free_start"`
+	// Total time in downtime windows.
+	DowntimeMillis int `json:"downtime_millis"`
+	// Consecutive windows where the error rate exceeded the threshold.
+	Downtime []DowntimeWindow `json:"downtime,omitempty"`
+	// Success ratio of each window.
+	Windows []AvailabilityWindow `json:"windows"`
+}
+
+// AvailabilityWindow contains the requests ending inside a window.
+type AvailabilityWindow struct {
+	// Start time of the window.
+	Start time.Time `json:"start"`
+	// Number of requests ending in the window.
+	Requests int `json:"requests"`
+	// Number of failed requests ending in the window.
+	Errors int `json:"errors"`
+}
+
+// SuccessRatio returns the fraction of successful requests in the window.
+// A window without requests has a success ratio of 1.
+func (w AvailabilityWindow) SuccessRatio() float64 {
+	if w.Requests == 0 {
+		return 1
+	}
+	return float64(w.Requests-w.Errors) / float64(w.Requests)
+}
+
+// DowntimeWindow is a period where the error rate exceeded the threshold.
+type DowntimeWindow struct {
+	Start          time.Time `json:"start"`
+	End            time.Time `json:"end"`
+	DurationMillis int       `json:"duration_millis"`
+	Requests       int       `json:"requests"`
+	Errors         int       `json:"errors"`
+}
+
+// availability returns availability metrics of the operations.
+// Requests are placed in the window where they ended.
+func availability(ops bench.Operations, windowDur time.Duration, threshold float64) *Availability {
+	if len(ops) == 0 || windowDur <= 0 {
+		return nil
+	}
+	if threshold <= 0 {
+		threshold = DefaultDowntimeThreshold
+	}
+	start, end := ops.TimeRange()
+	n := int(end.Sub(start)/windowDur) + 1
+	res := Availability{
+		WindowMillis:      durToMillis(windowDur),
+		DowntimeThreshold: threshold,
+		Requests:          len(ops),
+		Windows:           make([]AvailabilityWindow, n),
+	}
+	for i := range res.Windows {
+		res.Windows[i].Start = start.Add(time.Duration(i) * windowDur)
+	}
+	var errTimes []time.Time
+	for _, op := range ops {
+		idx := int(op.End.Sub(start) / windowDur)
+		if idx < 0 || idx >= n {
+			continue
+		}
+		res.Windows[idx].Requests++
+		if op.Err != "" {
+			res.Windows[idx].Errors++
+			res.Errors++
+			errTimes = append(errTimes, op.End)
+		}
+	}
+	res.SuccessRatio = float64(res.Requests-res.Errors) / float64(res.Requests)
+
+	// Error free streaks are the gaps between errors, including the start and end of the run.
+	sort.Slice(errTimes, func(i, j int) bool { return errTimes[i].Before(errTimes[j]) })
+	if len(errTimes) > 0 {
+		first := durToMillis(errTimes[0].Sub(start))
+		res.TimeToFirstErrorMillis = &first
+	}
+	prev := start
+	for _, t := range append(errTimes, end) {
+		if d := durToMillis(t.Sub(prev)); d > res.LongestErrorFreeMillis {
+			res.LongestErrorFreeMillis = d
+			res.LongestErrorFreeStart = prev
+		}
+		prev = t
+	}
+
+	res.MinWindowSuccessRatio = 1
+	var down *DowntimeWindow
+	for i, w := range res.Windows {
+		if w.Requests > 0 && w.SuccessRatio() < res.MinWindowSuccessRatio {
+			res.MinWindowSuccessRatio = w.SuccessRatio()
+			res.MinWindowStart = w.Start
+		}
+		if w.Requests == 0 || float64(w.Errors)/float64(w.Requests) <= threshold {
+			down = nil
+			continue
+		}
+		wEnd := w.Start.Add(windowDur)
+		if i == n-1 && end.Before(wEnd) {
+			wEnd = end
+		}
+		if down == nil {
+			res.Downtime = append(res.Downtime, DowntimeWindow{Start: w.Start})
+			down = &res.Downtime[len(res.Downtime)-1]
+		}
+		down.End = wEnd
+		down.DurationMillis = durToMillis(wEnd.Sub(down.Start))
+		down.Requests += w.Requests
+		down.Errors += w.Errors
+	}
+	for _, d := range res.Downtime {
+		res.DowntimeMillis += d.DurationMillis
+	}
+	res.TimeAvailability = 1
+	if total := durToMillis(end.Sub(start)); total > 0 {
+		res.TimeAvailability = max(0, 1-float64(res.DowntimeMillis)/float64(total))
+	}
+	return &res
+}
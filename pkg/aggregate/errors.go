@@ -0,0 +1,129 @@
+/*
+ * Warp (C) 2019-2020 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package aggregate
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/minio/warp/pkg/bench"
+)
+
+// ErrorTimeline contains the error rate of each segment split by error class.
+type ErrorTimeline struct {
+	// Duration of each segment.
+	SegmentDurationMillis int `json:"segment_duration_millis"`
+	// Sorted error classes seen.
+	Classes []string `json:"classes"`
+	// Segments in time order.
+	Segments []ErrorSegment `json:"segments"`
+}
+
+// ErrorSegment contains the requests and errors ending inside a segment.
+type ErrorSegment struct {
+	// Start time of the segment.
+	Start time.Time `json:"start"`
+	// Number of requests ending in the segment.
+	Requests int `json:"requests"`
+	// Number of failed requests ending in the segment.
+	Errors int `json:"errors"`
+	// Errors by class.
+	ByClass map[string]int `json:"by_class,omitempty"`
+	// Average latency of successful requests in milliseconds.
+	AvgLatencyMillis float64 `json:"avg_latency_millis"`
+}
+
+// ErrorRate returns the fraction of requests that failed.
+func (e ErrorSegment) ErrorRate() float64 {
+	if e.Requests == 0 {
+		return 0
+	}
+	return float64(e.Errors) / float64(e.Requests)
+}
+
+// ErrorClass returns a coarse class for an error string.
+func ErrorClass(err string) string {
+	e := strings.ToLower(err)
+	switch {
+	case strings.Contains(e, "timeout"), strings.Contains(e, "deadline exceeded"):
+		return "timeout"
+	case strings.Contains(e, "connection refused"), strings.Contains(e, "connection reset"),
+		strings.Contains(e, "broken pipe"), strings.Contains(e, "eof"), strings.Contains(e, "no such host"):
+		return "connection"
+	case strings.Contains(e, "slowdown"), strings.Contains(e, "slow down"), strings.Contains(e, "503"),
+		strings.Contains(e, "service unavailable"), strings.Contains(e, "too many requests"):
+		return "throttled"
+	case strings.Contains(e, "nosuchkey"), strings.Contains(e, "not exist"), strings.Contains(e, "not found"):
+		return "not found"
+	case strings.Contains(e, "accessdenied"), strings.Contains(e, "access denied"), strings.Contains(e, "forbidden"):
+		return "access denied"
+	case strings.Contains(e, "internalerror"), strings.Contains(e, "internal error"), strings.Contains(e, "500"):
+		return "server"
+	}
+	return "other"
+}
+
+// errorTimeline returns the error timeline of the operations.
+// Requests are placed in the segment where they ended.
+func errorTimeline(ops bench.Operations, segmentDur time.Duration) *ErrorTimeline {
+	if len(ops) == 0 || segmentDur <= 0 {
+		return nil
+	}
+	start, end := ops.TimeRange()
+	n := int(end.Sub(start)/segmentDur) + 1
+	segs := make([]ErrorSegment, n)
+	latency := make([]time.Duration, n)
+	classes := make(map[string]struct{})
+	for i := range segs {
+		segs[i].Start = start.Add(time.Duration(i) * segmentDur)
+	}
+	for _, op := range ops {
+		idx := int(op.End.Sub(start) / segmentDur)
+		if idx < 0 || idx >= n {
+			continue
+		}
+		seg := &segs[idx]
+		seg.Requests++
+		if op.Err == "" {
+			latency[idx] += op.Duration()
+			continue
+		}
+		seg.Errors++
+		class := ErrorClass(op.Err)
+		if seg.ByClass == nil {
+			seg.ByClass = make(map[string]int)
+		}
+		seg.ByClass[class]++
+		classes[class] = struct{}{}
+	}
+	res := ErrorTimeline{
+		SegmentDurationMillis: durToMillis(segmentDur),
+		Segments:              segs,
+	}
+	for i := range segs {
+		if ok := segs[i].Requests - segs[i].Errors; ok > 0 {
+			segs[i].AvgLatencyMillis = float64(latency[i]) / float64(ok) / float64(time.Millisecond)
+		}
+	}
+	for class := range classes {
+		res.Classes = append(res.Classes, class)
+	}
+	sort.Strings(res.Classes)
+	return &res
+}
@@ -52,6 +52,9 @@ type SingleSizedRequests struct {
 	// Slowest request time.
 	SlowestMillis int `json:"slowest_millis"`
 
+	// Request ID of the slowest request, if recorded.
+	SlowestRequestID string `json:"slowest_request_id,omitempty"`
+
 	// StdDev is the standard deviation of requests.
 	StdDev int `json:"std_dev_millis"`
 
@@ -86,6 +89,9 @@ func (a *SingleSizedRequests) fill(ops bench.Operations) {
 	a.Dur99Millis = durToMillis(ops.Median(0.99).Duration())
 	a.SlowestMillis = durToMillis(ops.Median(1).Duration())
 	a.FastestMillis = durToMillis(ops.Median(0).Duration())
+	if len(ops) > 0 {
+		a.SlowestRequestID = ops[len(ops)-1].RequestID
+	}
 	a.FirstByte = TtfbFromBench(ops.TTFB(start, end))
 	for i := range a.DurPct[:] {
 		a.DurPct[i] = durToMillis(ops.Median(float64(i) / 100).Duration())
@@ -130,6 +130,10 @@ type SegmentSmall struct {
 
 	// Errors logged during the time segment.
 	Errors int `json:"errors,omitempty"`
+
+	// Active clients during the time segment.
+	// Only set when operations are from more than one client.
+	Clients int `json:"clients,omitempty"`
 }
 
 // cloneBenchSegments clones benchmark segments to the simpler representation.
@@ -138,10 +142,11 @@ func cloneBenchSegments(s bench.Segments) []SegmentSmall {
 	for i, seg := range s {
 		mbps, _, ops := seg.SpeedPerSec()
 		res[i] = SegmentSmall{
-			BPS:    math.Round(mbps * (1 << 20)),
-			OPS:    math.Round(ops*100) / 100,
-			Errors: seg.Errors,
-			Start:  seg.Start,
+			BPS:     math.Round(mbps * (1 << 20)),
+			OPS:     math.Round(ops*100) / 100,
+			Errors:  seg.Errors,
+			Start:   seg.Start,
+			Clients: seg.Clients,
 		}
 	}
 	return res
@@ -0,0 +1,107 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"math/rand"
+	"sort"
+	"sync/atomic"
+
+	"github.com/minio/warp/pkg/generator"
+)
+
+// AccessOrder selects the order in which objects are read.
+type AccessOrder string
+
+const (
+	// AccessRandom reads a random object for each request.
+	AccessRandom AccessOrder = "random"
+
+	// AccessSequential reads objects in name order.
+	// Each thread reads its own range of the objects.
+	AccessSequential AccessOrder = "sequential"
+
+	// AccessGlobal reads objects in name order.
+	// All threads take the next object from a shared position.
+	AccessGlobal AccessOrder = "global"
+
+	// AccessStrided reads objects in name order.
+	// Each thread reads every n'th object starting at its thread number,
+	// where n is the number of threads.
+	AccessStrided AccessOrder = "strided"
+)
+
+// AccessOrders contains all supported access orders.
+var AccessOrders = []AccessOrder{AccessRandom, AccessSequential, AccessGlobal, AccessStrided}
+
+// access selects objects in an access order.
+type access struct {
+	order   AccessOrder
+	n       int
+	threads int
+	// next is the shared position of AccessGlobal.
+	next atomic.Int64
+}
+
+// newAccess returns object selection in the specified order for the number of threads.
+// Unless the order is random, objs are sorted by name.
+func newAccess(order AccessOrder, objs generator.Objects, threads int) *access {
+	if order != AccessRandom && order != "" {
+		sort.SliceStable(objs, func(i, j int) bool { return objs[i].Name < objs[j].Name })
+	}
+	return &access{order: order, n: len(objs), threads: max(threads, 1)}
+}
+
+// picker returns a function that returns the index of the next object to read by the thread.
+// rng is used for random access.
+func (a *access) picker(thread int, rng *rand.Rand) func() int {
+	switch a.order {
+	case AccessSequential:
+		start, end := thread*a.n/a.threads, (thread+1)*a.n/a.threads
+		if end <= start {
+			// More threads than objects.
+			start = thread % a.n
+			end = start + 1
+		}
+		i := start
+		return func() int {
+			idx := i
+			if i++; i >= end {
+				i = start
+			}
+			return idx
+		}
+	case AccessGlobal:
+		return func() int {
+			return int((a.next.Add(1) - 1) % int64(a.n))
+		}
+	case AccessStrided:
+		first := thread % a.n
+		i := first
+		return func() int {
+			idx := i
+			if i += a.threads; i >= a.n {
+				i = first
+			}
+			return idx
+		}
+	}
+	return func() int {
+		return rng.Intn(a.n)
+	}
+}
@@ -0,0 +1,193 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// AdjustOp is the operation type recorded when the concurrency or request rate
+// of a running benchmark is changed.
+// The new values are recorded in the headers as AdjustConcurrency and AdjustRPS.
+const AdjustOp = "ADJUST"
+
+const (
+	// AdjustConcurrency is the header of adjust events containing the number of active threads.
+	AdjustConcurrency = "concurrency"
+	// AdjustRPS is the header of adjust events containing the request rate limit. 0 is unlimited.
+	AdjustRPS = "rps"
+)
+
+// Adjuster allows changing the number of active threads and the request rate
+// of a running benchmark. Changes are recorded as events.
+//
+// Threads are started for the maximum concurrency.
+// When fewer threads are active, threads wait before starting a new request
+// until a slot is available.
+type Adjuster struct {
+	mu      sync.Mutex
+	max     int
+	active  int
+	held    int
+	armed   bool
+	changed chan struct{}
+	limiter *rate.Limiter
+	rps     float64
+	events  Operations
+}
+
+// NewAdjuster returns an adjuster for a benchmark with the number of threads, starting with active threads.
+// The request rate is controlled by limiter, which must be set.
+func NewAdjuster(threads, active int, limiter *rate.Limiter) *Adjuster {
+	if active <= 0 || active > threads {
+		active = threads
+	}
+	rps := float64(limiter.Limit())
+	if limiter.Limit() == rate.Inf {
+		rps = 0
+	}
+	return &Adjuster{max: threads, active: active, limiter: limiter, rps: rps, changed: make(chan struct{})}
+}
+
+// Start limiting the number of active threads and record the initial values.
+// Should be called before the threads of the benchmark are started.
+// All threads are assumed to hold a slot until they first wait.
+func (a *Adjuster) Start() {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	a.held = a.max
+	a.armed = true
+	a.record()
+	a.mu.Unlock()
+}
+
+// Concurrency returns the number of active threads and the maximum.
+func (a *Adjuster) Concurrency() (active, threads int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.active, a.max
+}
+
+// RPS returns the request rate limit. 0 is unlimited.
+func (a *Adjuster) RPS() float64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.rps
+}
+
+// SetConcurrency sets the number of active threads.
+func (a *Adjuster) SetConcurrency(n int) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if n < 1 || n > a.max {
+		return fmt.Errorf("concurrency must be between 1 and %d", a.max)
+	}
+	if n == a.active {
+		return nil
+	}
+	a.active = n
+	a.record()
+	a.wake()
+	return nil
+}
+
+// SetRPS sets the request rate limit. 0 is unlimited.
+func (a *Adjuster) SetRPS(rps float64) error {
+	if rps < 0 {
+		return errors.New("rps must be >= 0")
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if rps == a.rps {
+		return nil
+	}
+	a.rps = rps
+	if rps == 0 {
+		a.limiter.SetLimit(rate.Inf)
+	} else {
+		a.limiter.SetLimit(rate.Limit(rps))
+	}
+	a.record()
+	return nil
+}
+
+// record the current values as an event.
+// a.mu must be held.
+func (a *Adjuster) record() {
+	now := time.Now()
+	a.events = append(a.events, Operation{
+		OpType: AdjustOp,
+		Start:  now,
+		End:    now,
+		Headers: map[string]string{
+			AdjustConcurrency: strconv.Itoa(a.active),
+			AdjustRPS:         strconv.FormatFloat(a.rps, 'f', -1, 64),
+		},
+	})
+}
+
+// wake threads waiting for a slot.
+// a.mu must be held.
+func (a *Adjuster) wake() {
+	close(a.changed)
+	a.changed = make(chan struct{})
+}
+
+// wait releases the slot held by the calling thread and waits until a slot is available.
+func (a *Adjuster) wait(ctx context.Context) error {
+	a.mu.Lock()
+	if !a.armed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.held--
+	for a.held >= a.active {
+		changed := a.changed
+		a.mu.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		a.mu.Lock()
+	}
+	a.held++
+	a.mu.Unlock()
+	return nil
+}
+
+// Events returns the recorded events and clears them.
+func (a *Adjuster) Events() Operations {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	ev := a.events
+	a.events = nil
+	return ev
+}
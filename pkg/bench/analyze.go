@@ -32,6 +32,9 @@ type SegmentOptions struct {
 	PerSegDuration time.Duration
 	AllThreads     bool
 	MultiOp        bool
+	// Align starts segments at multiples of PerSegDuration in wall clock time,
+	// for example every minute on the minute.
+	Align bool
 }
 
 // A Segment represents totals of operations in a specific time segment
@@ -50,6 +53,9 @@ type Segment struct {
 	ReqAvg     float64   `json:"req_avg_ms"` // Average duration of operations ending in segment.
 	TotalBytes int64     `json:"total_bytes"`
 	ObjsPerOp  int       `json:"objects_per_op"`
+	// Clients with operations in the segment.
+	// Only set when operations are from more than one client.
+	Clients int `json:"clients,omitempty"`
 }
 
 // TTFB contains time to first byte stats.
@@ -165,6 +171,11 @@ func (o Operations) Segment(so SegmentOptions) Segments {
 	if start.After(so.From) {
 		so.From = start
 	}
+	if so.Align {
+		if t := so.From.Truncate(so.PerSegDuration); t.Before(so.From) {
+			so.From = t.Add(so.PerSegDuration)
+		}
+	}
 	var segments []Segment
 	segStart := so.From
 	host := ""
@@ -172,6 +183,10 @@ func (o Operations) Segment(so SegmentOptions) Segments {
 		host = e[0]
 	}
 	ops := o
+	var clients map[string]struct{}
+	if o.multipleClients() {
+		clients = make(map[string]struct{})
+	}
 	for segStart.Before(end.Add(-so.PerSegDuration)) {
 		s := Segment{
 			OpType:     o.FirstOpType(),
@@ -205,6 +220,18 @@ func (o Operations) Segment(so SegmentOptions) Segments {
 			}
 		}
 		ops = ops[first:]
+		if clients != nil {
+			clear(clients)
+			for _, op := range ops {
+				if !op.Start.Before(s.EndsBefore) {
+					break
+				}
+				if op.End.After(s.Start) {
+					clients[op.ClientID] = struct{}{}
+				}
+			}
+			s.Clients = len(clients)
+		}
 		if s.OpsEnded > 0 {
 			s.ReqAvg /= float64(s.OpsEnded)
 		}
@@ -214,6 +241,16 @@ func (o Operations) Segment(so SegmentOptions) Segments {
 	return segments
 }
 
+// multipleClients returns whether operations are from more than one client.
+func (o Operations) multipleClients() bool {
+	for i := range o {
+		if o[i].ClientID != o[0].ClientID {
+			return true
+		}
+	}
+	return false
+}
+
 // SpeedPerSec returns mb/s for the segment and the ops ended per second.
 func (s Segment) SpeedPerSec() (mib, ops, objs float64) {
 	scale := float64(s.EndsBefore.Sub(s.Start)) / float64(time.Second)
@@ -256,6 +293,7 @@ func (s Segments) CSV(w io.Writer) error {
 		"reqs_ended_avg_ms",
 		"start_time",
 		"end_time",
+		"clients",
 	})
 	if err != nil {
 		return err
@@ -291,6 +329,7 @@ func (s Segment) CSV(w *csv.Writer, idx int) error {
 		fmt.Sprint(s.ReqAvg),
 		fmt.Sprint(s.Start),
 		fmt.Sprint(s.EndsBefore),
+		fmt.Sprint(s.Clients),
 	})
 }
 
@@ -306,8 +345,12 @@ func (s Segment) String() string {
 	if mib > 0 {
 		speed = fmt.Sprintf("%.02f MiB/s, ", mib)
 	}
-	return fmt.Sprintf("%s%.02f obj/s (%v, starting %v)",
-		speed, objs, s.EndsBefore.Sub(s.Start).Round(time.Millisecond), s.Start.Format("15:04:05 MST"))
+	clients := ""
+	if s.Clients > 0 {
+		clients = fmt.Sprintf(", %d clients", s.Clients)
+	}
+	return fmt.Sprintf("%s%.02f obj/s (%v, starting %v%s)",
+		speed, objs, s.EndsBefore.Sub(s.Start).Round(time.Millisecond), s.Start.Format("15:04:05 MST"), clients)
 }
 
 // ShortString returns a string representation of the segment without ops ended/s.
@@ -20,6 +20,7 @@ package bench
 import (
 	"bytes"
 	"os"
+	"reflect"
 	"testing"
 	"time"
 
@@ -70,3 +71,122 @@ func TestOperations_Segment(t *testing.T) {
 		t.Log(buf.String())
 	}
 }
+
+func TestOperations_CorrectOmission(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// One thread at 10 requests/s. The second request stalls for 1s.
+	durs := []time.Duration{10, 1000, 10, 10}
+	var ops Operations
+	t0 := start
+	for _, d := range durs {
+		ops = append(ops, Operation{OpType: "GET", Start: t0, End: t0.Add(d * time.Millisecond)})
+		t0 = t0.Add(max(d*time.Millisecond, 100*time.Millisecond))
+	}
+	got := ops.CorrectOmission(10)
+	want := []time.Duration{10, 1000, 910, 910}
+	for i, op := range got {
+		if op.Duration() != want[i]*time.Millisecond {
+			t.Errorf("op %d: got duration %v, want %v", i, op.Duration(), want[i]*time.Millisecond)
+		}
+	}
+	if ops[2].Duration() != 10*time.Millisecond {
+		t.Errorf("input was modified")
+	}
+}
+
+func TestOperationsFromCSV(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+	firstByte := start.Add(time.Millisecond)
+	end := start.Add(time.Second)
+	reused := true
+	full := Operation{
+		Start:         start,
+		End:           end,
+		FirstByte:     &firstByte,
+		OpType:        "GET",
+		Err:           "some error",
+		File:          "prefix/obj",
+		ClientID:      "client-1",
+		Endpoint:      "127.0.0.1:9000",
+		RequestID:     "17A1B2C3D4E5F6",
+		Headers:       map[string]string{"X-Amz-Id-2": "abc"},
+		Phases:        &Phases{DNS: 1, Connect: 2, TLS: 3, Send: 4, TTFB: 5, Transfer: 6, Continue: 7},
+		ObjPerOp:      2,
+		Size:          1024,
+		Thread:        3,
+		ConnReused:    &reused,
+		Addressing:    "path",
+		IPFamily:      "IPv6",
+		OpID:          "op-1",
+		WireSent:      100,
+		WireRecv:      2000,
+		Stalls:        1,
+		StallTime:     time.Millisecond,
+		Throttled:     2,
+		RetryAfter:    time.Second,
+		RetryEarly:    1,
+		ThrottledTime: 500 * time.Millisecond,
+		Region:        "us-east-1",
+	}
+	var written bytes.Buffer
+	if err := (Operations{full}).CSV(&written, "comment"); err != nil {
+		t.Fatal(err)
+	}
+	const ts = "2024-01-02T03:04:05.000000006Z"
+	const te = "2024-01-02T03:04:06.000000006Z"
+
+	tests := []struct {
+		name    string
+		in      string
+		want    Operations
+		wantErr bool
+	}{
+		{
+			name: "all-fields",
+			in:   written.String(),
+			want: Operations{full},
+		},
+		{
+			name: "schema-1",
+			in: "idx\tthread\top\tclient_id\tn_objects\tbytes\tendpoint\tfile\terror\tstart\tfirst_byte\tend\tduration_ns\n" +
+				"0\t1\tPUT\tclient-1\t1\t10\t127.0.0.1:9000\tobj\t\t" + ts + "\t\t" + te + "\t1000000000\n",
+			want: Operations{{
+				Start: start, End: end, OpType: "PUT", File: "obj", ClientID: "client-1",
+				Endpoint: "127.0.0.1:9000", ObjPerOp: 1, Size: 10, Thread: 1,
+			}},
+		},
+		{
+			name: "empty-optional",
+			in: csvVersion + csvHeader +
+				"0\t1\tPUT\t\t1\t10\t\tobj\t\t" + ts + "\t\t" + te + "\t1000000000\t\t\t\t\t\t\t\t\t\t\t\t\t\t\t\t\n",
+			want: Operations{{
+				Start: start, End: end, OpType: "PUT", File: "obj", ObjPerOp: 1, Size: 10, Thread: 1,
+			}},
+		},
+		{
+			name: "missing-required",
+			in: "idx\tthread\top\tn_objects\tbytes\tfile\terror\tend\n" +
+				"0\t1\tPUT\t1\t10\tobj\t\t" + te + "\n",
+			wantErr: true,
+		},
+		{
+			name:    "newer-schema",
+			in:      csvVersionPrefix + "999\n" + csvHeader,
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := OperationsFromCSV(bytes.NewBufferString(test.in), false, 0, 0, nil)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want error: %v", err, test.wantErr)
+			}
+			if test.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got  %+v\nwant %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync/atomic"
+
+	"golang.org/x/time/rate"
+)
+
+// bandwidthBurst is the maximum number of bytes transferred without waiting.
+const bandwidthBurst = 64 << 10
+
+// BandwidthLimiter limits the number of bytes per second sent and received
+// by requests made through the transports it wraps.
+type BandwidthLimiter struct {
+	lim   *rate.Limiter
+	bytes atomic.Int64
+}
+
+// NewBandwidthLimiter returns a limiter allowing bytesPerSec bytes per second.
+func NewBandwidthLimiter(bytesPerSec float64) *BandwidthLimiter {
+	return &BandwidthLimiter{lim: rate.NewLimiter(rate.Limit(bytesPerSec), bandwidthBurst)}
+}
+
+// SetLimit changes the number of bytes allowed per second.
+func (b *BandwidthLimiter) SetLimit(bytesPerSec float64) {
+	b.lim.SetLimit(rate.Limit(bytesPerSec))
+}
+
+// Bytes returns the number of bytes transferred so far.
+func (b *BandwidthLimiter) Bytes() int64 {
+	return b.bytes.Load()
+}
+
+// Wrap returns a transport limiting the request and response bodies of rt.
+// If b is nil, rt is returned.
+func (b *BandwidthLimiter) Wrap(rt http.RoundTripper) http.RoundTripper {
+	if b == nil {
+		return rt
+	}
+	return bandwidthTransport{rt: rt, b: b}
+}
+
+type bandwidthTransport struct {
+	rt http.RoundTripper
+	b  *BandwidthLimiter
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t bandwidthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil && req.Body != http.NoBody {
+		req = req.Clone(req.Context())
+		req.Body = &bandwidthReader{ReadCloser: req.Body, b: t.b, ctx: req.Context()}
+	}
+	resp, err := t.rt.RoundTrip(req)
+	if err != nil || resp.Body == nil {
+		return resp, err
+	}
+	resp.Body = &bandwidthReader{ReadCloser: resp.Body, b: t.b, ctx: req.Context()}
+	return resp, nil
+}
+
+// bandwidthReader waits for the limiter after each read.
+type bandwidthReader struct {
+	io.ReadCloser
+	b   *BandwidthLimiter
+	ctx context.Context
+}
+
+func (r *bandwidthReader) Read(p []byte) (int, error) {
+	if len(p) > bandwidthBurst {
+		p = p[:bandwidthBurst]
+	}
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.b.bytes.Add(int64(n))
+		if werr := r.b.lim.WaitN(r.ctx, n); werr != nil && err == nil {
+			err = werr
+		}
+	}
+	return n, err
+}
@@ -22,8 +22,11 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"math/rand"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/minio/minio-go/v7"
@@ -91,6 +94,9 @@ type Common struct {
 	// Clear bucket before benchmark
 	Clear bool
 
+	// Quiet disables progress output.
+	Quiet bool
+
 	// DiscardOutput output.
 	DiscardOutput bool // indicates if we prefer a terse output useful in lengthy runs
 
@@ -100,8 +106,74 @@ type Common struct {
 	// ratelimiting
 	RpsLimiter *rate.Limiter
 
+	// RpsRequests counts requests allowed by RpsLimiter, if set.
+	RpsRequests *atomic.Int64
+
+	// BwLimiter limits the bandwidth of Transport and Client, if set.
+	BwLimiter *BandwidthLimiter
+
+	// RpsJitter adds a random delay up to this duration to each rate limited request.
+	RpsJitter time.Duration
+
+	// StartJitter delays the start of each thread by a random duration up to this value.
+	StartJitter time.Duration
+
 	// Transport used.
 	Transport http.RoundTripper
+
+	// RecordHeaders contains response headers to record with each operation.
+	// Responses are recorded for benchmark operations, but not while preparing.
+	RecordHeaders []string
+
+	// OpSample will only record 1 in OpSample operations of the benchmark, if above 1.
+	// Exact results of all operations are recorded as OpSampleOp events.
+	OpSample int
+
+	// Shadow will mirror downloads to a secondary endpoint, if set.
+	Shadow *Shadow
+
+	// Health checks endpoints and records failover events, if set.
+	Health *HealthChecker
+
+	// PrepareStrategy selects how objects are created when preparing.
+	PrepareStrategy PrepareStrategy
+
+	// PrepareConcurrency is the number of threads uploading objects when preparing.
+	// Concurrency is used if 0.
+	PrepareConcurrency int
+
+	// PrepareSeeds is the number of objects uploaded per thread
+	// before copying when using PrepareStrategyCopy.
+	PrepareSeeds int
+
+	// PrepareBatch is the maximum number of objects in each upload
+	// when using PrepareStrategySnowball.
+	PrepareBatch int
+
+	// CleanupConcurrency is the number of concurrent delete requests when clearing objects.
+	// If 0, objects are deleted in a single stream unless CleanupBatch or CleanupRate is set.
+	CleanupConcurrency int
+
+	// CleanupBatch is the number of objects deleted in each request when clearing objects.
+	CleanupBatch int
+
+	// SpoolDir, if set, makes operations be written to files per thread
+	// in a directory inside SpoolDir while running.
+	SpoolDir string
+
+	// CleanupRate is the maximum number of objects deleted per second when clearing objects.
+	// 0 means unlimited.
+	CleanupRate float64
+
+	// Adjust allows changing the concurrency and request rate while running, if set.
+	// The request rate is changed on RpsLimiter.
+	Adjust *Adjuster
+
+	// pacing records the accuracy of RpsLimiter.
+	pacing *pacing
+
+	// TCPStats samples the connections of Transport, if set.
+	TCPStats *TCPStats
 }
 
 const (
@@ -189,23 +261,26 @@ func (c *Common) createEmptyBucket(ctx context.Context) error {
 
 // deleteAllInBucket will delete all content in a bucket.
 // If no prefixes are specified everything in bucket is deleted.
+// If the bucket has versioning enabled or suspended, all versions and delete markers are deleted.
 func (c *Common) deleteAllInBucket(ctx context.Context, prefixes ...string) {
 	if len(prefixes) == 0 {
 		prefixes = []string{""}
 	}
 
-	doneCh := make(chan struct{})
-	defer close(doneCh)
-
 	cl, done := c.Client()
 	defer done()
 
-	objectsCh := make(chan minio.ObjectInfo)
+	versioned := c.Versioned
+	if bvc, err := cl.GetBucketVersioning(ctx, c.Bucket); err == nil && bvc.Status != "" {
+		versioned = true
+	}
+
+	objectsCh := make(chan minio.ObjectInfo, 1000)
 	go func() {
 		defer close(objectsCh)
 		opts := minio.ListObjectsOptions{
 			Recursive:    true,
-			WithVersions: c.Versioned,
+			WithVersions: versioned,
 		}
 		for _, prefix := range prefixes {
 			opts.Prefix = prefix
@@ -219,8 +294,10 @@ func (c *Common) deleteAllInBucket(ctx context.Context, prefixes ...string) {
 				}
 				objectsCh <- object
 			}
-			console.Eraseline()
-			console.Infof("\rClearing Prefix %q...", strings.Join([]string{c.Bucket, opts.Prefix}, "/"))
+			if !c.Quiet {
+				console.Eraseline()
+				console.Infof("\rClearing Prefix %q...", strings.Join([]string{c.Bucket, opts.Prefix}, "/"))
+			}
 		}
 	}()
 
@@ -230,15 +307,113 @@ func (c *Common) deleteAllInBucket(ctx context.Context, prefixes ...string) {
 		delOpts.GovernanceBypass = true
 	}
 
-	errCh := cl.RemoveObjects(ctx, c.Bucket, objectsCh, delOpts)
-	for err := range errCh {
-		if err.Err != nil {
-			c.Error(err.Err)
-			continue
+	var deleted atomic.Int64
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		if c.CleanupConcurrency > 0 || c.CleanupRate > 0 || (c.CleanupBatch > 0 && c.CleanupBatch < 1000) {
+			c.deleteObjectsParallel(ctx, objectsCh, delOpts, &deleted)
+			return
+		}
+		for err := range cl.RemoveObjects(ctx, c.Bucket, objectsCh, delOpts) {
+			if err.Err != nil {
+				c.Error(err.Err)
+				continue
+			}
+			deleted.Add(1)
+		}
+	}()
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+	for {
+		select {
+		case <-finished:
+			return
+		case <-t.C:
+			if c.Quiet {
+				continue
+			}
+			console.Eraseline()
+			console.Infof("\rClearing Bucket %q, %d objects deleted...", c.Bucket, deleted.Load())
 		}
 	}
 }
 
+// deleteObjectsParallel deletes the objects from objectsCh in batches
+// using the requested cleanup concurrency, batch size and rate.
+func (c *Common) deleteObjectsParallel(ctx context.Context, objectsCh <-chan minio.ObjectInfo, delOpts minio.RemoveObjectsOptions, deleted *atomic.Int64) {
+	concurrency := c.CleanupConcurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	batchSize := c.CleanupBatch
+	if batchSize <= 0 || batchSize > 1000 {
+		batchSize = 1000
+	}
+
+	var limiter *rate.Limiter
+	if c.CleanupRate > 0 {
+		limiter = rate.NewLimiter(rate.Limit(c.CleanupRate), batchSize)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			cl, done := c.Client()
+			defer done()
+			batch := make([]minio.ObjectInfo, 0, batchSize)
+			remove := func() {
+				if len(batch) == 0 {
+					return
+				}
+				if limiter != nil {
+					if err := limiter.WaitN(ctx, len(batch)); err != nil {
+						batch = batch[:0]
+						return
+					}
+				}
+				ch := make(chan minio.ObjectInfo, len(batch))
+				for _, obj := range batch {
+					ch <- obj
+				}
+				close(ch)
+				n := int64(len(batch))
+				for err := range cl.RemoveObjects(ctx, c.Bucket, ch, delOpts) {
+					if err.Err != nil {
+						c.Error(err.Err)
+						n--
+					}
+				}
+				deleted.Add(n)
+				batch = batch[:0]
+			}
+			for obj := range objectsCh {
+				batch = append(batch, obj)
+				if len(batch) >= batchSize {
+					remove()
+				}
+			}
+			remove()
+		}()
+	}
+	wg.Wait()
+}
+
+// RemoveBucket will remove the benchmark bucket.
+// The bucket must be empty.
+func (c *Common) RemoveBucket(ctx context.Context) error {
+	cl, done := c.Client()
+	defer done()
+	err := cl.RemoveBucket(ctx, c.Bucket)
+	if minio.ToErrorResponse(err).Code == "NoSuchBucket" {
+		// Removed by another client.
+		return nil
+	}
+	return err
+}
+
 // prepareProgress updates preparation progess with the value 0->1.
 func (c *Common) prepareProgress(progress float64) {
 	if c.PrepareProgress == nil {
@@ -252,20 +427,95 @@ func (c *Common) prepareProgress(progress float64) {
 }
 
 func (c *Common) addCollector() {
-	if c.DiscardOutput {
+	switch {
+	case c.DiscardOutput:
 		c.Collector = NewNullCollector()
-	} else {
+	case c.SpoolDir != "":
+		col, err := NewSpoolCollector(c.SpoolDir)
+		if err != nil {
+			c.Error(fmt.Errorf("unable to create operation files: %w", err))
+			col = NewCollector()
+		} else if !c.Quiet {
+			console.Infoln("Writing operations to", col.SpoolDir())
+		}
+		c.Collector = col
+	default:
 		c.Collector = NewCollector()
 	}
 	c.Collector.extra = c.ExtraOut
+	c.Collector.health = c.Health
+	c.Collector.adjust = c.Adjust
+	if c.pacing == nil && c.RpsLimiter != nil {
+		c.pacing = newPacing(c.RpsLimiter)
+	}
+	c.Collector.pacing = c.pacing
+	c.Collector.tcpStats = c.TCPStats
+	if c.OpSample > 1 && !c.DiscardOutput {
+		c.Collector.sampler = newOpSampler(c.OpSample)
+	}
 }
 
 func (c *Common) rpsLimit(ctx context.Context) error {
+	if c.Adjust != nil {
+		if err := c.Adjust.wait(ctx); err != nil {
+			return err
+		}
+	}
 	if c.RpsLimiter == nil {
 		return nil
 	}
 
-	return c.RpsLimiter.Wait(ctx)
+	r := c.RpsLimiter.Reserve()
+	if !r.OK() {
+		return errors.New("request rate limit cannot be reached")
+	}
+	delay := r.Delay()
+	intended := time.Now().Add(delay)
+	if delay > 0 {
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			r.Cancel()
+			return ctx.Err()
+		}
+	}
+	c.pacing.add(intended, delay > 0)
+	if c.RpsRequests != nil {
+		c.RpsRequests.Add(1)
+	}
+	if c.RpsJitter <= 0 {
+		return nil
+	}
+	t := time.NewTimer(time.Duration(rand.Int63n(int64(c.RpsJitter))))
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// startWait waits for the start channel to be closed.
+// If StartJitter is set, the thread is delayed by an additional random duration.
+func (c *Common) startWait(wait <-chan struct{}) {
+	<-wait
+	if c.Collector != nil {
+		c.Collector.sampler.start(time.Now())
+	}
+	if c.StartJitter > 0 {
+		time.Sleep(time.Duration(rand.Int63n(int64(c.StartJitter))))
+	}
+}
+
+// prepareThreads returns the number of threads uploading objects when preparing.
+func (c *Common) prepareThreads() int {
+	if c.PrepareConcurrency > 0 {
+		return c.PrepareConcurrency
+	}
+	return c.Concurrency
 }
 
 func splitObjs(objects, concurrency int) [][]struct{} {
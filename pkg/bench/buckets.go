@@ -0,0 +1,240 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/pkg/v2/console"
+)
+
+// Operation types of the buckets benchmark.
+const (
+	BucketsListOp = "LISTBUCKETS"
+	BucketsHeadOp = "HEADBUCKET"
+)
+
+// Buckets benchmarks listing and checking buckets when many buckets exist.
+type Buckets struct {
+	Common
+
+	// CreateBuckets is the number of buckets created by each client before the benchmark.
+	CreateBuckets int
+
+	// ListFraction is the fraction of requests, 0 to 1, listing all buckets.
+	// The remaining requests check a random bucket with HeadBucket.
+	ListFraction float64
+
+	prefix  string
+	buckets []string
+}
+
+// BucketsPrefix returns the prefix of buckets created by a client.
+// A random part is added, so buckets of earlier runs are not reused.
+func BucketsPrefix(bucket string, clientIdx int) string {
+	return fmt.Sprintf("%s-%s-%d-", bucket, iamRandString(4), clientIdx)
+}
+
+// Prepare creates the buckets.
+func (g *Buckets) Prepare(ctx context.Context) error {
+	g.prefix = BucketsPrefix(g.Bucket, g.ClientIdx)
+	names := make([]string, g.CreateBuckets)
+	for i := range names {
+		names[i] = fmt.Sprintf("%s%d", g.prefix, i)
+	}
+	console.Eraseline()
+	console.Info("\rCreating ", len(names), " buckets")
+	g.addCollector()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var groupErr error
+	var next atomic.Int64
+	threads := g.prepareThreads()
+	for i := 0; i < threads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				j := int(next.Add(1) - 1)
+				if j >= len(names) || ctx.Err() != nil {
+					return
+				}
+				if g.rpsLimit(ctx) != nil {
+					return
+				}
+				cl, done := g.Client()
+				err := cl.MakeBucket(ctx, names[j], minio.MakeBucketOptions{Region: g.Location})
+				done()
+				mu.Lock()
+				if err != nil {
+					groupErr = errors.Join(groupErr, fmt.Errorf("creating bucket %s: %w", names[j], err))
+					mu.Unlock()
+					return
+				}
+				g.buckets = append(g.buckets, names[j])
+				g.prepareProgress(float64(len(g.buckets)) / float64(len(names)))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return groupErr
+}
+
+// Start will execute the main benchmark.
+// Operations should begin executing when the start channel is closed.
+func (g *Buckets) Start(ctx context.Context, wait chan struct{}) (Operations, error) {
+	var wg sync.WaitGroup
+	wg.Add(g.Concurrency)
+	c := g.Collector
+	if g.AutoTermDur > 0 {
+		ctx = c.AutoTerm(ctx, BucketsHeadOp, g.AutoTermScale, autoTermCheck, autoTermSamples, g.AutoTermDur)
+	}
+	// Non-terminating context.
+	nonTerm := context.Background()
+
+	for i := 0; i < g.Concurrency; i++ {
+		go func(i int) {
+			rcv := c.Receiver()
+			defer wg.Done()
+			done := ctx.Done()
+			rng := rand.New(rand.NewSource(int64(rand.Uint64())))
+
+			g.startWait(wait)
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if g.rpsLimit(ctx) != nil {
+					return
+				}
+				if rng.Float64() < g.ListFraction {
+					rcv <- g.listBuckets(nonTerm, i)
+					continue
+				}
+				rcv <- g.headBucket(nonTerm, i, g.buckets[rng.Intn(len(g.buckets))])
+			}
+		}(i)
+	}
+	wg.Wait()
+	return c.Close(), nil
+}
+
+// listBuckets lists all buckets and checks that the buckets of this client are included.
+// The number of buckets returned is recorded as the objects of the operation.
+func (g *Buckets) listBuckets(ctx context.Context, thread int) Operation {
+	client, cldone := g.Client()
+	defer cldone()
+	op := Operation{
+		OpType:   BucketsListOp,
+		Thread:   uint16(thread),
+		Endpoint: client.EndpointURL().String(),
+	}
+	opCtx, resp := recordResponse(ctx)
+	op.Start = time.Now()
+	buckets, err := client.ListBuckets(opCtx)
+	op.End = time.Now()
+	resp.apply(&op, g.RecordHeaders)
+	if err != nil {
+		g.Error("list buckets error: ", err)
+		op.Err = err.Error()
+		return op
+	}
+	op.ObjPerOp = len(buckets)
+	var found int
+	for _, b := range buckets {
+		if strings.HasPrefix(b.Name, g.prefix) {
+			found++
+		}
+	}
+	if found != len(g.buckets) {
+		op.Err = fmt.Sprintf("listed %d of %d buckets", found, len(g.buckets))
+		g.Error(op.Err)
+	}
+	return op
+}
+
+// headBucket checks that a bucket exists.
+func (g *Buckets) headBucket(ctx context.Context, thread int, bucket string) Operation {
+	client, cldone := g.Client()
+	defer cldone()
+	op := Operation{
+		OpType:   BucketsHeadOp,
+		Thread:   uint16(thread),
+		File:     bucket,
+		ObjPerOp: 1,
+		Endpoint: client.EndpointURL().String(),
+	}
+	opCtx, resp := recordResponse(ctx)
+	op.Start = time.Now()
+	found, err := client.BucketExists(opCtx, bucket)
+	op.End = time.Now()
+	resp.apply(&op, g.RecordHeaders)
+	switch {
+	case err != nil:
+		g.Error("head bucket error: ", err)
+		op.Err = err.Error()
+	case !found:
+		op.Err = "bucket not found: " + bucket
+		g.Error(op.Err)
+	}
+	return op
+}
+
+// Cleanup removes the buckets.
+func (g *Buckets) Cleanup(ctx context.Context) {
+	console.Eraseline()
+	console.Info("\rRemoving ", len(g.buckets), " buckets")
+	var wg sync.WaitGroup
+	var next atomic.Int64
+	concurrency := g.CleanupConcurrency
+	if concurrency <= 0 {
+		concurrency = g.Concurrency
+	}
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				j := int(next.Add(1) - 1)
+				if j >= len(g.buckets) {
+					return
+				}
+				cl, done := g.Client()
+				err := cl.RemoveBucket(ctx, g.buckets[j])
+				done()
+				if err != nil {
+					g.Error("cleanup error: ", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	g.buckets = nil
+}
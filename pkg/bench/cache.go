@@ -0,0 +1,281 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/generator"
+)
+
+// Operation types of the cache benchmark.
+const (
+	// CacheColdOp is the first read of an object.
+	CacheColdOp = http.MethodGet + "-COLD"
+	// CacheWarmOp is a read of an object that has been read before.
+	CacheWarmOp = http.MethodGet + "-WARM"
+)
+
+// Cache benchmarks reads of objects that have not been read before
+// compared to reads of the same objects after they have been read.
+// The cold pass reads each object exactly once in random order.
+// The warm passes then read all objects again, each in a new random order.
+type Cache struct {
+	Common
+	objects generator.Objects
+
+	CreateObjects int
+	GetOpts       minio.GetObjectOptions
+
+	// WarmPasses is the number of times all objects are read after the cold pass.
+	WarmPasses int
+
+	// WarmDelay is the time to wait between the cold pass and the first warm pass.
+	WarmDelay time.Duration
+}
+
+// Prepare will create an empty bucket or delete any content already there
+// and upload a number of objects.
+func (g *Cache) Prepare(ctx context.Context) error {
+	if err := g.createEmptyBucket(ctx); err != nil {
+		return err
+	}
+	src := g.Source()
+	console.Eraseline()
+	console.Info("\rUploading ", g.CreateObjects, " objects of ", src.String())
+
+	var wg sync.WaitGroup
+	wg.Add(g.prepareThreads())
+	g.addCollector()
+	objs := splitObjs(g.CreateObjects, g.prepareThreads())
+	rcv := g.Collector.rcv
+	var groupErr error
+	var mu sync.Mutex
+
+	for i, obj := range objs {
+		go func(i int, obj []struct{}) {
+			defer wg.Done()
+			src := g.Source()
+			opts := g.PutOpts
+			prep := g.preparer(i, rcv)
+
+			for range obj {
+				select {
+				case <-ctx.Done():
+					return
+				default:
+				}
+
+				if g.rpsLimit(ctx) != nil {
+					return
+				}
+
+				obj := src.Object()
+				client, cldone := g.Client()
+				op := Operation{
+					OpType:   http.MethodPut,
+					Thread:   uint16(i),
+					Size:     obj.Size,
+					File:     obj.Name,
+					ObjPerOp: 1,
+					Endpoint: client.EndpointURL().String(),
+				}
+
+				opts.ContentType = obj.ContentType
+				op.Start = time.Now()
+				res, queued, err := prep.upload(ctx, client, &op, obj, opts)
+				op.End = time.Now()
+				cldone()
+				if err == nil && res.Size != obj.Size {
+					err = fmt.Errorf("short upload. want: %d, got %d", obj.Size, res.Size)
+				}
+				if err != nil {
+					err := fmt.Errorf("upload error: %w", err)
+					g.Error(err)
+					mu.Lock()
+					if groupErr == nil {
+						groupErr = err
+					}
+					mu.Unlock()
+					return
+				}
+				mu.Lock()
+				obj.Reader = nil
+				g.objects = append(g.objects, *obj)
+				g.prepareProgress(float64(len(g.objects)) / float64(g.CreateObjects))
+				mu.Unlock()
+				if !queued {
+					rcv <- op
+				}
+			}
+			client, cldone := g.Client()
+			err := prep.flush(ctx, client)
+			cldone()
+			if err != nil {
+				g.Error(err)
+				mu.Lock()
+				if groupErr == nil {
+					groupErr = err
+				}
+				mu.Unlock()
+			}
+		}(i, obj)
+	}
+	wg.Wait()
+	return groupErr
+}
+
+// Start will execute the main benchmark.
+// Operations should begin executing when the start channel is closed.
+// The benchmark ends when all passes are done or ctx is canceled.
+func (g *Cache) Start(ctx context.Context, wait chan struct{}) (Operations, error) {
+	c := g.Collector
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	for pass := 0; pass <= g.WarmPasses; pass++ {
+		opType := CacheColdOp
+		if pass > 0 {
+			opType = CacheWarmOp
+			if pass == 1 && g.WarmDelay > 0 {
+				select {
+				case <-ctx.Done():
+				case <-time.After(g.WarmDelay):
+				}
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		console.Eraseline()
+		console.Infof("\rReading %d objects, pass %d of %d (%s)", len(g.objects), pass+1, g.WarmPasses+1, opType)
+		g.readAll(ctx, opType, rng.Perm(len(g.objects)), wait, pass == 0)
+	}
+	ops := c.Close()
+	g.printComparison(ops)
+	return ops, nil
+}
+
+// readAll reads the objects in the order given by perm, each exactly once.
+func (g *Cache) readAll(ctx context.Context, opType string, perm []int, wait chan struct{}, first bool) {
+	var wg sync.WaitGroup
+	wg.Add(g.Concurrency)
+	rcv := g.Collector.Receiver()
+	var next atomic.Int64
+	// Non-terminating context.
+	nonTerm := context.Background()
+
+	for i := 0; i < g.Concurrency; i++ {
+		go func(i int) {
+			defer wg.Done()
+			done := ctx.Done()
+			if first {
+				g.startWait(wait)
+			}
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				idx := next.Add(1) - 1
+				if idx >= int64(len(perm)) {
+					return
+				}
+
+				if g.rpsLimit(ctx) != nil {
+					return
+				}
+
+				obj := g.objects[perm[idx]]
+				client, cldone := g.Client()
+				op := Operation{
+					OpType:   opType,
+					Thread:   uint16(i),
+					Size:     obj.Size,
+					File:     obj.Name,
+					ObjPerOp: 1,
+					Endpoint: client.EndpointURL().String(),
+				}
+				if g.DiscardOutput {
+					op.File = ""
+				}
+
+				fbr := firstByteRecorder{}
+				op.Start = time.Now()
+				opCtx, resp := recordResponse(nonTerm)
+				o, err := client.GetObject(opCtx, g.Bucket, obj.Name, g.GetOpts)
+				if err != nil {
+					g.Error("download error:", err)
+					op.Err = err.Error()
+					op.End = time.Now()
+					resp.apply(&op, g.RecordHeaders)
+					rcv <- op
+					cldone()
+					continue
+				}
+				fbr.r = o
+				n, err := io.Copy(io.Discard, &fbr)
+				if err != nil {
+					g.Error("download error:", err)
+					op.Err = err.Error()
+				}
+				op.FirstByte = fbr.t
+				op.End = time.Now()
+				o.Close()
+				resp.apply(&op, g.RecordHeaders)
+				if n != op.Size && op.Err == "" {
+					op.Err = fmt.Sprint("unexpected download size. want:", op.Size, ", got:", n)
+					g.Error(op.Err)
+				}
+				rcv <- op
+				cldone()
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// printComparison prints the average request time and TTFB of warm reads relative to cold reads.
+func (g *Cache) printComparison(ops Operations) {
+	cold := ops.FilterByOp(CacheColdOp).FilterSuccessful()
+	warm := ops.FilterByOp(CacheWarmOp).FilterSuccessful()
+	if len(cold) == 0 || len(warm) == 0 {
+		return
+	}
+	coldDur, warmDur := cold.AvgDuration(), warm.AvgDuration()
+	if warmDur <= 0 {
+		return
+	}
+	console.Eraseline()
+	console.Infof("\rCold reads: %d, average %v. Warm reads: %d, average %v. Warm reads are %.02fx faster.\n",
+		len(cold), coldDur.Round(time.Microsecond), len(warm), warmDur.Round(time.Microsecond), float64(coldDur)/float64(warmDur))
+}
+
+// Cleanup deletes everything uploaded to the bucket.
+func (g *Cache) Cleanup(ctx context.Context) {
+	g.deleteAllInBucket(ctx, g.objects.Prefixes()...)
+}
@@ -19,6 +19,8 @@ package bench
 
 import (
 	"context"
+	"errors"
+	"io"
 	"math"
 	"sync"
 	"time"
@@ -34,6 +36,27 @@ type Collector struct {
 	// The mutex protects the ops above.
 	// Once ops have been added, they should no longer be modified.
 	opsMu sync.Mutex
+	// discard operations, only forward them.
+	discard bool
+	// health events are added when closing.
+	health *HealthChecker
+	// adjust events are added when closing.
+	adjust *Adjuster
+	// pacing events are added when closing.
+	pacing *pacing
+	// tcpStats events are added when closing.
+	tcpStats *TCPStats
+	// spool writes operations to files instead of ops.
+	spool *opSpool
+	// spoolShared is the file of operations sent to rcv or added in batches when spooling.
+	// Protected by opsMu.
+	spoolShared *spoolFile
+	// spoolRcv are the receivers writing to their own file when spooling.
+	// Protected by opsMu.
+	spoolRcv []chan Operation
+	// sampler selects the operations to record, if set.
+	// Exact results are added as events when closing.
+	sampler *opSampler
 }
 
 func NewCollector() *Collector {
@@ -48,6 +71,9 @@ func NewCollector() *Collector {
 			for _, ch := range r.extra {
 				ch <- op
 			}
+			if !r.sampler.keep(op) {
+				continue
+			}
 			r.opsMu.Lock()
 			r.ops = append(r.ops, op)
 			r.opsMu.Unlock()
@@ -56,11 +82,69 @@ func NewCollector() *Collector {
 	return r
 }
 
+// NewSpoolCollector collects operations in files in a new directory inside dir.
+// Each receiver writes to its own file, so threads do not share a lock.
+// Operations are not returned by Close, but must be written using WriteSpoolCSV.
+// Operations are not available for AutoTerm.
+func NewSpoolCollector(dir string) (*Collector, error) {
+	spool, err := newOpSpool(dir)
+	if err != nil {
+		return nil, err
+	}
+	r := &Collector{
+		rcv:   make(chan Operation, 1000),
+		spool: spool,
+	}
+	r.spoolShared = spool.newFile()
+	r.rcvWg.Add(1)
+	go func() {
+		defer r.rcvWg.Done()
+		for op := range r.rcv {
+			for _, ch := range r.extra {
+				ch <- op
+			}
+			if !r.sampler.keep(op) {
+				continue
+			}
+			r.opsMu.Lock()
+			r.spoolShared.add(op)
+			r.opsMu.Unlock()
+		}
+	}()
+	return r, nil
+}
+
+// Spooled returns whether operations are written to files instead of being returned by Close.
+func (c *Collector) Spooled() bool {
+	return c != nil && c.spool != nil
+}
+
+// SpoolDir returns the directory operations are written to when spooling.
+func (c *Collector) SpoolDir() string {
+	if !c.Spooled() {
+		return ""
+	}
+	return c.spool.dir
+}
+
+// WriteSpoolCSV writes the spooled operations and extra to w as CSV, merged by start time.
+// Operations are streamed from the files and are not all kept in memory.
+// fn is called with each operation before it is written, if set.
+// The comment, if any, is written at the end, like Operations.CSV.
+// Must be called after Close. Files are deleted when written successfully, otherwise they are kept.
+func (c *Collector) WriteSpoolCSV(w io.Writer, extra Operations, comment string, fn func(op *Operation)) error {
+	if !c.Spooled() {
+		return errors.New("operations are not spooled")
+	}
+	return c.spool.writeCSV(w, extra, comment, fn)
+}
+
 // NewNullCollector collects operations, but discards them.
 func NewNullCollector() *Collector {
 	r := &Collector{
-		ops: make(Operations, 0),
-		rcv: make(chan Operation, 1000),
+		ops:     make(Operations, 0),
+		rcv:     make(chan Operation, 1000),
+		discard: true,
 	}
 	r.rcvWg.Add(1)
 	go func() {
@@ -150,15 +234,89 @@ func (c *Collector) AutoTerm(ctx context.Context, op string, threshold float64,
 	return ctx
 }
 
+// Receiver returns a channel operations can be sent to.
+// When spooling, each receiver writes to its own file.
 func (c *Collector) Receiver() chan<- Operation {
-	return c.rcv
+	if c.spool == nil {
+		return c.rcv
+	}
+	rcv := make(chan Operation, 1000)
+	t := c.spool.newFile()
+	c.opsMu.Lock()
+	c.spoolRcv = append(c.spoolRcv, rcv)
+	c.opsMu.Unlock()
+	c.rcvWg.Add(1)
+	go func() {
+		defer c.rcvWg.Done()
+		for op := range rcv {
+			for _, ch := range c.extra {
+				ch <- op
+			}
+			if c.sampler.keep(op) {
+				t.add(op)
+			}
+		}
+	}()
+	return rcv
+}
+
+// AddBatch adds several operations at once.
+// This has less overhead than sending each operation to the receiver.
+// Must not be called after Close.
+func (c *Collector) AddBatch(ops []Operation) {
+	if len(ops) == 0 {
+		return
+	}
+	for _, ch := range c.extra {
+		for _, op := range ops {
+			ch <- op
+		}
+	}
+	if c.discard {
+		return
+	}
+	if c.sampler != nil {
+		kept := make([]Operation, 0, len(ops))
+		for _, op := range ops {
+			if c.sampler.keep(op) {
+				kept = append(kept, op)
+			}
+		}
+		ops = kept
+	}
+	c.opsMu.Lock()
+	if c.spool != nil {
+		for _, op := range ops {
+			c.spoolShared.add(op)
+		}
+	} else {
+		c.ops = append(c.ops, ops...)
+	}
+	c.opsMu.Unlock()
 }
 
 func (c *Collector) Close() Operations {
 	close(c.rcv)
+	c.opsMu.Lock()
+	for _, rcv := range c.spoolRcv {
+		close(rcv)
+	}
+	c.opsMu.Unlock()
 	c.rcvWg.Wait()
 	for _, ch := range c.extra {
 		close(ch)
 	}
+	if c.spool != nil {
+		if err := c.spool.close(); err != nil {
+			console.Errorln("Unable to write operation files, files kept in", c.spool.dir+":", err)
+		}
+	}
+	if !c.discard {
+		c.ops = append(c.ops, c.health.Events()...)
+		c.ops = append(c.ops, c.adjust.Events()...)
+		c.ops = append(c.ops, c.pacing.Events()...)
+		c.ops = append(c.ops, c.tcpStats.Events()...)
+		c.ops = append(c.ops, c.sampler.Events()...)
+	}
 	return c.ops
 }
@@ -0,0 +1,101 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Dataset contains the number of live objects during a benchmark
+// where objects are added and removed.
+type Dataset struct {
+	initial int
+	// times and counts contain the number of objects after each change.
+	times  []time.Time
+	counts []int
+}
+
+// Dataset returns the number of live objects over time, starting with initial objects.
+// Successful PUT operations add objects and successful DELETE operations remove them when they complete.
+// Uploads to names already uploaded by an earlier operation and not deleted are not counted.
+func (o Operations) Dataset(initial int) *Dataset {
+	var changes Operations
+	for _, op := range o {
+		if op.Err == "" && (op.OpType == http.MethodPut || op.OpType == http.MethodDelete) {
+			changes = append(changes, op)
+		}
+	}
+	changes = changes.Clone()
+	changes.SortByEndTime()
+	d := Dataset{initial: initial}
+	live := make(map[string]struct{})
+	n := initial
+	for _, op := range changes {
+		objs := op.ObjPerOp
+		if objs < 1 {
+			objs = 1
+		}
+		switch op.OpType {
+		case http.MethodPut:
+			if _, ok := live[op.File]; ok {
+				continue
+			}
+			live[op.File] = struct{}{}
+			n += objs
+		case http.MethodDelete:
+			delete(live, op.File)
+			n = max(n-objs, 0)
+		}
+		d.times = append(d.times, op.End)
+		d.counts = append(d.counts, n)
+	}
+	return &d
+}
+
+// At returns the number of live objects at time t.
+func (d *Dataset) At(t time.Time) int {
+	i := sort.Search(len(d.times), func(i int) bool { return d.times[i].After(t) })
+	if i == 0 {
+		return d.initial
+	}
+	return d.counts[i-1]
+}
+
+// MinMax returns the smallest and biggest number of live objects.
+func (d *Dataset) MinMax() (lo, hi int) {
+	lo, hi = d.initial, d.initial
+	for _, n := range d.counts {
+		lo = min(lo, n)
+		hi = max(hi, n)
+	}
+	return lo, hi
+}
+
+// Changes returns whether objects were both added and removed.
+func (d *Dataset) Changes() bool {
+	var added, removed bool
+	prev := d.initial
+	for _, n := range d.counts {
+		added = added || n > prev
+		removed = removed || n < prev
+		prev = n
+	}
+	return added && removed
+}
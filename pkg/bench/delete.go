@@ -106,9 +106,9 @@ func (d *Delete) Prepare(ctx context.Context) error {
 	console.Eraseline()
 	console.Info("\rUploading ", d.CreateObjects, " objects of ", src.String())
 	var wg sync.WaitGroup
-	wg.Add(d.Concurrency)
+	wg.Add(d.prepareThreads())
 	d.addCollector()
-	objs := splitObjs(d.CreateObjects, d.Concurrency)
+	objs := splitObjs(d.CreateObjects, d.prepareThreads())
 
 	var mu sync.Mutex
 	for i, obj := range objs {
@@ -208,7 +208,7 @@ func (d *Delete) Start(ctx context.Context, wait chan struct{}) (Operations, err
 			defer wg.Done()
 			done := ctx.Done()
 
-			<-wait
+			d.startWait(wait)
 			for {
 				select {
 				case <-done:
@@ -253,9 +253,10 @@ func (d *Delete) Start(ctx context.Context, wait chan struct{}) (Operations, err
 					op.File = ""
 				}
 
+				opCtx, resp := recordResponse(nonTerm)
 				op.Start = time.Now()
 				// RemoveObjectsWithContext will split any batches > 1000 into separate requests.
-				errCh := client.RemoveObjects(nonTerm, d.Bucket, objects, minio.RemoveObjectsOptions{})
+				errCh := client.RemoveObjects(opCtx, d.Bucket, objects, minio.RemoveObjectsOptions{})
 
 				// Wait for errCh to close.
 				for {
@@ -269,6 +270,7 @@ func (d *Delete) Start(ctx context.Context, wait chan struct{}) (Operations, err
 					}
 				}
 				op.End = time.Now()
+				resp.apply(&op, d.RecordHeaders)
 				cldone()
 				rcv <- op
 			}
@@ -0,0 +1,338 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/generator"
+)
+
+// DeleteMarkers benchmarks reads and listings while delete markers accumulate.
+// Each thread operates on its own prefix.
+// Deletes are done without a version, so each delete adds a delete marker.
+// Reads are done on the uploaded versions, which remain available.
+type DeleteMarkers struct {
+	Common
+	objects []generator.Objects
+
+	GetOpts       minio.GetObjectOptions
+	CreateObjects int
+
+	// Distribution of DELETE, GET and LIST operations.
+	DeleteDist, GetDist, ListDist float64
+
+	// ListVersions will list all versions including delete markers.
+	// Otherwise only the latest versions are listed.
+	ListVersions bool
+
+	ops []string
+}
+
+// Prepare will create an empty versioned bucket or delete any content already there
+// and upload a number of objects.
+func (d *DeleteMarkers) Prepare(ctx context.Context) error {
+	if err := d.generateOps(); err != nil {
+		return err
+	}
+	if err := d.createEmptyBucket(ctx); err != nil {
+		return err
+	}
+	if !d.Versioned {
+		cl, done := d.Client()
+		err := cl.EnableVersioning(ctx, d.Bucket)
+		done()
+		if err != nil {
+			return err
+		}
+		d.Versioned = true
+	}
+
+	objPerPrefix := (d.CreateObjects + d.Concurrency - 1) / d.Concurrency
+	console.Eraseline()
+	console.Info("\rUploading ", objPerPrefix*d.Concurrency, " objects in ", d.Concurrency, " prefixes")
+	var wg sync.WaitGroup
+	wg.Add(d.Concurrency)
+	d.addCollector()
+	d.objects = make([]generator.Objects, d.Concurrency)
+	var mu sync.Mutex
+	objsCreated := 0
+	var groupErr error
+	for i := 0; i < d.Concurrency; i++ {
+		go func(i int) {
+			defer wg.Done()
+			src := d.Source()
+			opts := d.PutOpts
+			rcv := d.Collector.Receiver()
+			done := ctx.Done()
+			exists := make(map[string]struct{}, objPerPrefix)
+
+			for j := 0; j < objPerPrefix; j++ {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				if d.rpsLimit(ctx) != nil {
+					return
+				}
+
+				obj := src.Object()
+				// Assure we don't have duplicates
+				for {
+					if _, ok := exists[obj.Name]; ok {
+						obj = src.Object()
+						continue
+					}
+					break
+				}
+				exists[obj.Name] = struct{}{}
+				client, cldone := d.Client()
+				op := Operation{
+					OpType:   http.MethodPut,
+					Thread:   uint16(i),
+					Size:     obj.Size,
+					File:     obj.Name,
+					ObjPerOp: 1,
+					Endpoint: client.EndpointURL().String(),
+				}
+
+				opts.ContentType = obj.ContentType
+				op.Start = time.Now()
+				res, err := client.PutObject(ctx, d.Bucket, obj.Name, obj.Reader, obj.Size, opts)
+				op.End = time.Now()
+				cldone()
+				if err == nil && res.Size != obj.Size {
+					err = fmt.Errorf("short upload. want: %d, got %d", obj.Size, res.Size)
+				}
+				if err == nil && res.VersionID == "" {
+					err = errors.New("no version returned, versioning must be enabled on the bucket")
+				}
+				if err != nil {
+					err := fmt.Errorf("upload error: %w", err)
+					d.Error(err)
+					mu.Lock()
+					if groupErr == nil {
+						groupErr = err
+					}
+					mu.Unlock()
+					return
+				}
+				obj.VersionID = res.VersionID
+				mu.Lock()
+				obj.Reader = nil
+				d.objects[i] = append(d.objects[i], *obj)
+				objsCreated++
+				d.prepareProgress(float64(objsCreated) / float64(objPerPrefix*d.Concurrency))
+				mu.Unlock()
+				rcv <- op
+			}
+		}(i)
+	}
+	wg.Wait()
+	return groupErr
+}
+
+// generateOps will generate a shuffled list of operations matching the distribution.
+func (d *DeleteMarkers) generateOps() error {
+	dist := map[string]float64{
+		http.MethodDelete: d.DeleteDist,
+		http.MethodGet:    d.GetDist,
+		"LIST":            d.ListDist,
+	}
+	total := 0.0
+	for op, v := range dist {
+		if v < 0 {
+			return fmt.Errorf("negative distribution requested for op %q", op)
+		}
+		total += v
+	}
+	if total == 0 {
+		return errors.New("no distribution set, total is 0")
+	}
+	const genOps = 1000
+	d.ops = make([]string, 0, genOps)
+	for _, op := range []string{http.MethodDelete, http.MethodGet, "LIST"} {
+		add := int(0.5 + dist[op]/total*genOps)
+		for i := 0; i < add; i++ {
+			d.ops = append(d.ops, op)
+		}
+	}
+	rng := rand.New(rand.NewSource(0xabad1dea))
+	rng.Shuffle(len(d.ops), func(i, j int) {
+		d.ops[i], d.ops[j] = d.ops[j], d.ops[i]
+	})
+	return nil
+}
+
+// Start will execute the main benchmark.
+// Operations should begin executing when the start channel is closed.
+func (d *DeleteMarkers) Start(ctx context.Context, wait chan struct{}) (Operations, error) {
+	var wg sync.WaitGroup
+	wg.Add(d.Concurrency)
+	c := d.Collector
+	if d.AutoTermDur > 0 {
+		ctx = c.AutoTerm(ctx, http.MethodGet, d.AutoTermScale, autoTermCheck, autoTermSamples, d.AutoTermDur)
+	}
+	// Non-terminating context.
+	nonTerm := context.Background()
+
+	for i := 0; i < d.Concurrency; i++ {
+		go func(i int) {
+			rng := rand.New(rand.NewSource(int64(i)))
+			rcv := c.Receiver()
+			defer wg.Done()
+			done := ctx.Done()
+			objs := d.objects[i]
+			getOpts := d.GetOpts
+			// Start each thread at a different offset.
+			n := i * len(d.ops) / d.Concurrency
+
+			// Delete markers created by this thread.
+			markers := 0
+			// Objects with a delete marker as the latest version.
+			deleted := make(map[string]struct{}, len(objs))
+
+			d.startWait(wait)
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				if d.rpsLimit(ctx) != nil {
+					return
+				}
+
+				operation := d.ops[n%len(d.ops)]
+				n++
+				obj := objs[rng.Intn(len(objs))]
+				client, cldone := d.Client()
+				op := Operation{
+					OpType:   operation,
+					Thread:   uint16(i),
+					File:     obj.Name,
+					ObjPerOp: 1,
+					Endpoint: client.EndpointURL().String(),
+				}
+				switch operation {
+				case http.MethodDelete:
+					op.Start = time.Now()
+					opCtx, resp := recordResponse(nonTerm)
+					err := client.RemoveObject(opCtx, d.Bucket, obj.Name, minio.RemoveObjectOptions{})
+					op.End = time.Now()
+					resp.apply(&op, d.RecordHeaders)
+					if err != nil {
+						d.Error("delete error:", err)
+						op.Err = err.Error()
+						break
+					}
+					markers++
+					deleted[obj.Name] = struct{}{}
+				case http.MethodGet:
+					fbr := firstByteRecorder{}
+					op.Size = obj.Size
+					getOpts.VersionID = obj.VersionID
+					op.Start = time.Now()
+					opCtx, resp := recordResponse(nonTerm)
+					o, err := client.GetObject(opCtx, d.Bucket, obj.Name, getOpts)
+					if err != nil {
+						d.Error("download error:", err)
+						op.Err = err.Error()
+						op.End = time.Now()
+						break
+					}
+					fbr.r = o
+					read, err := io.Copy(io.Discard, &fbr)
+					if err != nil {
+						d.Error("download error:", err)
+						op.Err = err.Error()
+					}
+					op.FirstByte = fbr.t
+					op.End = time.Now()
+					o.Close()
+					resp.apply(&op, d.RecordHeaders)
+					if read != op.Size && op.Err == "" {
+						op.Err = fmt.Sprint("unexpected download size. want:", op.Size, ", got:", read)
+						d.Error(op.Err)
+					}
+				case "LIST":
+					op.File = obj.Prefix
+					op.ObjPerOp = 0
+					wantN := len(objs) - len(deleted)
+					if d.ListVersions {
+						wantN = len(objs) + markers
+					}
+					opCtx, resp := recordResponse(nonTerm)
+					op.Start = time.Now()
+					listCh := client.ListObjects(opCtx, d.Bucket, minio.ListObjectsOptions{
+						Prefix:       obj.Prefix,
+						Recursive:    true,
+						WithVersions: d.ListVersions,
+					})
+					for res := range listCh {
+						if res.Err != nil {
+							d.Error(res.Err)
+							op.Err = res.Err.Error()
+						}
+						if op.FirstByte == nil {
+							now := time.Now()
+							op.FirstByte = &now
+						}
+						op.ObjPerOp++
+					}
+					op.End = time.Now()
+					resp.apply(&op, d.RecordHeaders)
+					if op.Err == "" && op.ObjPerOp != wantN {
+						op.Err = fmt.Sprintf("Unexpected object count, want %d, got %d", wantN, op.ObjPerOp)
+						d.Error(op.Err)
+					}
+				}
+				cldone()
+				if d.DiscardOutput {
+					op.File = ""
+				}
+				rcv <- op
+			}
+		}(i)
+	}
+	wg.Wait()
+	return c.Close(), nil
+}
+
+// Cleanup deletes everything uploaded to the bucket.
+func (d *DeleteMarkers) Cleanup(ctx context.Context) {
+	var pf []string
+	for _, objs := range d.objects {
+		if len(objs) > 0 && objs[0].Prefix != "" {
+			pf = append(pf, objs[0].Prefix)
+		}
+	}
+	d.deleteAllInBucket(ctx, pf...)
+}
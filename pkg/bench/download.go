@@ -0,0 +1,139 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"unsafe"
+)
+
+// directAlign is the buffer and write alignment needed for O_DIRECT.
+const directAlign = 4096
+
+// alignedBuffer returns a buffer of size bytes aligned to directAlign.
+// Size must be a multiple of directAlign.
+func alignedBuffer(size int) []byte {
+	b := make([]byte, size+directAlign)
+	off := 0
+	if rem := int(uintptr(unsafe.Pointer(&b[0])) & (directAlign - 1)); rem != 0 {
+		off = directAlign - rem
+	}
+	return b[off : off+size : off+size]
+}
+
+// downloadFile writes downloaded content to a local file.
+// Content is written to a temporary file that is renamed
+// to the object name when the download is complete.
+type downloadFile struct {
+	f         *os.File
+	name, tmp string
+	direct    bool
+	sync      bool
+	buf       []byte
+	n         int
+}
+
+// newDownloadFile creates the file for an object in dir.
+// buf must be aligned if direct is true.
+func newDownloadFile(dir, object string, thread int, direct, sync bool, buf []byte) (*downloadFile, error) {
+	name := filepath.Join(dir, filepath.FromSlash(object))
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return nil, err
+	}
+	d := downloadFile{
+		name:   name,
+		tmp:    name + ".warp-" + strconv.Itoa(thread),
+		direct: direct,
+		sync:   sync,
+		buf:    buf,
+	}
+	var err error
+	if direct {
+		d.f, err = openDirect(d.tmp)
+	} else {
+		d.f, err = os.OpenFile(d.tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// Write implements io.Writer.
+func (d *downloadFile) Write(p []byte) (int, error) {
+	written := len(p)
+	for len(p) > 0 {
+		n := copy(d.buf[d.n:], p)
+		d.n += n
+		p = p[n:]
+		if d.n == len(d.buf) {
+			if _, err := d.f.Write(d.buf); err != nil {
+				return written - len(p), err
+			}
+			d.n = 0
+		}
+	}
+	return written, nil
+}
+
+// Close writes remaining content, syncs if requested
+// and moves the file to its final name.
+func (d *downloadFile) Close() error {
+	buf := d.buf[:d.n]
+	if d.direct && len(buf)%directAlign != 0 {
+		// The unaligned tail cannot be written with O_DIRECT.
+		aligned := len(buf) &^ (directAlign - 1)
+		if _, err := d.f.Write(buf[:aligned]); err != nil {
+			d.Abort()
+			return err
+		}
+		buf = buf[aligned:]
+		d.f.Close()
+		var err error
+		d.f, err = os.OpenFile(d.tmp, os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			os.Remove(d.tmp)
+			return err
+		}
+	}
+	if len(buf) > 0 {
+		if _, err := d.f.Write(buf); err != nil {
+			d.Abort()
+			return err
+		}
+	}
+	if d.sync {
+		if err := d.f.Sync(); err != nil {
+			d.Abort()
+			return err
+		}
+	}
+	if err := d.f.Close(); err != nil {
+		os.Remove(d.tmp)
+		return err
+	}
+	return os.Rename(d.tmp, d.name)
+}
+
+// Abort closes and removes the temporary file.
+func (d *downloadFile) Abort() {
+	d.f.Close()
+	os.Remove(d.tmp)
+}
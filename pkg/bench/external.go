@@ -0,0 +1,277 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// External runs a benchmark implemented by an external process.
+// One process is started per thread. Requests are written to the standard input
+// of the process and responses are read from its standard output,
+// one JSON object per line.
+//
+// Requests have the form {"request":"prepare"}, {"request":"op"} and {"request":"cleanup"}.
+// Each request must be answered by exactly one response.
+// Responses to "op" describe the executed operation,
+// for example {"op":"GET","key":"obj","size":1024,"objects":1,"endpoint":"http://host:9000"}.
+// Any request can be failed by setting "error" in the response.
+// Operations are timed by warp from the request being sent until the response is received.
+// The process should exit when its standard input is closed.
+type External struct {
+	Common
+
+	// Command is the executable to run.
+	Command string
+
+	// Args are passed to the command.
+	Args []string
+
+	// Env contains additional environment variables for the command.
+	Env []string
+
+	// ThreadEnv returns additional environment variables for a thread, if set.
+	ThreadEnv func(thread int) []string
+
+	procs []*externalProc
+}
+
+// ExternalResponse is a response from an external benchmark process.
+type ExternalResponse struct {
+	// Op is the operation type, for example "GET".
+	Op string `json:"op,omitempty"`
+
+	// Key is the object key of the operation.
+	Key string `json:"key,omitempty"`
+
+	// Endpoint the operation was executed against.
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Size is the number of bytes transferred.
+	Size int64 `json:"size,omitempty"`
+
+	// Objects is the number of objects handled by the operation.
+	// 1 is used if 0.
+	Objects int `json:"objects,omitempty"`
+
+	// Error is set if the request failed.
+	Error string `json:"error,omitempty"`
+}
+
+type externalRequest struct {
+	Request string `json:"request"`
+}
+
+type externalProc struct {
+	cmd *exec.Cmd
+	in  io.WriteCloser
+	enc *json.Encoder
+	dec *json.Decoder
+}
+
+// start the process for a thread.
+func (e *External) start(thread int) (*externalProc, error) {
+	cmd := exec.Command(e.Command, e.Args...)
+	cmd.Env = append(os.Environ(), e.Env...)
+	cmd.Env = append(cmd.Env, "WARP_THREAD="+strconv.Itoa(thread), "WARP_CONCURRENCY="+strconv.Itoa(e.Concurrency))
+	if e.ThreadEnv != nil {
+		cmd.Env = append(cmd.Env, e.ThreadEnv(thread)...)
+	}
+	cmd.Stderr = os.Stderr
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &externalProc{
+		cmd: cmd,
+		in:  in,
+		enc: json.NewEncoder(in),
+		dec: json.NewDecoder(bufio.NewReader(out)),
+	}, nil
+}
+
+// call sends a request and waits for the response.
+// An error is returned if the process could not be communicated with.
+func (p *externalProc) call(request string) (ExternalResponse, error) {
+	var resp ExternalResponse
+	if err := p.enc.Encode(externalRequest{Request: request}); err != nil {
+		return resp, fmt.Errorf("sending %s request: %w", request, err)
+	}
+	if err := p.dec.Decode(&resp); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return resp, fmt.Errorf("reading %s response: %w", request, err)
+	}
+	return resp, nil
+}
+
+// close stdin and wait for the process to exit.
+func (p *externalProc) close() error {
+	p.in.Close()
+	return p.cmd.Wait()
+}
+
+// Prepare will create an empty bucket, start a process per thread and ask each to prepare.
+func (e *External) Prepare(ctx context.Context) error {
+	if err := e.createEmptyBucket(ctx); err != nil {
+		return err
+	}
+	e.procs = make([]*externalProc, e.Concurrency)
+	for i := range e.procs {
+		p, err := e.start(i)
+		if err != nil {
+			e.stop()
+			return fmt.Errorf("starting %s: %w", e.Command, err)
+		}
+		e.procs[i] = p
+	}
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var groupErr error
+	var done int
+	for i, p := range e.procs {
+		wg.Add(1)
+		go func(i int, p *externalProc) {
+			defer wg.Done()
+			resp, err := p.call("prepare")
+			if err == nil && resp.Error != "" {
+				err = errors.New(resp.Error)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil && groupErr == nil {
+				groupErr = fmt.Errorf("thread %d: %w", i, err)
+			}
+			done++
+			e.prepareProgress(float64(done) / float64(len(e.procs)))
+		}(i, p)
+	}
+	wg.Wait()
+	if groupErr != nil {
+		e.stop()
+	}
+	return groupErr
+}
+
+// Start will execute the main benchmark.
+// Operations should begin executing when the start channel is closed.
+func (e *External) Start(ctx context.Context, wait chan struct{}) (Operations, error) {
+	var wg sync.WaitGroup
+	wg.Add(len(e.procs))
+	e.addCollector()
+	c := e.Collector
+	if e.AutoTermDur > 0 {
+		ctx = c.AutoTerm(ctx, "", e.AutoTermScale, autoTermCheck, autoTermSamples, e.AutoTermDur)
+	}
+	for i, p := range e.procs {
+		go func(i int, p *externalProc) {
+			rcv := c.Receiver()
+			defer wg.Done()
+			done := ctx.Done()
+
+			e.startWait(wait)
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				if e.rpsLimit(ctx) != nil {
+					return
+				}
+
+				op := Operation{
+					Thread:   uint16(i),
+					ObjPerOp: 1,
+				}
+				op.Start = time.Now()
+				resp, err := p.call("op")
+				op.End = time.Now()
+				if err != nil {
+					// The process cannot be used anymore.
+					e.Error(fmt.Sprintf("thread %d: %v", i, err))
+					return
+				}
+				op.OpType = resp.Op
+				op.File = resp.Key
+				op.Size = resp.Size
+				op.Endpoint = resp.Endpoint
+				if resp.Objects > 0 {
+					op.ObjPerOp = resp.Objects
+				}
+				if resp.Error != "" {
+					e.Error(resp.Op, " error: ", resp.Error)
+					op.Err = resp.Error
+				}
+				rcv <- op
+			}
+		}(i, p)
+	}
+	wg.Wait()
+	return c.Close(), nil
+}
+
+// Cleanup asks each process to clean up, stops the processes and deletes everything in the bucket.
+func (e *External) Cleanup(ctx context.Context) {
+	for i, p := range e.procs {
+		if p == nil {
+			continue
+		}
+		resp, err := p.call("cleanup")
+		if err == nil && resp.Error != "" {
+			err = errors.New(resp.Error)
+		}
+		if err != nil {
+			e.Error(fmt.Sprintf("thread %d cleanup: %v", i, err))
+		}
+	}
+	e.stop()
+	e.deleteAllInBucket(ctx)
+}
+
+// stop all running processes.
+func (e *External) stop() {
+	for i, p := range e.procs {
+		if p == nil {
+			continue
+		}
+		if err := p.close(); err != nil {
+			e.Error(fmt.Sprintf("thread %d: %s: %v", i, e.Command, err))
+		}
+		e.procs[i] = nil
+	}
+}
@@ -0,0 +1,84 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestExternalProc(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not found")
+	}
+	// Answers each request with the request type and the thread number.
+	script := `while read -r line; do
+	case "$line" in
+	*'"op"'*) echo '{"op":"GET","key":"obj-'$WARP_THREAD'","size":10,"objects":2}' ;;
+	*'"cleanup"'*) echo '{"error":"cleanup failed"}' ;;
+	*) echo '{}' ;;
+	esac
+done`
+	e := External{
+		Common:  Common{Concurrency: 2},
+		Command: "sh",
+		Args:    []string{"-c", script},
+	}
+	p, err := e.start(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := p.call("prepare")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp != (ExternalResponse{}) {
+		t.Errorf("prepare: got %+v", resp)
+	}
+	resp, err = p.call("op")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ExternalResponse{Op: "GET", Key: "obj-1", Size: 10, Objects: 2}
+	if resp != want {
+		t.Errorf("op: got %+v, want %+v", resp, want)
+	}
+	resp, err = p.call("cleanup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Error != "cleanup failed" {
+		t.Errorf("cleanup: got %+v", resp)
+	}
+	if err := p.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A process that exits must return an error.
+	e.Args = []string{"-c", "exit 0"}
+	p, err = e.start(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.call("op")
+	if err == nil || !strings.Contains(err.Error(), "op") {
+		t.Errorf("want error, got %v", err)
+	}
+	p.close()
+}
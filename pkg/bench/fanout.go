@@ -67,7 +67,7 @@ func (u *Fanout) Start(ctx context.Context, wait chan struct{}) (Operations, err
 			}
 			done := ctx.Done()
 
-			<-wait
+			u.startWait(wait)
 			for {
 				select {
 				case <-done:
@@ -97,9 +97,11 @@ func (u *Fanout) Start(ctx context.Context, wait chan struct{}) (Operations, err
 					Endpoint: client.EndpointURL().String(),
 				}
 
+				opCtx, resp := recordResponse(nonTerm)
 				op.Start = time.Now()
-				res, err := client.PutObjectFanOut(nonTerm, u.Bucket, obj.Reader, opts)
+				res, err := client.PutObjectFanOut(opCtx, u.Bucket, obj.Reader, opts)
 				op.End = time.Now()
+				resp.apply(&op, u.RecordHeaders)
 				if err != nil {
 					u.Error("upload error: ", err)
 					op.Err = err.Error()
@@ -18,14 +18,19 @@
 package bench
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"math"
 	"math/rand"
 	"net/http"
+	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/klauspost/compress/gzip"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/pkg/v2/console"
 	"github.com/minio/warp/pkg/generator"
@@ -46,11 +51,52 @@ type Get struct {
 	RangeSize     int64
 	ListExisting  bool
 	ListFlat      bool
+
+	// Resume will keep objects already uploaded under ResumePrefix
+	// and only upload missing objects and versions when preparing.
+	Resume       bool
+	ResumePrefix string
+
+	// DownloadDir will write downloaded objects to files in this directory
+	// instead of discarding the content.
+	DownloadDir string
+	// DownloadDirect will write files with O_DIRECT.
+	DownloadDirect bool
+	// DownloadSync will fsync each file before the download is complete.
+	DownloadSync bool
+
+	// ContentEncoding will compress uploaded objects and store them with this content encoding.
+	// Only "gzip" is supported.
+	ContentEncoding string
+	// Decompress will decompress downloaded objects stored with ContentEncoding.
+	Decompress bool
+
+	// Missing is the fraction of requests for objects that don't exist.
+	// These are recorded as GetMissingOp and must return 404.
+	Missing float64
+	// MissingDeleted will delete the same fraction of uploaded objects when preparing
+	// and request those instead of names that were never created.
+	MissingDeleted bool
+	deleted        []string
+
+	// AccessOrder is the order in which objects are read.
+	AccessOrder AccessOrder
 }
 
+// GetMissingOp is the operation type of GET requests for objects that don't exist.
+const GetMissingOp = "GET-MISSING"
+
+// downloadBufferSize is the size of the buffer used when writing downloads to disk.
+const downloadBufferSize = 1 << 20
+
 // Prepare will create an empty bucket or delete any content already there
 // and upload a number of objects.
 func (g *Get) Prepare(ctx context.Context) error {
+	if g.DownloadDir != "" {
+		if err := os.MkdirAll(g.DownloadDir, 0o755); err != nil {
+			return err
+		}
+	}
 	// prepare the bench by listing object from the bucket
 	g.addCollector()
 	if g.ListExisting {
@@ -119,6 +165,9 @@ func (g *Get) Prepare(ctx context.Context) error {
 	}
 
 	// prepare the bench by creating the bucket and pushing some objects
+	if g.Resume {
+		g.Clear = false
+	}
 	if err := g.createEmptyBucket(ctx); err != nil {
 		return err
 	}
@@ -133,17 +182,36 @@ func (g *Get) Prepare(ctx context.Context) error {
 		}
 		done()
 	}
+	create := g.CreateObjects
+	var partial []resumeKey
+	if g.Resume {
+		complete, p, err := g.resumeObjects(ctx)
+		if err != nil {
+			return err
+		}
+		partial = p
+		g.objects = complete
+		for _, k := range partial {
+			g.objects = append(g.objects, k.versions...)
+		}
+		keys := len(complete) / g.Versions
+		create -= keys + len(partial)
+		console.Eraseline()
+		console.Infof("\rResuming with %d complete and %d partial objects\n", keys, len(partial))
+	}
 	console.Eraseline()
 	x := ""
 	if g.Versions > 1 {
 		x = fmt.Sprintf(" with %d versions each", g.Versions)
 	}
-	console.Info("\rUploading ", g.CreateObjects, " objects", x)
+	console.Info("\rUploading ", create, " objects", x)
 
 	var wg sync.WaitGroup
-	wg.Add(g.Concurrency)
+	wg.Add(g.prepareThreads())
 
-	objs := splitObjs(g.CreateObjects, g.Concurrency)
+	// Partial objects are completed before new objects are created.
+	var nextPartial atomic.Int64
+	objs := splitObjs(create+len(partial), g.prepareThreads())
 	rcv := g.Collector.rcv
 	var groupErr error
 	var mu sync.Mutex
@@ -153,6 +221,7 @@ func (g *Get) Prepare(ctx context.Context) error {
 			defer wg.Done()
 			src := g.Source()
 			opts := g.PutOpts
+			prep := g.preparer(i, rcv)
 
 			for range obj {
 				select {
@@ -168,7 +237,14 @@ func (g *Get) Prepare(ctx context.Context) error {
 				obj := src.Object()
 
 				name := obj.Name
-				for ver := 0; ver < g.Versions; ver++ {
+				firstVer := 0
+				if len(partial) > 0 {
+					if idx := int(nextPartial.Add(1) - 1); idx < len(partial) {
+						name = partial[idx].name
+						firstVer = len(partial[idx].versions)
+					}
+				}
+				for ver := firstVer; ver < g.Versions; ver++ {
 					// New input for each version
 					obj := src.Object()
 					obj.Name = name
@@ -183,8 +259,21 @@ func (g *Get) Prepare(ctx context.Context) error {
 					}
 
 					opts.ContentType = obj.ContentType
+					if g.ContentEncoding != "" {
+						if err := encodeObject(obj); err != nil {
+							g.Error(err)
+							mu.Lock()
+							if groupErr == nil {
+								groupErr = err
+							}
+							mu.Unlock()
+							return
+						}
+						op.Size = obj.Size
+						opts.ContentEncoding = g.ContentEncoding
+					}
 					op.Start = time.Now()
-					res, err := client.PutObject(ctx, g.Bucket, obj.Name, obj.Reader, obj.Size, opts)
+					res, queued, err := prep.upload(ctx, client, &op, obj, opts)
 					op.End = time.Now()
 					if err != nil {
 						err := fmt.Errorf("upload error: %w", err)
@@ -213,15 +302,173 @@ func (g *Get) Prepare(ctx context.Context) error {
 					g.objects = append(g.objects, *obj)
 					g.prepareProgress(float64(len(g.objects)) / float64(g.CreateObjects*g.Versions))
 					mu.Unlock()
-					rcv <- op
+					if !queued {
+						rcv <- op
+					}
 				}
 			}
+			client, cldone := g.Client()
+			err := prep.flush(ctx, client)
+			cldone()
+			if err != nil {
+				g.Error(err)
+				mu.Lock()
+				if groupErr == nil {
+					groupErr = err
+				}
+				mu.Unlock()
+			}
 		}(i, obj)
 	}
 	wg.Wait()
+	if groupErr == nil && g.Missing > 0 && g.MissingDeleted {
+		return g.deleteMissing(ctx)
+	}
 	return groupErr
 }
 
+// deleteMissing deletes a Missing fraction of the uploaded objects, keeping at least one.
+// The deleted objects are removed from the objects used for regular requests.
+// In versioned buckets a delete marker is added.
+func (g *Get) deleteMissing(ctx context.Context) error {
+	var names []string
+	seen := make(map[string]bool, len(g.objects))
+	for _, obj := range g.objects {
+		if !seen[obj.Name] {
+			seen[obj.Name] = true
+			names = append(names, obj.Name)
+		}
+	}
+	n := min(int(math.Ceil(g.Missing*float64(len(names)))), len(names)-1)
+	if n <= 0 {
+		return nil
+	}
+	g.deleted = names[len(names)-n:]
+	del := make(map[string]bool, n)
+	for _, name := range g.deleted {
+		del[name] = true
+	}
+	keep := g.objects[:0]
+	for _, obj := range g.objects {
+		if !del[obj.Name] {
+			keep = append(keep, obj)
+		}
+	}
+	g.objects = keep
+
+	console.Eraseline()
+	console.Info("\rDeleting ", n, " objects for missing requests")
+	objects := make(chan minio.ObjectInfo)
+	go func() {
+		defer close(objects)
+		for _, name := range g.deleted {
+			select {
+			case objects <- minio.ObjectInfo{Key: name}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	cl, done := g.Client()
+	defer done()
+	for err := range cl.RemoveObjects(ctx, g.Bucket, objects, minio.RemoveObjectsOptions{}) {
+		if err.Err != nil {
+			return fmt.Errorf("deleting %s: %w", err.ObjectName, err.Err)
+		}
+	}
+	return ctx.Err()
+}
+
+// resumeKey is an object that is missing versions when resuming.
+type resumeKey struct {
+	name     string
+	versions generator.Objects
+}
+
+// resumeObjects lists objects already uploaded under the resume prefix.
+// Objects with all versions are returned as complete,
+// objects that are missing versions are returned as partial.
+// At most CreateObjects objects are returned.
+func (g *Get) resumeObjects(ctx context.Context) (complete generator.Objects, partial []resumeKey, err error) {
+	cl, done := g.Client()
+	defer done()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	versions := make(map[string]generator.Objects)
+	var keys []string
+	objectCh := cl.ListObjects(ctx, g.Bucket, minio.ListObjectsOptions{
+		WithVersions: g.Versions > 1,
+		Prefix:       g.ResumePrefix,
+		Recursive:    true,
+	})
+	for object := range objectCh {
+		if object.Err != nil {
+			return nil, nil, object.Err
+		}
+		if object.IsDeleteMarker {
+			continue
+		}
+		vers, found := versions[object.Key]
+		if !found {
+			if len(keys) >= g.CreateObjects {
+				// Versions are listed together, so we are done.
+				break
+			}
+			keys = append(keys, object.Key)
+		}
+		if len(vers) >= g.Versions {
+			continue
+		}
+		versions[object.Key] = append(vers, generator.Object{
+			Name:      object.Key,
+			Size:      object.Size,
+			VersionID: object.VersionID,
+		})
+	}
+	for _, key := range keys {
+		vers := versions[key]
+		if len(vers) < g.Versions {
+			partial = append(partial, resumeKey{name: key, versions: vers})
+			continue
+		}
+		complete = append(complete, vers...)
+	}
+	return complete, partial, nil
+}
+
+// encodeObject replaces the content of obj with gzip compressed content.
+func encodeObject(obj *generator.Object) error {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := io.Copy(zw, obj.Reader); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	obj.Reader = bytes.NewReader(buf.Bytes())
+	obj.Size = int64(buf.Len())
+	return nil
+}
+
+// decompress reads and decompresses the gzip content of r.
+// The number of compressed bytes read is returned.
+func decompress(r io.Reader) (int64, error) {
+	var n atomic.Int64
+	cr := countingBody{ReadCloser: io.NopCloser(r), n: &n}
+	zr, err := gzip.NewReader(cr)
+	if err != nil {
+		return n.Load(), err
+	}
+	if _, err := io.Copy(io.Discard, zr); err != nil {
+		return n.Load(), err
+	}
+	// Read any trailing data.
+	_, err = io.Copy(io.Discard, cr)
+	return n.Load(), err
+}
+
 type firstByteRecorder struct {
 	t *time.Time
 	r io.Reader
@@ -252,16 +499,22 @@ func (g *Get) Start(ctx context.Context, wait chan struct{}) (Operations, error)
 
 	// Non-terminating context.
 	nonTerm := context.Background()
+	access := newAccess(g.AccessOrder, g.objects, g.Concurrency)
 
 	for i := 0; i < g.Concurrency; i++ {
 		go func(i int) {
 			rng := rand.New(rand.NewSource(int64(i)))
+			next := access.picker(i, rng)
 			rcv := c.Receiver()
 			defer wg.Done()
 			opts := g.GetOpts
 			done := ctx.Done()
+			var buf []byte
+			if g.DownloadDir != "" {
+				buf = alignedBuffer(downloadBufferSize)
+			}
 
-			<-wait
+			g.startWait(wait)
 			for {
 				select {
 				case <-done:
@@ -273,8 +526,16 @@ func (g *Get) Start(ctx context.Context, wait chan struct{}) (Operations, error)
 					return
 				}
 
+				obj := g.objects[next()]
+				if g.Missing > 0 && rng.Float64() < g.Missing {
+					name := missingName(rng, obj.Name)
+					if len(g.deleted) > 0 {
+						name = g.deleted[rng.Intn(len(g.deleted))]
+					}
+					g.getMissing(i, name, rcv)
+					continue
+				}
 				fbr := firstByteRecorder{}
-				obj := g.objects[rng.Intn(len(g.objects))]
 				client, cldone := g.Client()
 				op := Operation{
 					OpType:   http.MethodGet,
@@ -307,29 +568,42 @@ func (g *Get) Start(ctx context.Context, wait chan struct{}) (Operations, error)
 				if g.Versions > 1 {
 					opts.VersionID = obj.VersionID
 				}
-				o, err := client.GetObject(nonTerm, g.Bucket, obj.Name, opts)
+				opCtx, resp := recordResponse(nonTerm)
+				o, err := client.GetObject(opCtx, g.Bucket, obj.Name, opts)
 				if err != nil {
 					g.Error("download error:", err)
 					op.Err = err.Error()
 					op.End = time.Now()
+					resp.apply(&op, g.RecordHeaders)
 					rcv <- op
 					cldone()
 					continue
 				}
 				fbr.r = o
-				n, err := io.Copy(io.Discard, &fbr)
+				r, h := g.shadowReader(&fbr)
+				var n int64
+				switch {
+				case g.DownloadDir != "":
+					n, err = g.download(r, obj.Name, i, buf)
+				case g.Decompress:
+					n, err = decompress(r)
+				default:
+					n, err = io.Copy(io.Discard, r)
+				}
 				if err != nil {
 					g.Error("download error:", err)
 					op.Err = err.Error()
 				}
 				op.FirstByte = fbr.t
 				op.End = time.Now()
+				resp.apply(&op, g.RecordHeaders)
 				if n != op.Size && op.Err == "" {
 					op.Err = fmt.Sprint("unexpected download size. want:", op.Size, ", got:", n)
 					g.Error(op.Err)
 				}
 				rcv <- op
 				cldone()
+				g.shadowGet(nonTerm, rcv, op, obj.Name, opts, o, h)
 				o.Close()
 			}
 		}(i)
@@ -338,6 +612,54 @@ func (g *Get) Start(ctx context.Context, wait chan struct{}) (Operations, error)
 	return c.Close(), nil
 }
 
+// getMissing sends a GET request for an object that doesn't exist.
+// Requests succeed when the object is not found.
+func (g *Get) getMissing(thread int, name string, rcv chan<- Operation) {
+	client, cldone := g.Client()
+	defer cldone()
+	op := Operation{
+		OpType:   GetMissingOp,
+		Thread:   uint16(thread),
+		File:     name,
+		ObjPerOp: 1,
+		Endpoint: client.EndpointURL().String(),
+	}
+	op.Start = time.Now()
+	opCtx, resp := recordResponse(context.Background())
+	o, err := client.GetObject(opCtx, g.Bucket, name, g.GetOpts)
+	if err == nil {
+		// The request is sent on the first read.
+		_, err = io.Copy(io.Discard, o)
+		o.Close()
+	}
+	op.End = time.Now()
+	resp.apply(&op, g.RecordHeaders)
+	switch {
+	case err == nil:
+		op.Err = "object exists: " + name
+		g.Error(op.Err)
+	case minio.ToErrorResponse(err).StatusCode != http.StatusNotFound:
+		g.Error("download error:", err)
+		op.Err = err.Error()
+	}
+	rcv <- op
+}
+
+// download writes the content of r to a file in the download directory.
+// The file is only kept if the entire content is written.
+func (g *Get) download(r io.Reader, object string, thread int, buf []byte) (int64, error) {
+	f, err := newDownloadFile(g.DownloadDir, object, thread, g.DownloadDirect, g.DownloadSync, buf)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(f, r)
+	if err != nil {
+		f.Abort()
+		return n, err
+	}
+	return n, f.Close()
+}
+
 // Cleanup deletes everything uploaded to the bucket.
 func (g *Get) Cleanup(ctx context.Context) {
 	if !g.ListExisting {
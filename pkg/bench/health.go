@@ -0,0 +1,170 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// EndpointDownOp is the operation type recorded when an endpoint is excluded.
+	// The operation starts at the last successful check and ends when the endpoint is excluded.
+	EndpointDownOp = "ENDPOINT-DOWN"
+	// EndpointUpOp is the operation type recorded when an endpoint is included again.
+	// The operation starts when the endpoint was excluded and ends when it is included.
+	EndpointUpOp = "ENDPOINT-UP"
+)
+
+// HealthChecker actively checks endpoints and excludes endpoints
+// that fail a number of consecutive checks until they pass a check again.
+// Exclusion and re-inclusion are recorded as operations.
+type HealthChecker struct {
+	// Endpoints checked.
+	Endpoints []string
+
+	// Interval between checks of each endpoint.
+	Interval time.Duration
+
+	// Failures is the number of consecutive failed checks before an endpoint is excluded.
+	Failures int
+
+	// Check the endpoint with the index.
+	Check func(ctx context.Context, idx int) error
+
+	down   []atomic.Bool
+	cancel context.CancelFunc
+	mu     sync.Mutex
+	events Operations
+}
+
+// Start checking endpoints until Stop is called.
+func (h *HealthChecker) Start() {
+	var ctx context.Context
+	ctx, h.cancel = context.WithCancel(context.Background())
+	h.down = make([]atomic.Bool, len(h.Endpoints))
+	for i := range h.Endpoints {
+		go h.run(ctx, i)
+	}
+}
+
+// Stop checking endpoints.
+func (h *HealthChecker) Stop() {
+	if h != nil && h.cancel != nil {
+		h.cancel()
+	}
+}
+
+// Healthy returns whether the endpoint with the index should receive requests.
+func (h *HealthChecker) Healthy(idx int) bool {
+	return h == nil || !h.down[idx].Load()
+}
+
+// AnyHealthy returns whether any endpoint should receive requests.
+// If none are, requests should be sent to all endpoints.
+func (h *HealthChecker) AnyHealthy() bool {
+	if h == nil {
+		return true
+	}
+	for i := range h.down {
+		if !h.down[i].Load() {
+			return true
+		}
+	}
+	return false
+}
+
+// Events returns the recorded events and clears them.
+func (h *HealthChecker) Events() Operations {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ev := h.events
+	h.events = nil
+	return ev
+}
+
+func (h *HealthChecker) run(ctx context.Context, idx int) {
+	ticker := time.NewTicker(h.Interval)
+	defer ticker.Stop()
+	lastOK := time.Now()
+	var downSince time.Time
+	var failures int
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		cctx, cancel := context.WithTimeout(ctx, h.Interval)
+		err := h.Check(cctx, idx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		now := time.Now()
+		if err == nil {
+			failures = 0
+			lastOK = now
+			if h.down[idx].CompareAndSwap(true, false) {
+				h.record(Operation{OpType: EndpointUpOp, Endpoint: h.Endpoints[idx], Start: downSince, End: now})
+			}
+			continue
+		}
+		failures++
+		if failures >= h.Failures && h.down[idx].CompareAndSwap(false, true) {
+			downSince = now
+			h.record(Operation{OpType: EndpointDownOp, Endpoint: h.Endpoints[idx], Start: lastOK, End: now, Err: err.Error()})
+		}
+	}
+}
+
+func (h *HealthChecker) record(op Operation) {
+	h.mu.Lock()
+	h.events = append(h.events, op)
+	h.mu.Unlock()
+}
+
+// SplitEndpointEvents returns the operations without endpoint, adjust, pacing, TCP statistics and sample events
+// and the events separately.
+func (o Operations) SplitEndpointEvents() (ops, events Operations) {
+	for _, op := range o {
+		if isEvent(op) {
+			events = append(events, op)
+			continue
+		}
+		ops = append(ops, op)
+	}
+	if len(events) == 0 {
+		return o, nil
+	}
+	return ops, events
+}
+
+// isEvent returns whether op is an endpoint, adjust, pacing, TCP statistics or sample event.
+func isEvent(op Operation) bool {
+	switch op.OpType {
+	case EndpointDownOp, EndpointUpOp, AdjustOp, PacingOp, TCPStatsOp, OpSampleOp:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,308 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/minio/madmin-go/v3"
+	"github.com/minio/pkg/v2/console"
+	"golang.org/x/time/rate"
+)
+
+// IAM operation types. The distribution uses the operation group,
+// which is recorded as the add or remove operation depending on the current state.
+const (
+	IAMServiceAccount = "SVCACCT"
+	IAMPolicy         = "POLICY"
+	IAMUser           = "USER"
+	IAMListUsers      = "USER-LIST"
+)
+
+// IAM benchmarks MinIO admin operations on users, service accounts and policies.
+type IAM struct {
+	Common
+	Admin *madmin.AdminClient
+	Dist  *MixedDistribution
+
+	// Users is the number of users created before the benchmark.
+	// Service accounts and policies are added to these users.
+	Users int
+
+	// Keep is the number of service accounts and users each thread
+	// creates before the oldest is removed.
+	Keep int
+
+	// OpRpsLimits contains rate limits for operation groups.
+	OpRpsLimits map[string]*rate.Limiter
+
+	prefix string
+	policy string
+	users  []string
+
+	// Created users and service accounts not yet removed.
+	mu      sync.Mutex
+	created map[string]bool
+}
+
+// iamPolicy allows reading the benchmark bucket.
+const iamPolicy = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["s3:GetObject","s3:ListBucket"],"Resource":["arn:aws:s3:::%[1]s","arn:aws:s3:::%[1]s/*"]}]}`
+
+// Prepare creates the policy and users.
+func (g *IAM) Prepare(ctx context.Context) error {
+	if g.Admin == nil {
+		return errors.New("no admin client")
+	}
+	if g.Users < g.Concurrency {
+		return errors.New("at least one user per thread is required")
+	}
+	// Access keys are limited to 20 characters.
+	g.prefix = "w" + iamRandString(6)
+	g.policy = g.prefix + "-policy"
+	g.created = make(map[string]bool)
+	if err := g.Admin.AddCannedPolicy(ctx, g.policy, []byte(fmt.Sprintf(iamPolicy, g.Bucket))); err != nil {
+		return fmt.Errorf("adding policy: %w", err)
+	}
+	console.Eraseline()
+	console.Info("\rCreating ", g.Users, " users")
+	g.addCollector()
+	g.users = make([]string, g.Users)
+	for i := range g.users {
+		g.users[i] = fmt.Sprintf("%s-u%d", g.prefix, i)
+	}
+	var wg sync.WaitGroup
+	var groupErr error
+	var errMu sync.Mutex
+	for i := 0; i < g.Concurrency; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := i; j < len(g.users); j += g.Concurrency {
+				if err := g.Admin.AddUser(ctx, g.users[j], iamRandString(24)); err != nil {
+					errMu.Lock()
+					groupErr = errors.Join(groupErr, err)
+					errMu.Unlock()
+					return
+				}
+				g.prepareProgress(float64(j+1) / float64(len(g.users)))
+			}
+		}(i)
+	}
+	wg.Wait()
+	return groupErr
+}
+
+// Start will execute the main benchmark.
+// Operations should begin executing when the start channel is closed.
+func (g *IAM) Start(ctx context.Context, wait chan struct{}) (Operations, error) {
+	var wg sync.WaitGroup
+	wg.Add(g.Concurrency)
+	c := g.Collector
+	if g.AutoTermDur > 0 {
+		ctx = c.AutoTerm(ctx, "", g.AutoTermScale, autoTermCheck, autoTermSamples, g.AutoTermDur)
+	}
+	// Non-terminating context.
+	nonTerm := context.Background()
+	endpoint := g.Admin.GetEndpointURL().String()
+
+	for i := 0; i < g.Concurrency; i++ {
+		go func(i int) {
+			rcv := c.Receiver()
+			defer wg.Done()
+			done := ctx.Done()
+			rng := rand.New(rand.NewSource(int64(rand.Uint64())))
+
+			// Each thread uses its own users, so policies can be attached and detached independently.
+			var users []string
+			for j := i; j < len(g.users); j += g.Concurrency {
+				users = append(users, g.users[j])
+			}
+			attached := make(map[string]bool, len(users))
+			var svcAccts, newUsers []string
+
+			do := func(opType string, fn func(ctx context.Context) error) {
+				op := Operation{
+					OpType:   opType,
+					Thread:   uint16(i),
+					ObjPerOp: 1,
+					Endpoint: endpoint,
+				}
+				opCtx, resp := recordResponse(nonTerm)
+				op.Start = time.Now()
+				err := fn(opCtx)
+				op.End = time.Now()
+				if err != nil {
+					g.Error(opType, " error: ", err)
+					op.Err = err.Error()
+				}
+				resp.apply(&op, g.RecordHeaders)
+				rcv <- op
+			}
+
+			g.startWait(wait)
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if g.rpsLimit(ctx) != nil {
+					return
+				}
+				group, err := g.nextOp(ctx)
+				if err != nil {
+					return
+				}
+				switch group {
+				case IAMServiceAccount:
+					if len(svcAccts) >= g.Keep {
+						key := svcAccts[0]
+						svcAccts = svcAccts[1:]
+						do("SVCACCT-DELETE", func(ctx context.Context) error {
+							return g.Admin.DeleteServiceAccount(ctx, key)
+						})
+						g.removed(key)
+						continue
+					}
+					do("SVCACCT-ADD", func(ctx context.Context) error {
+						creds, err := g.Admin.AddServiceAccount(ctx, madmin.AddServiceAccountReq{
+							TargetUser: users[rng.Intn(len(users))],
+							Name:       g.prefix,
+						})
+						if err == nil {
+							svcAccts = append(svcAccts, creds.AccessKey)
+							g.add(creds.AccessKey, false)
+						}
+						return err
+					})
+				case IAMPolicy:
+					user := users[rng.Intn(len(users))]
+					req := madmin.PolicyAssociationReq{Policies: []string{g.policy}, User: user}
+					if attached[user] {
+						do("POLICY-DETACH", func(ctx context.Context) error {
+							_, err := g.Admin.DetachPolicy(ctx, req)
+							return err
+						})
+						attached[user] = false
+						continue
+					}
+					do("POLICY-ATTACH", func(ctx context.Context) error {
+						_, err := g.Admin.AttachPolicy(ctx, req)
+						return err
+					})
+					attached[user] = true
+				case IAMUser:
+					if len(newUsers) >= g.Keep {
+						user := newUsers[0]
+						newUsers = newUsers[1:]
+						do("USER-REMOVE", func(ctx context.Context) error {
+							return g.Admin.RemoveUser(ctx, user)
+						})
+						g.removed(user)
+						continue
+					}
+					user := fmt.Sprintf("%s-%s", g.prefix, iamRandString(8))
+					do("USER-ADD", func(ctx context.Context) error {
+						return g.Admin.AddUser(ctx, user, iamRandString(24))
+					})
+					newUsers = append(newUsers, user)
+					g.add(user, true)
+				case IAMListUsers:
+					do(IAMListUsers, func(ctx context.Context) error {
+						_, err := g.Admin.ListUsers(ctx)
+						return err
+					})
+				default:
+					g.Error("unknown operation: ", group)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	return c.Close(), nil
+}
+
+// nextOp returns the next operation group from the distribution.
+func (g *IAM) nextOp(ctx context.Context) (string, error) {
+	for i := 1; ; i++ {
+		op := g.Dist.getOp()
+		lim := g.OpRpsLimits[op]
+		if lim == nil || lim.Allow() {
+			return op, nil
+		}
+		if i == mixedLimitDraws {
+			return op, lim.Wait(ctx)
+		}
+	}
+}
+
+// iamRandString returns a random string of lowercase letters and digits.
+func iamRandString(n int) string {
+	const chars = "abcdefghijklmnopqrstuvwxyz0123456789"
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = chars[rand.Intn(len(chars))]
+	}
+	return string(b)
+}
+
+func (g *IAM) add(name string, user bool) {
+	g.mu.Lock()
+	g.created[name] = user
+	g.mu.Unlock()
+}
+
+func (g *IAM) removed(name string) {
+	g.mu.Lock()
+	delete(g.created, name)
+	g.mu.Unlock()
+}
+
+// Cleanup removes all users, service accounts and the policy created.
+func (g *IAM) Cleanup(ctx context.Context) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for name, user := range g.created {
+		var err error
+		if user {
+			err = g.Admin.RemoveUser(ctx, name)
+		} else {
+			err = g.Admin.DeleteServiceAccount(ctx, name)
+		}
+		if err != nil {
+			g.Error("cleanup error: ", err)
+		}
+	}
+	g.created = nil
+	// Removing the users also removes their service accounts.
+	for _, user := range g.users {
+		if err := g.Admin.RemoveUser(ctx, user); err != nil {
+			g.Error("cleanup error: ", err)
+		}
+	}
+	if g.policy != "" {
+		if err := g.Admin.RemoveCannedPolicy(ctx, g.policy); err != nil {
+			g.Error("cleanup error: ", err)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"sort"
+	"time"
+)
+
+// InFlightSegment contains the number of operations in flight during a segment.
+type InFlightSegment struct {
+	Start time.Time `json:"start"`
+	// Avg is the time weighted average number of operations in flight.
+	Avg float64 `json:"avg"`
+	// Min and Max are the lowest and highest number of operations in flight.
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+// InFlight returns the number of operations in flight in each segment of segDur
+// between start and end, based on the overlap of operation start and end times.
+func (o Operations) InFlight(start, end time.Time, segDur time.Duration) []InFlightSegment {
+	if segDur <= 0 || !end.After(start) {
+		return nil
+	}
+	n := int((end.Sub(start) + segDur - 1) / segDur)
+	segs := make([]InFlightSegment, n)
+	busy := make([]time.Duration, n)
+	for i := range segs {
+		segs[i].Start = start.Add(time.Duration(i) * segDur)
+		segs[i].Min = -1
+	}
+	segIdx := func(t time.Time) int {
+		return min(int(t.Sub(start)/segDur), n-1)
+	}
+
+	type event struct {
+		t     time.Time
+		delta int
+	}
+	events := make([]event, 0, len(o)*2)
+	for _, op := range o {
+		events = append(events, event{t: op.Start, delta: 1}, event{t: op.End, delta: -1})
+		s, e := op.Start, op.End
+		if s.Before(start) {
+			s = start
+		}
+		if e.After(end) {
+			e = end
+		}
+		if !e.After(s) {
+			continue
+		}
+		// Add the time in flight to each segment the operation overlaps.
+		for i := segIdx(s); i <= segIdx(e) && i < n; i++ {
+			segStart := segs[i].Start
+			segEnd := segStart.Add(segDur)
+			from, to := s, e
+			if from.Before(segStart) {
+				from = segStart
+			}
+			if to.After(segEnd) {
+				to = segEnd
+			}
+			if to.After(from) {
+				busy[i] += to.Sub(from)
+			}
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].t.Equal(events[j].t) {
+			// End operations before starting new ones.
+			return events[i].delta < events[j].delta
+		}
+		return events[i].t.Before(events[j].t)
+	})
+
+	// Sweep events, tracking the number in flight.
+	current, seg := 0, -1
+	observe := func(i int) {
+		s := &segs[i]
+		if s.Min < 0 || current < s.Min {
+			s.Min = current
+		}
+		if current > s.Max {
+			s.Max = current
+		}
+	}
+	for _, ev := range events {
+		if !ev.t.Before(start) {
+			// Segments entered carry the current number in flight.
+			for next := segIdx(ev.t); seg < next && seg < n-1; {
+				seg++
+				observe(seg)
+			}
+		}
+		current += ev.delta
+		if seg >= 0 && ev.t.Before(end) {
+			observe(seg)
+		}
+	}
+	for seg < n-1 {
+		seg++
+		observe(seg)
+	}
+	for i := range segs {
+		segEnd := segs[i].Start.Add(segDur)
+		if segEnd.After(end) {
+			segEnd = end
+		}
+		if d := segEnd.Sub(segs[i].Start); d > 0 {
+			segs[i].Avg = float64(busy[i]) / float64(d)
+		}
+	}
+	return segs
+}
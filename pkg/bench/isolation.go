@@ -0,0 +1,332 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/generator"
+)
+
+// IsolationBurstPrefix is the prefix of the operation types of the aggressor burst.
+const IsolationBurstPrefix = "BURST-"
+
+// IsolationTenant returns the object prefix of a tenant.
+// Tenant 0 receives the aggressor burst.
+func IsolationTenant(t int) string {
+	return fmt.Sprintf("tenant-%d", t)
+}
+
+// Isolation runs identical GET workloads on several tenant prefixes,
+// while the first tenant receives a burst of additional requests.
+type Isolation struct {
+	Common
+
+	// Tenants is the number of tenant prefixes.
+	// Each tenant is accessed by Concurrency threads.
+	Tenants int
+
+	// CreateObjects is the number of objects uploaded to each tenant.
+	CreateObjects int
+
+	// BurstConcurrency is the number of threads running the burst.
+	BurstConcurrency int
+
+	// BurstOp is the burst operation, http.MethodGet or http.MethodPut.
+	BurstOp string
+
+	// BurstDelay is the time from the start of the benchmark until the burst begins.
+	BurstDelay time.Duration
+
+	// BurstDuration is the length of the burst.
+	BurstDuration time.Duration
+
+	GetOpts minio.GetObjectOptions
+
+	objects []generator.Objects
+
+	// Objects uploaded by the burst.
+	mu    sync.Mutex
+	burst generator.Objects
+}
+
+// Prepare will create an empty bucket or delete any content already there
+// and upload the objects of each tenant.
+func (g *Isolation) Prepare(ctx context.Context) error {
+	if err := g.createEmptyBucket(ctx); err != nil {
+		return err
+	}
+	console.Eraseline()
+	console.Info("\rUploading ", g.CreateObjects, " objects to each of ", g.Tenants, " tenants")
+
+	g.objects = make([]generator.Objects, g.Tenants)
+	total := g.CreateObjects * g.Tenants
+	var wg sync.WaitGroup
+	wg.Add(g.prepareThreads())
+	g.addCollector()
+	objs := splitObjs(total, g.prepareThreads())
+	rcv := g.Collector.rcv
+	var groupErr error
+	var mu sync.Mutex
+	var uploaded int
+
+	for i, obj := range objs {
+		go func(i int, obj []struct{}) {
+			defer wg.Done()
+			src := g.Source()
+			opts := g.PutOpts
+			prep := g.preparer(i, rcv)
+
+			for j := range obj {
+				select {
+				case <-ctx.Done():
+					return
+				default:
+				}
+
+				if g.rpsLimit(ctx) != nil {
+					return
+				}
+
+				tenant := (i + j*len(objs)) % g.Tenants
+				obj := src.Object()
+				obj.Name = path.Join(IsolationTenant(tenant), obj.Name)
+				obj.Prefix = path.Join(IsolationTenant(tenant), obj.Prefix)
+				client, cldone := g.Client()
+				op := Operation{
+					OpType:   http.MethodPut,
+					Thread:   uint16(i),
+					Size:     obj.Size,
+					File:     obj.Name,
+					ObjPerOp: 1,
+					Endpoint: client.EndpointURL().String(),
+				}
+
+				opts.ContentType = obj.ContentType
+				op.Start = time.Now()
+				res, queued, err := prep.upload(ctx, client, &op, obj, opts)
+				op.End = time.Now()
+				cldone()
+				if err == nil && res.Size != obj.Size {
+					err = fmt.Errorf("short upload. want: %d, got %d", obj.Size, res.Size)
+				}
+				if err != nil {
+					err := fmt.Errorf("upload error: %w", err)
+					g.Error(err)
+					mu.Lock()
+					if groupErr == nil {
+						groupErr = err
+					}
+					mu.Unlock()
+					return
+				}
+				mu.Lock()
+				obj.Reader = nil
+				g.objects[tenant] = append(g.objects[tenant], *obj)
+				uploaded++
+				g.prepareProgress(float64(uploaded) / float64(total))
+				mu.Unlock()
+				if !queued {
+					rcv <- op
+				}
+			}
+			client, cldone := g.Client()
+			err := prep.flush(ctx, client)
+			cldone()
+			if err != nil {
+				g.Error(err)
+				mu.Lock()
+				if groupErr == nil {
+					groupErr = err
+				}
+				mu.Unlock()
+			}
+		}(i, obj)
+	}
+	wg.Wait()
+	if groupErr == nil {
+		for t, objs := range g.objects {
+			if len(objs) == 0 {
+				return fmt.Errorf("no objects uploaded to %s", IsolationTenant(t))
+			}
+		}
+	}
+	return groupErr
+}
+
+// Start will execute the main benchmark.
+// Operations should begin executing when the start channel is closed.
+func (g *Isolation) Start(ctx context.Context, wait chan struct{}) (Operations, error) {
+	var wg sync.WaitGroup
+	c := g.Collector
+	// Non-terminating context.
+	nonTerm := context.Background()
+
+	for t := 0; t < g.Tenants; t++ {
+		for i := 0; i < g.Concurrency; i++ {
+			wg.Add(1)
+			go func(t, thread int) {
+				defer wg.Done()
+				rcv := c.Receiver()
+				rng := rand.New(rand.NewSource(int64(thread)))
+				done := ctx.Done()
+
+				g.startWait(wait)
+				for {
+					select {
+					case <-done:
+						return
+					default:
+					}
+					if g.rpsLimit(ctx) != nil {
+						return
+					}
+					rcv <- g.get(nonTerm, rng, t, thread, http.MethodGet)
+				}
+			}(t, t*g.Concurrency+i)
+		}
+	}
+
+	for i := 0; i < g.BurstConcurrency; i++ {
+		wg.Add(1)
+		go func(thread int) {
+			defer wg.Done()
+			rcv := c.Receiver()
+			rng := rand.New(rand.NewSource(int64(thread)))
+			src := g.Source()
+			done := ctx.Done()
+
+			g.startWait(wait)
+			select {
+			case <-done:
+				return
+			case <-time.After(g.BurstDelay):
+			}
+			end := time.Now().Add(g.BurstDuration)
+			for time.Now().Before(end) {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if g.BurstOp == http.MethodPut {
+					rcv <- g.put(nonTerm, src, thread)
+					continue
+				}
+				rcv <- g.get(nonTerm, rng, 0, thread, IsolationBurstPrefix+http.MethodGet)
+			}
+		}(g.Tenants*g.Concurrency + i)
+	}
+	wg.Wait()
+	return c.Close(), nil
+}
+
+// get downloads a random object of a tenant.
+func (g *Isolation) get(ctx context.Context, rng *rand.Rand, tenant, thread int, opType string) Operation {
+	objs := g.objects[tenant]
+	obj := objs[rng.Intn(len(objs))]
+	client, cldone := g.Client()
+	defer cldone()
+	op := Operation{
+		OpType:   opType,
+		Thread:   uint16(thread),
+		Size:     obj.Size,
+		File:     obj.Name,
+		ObjPerOp: 1,
+		Endpoint: client.EndpointURL().String(),
+	}
+	fbr := firstByteRecorder{}
+	op.Start = time.Now()
+	opCtx, resp := recordResponse(ctx)
+	o, err := client.GetObject(opCtx, g.Bucket, obj.Name, g.GetOpts)
+	if err != nil {
+		g.Error("download error:", err)
+		op.Err = err.Error()
+		op.End = time.Now()
+		resp.apply(&op, g.RecordHeaders)
+		return op
+	}
+	defer o.Close()
+	fbr.r = o
+	n, err := io.Copy(io.Discard, &fbr)
+	if err != nil {
+		g.Error("download error:", err)
+		op.Err = err.Error()
+	}
+	op.FirstByte = fbr.t
+	op.End = time.Now()
+	resp.apply(&op, g.RecordHeaders)
+	if n != obj.Size && op.Err == "" {
+		op.Err = fmt.Sprint("unexpected download size. want:", obj.Size, ", got:", n)
+		g.Error(op.Err)
+	}
+	return op
+}
+
+// put uploads a new object to the burst prefix of the first tenant.
+func (g *Isolation) put(ctx context.Context, src generator.Source, thread int) Operation {
+	obj := src.Object()
+	obj.Name = path.Join(IsolationTenant(0), "burst", obj.Name)
+	obj.Prefix = path.Join(IsolationTenant(0), "burst", obj.Prefix)
+	client, cldone := g.Client()
+	defer cldone()
+	op := Operation{
+		OpType:   IsolationBurstPrefix + http.MethodPut,
+		Thread:   uint16(thread),
+		Size:     obj.Size,
+		File:     obj.Name,
+		ObjPerOp: 1,
+		Endpoint: client.EndpointURL().String(),
+	}
+	opts := g.PutOpts
+	opts.ContentType = obj.ContentType
+	op.Start = time.Now()
+	opCtx, resp := recordResponse(ctx)
+	res, err := client.PutObject(opCtx, g.Bucket, obj.Name, obj.Reader, obj.Size, opts)
+	op.End = time.Now()
+	resp.apply(&op, g.RecordHeaders)
+	if err != nil {
+		g.Error("upload error:", err)
+		op.Err = err.Error()
+		return op
+	}
+	if res.Size != obj.Size {
+		op.Err = fmt.Sprint("short upload. want:", obj.Size, ", got:", res.Size)
+		g.Error(op.Err)
+	}
+	obj.Reader = nil
+	g.mu.Lock()
+	g.burst = append(g.burst, *obj)
+	g.mu.Unlock()
+	return op
+}
+
+// Cleanup deletes everything uploaded to the bucket.
+func (g *Isolation) Cleanup(ctx context.Context) {
+	all := append([]generator.Objects{g.burst}, g.objects...)
+	g.deleteAllInBucket(ctx, generator.MergeObjectPrefixes(all)...)
+}
@@ -0,0 +1,406 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math/rand"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+const (
+	// LargePutProgressOp is the operation type of progress reported during an upload.
+	LargePutProgressOp = "PUT-PROGRESS"
+	// LargeGetProgressOp is the operation type of progress reported during a download.
+	LargeGetProgressOp = "GET-PROGRESS"
+)
+
+// Large benchmarks upload and download of very large objects.
+// Content is generated while streaming and can be regenerated at any offset,
+// so downloads can be verified by comparing sampled byte ranges.
+// Each thread uploads an object, downloads it and deletes it.
+type Large struct {
+	Common
+
+	GetOpts    minio.GetObjectOptions
+	ObjectSize int64
+
+	// ProgressInterval is the interval at which progress operations
+	// are emitted during a single upload or download.
+	ProgressInterval time.Duration
+
+	// VerifySamples is the number of byte ranges of VerifySize
+	// that are compared on each download.
+	VerifySamples int
+	VerifySize    int64
+
+	// SkipDownload will only upload and delete objects.
+	SkipDownload bool
+
+	mu       sync.Mutex
+	prefixes map[string]struct{}
+}
+
+// largeReader returns deterministic content for an object.
+// Content is an AES-CTR keystream keyed by the object name,
+// so any range can be generated without reading what comes before it.
+type largeReader struct {
+	block     cipher.Block
+	seed      int64
+	size, pos int64
+}
+
+func newLargeReader(name string, size int64) *largeReader {
+	h := fnv.New128a()
+	h.Write([]byte(name))
+	key := h.Sum(nil)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		panic(err)
+	}
+	return &largeReader{block: block, seed: int64(binary.LittleEndian.Uint64(key)), size: size}
+}
+
+// ReadAt fills p with the content at offset off.
+func (l *largeReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	if off >= l.size {
+		return 0, io.EOF
+	}
+	var err error
+	if rem := l.size - off; int64(len(p)) > rem {
+		p = p[:rem]
+		err = io.EOF
+	}
+	var iv [aes.BlockSize]byte
+	binary.BigEndian.PutUint64(iv[8:], uint64(off/aes.BlockSize))
+	ctr := cipher.NewCTR(l.block, iv[:])
+	if skip := off % aes.BlockSize; skip > 0 {
+		var tmp [aes.BlockSize]byte
+		ctr.XORKeyStream(tmp[:skip], tmp[:skip])
+	}
+	clear(p)
+	ctr.XORKeyStream(p, p)
+	return len(p), err
+}
+
+// Read implements io.Reader.
+func (l *largeReader) Read(p []byte) (int, error) {
+	n, err := l.ReadAt(p, l.pos)
+	l.pos += int64(n)
+	if n > 0 && err == io.EOF {
+		err = nil
+	}
+	return n, err
+}
+
+// Seek implements io.Seeker.
+func (l *largeReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += l.pos
+	case io.SeekEnd:
+		offset += l.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	l.pos = offset
+	return offset, nil
+}
+
+// progressCounter counts transferred bytes.
+// It is used as upload progress reader and download writer.
+type progressCounter struct {
+	n atomic.Int64
+}
+
+func (p *progressCounter) Read(b []byte) (int, error) {
+	p.n.Add(int64(len(b)))
+	return len(b), nil
+}
+
+// reportProgress sends an operation with the bytes transferred since the last report
+// every interval until the returned function is called.
+// The returned function will send the remaining bytes.
+func reportProgress(rcv chan<- Operation, template Operation, counter *progressCounter, interval time.Duration) func() {
+	if interval <= 0 {
+		return func() {}
+	}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		last := time.Now()
+		var sent int64
+		send := func() {
+			now := time.Now()
+			n := counter.n.Load()
+			op := template
+			op.Size = n - sent
+			op.Start = last
+			op.End = now
+			rcv <- op
+			sent, last = n, now
+		}
+		for {
+			select {
+			case <-ticker.C:
+				send()
+			case <-stop:
+				if counter.n.Load() > sent {
+					send()
+				}
+				return
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		<-done
+	}
+}
+
+// sampleVerifier compares sampled ranges of a download
+// to the generated content and counts the bytes written.
+type sampleVerifier struct {
+	src      *largeReader
+	samples  [][2]int64
+	pos      int64
+	buf      []byte
+	progress *progressCounter
+}
+
+// newSampleVerifier returns a verifier of n ranges of size bytes.
+// The last bytes of the object are always included.
+func newSampleVerifier(src *largeReader, n int, size int64, progress *progressCounter) *sampleVerifier {
+	v := sampleVerifier{src: src, progress: progress}
+	if n <= 0 || size <= 0 {
+		return &v
+	}
+	size = min(size, src.size)
+	rng := rand.New(rand.NewSource(src.seed))
+	v.samples = append(v.samples, [2]int64{src.size - size, src.size})
+	for i := 1; i < n; i++ {
+		start := rng.Int63n(src.size - size + 1)
+		v.samples = append(v.samples, [2]int64{start, start + size})
+	}
+	sort.Slice(v.samples, func(i, j int) bool {
+		return v.samples[i][0] < v.samples[j][0]
+	})
+	v.buf = make([]byte, size)
+	return &v
+}
+
+// Write implements io.Writer.
+func (v *sampleVerifier) Write(p []byte) (int, error) {
+	start, end := v.pos, v.pos+int64(len(p))
+	for _, s := range v.samples {
+		if s[0] >= end {
+			break
+		}
+		lo, hi := max(s[0], start), min(s[1], end)
+		if lo >= hi {
+			continue
+		}
+		want := v.buf[:hi-lo]
+		v.src.ReadAt(want, lo)
+		if !bytes.Equal(p[lo-start:hi-start], want) {
+			return 0, fmt.Errorf("content mismatch in range %d-%d", lo, hi)
+		}
+	}
+	v.pos = end
+	v.progress.n.Add(int64(len(p)))
+	return len(p), nil
+}
+
+// Prepare will create an empty bucket or delete any content already there.
+func (l *Large) Prepare(ctx context.Context) error {
+	if l.ObjectSize <= 0 {
+		return errors.New("object size must be specified")
+	}
+	if err := l.createEmptyBucket(ctx); err != nil {
+		return err
+	}
+	l.addCollector()
+	l.prefixes = make(map[string]struct{}, l.Concurrency)
+	return nil
+}
+
+// Start will execute the main benchmark.
+// Operations should begin executing when the start channel is closed.
+func (l *Large) Start(ctx context.Context, wait chan struct{}) (Operations, error) {
+	var wg sync.WaitGroup
+	wg.Add(l.Concurrency)
+	c := l.Collector
+	if l.AutoTermDur > 0 {
+		ctx = c.AutoTerm(ctx, LargePutProgressOp, l.AutoTermScale, autoTermCheck, autoTermSamples, l.AutoTermDur)
+	}
+	// Non-terminating context.
+	nonTerm := context.Background()
+
+	for i := 0; i < l.Concurrency; i++ {
+		go func(i int) {
+			rcv := c.Receiver()
+			defer wg.Done()
+			done := ctx.Done()
+			src := l.Source()
+			l.mu.Lock()
+			l.prefixes[src.Prefix()] = struct{}{}
+			l.mu.Unlock()
+
+			l.startWait(wait)
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				if l.rpsLimit(ctx) != nil {
+					return
+				}
+
+				name := src.Object().Name
+				client, cldone := l.Client()
+				op := Operation{
+					OpType:   http.MethodPut,
+					Thread:   uint16(i),
+					Size:     l.ObjectSize,
+					File:     name,
+					ObjPerOp: 1,
+					Endpoint: client.EndpointURL().String(),
+				}
+				if l.DiscardOutput {
+					op.File = ""
+				}
+				progress := op
+				progress.OpType = LargePutProgressOp
+				progress.ObjPerOp = 0
+
+				// Upload
+				var counter progressCounter
+				opts := l.PutOpts
+				opts.Progress = &counter
+				stop := reportProgress(rcv, progress, &counter, l.ProgressInterval)
+				opCtx, resp := recordResponse(nonTerm)
+				op.Start = time.Now()
+				res, err := client.PutObject(opCtx, l.Bucket, name, newLargeReader(name, l.ObjectSize), l.ObjectSize, opts)
+				op.End = time.Now()
+				stop()
+				resp.apply(&op, l.RecordHeaders)
+				if err == nil && res.Size != l.ObjectSize {
+					err = fmt.Errorf("short upload. want: %d, got %d", l.ObjectSize, res.Size)
+				}
+				if err != nil {
+					l.Error("upload error: ", err)
+					op.Err = err.Error()
+				}
+				rcv <- op
+				if op.Err != "" {
+					cldone()
+					continue
+				}
+
+				// Download
+				if !l.SkipDownload {
+					counter.n.Store(0)
+					progress.OpType = LargeGetProgressOp
+					op.OpType = http.MethodGet
+					op.Err = ""
+					op.FirstByte = nil
+					verify := newSampleVerifier(newLargeReader(name, l.ObjectSize), l.VerifySamples, l.VerifySize, &counter)
+					stop = reportProgress(rcv, progress, &counter, l.ProgressInterval)
+					opCtx, resp = recordResponse(nonTerm)
+					op.Start = time.Now()
+					o, err := client.GetObject(opCtx, l.Bucket, name, l.GetOpts)
+					var n int64
+					if err == nil {
+						fbr := firstByteRecorder{r: o}
+						n, err = io.CopyBuffer(verify, &fbr, make([]byte, 1<<20))
+						op.FirstByte = fbr.t
+						o.Close()
+					}
+					op.End = time.Now()
+					stop()
+					resp.apply(&op, l.RecordHeaders)
+					if err == nil && n != l.ObjectSize {
+						err = fmt.Errorf("unexpected download size. want: %d, got: %d", l.ObjectSize, n)
+					}
+					if err != nil {
+						l.Error("download error: ", err)
+						op.Err = err.Error()
+					}
+					rcv <- op
+				}
+
+				// Delete
+				op = Operation{
+					OpType:   http.MethodDelete,
+					Thread:   uint16(i),
+					File:     op.File,
+					ObjPerOp: 1,
+					Endpoint: op.Endpoint,
+				}
+				opCtx, resp = recordResponse(nonTerm)
+				op.Start = time.Now()
+				err = client.RemoveObject(opCtx, l.Bucket, name, minio.RemoveObjectOptions{})
+				op.End = time.Now()
+				resp.apply(&op, l.RecordHeaders)
+				cldone()
+				if err != nil {
+					l.Error("delete error: ", err)
+					op.Err = err.Error()
+				}
+				rcv <- op
+			}
+		}(i)
+	}
+	wg.Wait()
+	return c.Close(), nil
+}
+
+// Cleanup deletes everything uploaded to the bucket.
+func (l *Large) Cleanup(ctx context.Context) {
+	pf := make([]string, 0, len(l.prefixes))
+	for p := range l.prefixes {
+		pf = append(pf, p)
+	}
+	l.deleteAllInBucket(ctx, pf...)
+}
@@ -22,6 +22,8 @@ import (
 	"fmt"
 	"math/rand"
 	"net/http"
+	"path"
+	"strings"
 	"sync"
 	"time"
 
@@ -31,6 +33,42 @@ import (
 	"github.com/minio/warp/pkg/generator"
 )
 
+// ListAPI is a listing API variant.
+type ListAPI string
+
+const (
+	// ListAPIV1 uses the ListObjects (V1) API.
+	ListAPIV1 ListAPI = "v1"
+	// ListAPIV2 uses the ListObjectsV2 API.
+	// Owner information is always requested.
+	ListAPIV2 ListAPI = "v2"
+	// ListAPIMetadata uses the MinIO ListObjectsV2 extension that returns metadata.
+	ListAPIMetadata ListAPI = "metadata"
+)
+
+// ListAPIs contains all supported listing API variants.
+var ListAPIs = []ListAPI{ListAPIV1, ListAPIV2, ListAPIMetadata}
+
+// options returns the list options for the API variant.
+func (l ListAPI) options(o minio.ListObjectsOptions) minio.ListObjectsOptions {
+	switch l {
+	case ListAPIV1:
+		o.UseV1 = true
+	case ListAPIMetadata:
+		o.WithMetadata = true
+	}
+	return o
+}
+
+// opType returns the operation type used for the variant.
+// If only a single variant is benchmarked 'LIST' is used.
+func (l ListAPI) opType(compare bool) string {
+	if !compare {
+		return "LIST"
+	}
+	return "LIST-" + strings.ToUpper(string(l))
+}
+
 // List benchmarks listing speed.
 type List struct {
 	Common
@@ -40,8 +78,30 @@ type List struct {
 	Versions      int
 	NoPrefix      bool
 	Metadata      bool
+
+	// APIs to benchmark. If more than one is specified
+	// they will be used in turn, so they can be compared.
+	// If empty, ListAPIV2 is used unless Metadata is set.
+	APIs []ListAPI
+
+	// Depth will place objects in a directory tree of this depth below each prefix.
+	Depth int
+	// FanOut is the number of directories on each level of the tree.
+	FanOut int
+	// MaxKeys is the number of objects requested per page.
+	MaxKeys int
+	// Delimiter will list using '/' as delimiter and traverse all prefixes.
+	// Each page will be recorded as a separate operation.
+	Delimiter bool
+
+	// Mutate is the number of threads that PUT and DELETE objects in the listed prefixes while listing.
+	// Listings must then contain each prepared object exactly once.
+	Mutate int
 }
 
+// mutateKeep is the number of objects each mutating thread keeps before deleting the oldest.
+const mutateKeep = 10
+
 // Prepare will create an empty bucket or delete any content already there
 // and upload a number of objects.
 func (d *List) Prepare(ctx context.Context) error {
@@ -86,6 +146,7 @@ func (d *List) Prepare(ctx context.Context) error {
 			rcv := d.Collector.Receiver()
 			done := ctx.Done()
 			exists := make(map[string]struct{}, objPerPrefix)
+			prep := d.preparer(i, rcv)
 
 			for j := 0; j < objPerPrefix; j++ {
 				select {
@@ -109,6 +170,9 @@ func (d *List) Prepare(ctx context.Context) error {
 				}
 				name := obj.Name
 				exists[name] = struct{}{}
+				if d.Depth > 0 {
+					name = d.treeName(obj, j)
+				}
 				for ver := 0; ver < d.Versions; ver++ {
 					// New input for each version
 					obj := src.Object()
@@ -125,7 +189,7 @@ func (d *List) Prepare(ctx context.Context) error {
 
 					opts.ContentType = obj.ContentType
 					op.Start = time.Now()
-					res, err := client.PutObject(ctx, d.Bucket, obj.Name, obj.Reader, obj.Size, opts)
+					res, queued, err := prep.upload(ctx, client, &op, obj, opts)
 					op.End = time.Now()
 					if err != nil {
 						err := fmt.Errorf("upload error: %w", err)
@@ -155,8 +219,21 @@ func (d *List) Prepare(ctx context.Context) error {
 					objsCreated++
 					d.prepareProgress(float64(objsCreated) / float64(objPerPrefix*d.Concurrency*d.Versions))
 					mu.Unlock()
-					rcv <- op
+					if !queued {
+						rcv <- op
+					}
+				}
+			}
+			client, cldone := d.Client()
+			err := prep.flush(ctx, client)
+			cldone()
+			if err != nil {
+				d.Error(err)
+				mu.Lock()
+				if groupErr == nil {
+					groupErr = err
 				}
+				mu.Unlock()
 			}
 		}(i)
 	}
@@ -175,14 +252,29 @@ func (d *List) Prepare(ctx context.Context) error {
 // Operations should begin executing when the start channel is closed.
 func (d *List) Start(ctx context.Context, wait chan struct{}) (Operations, error) {
 	var wg sync.WaitGroup
-	wg.Add(d.Concurrency)
+	wg.Add(d.Concurrency + d.Mutate)
 	c := d.Collector
+	apis := d.APIs
+	if len(apis) == 0 {
+		apis = []ListAPI{ListAPIV2}
+		if d.Metadata {
+			apis = []ListAPI{ListAPIMetadata}
+		}
+	}
+	compare := len(apis) > 1
 	if d.AutoTermDur > 0 {
-		ctx = c.AutoTerm(ctx, "LIST", d.AutoTermScale, autoTermCheck, autoTermSamples, d.AutoTermDur)
+		ctx = c.AutoTerm(ctx, apis[0].opType(compare), d.AutoTermScale, autoTermCheck, autoTermSamples, d.AutoTermDur)
 	}
 	// Non-terminating context.
 	nonTerm := context.Background()
 
+	for i := 0; i < d.Mutate; i++ {
+		go func(i int) {
+			defer wg.Done()
+			d.startWait(wait)
+			d.mutate(ctx, d.Concurrency+i, d.objects[i%d.Concurrency][0].Prefix, c.Receiver())
+		}(i)
+	}
 	for i := 0; i < d.Concurrency; i++ {
 		go func(i int) {
 			rcv := c.Receiver()
@@ -193,8 +285,22 @@ func (d *List) Start(ctx context.Context, wait chan struct{}) (Operations, error
 			if d.NoPrefix {
 				wantN *= d.Concurrency
 			}
+			var stable map[string]struct{}
+			if d.Mutate > 0 {
+				stable = make(map[string]struct{}, wantN)
+				prefix := objs[0].Prefix
+				for _, objs := range d.objects {
+					for _, obj := range objs {
+						if d.NoPrefix || obj.Prefix == prefix {
+							stable[obj.Name] = struct{}{}
+						}
+					}
+				}
+			}
+			// Start each thread on a different API.
+			n := i
 
-			<-wait
+			d.startWait(wait)
 			for {
 				select {
 				case <-done:
@@ -207,25 +313,48 @@ func (d *List) Start(ctx context.Context, wait chan struct{}) (Operations, error
 				}
 
 				prefix := objs[0].Prefix
+				api := apis[n%len(apis)]
+				n++
 				client, cldone := d.Client()
 				op := Operation{
 					File:     prefix,
-					OpType:   "LIST",
+					OpType:   api.opType(compare),
 					Thread:   uint16(i),
 					Size:     0,
 					Endpoint: client.EndpointURL().String(),
 				}
 
 				op.Start = time.Now()
+				if d.Delimiter {
+					found, err := d.listTree(client, api, prefix, op, rcv)
+					op.End = time.Now()
+					op.ObjPerOp = found
+					if err != nil {
+						d.Error(err)
+						op.Err = err.Error()
+					}
+					if op.Err == "" && found != wantN {
+						op.Err = fmt.Sprintf("Unexpected object count, want %d, got %d", wantN, found)
+					}
+					cldone()
+					rcv <- op
+					continue
+				}
 
 				// List all objects with prefix
-				listCh := client.ListObjects(nonTerm, d.Bucket, minio.ListObjectsOptions{
-					WithMetadata: d.Metadata,
+				opCtx, resp := recordResponse(nonTerm)
+				listCh := client.ListObjects(opCtx, d.Bucket, api.options(minio.ListObjectsOptions{
 					Prefix:       objs[0].Prefix,
 					Recursive:    true,
 					WithVersions: d.Versions > 1,
-					MaxKeys:      100,
-				})
+					MaxKeys:      d.maxKeys(),
+				}))
+
+				var seen map[string]struct{}
+				var duplicates int
+				if stable != nil {
+					seen = make(map[string]struct{}, wantN+mutateKeep)
+				}
 
 				// Wait for errCh to close.
 				for {
@@ -236,6 +365,11 @@ func (d *List) Start(ctx context.Context, wait chan struct{}) (Operations, error
 					if err.Err != nil {
 						d.Error(err.Err)
 						op.Err = err.Err.Error()
+					} else if seen != nil {
+						if _, ok := seen[err.Key]; ok {
+							duplicates++
+						}
+						seen[err.Key] = struct{}{}
 					}
 					op.ObjPerOp++
 					if op.FirstByte == nil {
@@ -243,12 +377,25 @@ func (d *List) Start(ctx context.Context, wait chan struct{}) (Operations, error
 						op.FirstByte = &now
 					}
 				}
-				if op.ObjPerOp != wantN {
-					if op.Err == "" {
-						op.Err = fmt.Sprintf("Unexpected object count, want %d, got %d", wantN, op.ObjPerOp)
+				switch {
+				case op.Err != "":
+				case stable != nil:
+					var found int
+					for name := range stable {
+						if _, ok := seen[name]; ok {
+							found++
+						}
+					}
+					if duplicates > 0 {
+						op.Err = fmt.Sprintf("Listing returned %d duplicate entries", duplicates)
+					} else if found != len(stable) {
+						op.Err = fmt.Sprintf("Listing is missing %d of %d objects", len(stable)-found, len(stable))
 					}
+				case op.ObjPerOp != wantN:
+					op.Err = fmt.Sprintf("Unexpected object count, want %d, got %d", wantN, op.ObjPerOp)
 				}
 				op.End = time.Now()
+				resp.apply(&op, d.RecordHeaders)
 				cldone()
 				rcv <- op
 			}
@@ -258,6 +405,140 @@ func (d *List) Start(ctx context.Context, wait chan struct{}) (Operations, error
 	return c.Close(), nil
 }
 
+// mutate will PUT new objects in prefix and DELETE the oldest, keeping mutateKeep objects, until ctx is canceled.
+// The objects left are deleted on cleanup.
+func (d *List) mutate(ctx context.Context, thread int, prefix string, rcv chan<- Operation) {
+	rng := rand.New(rand.NewSource(int64(thread)))
+	src := d.Source()
+	opts := d.PutOpts
+	// Non-terminating context.
+	nonTerm := context.Background()
+	var created []string
+	for ctx.Err() == nil {
+		client, cldone := d.Client()
+		op := Operation{
+			Thread:   uint16(thread),
+			ObjPerOp: 1,
+			Endpoint: client.EndpointURL().String(),
+		}
+		opCtx, resp := recordResponse(nonTerm)
+		var err error
+		if len(created) < mutateKeep {
+			obj := src.Object()
+			obj.Name = path.Join(prefix, fmt.Sprintf("mutate-%d-%016x", thread, rng.Uint64()))
+			opts.ContentType = obj.ContentType
+			op.OpType, op.File, op.Size = http.MethodPut, obj.Name, obj.Size
+			op.Start = time.Now()
+			_, err = client.PutObject(opCtx, d.Bucket, obj.Name, obj.Reader, obj.Size, opts)
+			if err == nil {
+				created = append(created, obj.Name)
+			}
+		} else {
+			op.OpType, op.File = http.MethodDelete, created[0]
+			op.Start = time.Now()
+			err = client.RemoveObject(opCtx, d.Bucket, created[0], minio.RemoveObjectOptions{})
+			if err == nil {
+				created = created[1:]
+			}
+		}
+		op.End = time.Now()
+		resp.apply(&op, d.RecordHeaders)
+		cldone()
+		if err != nil {
+			d.Error(op.OpType, " error: ", err)
+			op.Err = err.Error()
+		}
+		rcv <- op
+	}
+}
+
+// maxKeys returns the number of keys to request per page.
+func (d *List) maxKeys() int {
+	if d.MaxKeys <= 0 {
+		return 100
+	}
+	return d.MaxKeys
+}
+
+// treeName returns the name of object n placed in a directory tree below the object prefix.
+// Objects are distributed evenly between the leaves of the tree.
+func (d *List) treeName(obj *generator.Object, n int) string {
+	fanOut := d.FanOut
+	if fanOut <= 0 {
+		fanOut = 10
+	}
+	dirs := make([]string, 0, d.Depth+2)
+	dirs = append(dirs, obj.Prefix)
+	for l := 0; l < d.Depth; l++ {
+		dirs = append(dirs, fmt.Sprintf("d%d", n%fanOut))
+		n /= fanOut
+	}
+	dirs = append(dirs, path.Base(obj.Name))
+	return path.Join(dirs...)
+}
+
+// listTree will list prefix using '/' as delimiter and descend into all common prefixes.
+// Each page is sent as an operation to rcv, with op used as template.
+// The number of objects found is returned.
+func (d *List) listTree(client *minio.Client, api ListAPI, prefix string, op Operation, rcv chan<- Operation) (objects int, err error) {
+	core := minio.Core{Client: client}
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	todo := []string{prefix}
+	for len(todo) > 0 {
+		prefix := todo[0]
+		todo = todo[1:]
+		var marker string
+		for {
+			page := op
+			page.OpType = op.OpType + "-PAGE"
+			page.File = prefix
+			page.Start = time.Now()
+			var contents []minio.ObjectInfo
+			var prefixes []minio.CommonPrefix
+			var truncated bool
+			switch api {
+			case ListAPIV1:
+				var res minio.ListBucketResult
+				res, err = core.ListObjects(d.Bucket, prefix, marker, "/", d.maxKeys())
+				contents, prefixes, truncated = res.Contents, res.CommonPrefixes, res.IsTruncated
+				marker = res.NextMarker
+				if marker == "" {
+					// Without NextMarker continue after the last key or common prefix returned.
+					if len(contents) > 0 {
+						marker = contents[len(contents)-1].Key
+					}
+					if len(prefixes) > 0 && prefixes[len(prefixes)-1].Prefix > marker {
+						marker = prefixes[len(prefixes)-1].Prefix
+					}
+				}
+			default:
+				var res minio.ListBucketV2Result
+				res, err = core.ListObjectsV2(d.Bucket, prefix, "", marker, "/", d.maxKeys())
+				contents, prefixes, truncated = res.Contents, res.CommonPrefixes, res.IsTruncated
+				marker = res.NextContinuationToken
+			}
+			page.End = time.Now()
+			page.ObjPerOp = len(contents) + len(prefixes)
+			if err != nil {
+				page.Err = err.Error()
+				rcv <- page
+				return objects, err
+			}
+			rcv <- page
+			objects += len(contents)
+			for _, p := range prefixes {
+				todo = append(todo, p.Prefix)
+			}
+			if !truncated {
+				break
+			}
+		}
+	}
+	return objects, nil
+}
+
 // Cleanup deletes everything uploaded to the bucket.
 func (d *List) Cleanup(ctx context.Context) {
 	d.deleteAllInBucket(ctx, generator.MergeObjectPrefixes(d.objects)...)
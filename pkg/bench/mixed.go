@@ -24,12 +24,15 @@ import (
 	"io"
 	"math/rand"
 	"net/http"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/pkg/v2/console"
 	"github.com/minio/warp/pkg/generator"
+	"golang.org/x/time/rate"
 )
 
 // Mixed benchmarks mixed operations all inclusive.
@@ -40,6 +43,57 @@ type Mixed struct {
 	GetOpts       minio.GetObjectOptions
 	StatOpts      minio.StatObjectOptions
 	CreateObjects int
+
+	// Chain contains the steps executed on a new object
+	// when MixedChainOp is selected by the distribution.
+	Chain []ChainStep
+
+	// OpRpsLimits contains rate limits for operation types.
+	// Operation types without a limit are not limited.
+	OpRpsLimits map[string]*rate.Limiter
+}
+
+// mixedLimitDraws is the number of operations drawn before waiting
+// for the rate limit of the last drawn operation.
+const mixedLimitDraws = 10
+
+// MixedChainOp is the operation type of a complete operation chain.
+const MixedChainOp = "CHAIN"
+
+// ChainStep is a single step of an operation chain.
+type ChainStep struct {
+	// OpType is PUT, GET, STAT or DELETE.
+	OpType string
+	// Delay before the step is executed.
+	Delay time.Duration
+}
+
+// ParseChain parses an operation chain, for example "PUT,GET:100ms,DELETE".
+// Each step can have a delay before it is executed.
+// The chain must start with a PUT.
+func ParseChain(s string) ([]ChainStep, error) {
+	var steps []ChainStep
+	for _, part := range strings.Split(s, ",") {
+		op, delay, hasDelay := strings.Cut(strings.TrimSpace(part), ":")
+		step := ChainStep{OpType: strings.ToUpper(op)}
+		switch step.OpType {
+		case http.MethodPut, http.MethodGet, "STAT", http.MethodDelete:
+		default:
+			return nil, fmt.Errorf("unknown chain operation %q", op)
+		}
+		if hasDelay {
+			d, err := time.ParseDuration(delay)
+			if err != nil {
+				return nil, fmt.Errorf("chain step %q: %w", part, err)
+			}
+			step.Delay = d
+		}
+		steps = append(steps, step)
+	}
+	if len(steps) == 0 || steps[0].OpType != http.MethodPut {
+		return nil, errors.New("operation chain must start with PUT")
+	}
+	return steps, nil
 }
 
 // MixedDistribution keeps track of operation distribution
@@ -47,8 +101,20 @@ type Mixed struct {
 type MixedDistribution struct {
 	// Operation -> distribution.
 	Distribution map[string]float64
-	objects      map[string]generator.Object
-	rng          *rand.Rand
+
+	// Recency makes reads select recently uploaded objects more often than old objects.
+	// The age of read objects is exponentially distributed,
+	// with the mean age as a fraction of the objects in the pool.
+	// 0 selects objects uniformly.
+	Recency float64
+
+	objects map[string]generator.Object
+	rng     *rand.Rand
+
+	// seq contains the upload order of objects in the pool.
+	seq   map[string]uint64
+	order []string
+	added uint64
 
 	ops []string
 
@@ -61,6 +127,7 @@ func (m *MixedDistribution) Generate(allocObjs int) error {
 		return errors.New("DELETE distribution cannot be bigger than PUT")
 	}
 	m.objects = make(map[string]generator.Object, allocObjs)
+	m.seq = make(map[string]uint64, allocObjs)
 
 	err := m.normalize()
 	if err != nil {
@@ -82,6 +149,30 @@ func (m *MixedDistribution) Generate(allocObjs int) error {
 	return nil
 }
 
+// Update replaces the distribution while the benchmark is running.
+// Objects are kept.
+func (m *MixedDistribution) Update(dist map[string]float64) error {
+	next := MixedDistribution{Distribution: dist}
+	if err := next.Generate(0); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.Distribution, m.ops, m.current = next.Distribution, next.ops, 0
+	m.mu.Unlock()
+	return nil
+}
+
+// Current returns a copy of the current normalized distribution.
+func (m *MixedDistribution) Current() map[string]float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	res := make(map[string]float64, len(m.Distribution))
+	for op, v := range m.Distribution {
+		res[op] = v
+	}
+	return res
+}
+
 func (m *MixedDistribution) Objects() generator.Objects {
 	res := make(generator.Objects, 0, len(m.objects))
 	for _, v := range m.objects {
@@ -110,6 +201,15 @@ func (m *MixedDistribution) normalize() error {
 func (m *MixedDistribution) randomObj() (obj generator.Object, done func()) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if o, ok := m.recentObj(); ok {
+		k := o.Name
+		delete(m.objects, k)
+		return o, func() {
+			m.mu.Lock()
+			m.objects[k] = obj
+			m.mu.Unlock()
+		}
+	}
 	// Use map randomness to select.
 	for k, o := range m.objects {
 		delete(m.objects, k)
@@ -128,6 +228,7 @@ func (m *MixedDistribution) deleteRandomObj() generator.Object {
 	// Use map randomness to select.
 	for k, o := range m.objects {
 		delete(m.objects, k)
+		delete(m.seq, k)
 		return o
 	}
 	panic("ran out of objects")
@@ -136,9 +237,64 @@ func (m *MixedDistribution) deleteRandomObj() generator.Object {
 func (m *MixedDistribution) addObj(o generator.Object) {
 	m.mu.Lock()
 	m.objects[o.Name] = o
+	if m.Recency > 0 {
+		m.added++
+		if _, ok := m.seq[o.Name]; !ok {
+			m.order = append(m.order, o.Name)
+		}
+		m.seq[o.Name] = m.added
+		m.compactOrder()
+	}
 	m.mu.Unlock()
 }
 
+// recentObj selects an object with recency weighting.
+// Objects in use by other operations are skipped.
+// Returns false if recency weighting is disabled or no object was found.
+// m.mu must be held.
+func (m *MixedDistribution) recentObj() (generator.Object, bool) {
+	if m.Recency <= 0 || len(m.order) == 0 {
+		return generator.Object{}, false
+	}
+	mean := m.Recency * float64(len(m.seq))
+	for tries := 0; tries < 10; tries++ {
+		age := int(m.rng.ExpFloat64() * mean)
+		if age >= len(m.order) {
+			continue
+		}
+		name := m.order[len(m.order)-1-age]
+		if o, ok := m.objects[name]; ok {
+			return o, true
+		}
+	}
+	return generator.Object{}, false
+}
+
+// compactOrder removes deleted objects and duplicate names from the upload order,
+// when they make up more than half of it.
+// m.mu must be held.
+func (m *MixedDistribution) compactOrder() {
+	if len(m.order) < 2*len(m.seq)+1000 {
+		return
+	}
+	order := make([]string, 0, len(m.seq))
+	seen := make(map[string]struct{}, len(m.seq))
+	// Keep the latest position of each name.
+	for i := len(m.order) - 1; i >= 0; i-- {
+		name := m.order[i]
+		if _, ok := m.seq[name]; !ok {
+			continue
+		}
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		order = append(order, name)
+	}
+	slices.Reverse(order)
+	m.order = order
+}
+
 func (m *MixedDistribution) getOp() string {
 	m.mu.Lock()
 	op := m.ops[m.current]
@@ -160,11 +316,11 @@ func (g *Mixed) Prepare(ctx context.Context) error {
 	console.Eraseline()
 	console.Info("\rUploading ", g.CreateObjects, " objects of ", src.String())
 	var wg sync.WaitGroup
-	wg.Add(g.Concurrency)
+	wg.Add(g.prepareThreads())
 	g.addCollector()
 	var groupErr error
 
-	objs := splitObjs(g.CreateObjects, g.Concurrency)
+	objs := splitObjs(g.CreateObjects, g.prepareThreads())
 	var mu sync.Mutex
 	for _, obj := range objs {
 		go func(obj []struct{}) {
@@ -243,7 +399,183 @@ func (g *Mixed) Start(ctx context.Context, wait chan struct{}) (Operations, erro
 			statOpts := g.StatOpts
 			getOpts := g.GetOpts
 
-			<-wait
+			get := func(obj generator.Object) Operation {
+				fbr := firstByteRecorder{}
+				client, clDone := g.Client()
+				defer clDone()
+				op := Operation{
+					OpType:   http.MethodGet,
+					Thread:   uint16(i),
+					Size:     obj.Size,
+					File:     obj.Name,
+					ObjPerOp: 1,
+					Endpoint: client.EndpointURL().String(),
+				}
+
+				op.Start = time.Now()
+				getOpts.VersionID = obj.VersionID
+				opCtx, resp := recordResponse(nonTerm)
+				o, err := client.GetObject(opCtx, g.Bucket, obj.Name, getOpts)
+				if err != nil {
+					g.Error("download error:", err)
+					op.Err = err.Error()
+					op.End = time.Now()
+					return op
+				}
+				defer o.Close()
+				fbr.r = o
+				r, h := g.shadowReader(&fbr)
+				n, err := io.Copy(io.Discard, r)
+				if err != nil {
+					g.Error("download error:", err)
+					op.Err = err.Error()
+				}
+				op.FirstByte = fbr.t
+				op.End = time.Now()
+				resp.apply(&op, g.RecordHeaders)
+				if n != obj.Size && op.Err == "" {
+					op.Err = fmt.Sprint("unexpected download size. want:", obj.Size, ", got:", n)
+					g.Error(op.Err)
+				}
+				g.shadowGet(nonTerm, rcv, op, obj.Name, getOpts, o, h)
+				return op
+			}
+			put := func(obj *generator.Object) Operation {
+				putOpts.ContentType = obj.ContentType
+				client, clDone := g.Client()
+				defer clDone()
+				op := Operation{
+					OpType:   http.MethodPut,
+					Thread:   uint16(i),
+					Size:     obj.Size,
+					File:     obj.Name,
+					ObjPerOp: 1,
+					Endpoint: client.EndpointURL().String(),
+				}
+				opCtx, resp := recordResponse(nonTerm)
+				op.Start = time.Now()
+				res, err := client.PutObject(opCtx, g.Bucket, obj.Name, obj.Reader, obj.Size, putOpts)
+				op.End = time.Now()
+				resp.apply(&op, g.RecordHeaders)
+				if err != nil {
+					g.Error("upload error:", err)
+					op.Err = err.Error()
+				}
+				obj.VersionID = res.VersionID
+
+				if res.Size != obj.Size && op.Err == "" {
+					err := fmt.Sprint("short upload. want:", obj.Size, ", got:", res.Size)
+					op.Err = err
+					g.Error(err)
+				}
+				return op
+			}
+			del := func(obj generator.Object) Operation {
+				client, clDone := g.Client()
+				defer clDone()
+				op := Operation{
+					OpType:   http.MethodDelete,
+					Thread:   uint16(i),
+					Size:     0,
+					File:     obj.Name,
+					ObjPerOp: 1,
+					Endpoint: client.EndpointURL().String(),
+				}
+
+				opCtx, resp := recordResponse(nonTerm)
+				op.Start = time.Now()
+				err := client.RemoveObject(opCtx, g.Bucket, obj.Name, minio.RemoveObjectOptions{VersionID: obj.VersionID})
+				op.End = time.Now()
+				resp.apply(&op, g.RecordHeaders)
+				if err != nil {
+					g.Error("delete error: ", err)
+					op.Err = err.Error()
+				}
+				return op
+			}
+			stat := func(obj generator.Object) Operation {
+				client, clDone := g.Client()
+				defer clDone()
+				op := Operation{
+					OpType:   "STAT",
+					Thread:   uint16(i),
+					Size:     0,
+					File:     obj.Name,
+					ObjPerOp: 1,
+					Endpoint: client.EndpointURL().String(),
+				}
+				opCtx, resp := recordResponse(nonTerm)
+				op.Start = time.Now()
+				objI, err := client.StatObject(opCtx, g.Bucket, obj.Name, statOpts)
+				if err != nil {
+					g.Error("stat error: ", err)
+					op.Err = err.Error()
+				}
+				op.End = time.Now()
+				resp.apply(&op, g.RecordHeaders)
+				if objI.Size != obj.Size && op.Err == "" {
+					op.Err = fmt.Sprint("unexpected stat size. want:", obj.Size, ", got:", objI.Size)
+					g.Error(op.Err)
+				}
+				return op
+			}
+			chain := func() {
+				obj := src.Object()
+				op := Operation{
+					OpType:   MixedChainOp,
+					Thread:   uint16(i),
+					File:     obj.Name,
+					ObjPerOp: 1,
+				}
+				exists := false
+				defer func() {
+					if exists {
+						g.Dist.addObj(*obj)
+					}
+				}()
+				for _, step := range g.Chain {
+					if step.Delay > 0 {
+						select {
+						case <-done:
+							return
+						case <-time.After(step.Delay):
+						}
+					}
+					var stepOp Operation
+					switch step.OpType {
+					case http.MethodPut:
+						if obj.Reader == nil {
+							// Uploaded before, generate new content.
+							next := src.Object()
+							next.Name = obj.Name
+							obj = next
+						}
+						stepOp = put(obj)
+						exists = exists || stepOp.Err == ""
+						obj.Reader = nil
+					case http.MethodGet:
+						stepOp = get(*obj)
+					case "STAT":
+						stepOp = stat(*obj)
+					case http.MethodDelete:
+						stepOp = del(*obj)
+						exists = exists && stepOp.Err != ""
+					}
+					rcv <- stepOp
+					if op.Start.IsZero() {
+						op.Start = stepOp.Start
+						op.Endpoint = stepOp.Endpoint
+					}
+					op.End = stepOp.End
+					if stepOp.Err != "" {
+						op.Err = stepOp.Err
+						break
+					}
+				}
+				rcv <- op
+			}
+
+			g.startWait(wait)
 			for {
 				select {
 				case <-done:
@@ -255,131 +587,32 @@ func (g *Mixed) Start(ctx context.Context, wait chan struct{}) (Operations, erro
 					return
 				}
 
-				operation := g.Dist.getOp()
+				operation, err := g.nextOp(ctx)
+				if err != nil {
+					return
+				}
 				switch operation {
 				case http.MethodGet:
-					fbr := firstByteRecorder{}
 					obj, objDone := g.Dist.randomObj()
-					client, clDone := g.Client()
-					op := Operation{
-						OpType:   operation,
-						Thread:   uint16(i),
-						Size:     obj.Size,
-						File:     obj.Name,
-						ObjPerOp: 1,
-						Endpoint: client.EndpointURL().String(),
-					}
-
-					op.Start = time.Now()
-					var err error
-					getOpts.VersionID = obj.VersionID
-					o, err := client.GetObject(nonTerm, g.Bucket, obj.Name, getOpts)
-					fbr.r = o
-					if err != nil {
-						g.Error("download error:", err)
-						op.Err = err.Error()
-						op.End = time.Now()
-						rcv <- op
-						clDone()
-						objDone()
-						continue
-					}
-					n, err := io.Copy(io.Discard, &fbr)
-					if err != nil {
-						g.Error("download error:", err)
-						op.Err = err.Error()
-					}
-					op.FirstByte = fbr.t
-					op.End = time.Now()
-					if n != obj.Size && op.Err == "" {
-						op.Err = fmt.Sprint("unexpected download size. want:", obj.Size, ", got:", n)
-						g.Error(op.Err)
-					}
-					rcv <- op
+					rcv <- get(obj)
 					objDone()
-					clDone()
-					o.Close()
-
 				case http.MethodPut:
 					obj := src.Object()
-					putOpts.ContentType = obj.ContentType
-					client, clDone := g.Client()
-					op := Operation{
-						OpType:   operation,
-						Thread:   uint16(i),
-						Size:     obj.Size,
-						File:     obj.Name,
-						ObjPerOp: 1,
-						Endpoint: client.EndpointURL().String(),
-					}
-					op.Start = time.Now()
-					res, err := client.PutObject(nonTerm, g.Bucket, obj.Name, obj.Reader, obj.Size, putOpts)
-					op.End = time.Now()
-					if err != nil {
-						g.Error("upload error:", err)
-						op.Err = err.Error()
-					}
-					obj.VersionID = res.VersionID
-
-					if res.Size != obj.Size && op.Err == "" {
-						err := fmt.Sprint("short upload. want:", obj.Size, ", got:", res.Size)
-						if op.Err == "" {
-							op.Err = err
-						}
-						g.Error(err)
-					}
-					clDone()
+					op := put(obj)
 					if op.Err == "" {
+						obj.Reader = nil
 						g.Dist.addObj(*obj)
 					}
 					rcv <- op
 				case http.MethodDelete:
-					client, clDone := g.Client()
 					obj := g.Dist.deleteRandomObj()
-					op := Operation{
-						OpType:   operation,
-						Thread:   uint16(i),
-						Size:     0,
-						File:     obj.Name,
-						ObjPerOp: 1,
-						Endpoint: client.EndpointURL().String(),
-					}
-
-					op.Start = time.Now()
-					err := client.RemoveObject(nonTerm, g.Bucket, obj.Name, minio.RemoveObjectOptions{VersionID: obj.VersionID})
-					op.End = time.Now()
-					clDone()
-					if err != nil {
-						g.Error("delete error: ", err)
-						op.Err = err.Error()
-					}
-					rcv <- op
+					rcv <- del(obj)
 				case "STAT":
 					obj, objDone := g.Dist.randomObj()
-					client, clDone := g.Client()
-					op := Operation{
-						OpType:   operation,
-						Thread:   uint16(i),
-						Size:     0,
-						File:     obj.Name,
-						ObjPerOp: 1,
-						Endpoint: client.EndpointURL().String(),
-					}
-					op.Start = time.Now()
-					var err error
-					objI, err := client.StatObject(nonTerm, g.Bucket, obj.Name, statOpts)
-					if err != nil {
-						g.Error("stat error: ", err)
-						op.Err = err.Error()
-					}
-					op.End = time.Now()
-					if objI.Size != obj.Size && op.Err == "" {
-						op.Err = fmt.Sprint("unexpected stat size. want:", obj.Size, ", got:", objI.Size)
-						g.Error(op.Err)
-					}
-					rcv <- op
+					rcv <- stat(obj)
 					objDone()
-					clDone()
+				case MixedChainOp:
+					chain()
 				default:
 					g.Error("unknown operation: ", operation)
 				}
@@ -390,6 +623,22 @@ func (g *Mixed) Start(ctx context.Context, wait chan struct{}) (Operations, erro
 	return c.Close(), nil
 }
 
+// nextOp returns the next operation from the distribution.
+// With per operation rate limits, operations are drawn
+// until one is within its limit.
+func (g *Mixed) nextOp(ctx context.Context) (string, error) {
+	for i := 1; ; i++ {
+		op := g.Dist.getOp()
+		lim := g.OpRpsLimits[op]
+		if lim == nil || lim.Allow() {
+			return op, nil
+		}
+		if i == mixedLimitDraws {
+			return op, lim.Wait(ctx)
+		}
+	}
+}
+
 // Cleanup deletes everything uploaded to the bucket.
 func (g *Mixed) Cleanup(ctx context.Context) {
 	g.deleteAllInBucket(ctx, g.Dist.Objects().Prefixes()...)
@@ -212,7 +212,7 @@ func (g *Multipart) Start(ctx context.Context, wait chan struct{}) (Operations,
 			opts := g.GetOpts
 			done := ctx.Done()
 
-			<-wait
+			g.startWait(wait)
 			for {
 				select {
 				case <-done:
@@ -240,11 +240,13 @@ func (g *Multipart) Start(ctx context.Context, wait chan struct{}) (Operations,
 
 				op.Start = time.Now()
 				opts.PartNumber = part
-				o, err := client.GetObject(nonTerm, g.Bucket, obj.Name, opts)
+				opCtx, resp := recordResponse(nonTerm)
+				o, err := client.GetObject(opCtx, g.Bucket, obj.Name, opts)
 				if err != nil {
 					g.Error("download error:", err)
 					op.Err = err.Error()
 					op.End = time.Now()
+					resp.apply(&op, g.RecordHeaders)
 					rcv <- op
 					cldone()
 					continue
@@ -257,6 +259,7 @@ func (g *Multipart) Start(ctx context.Context, wait chan struct{}) (Operations,
 				}
 				op.FirstByte = fbr.t
 				op.End = time.Now()
+				resp.apply(&op, g.RecordHeaders)
 				if n != op.Size && op.Err == "" {
 					op.Err = fmt.Sprint("unexpected download size. want:", op.Size, ", got:", n)
 					g.Error(op.Err)
@@ -0,0 +1,220 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/warp/pkg/generator"
+)
+
+// MultipartPutPartOp is the operation type of a single part upload.
+// Uploads of complete objects are recorded as PUT operations.
+const MultipartPutPartOp = "PUTPART"
+
+// MultipartPut benchmarks multipart uploads of complete objects.
+// Each thread uploads one object at a time, with the parts
+// uploaded by PartConcurrency concurrent part-streams.
+type MultipartPut struct {
+	Common
+
+	// Parts is the number of parts of each object.
+	Parts int
+	// PartConcurrency is the number of parts uploaded concurrently for each object.
+	PartConcurrency int
+
+	mu       sync.Mutex
+	prefixes map[string]struct{}
+}
+
+// Prepare will create an empty bucket or delete any content already there.
+func (g *MultipartPut) Prepare(ctx context.Context) error {
+	if g.Parts <= 0 || g.Parts > 10000 {
+		return errors.New("number of parts must be between 1 and 10000")
+	}
+	if g.PartConcurrency <= 0 {
+		g.PartConcurrency = 1
+	}
+	g.prefixes = make(map[string]struct{}, g.Concurrency)
+	if err := g.createEmptyBucket(ctx); err != nil {
+		return err
+	}
+	g.addCollector()
+	return nil
+}
+
+// Start will execute the main benchmark.
+// Operations should begin executing when the start channel is closed.
+func (g *MultipartPut) Start(ctx context.Context, wait chan struct{}) (Operations, error) {
+	var wg sync.WaitGroup
+	wg.Add(g.Concurrency)
+	c := g.Collector
+	if g.AutoTermDur > 0 {
+		ctx = c.AutoTerm(ctx, http.MethodPut, g.AutoTermScale, autoTermCheck, autoTermSamples, g.AutoTermDur)
+	}
+	// Non-terminating context.
+	nonTerm := context.Background()
+
+	for i := 0; i < g.Concurrency; i++ {
+		go func(i int) {
+			rcv := c.Receiver()
+			defer wg.Done()
+			done := ctx.Done()
+			// A source for each part-stream.
+			srcs := make([]generator.Source, g.PartConcurrency)
+			for j := range srcs {
+				srcs[j] = g.Source()
+			}
+			g.mu.Lock()
+			g.prefixes[srcs[0].Prefix()] = struct{}{}
+			g.mu.Unlock()
+
+			g.startWait(wait)
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				if g.rpsLimit(ctx) != nil {
+					return
+				}
+
+				name := srcs[0].Object().Name
+				client, cldone := g.Client()
+				core := minio.Core{Client: client}
+				op := Operation{
+					OpType:   http.MethodPut,
+					Thread:   uint16(i),
+					File:     name,
+					ObjPerOp: 1,
+					Endpoint: client.EndpointURL().String(),
+				}
+				if g.DiscardOutput {
+					op.File = ""
+				}
+
+				// Records the requests that create and complete the upload.
+				opCtx, resp := recordResponse(nonTerm)
+				op.Start = time.Now()
+				uploadID, err := core.NewMultipartUpload(opCtx, g.Bucket, name, g.PutOpts)
+				if err != nil {
+					g.Error("new multipart upload error: ", err)
+					op.Err = err.Error()
+					op.End = time.Now()
+					resp.apply(&op, g.RecordHeaders)
+					rcv <- op
+					cldone()
+					continue
+				}
+
+				partCh := make(chan int, g.Parts)
+				for p := 1; p <= g.Parts; p++ {
+					partCh <- p
+				}
+				close(partCh)
+				parts := make([]minio.CompletePart, g.Parts)
+				var mu sync.Mutex
+				var partErr error
+				var pwg sync.WaitGroup
+				pwg.Add(len(srcs))
+				for _, src := range srcs {
+					go func(src generator.Source) {
+						defer pwg.Done()
+						mpopts := minio.PutObjectPartOptions{
+							SSE:                  g.PutOpts.ServerSideEncryption,
+							DisableContentSha256: g.PutOpts.DisableContentSha256,
+						}
+						for partN := range partCh {
+							mu.Lock()
+							failed := partErr != nil
+							mu.Unlock()
+							if failed {
+								return
+							}
+							obj := src.Object()
+							part := Operation{
+								OpType:   MultipartPutPartOp,
+								Thread:   uint16(i),
+								Size:     obj.Size,
+								File:     op.File,
+								ObjPerOp: 1,
+								Endpoint: op.Endpoint,
+							}
+							opCtx, resp := recordResponse(nonTerm)
+							part.Start = time.Now()
+							res, err := core.PutObjectPart(opCtx, g.Bucket, name, uploadID, partN, obj.Reader, obj.Size, mpopts)
+							part.End = time.Now()
+							resp.apply(&part, g.RecordHeaders)
+							if err == nil && res.Size != obj.Size {
+								err = fmt.Errorf("short upload. want: %d, got %d", obj.Size, res.Size)
+							}
+							mu.Lock()
+							if err != nil {
+								g.Error("upload part error: ", err)
+								part.Err = err.Error()
+								if partErr == nil {
+									partErr = err
+								}
+							} else {
+								parts[partN-1] = minio.CompletePart{PartNumber: partN, ETag: res.ETag}
+								op.Size += obj.Size
+							}
+							mu.Unlock()
+							rcv <- part
+						}
+					}(src)
+				}
+				pwg.Wait()
+
+				if partErr != nil {
+					op.Err = partErr.Error()
+					core.AbortMultipartUpload(opCtx, g.Bucket, name, uploadID)
+				} else {
+					_, err = core.CompleteMultipartUpload(opCtx, g.Bucket, name, uploadID, parts, g.PutOpts)
+					if err != nil {
+						g.Error("complete multipart upload error: ", err)
+						op.Err = err.Error()
+					}
+				}
+				op.End = time.Now()
+				resp.apply(&op, g.RecordHeaders)
+				cldone()
+				rcv <- op
+			}
+		}(i)
+	}
+	wg.Wait()
+	return c.Close(), nil
+}
+
+// Cleanup deletes everything uploaded to the bucket.
+func (g *MultipartPut) Cleanup(ctx context.Context) {
+	pf := make([]string, 0, len(g.prefixes))
+	for p := range g.prefixes {
+		pf = append(pf, p)
+	}
+	g.deleteAllInBucket(ctx, pf...)
+}
@@ -0,0 +1,69 @@
+/*
+ * Warp (C) 2019-2020 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"sort"
+	"time"
+)
+
+// CorrectOmission returns a copy of the operations with latencies corrected for coordinated omission.
+//
+// Each client is assumed to send rate requests per second, shared equally by its threads.
+// Each thread therefore intends to start a request every threads/rate seconds from its first request.
+// When a request is slow, the following requests of the thread start late
+// and the waiting time is missing from the measured latency.
+// The start time of operations that started later than intended is set to the intended start time,
+// so the duration of the returned operations includes the time spent waiting.
+// The time to first byte is not modified.
+// The returned operations are sorted by start time.
+func (o Operations) CorrectOmission(rate float64) Operations {
+	if rate <= 0 || len(o) == 0 {
+		return nil
+	}
+	type threadKey struct {
+		client string
+		thread uint16
+	}
+	byThread := make(map[threadKey][]int)
+	threads := make(map[string]int)
+	for i, op := range o {
+		k := threadKey{client: op.ClientID, thread: op.Thread}
+		if _, ok := byThread[k]; !ok {
+			threads[op.ClientID]++
+		}
+		byThread[k] = append(byThread[k], i)
+	}
+	res := make(Operations, len(o))
+	copy(res, o)
+	for k, idxs := range byThread {
+		sort.Slice(idxs, func(i, j int) bool {
+			return o[idxs[i]].Start.Before(o[idxs[j]].Start)
+		})
+		interval := time.Duration(float64(threads[k.client]) / rate * float64(time.Second))
+		intended := o[idxs[0]].Start
+		for _, idx := range idxs {
+			if res[idx].Start.After(intended) {
+				res[idx].Start = intended
+			}
+			intended = intended.Add(interval)
+		}
+	}
+	res.SortByStartTime()
+	return res
+}
@@ -23,6 +23,8 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"net/url"
+	"path"
 	"sort"
 	"strconv"
 	"strings"
@@ -35,17 +37,48 @@ import (
 type Operations []Operation
 
 type Operation struct {
-	Start     time.Time  `json:"start"`
-	End       time.Time  `json:"end"`
-	FirstByte *time.Time `json:"first_byte"`
-	OpType    string     `json:"type"`
-	Err       string     `json:"err"`
-	File      string     `json:"file,omitempty"`
-	ClientID  string     `json:"client_id"`
-	Endpoint  string     `json:"endpoint"`
-	ObjPerOp  int        `json:"ops"`
-	Size      int64      `json:"size"`
-	Thread    uint16     `json:"thread"`
+	Start     time.Time         `json:"start"`
+	End       time.Time         `json:"end"`
+	FirstByte *time.Time        `json:"first_byte"`
+	OpType    string            `json:"type"`
+	Err       string            `json:"err"`
+	File      string            `json:"file,omitempty"`
+	ClientID  string            `json:"client_id"`
+	Endpoint  string            `json:"endpoint"`
+	RequestID string            `json:"request_id,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Phases    *Phases           `json:"phases,omitempty"`
+	ObjPerOp  int               `json:"ops"`
+	Size      int64             `json:"size"`
+	Thread    uint16            `json:"thread"`
+
+	// ConnReused is whether the request reused a connection, if known.
+	ConnReused *bool `json:"conn_reused,omitempty"`
+	// Addressing is the bucket addressing style, if selected.
+	Addressing string `json:"addressing,omitempty"`
+	// IPFamily is the address family of the connection, IPv4 or IPv6, if known.
+	IPFamily string `json:"ip_family,omitempty"`
+	// OpID is the ID sent to the server with all requests of the operation, if enabled.
+	OpID string `json:"op_id,omitempty"`
+	// WireSent and WireRecv are the bytes sent and received including request and response headers.
+	// Zero if not recorded.
+	WireSent int64 `json:"wire_sent,omitempty"`
+	WireRecv int64 `json:"wire_recv,omitempty"`
+	// Stalls is the number of times the download speed stayed below the stall speed.
+	// StallTime is the total time spent in stalls.
+	Stalls    int           `json:"stalls,omitempty"`
+	StallTime time.Duration `json:"stall_time,omitempty"`
+	// Throttled is the number of 429 and 503 responses received, including requests retried by the client.
+	// RetryAfter is the total wait advised by the Retry-After headers of those responses.
+	// RetryEarly is the number of requests retried before the advised wait had passed.
+	Throttled  int           `json:"throttled,omitempty"`
+	RetryAfter time.Duration `json:"retry_after,omitempty"`
+	RetryEarly int           `json:"retry_early,omitempty"`
+	// ThrottledTime is the time waited before retrying as advised by Retry-After, if honored.
+	// It is included in the duration of the operation.
+	ThrottledTime time.Duration `json:"throttled_time,omitempty"`
+	// Region is the bucket region returned by the endpoint, if known.
+	Region string `json:"region,omitempty"`
 }
 
 // Duration returns the duration o.End-o.Start
@@ -53,6 +86,100 @@ func (o Operation) Duration() time.Duration {
 	return o.End.Sub(o.Start)
 }
 
+// Phases contains the time spent in each phase of a request.
+// Phases that did not occur, for example DNS and connect on a reused connection, are zero.
+type Phases struct {
+	DNS     time.Duration `json:"dns"`
+	Connect time.Duration `json:"connect"`
+	TLS     time.Duration `json:"tls"`
+	// Send is the time from getting a connection until the request including the body is written.
+	Send time.Duration `json:"send"`
+	// TTFB is the time from the request being written until the first response byte.
+	TTFB time.Duration `json:"ttfb"`
+	// Transfer is the time from the first response byte until the operation ended.
+	Transfer time.Duration `json:"transfer"`
+	// Continue is the time from the request headers being written until a 100 Continue response
+	// was received, when the request was sent with 'Expect: 100-continue'. It is not included in Send.
+	Continue time.Duration `json:"continue,omitempty"`
+}
+
+// String returns the phases as comma separated nanoseconds.
+// Continue is only included if set.
+func (p Phases) String() string {
+	if p.Continue > 0 {
+		return fmt.Sprintf("%d,%d,%d,%d,%d,%d,%d", p.DNS, p.Connect, p.TLS, p.Send, p.TTFB, p.Transfer, p.Continue)
+	}
+	return fmt.Sprintf("%d,%d,%d,%d,%d,%d", p.DNS, p.Connect, p.TLS, p.Send, p.TTFB, p.Transfer)
+}
+
+// parsePhases parses phases in the format returned by Phases.String.
+func parsePhases(s string) (*Phases, error) {
+	fields := strings.Split(s, ",")
+	if len(fields) != 6 && len(fields) != 7 {
+		return nil, fmt.Errorf("invalid phases: %q", s)
+	}
+	var v [7]time.Duration
+	for i, f := range fields {
+		n, err := strconv.ParseInt(f, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		v[i] = time.Duration(n)
+	}
+	return &Phases{DNS: v[0], Connect: v[1], TLS: v[2], Send: v[3], TTFB: v[4], Transfer: v[5], Continue: v[6]}, nil
+}
+
+// SplitByConnReuse returns operations that reused a connection
+// and operations that required a new connection.
+// Operations where this is unknown are not returned.
+func (o Operations) SplitByConnReuse() (reused, newConn Operations) {
+	for _, op := range o {
+		if op.ConnReused == nil {
+			continue
+		}
+		if *op.ConnReused {
+			reused = append(reused, op)
+		} else {
+			newConn = append(newConn, op)
+		}
+	}
+	return reused, newConn
+}
+
+// AvgPhases returns the average phases of operations with recorded phases
+// and the number of operations with recorded phases.
+func (o Operations) AvgPhases() (Phases, int) {
+	var sum Phases
+	var n int
+	for _, op := range o {
+		p := op.Phases
+		if p == nil {
+			continue
+		}
+		sum.DNS += p.DNS
+		sum.Connect += p.Connect
+		sum.TLS += p.TLS
+		sum.Send += p.Send
+		sum.TTFB += p.TTFB
+		sum.Transfer += p.Transfer
+		sum.Continue += p.Continue
+		n++
+	}
+	if n == 0 {
+		return sum, 0
+	}
+	d := time.Duration(n)
+	return Phases{
+		DNS:      sum.DNS / d,
+		Connect:  sum.Connect / d,
+		TLS:      sum.TLS / d,
+		Send:     sum.Send / d,
+		TTFB:     sum.TTFB / d,
+		Transfer: sum.Transfer / d,
+		Continue: sum.Continue / d,
+	}, n
+}
+
 // Throughput is the throughput as bytes/second.
 type Throughput float64
 
@@ -726,6 +853,37 @@ func (o Operations) SplitSizes(minShare float64) []SizeSegment {
 	return res
 }
 
+// SplitSizeBuckets returns operations split into buckets by object size.
+// bounds must be sorted and a bucket is returned for sizes below the first bound,
+// between each bound and above the last bound.
+// Empty buckets are not returned.
+func (o Operations) SplitSizeBuckets(bounds []int64) []SizeSegment {
+	res := make([]SizeSegment, len(bounds)+1)
+	for i := range res {
+		if i > 0 {
+			res[i].Smallest = bounds[i-1]
+		}
+		if i < len(bounds) {
+			res[i].Biggest = bounds[i]
+		} else {
+			res[i].Biggest = math.MaxInt64
+		}
+	}
+	for _, op := range o {
+		i := sort.Search(len(bounds), func(i int) bool {
+			return op.Size < bounds[i]
+		})
+		res[i].Ops = append(res[i].Ops, op)
+	}
+	dst := res[:0]
+	for _, s := range res {
+		if len(s.Ops) > 0 {
+			dst = append(dst, s)
+		}
+	}
+	return dst
+}
+
 // Duration returns the full duration from start of first operation to end of the last.
 func (o Operations) Duration() time.Duration {
 	start, end := o.TimeRange()
@@ -1023,38 +1181,162 @@ func (o Operations) FilterErrors() Operations {
 // The comment, if any, is written at the end of the file, each line prefixed with '# '.
 func (o Operations) CSV(w io.Writer, comment string) error {
 	bw := bufio.NewWriter(w)
-	_, err := bw.WriteString("idx\tthread\top\tclient_id\tn_objects\tbytes\tendpoint\tfile\terror\tstart\tfirst_byte\tend\tduration_ns\n")
+	_, err := bw.WriteString(csvVersion + csvHeader)
 	if err != nil {
 		return err
 	}
 
 	for i, op := range o {
-		var ttfb string
-		if op.FirstByte != nil {
-			ttfb = op.FirstByte.Format(time.RFC3339Nano)
+		if err := op.writeCSV(bw, i); err != nil {
+			return err
 		}
-		_, err := fmt.Fprintf(bw, "%d\t%d\t%s\t%s\t%d\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%d\n", i, op.Thread, op.OpType, op.ClientID, op.ObjPerOp, op.Size, csvEscapeString(op.Endpoint), op.File, csvEscapeString(op.Err), op.Start.Format(time.RFC3339Nano), ttfb, op.End.Format(time.RFC3339Nano), op.End.Sub(op.Start)/time.Nanosecond)
-		if err != nil {
+	}
+	if err := writeCSVComment(bw, comment); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// writeCSVComment writes the comment, if any, with each line prefixed with '# '.
+func writeCSVComment(w io.StringWriter, comment string) error {
+	if len(comment) == 0 {
+		return nil
+	}
+	for _, txt := range strings.Split(comment, "\n") {
+		if _, err := w.WriteString("# " + txt + "\n"); err != nil {
 			return err
 		}
 	}
-	if len(comment) > 0 {
-		lines := strings.Split(comment, "\n")
-		for _, txt := range lines {
-			_, err := bw.WriteString("# " + txt + "\n")
+	return nil
+}
+
+// OperationsSchemaVersion is the schema version of operations written as CSV.
+// Version 1 is all files written before the version was added.
+// Columns are found by name and columns added since version 1 are optional, so all earlier versions can be read.
+// The version must be increased when the meaning of existing columns changes.
+const OperationsSchemaVersion = 2
+
+// csvVersionPrefix is the prefix of the comment line with the schema version written before the header.
+const csvVersionPrefix = "# schema: "
+
+// csvVersion is the schema version line of operations written as CSV.
+var csvVersion = csvVersionPrefix + strconv.Itoa(OperationsSchemaVersion) + "\n"
+
+// csvRequired are the columns that must be present in all versions.
+var csvRequired = []string{"thread", "op", "n_objects", "bytes", "file", "error", "start", "end"}
+
+// csvHeader is the header line of operations written as CSV.
+const csvHeader = "idx\tthread\top\tclient_id\tn_objects\tbytes\tendpoint\tfile\terror\tstart\tfirst_byte\tend\tduration_ns\trequest_id\theaders\tphases\tconn\taddressing\twire_sent\twire_recv\tstalls\tstall_ns\tip_family\top_id\tthrottled\tretry_after_ns\tretry_early\tthrottled_ns\tregion\n"
+
+// writeCSV writes the operation as a CSV line with the specified index.
+func (o Operation) writeCSV(w io.Writer, idx int) error {
+	var ttfb string
+	if o.FirstByte != nil {
+		ttfb = o.FirstByte.Format(time.RFC3339Nano)
+	}
+	var headers string
+	if len(o.Headers) > 0 {
+		h := make(url.Values, len(o.Headers))
+		for k, v := range o.Headers {
+			h.Set(k, v)
+		}
+		headers = h.Encode()
+	}
+	var phases string
+	if o.Phases != nil {
+		phases = o.Phases.String()
+	}
+	var conn string
+	if o.ConnReused != nil {
+		conn = "new"
+		if *o.ConnReused {
+			conn = "reused"
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%d\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\t%d\t%d\t%d\t%d\t%s\t%s\t%d\t%d\t%d\t%d\t%s\n", idx, o.Thread, o.OpType, o.ClientID, o.ObjPerOp, o.Size, csvEscapeString(o.Endpoint), o.File, csvEscapeString(o.Err), o.Start.Format(time.RFC3339Nano), ttfb, o.End.Format(time.RFC3339Nano), o.End.Sub(o.Start)/time.Nanosecond, csvEscapeString(o.RequestID), headers, phases, conn, o.Addressing, o.WireSent, o.WireRecv, o.Stalls, o.StallTime, o.IPFamily, o.OpID, o.Throttled, o.RetryAfter, o.RetryEarly, o.ThrottledTime, o.Region)
+	return err
+}
+
+// csvSchemaVersion reads the comment lines at the start of r and returns the schema version of the operations.
+// Files without a version line are version 1.
+func csvSchemaVersion(br *bufio.Reader) (int, error) {
+	version := 1
+	for {
+		b, err := br.Peek(1)
+		if err != nil || b[0] != '#' {
+			return version, nil
+		}
+		line, err := br.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		if v, ok := strings.CutPrefix(strings.TrimSuffix(line, "\n"), csvVersionPrefix); ok {
+			version, err = strconv.Atoi(strings.TrimSpace(v))
 			if err != nil {
-				return err
+				return 0, fmt.Errorf("invalid schema version %q: %w", v, err)
 			}
 		}
 	}
-
-	return bw.Flush()
 }
 
 // OperationsFromCSV will load operations from CSV.
+// Operations of all schema versions up to OperationsSchemaVersion can be read.
 func OperationsFromCSV(r io.Reader, analyzeOnly bool, offset, limit int, log func(msg string, v ...interface{})) (Operations, error) {
+	cr, err := newCSVOpReader(r, analyzeOnly)
+	if err != nil {
+		return nil, err
+	}
 	var ops Operations
-	cr := csv.NewReader(r)
+	for {
+		op, err := cr.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		ops = append(ops, op)
+		if log != nil && len(ops)%1000000 == 0 {
+			console.Eraseline()
+			log("\r%d operations loaded...", len(ops))
+		}
+		if limit > 0 && len(ops) >= limit {
+			break
+		}
+	}
+	if log != nil {
+		console.Eraseline()
+		log("\r%d operations loaded... Done!\n", len(ops))
+	}
+	return ops, nil
+}
+
+// csvOpReader reads operations from CSV one at a time.
+type csvOpReader struct {
+	cr        *csv.Reader
+	fieldIdx  map[string]int
+	getClient func(string) string
+	fileMap   func(string) string
+}
+
+// newCSVOpReader reads the schema version and header of operations in r.
+func newCSVOpReader(r io.Reader, analyzeOnly bool) (*csvOpReader, error) {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	version, err := csvSchemaVersion(br)
+	if err != nil {
+		return nil, err
+	}
+	if version > OperationsSchemaVersion {
+		return nil, fmt.Errorf("operations have schema version %d, newer than supported version %d", version, OperationsSchemaVersion)
+	}
+	cr := csv.NewReader(br)
 	cr.Comma = '\t'
 	cr.ReuseRecord = true
 	cr.Comment = '#'
@@ -1066,17 +1348,21 @@ func OperationsFromCSV(r io.Reader, analyzeOnly bool, offset, limit int, log fun
 	for i, s := range header {
 		fieldIdx[s] = i
 	}
+	for _, name := range csvRequired {
+		if _, ok := fieldIdx[name]; !ok {
+			return nil, fmt.Errorf("operations have no %q column", name)
+		}
+	}
 	clientMap := make(map[string]string, 16)
-	cb := byte('a')
 	getClient := func(c string) string {
 		if !analyzeOnly {
 			return c
 		}
+		// Keep a single copy of each client ID, so records are not referenced.
 		if v, ok := clientMap[c]; ok {
 			return v
 		}
-		clientMap[c] = string([]byte{cb})
-		cb++
+		clientMap[c] = strings.Clone(c)
 		return clientMap[c]
 	}
 	fileMap := func(s string) string {
@@ -1084,93 +1370,196 @@ func OperationsFromCSV(r io.Reader, analyzeOnly bool, offset, limit int, log fun
 	}
 	if analyzeOnly {
 		// When analyzing map file names to a number for less RAM.
+		// The directory is kept, so results can be split by prefix.
 		var i int
-		m := make(map[string]int)
+		m := make(map[string]string)
 		fileMap = func(s string) string {
 			if v, ok := m[s]; ok {
-				return strconv.Itoa(v)
+				return v
 			}
 			i++
-			m[s] = i
-			return strconv.Itoa(i)
+			v := strconv.Itoa(i)
+			if dir, _ := path.Split(s); dir != "" {
+				v = dir + v
+			}
+			m[strings.Clone(s)] = v
+			return v
 		}
 	}
-	for {
-		values, err := cr.Read()
-		if err == io.EOF {
-			break
+	return &csvOpReader{cr: cr, fieldIdx: fieldIdx, getClient: getClient, fileMap: fileMap}, nil
+}
+
+// next returns the next operation.
+// io.EOF is returned when there are no more operations.
+func (r *csvOpReader) next() (Operation, error) {
+	var values []string
+	for len(values) == 0 {
+		var err error
+		values, err = r.cr.Read()
+		if err != nil {
+			return Operation{}, err
 		}
+	}
+	start, err := time.Parse(time.RFC3339Nano, values[r.fieldIdx["start"]])
+	if err != nil {
+		return Operation{}, err
+	}
+	var ttfb *time.Time
+	if idx, ok := r.fieldIdx["first_byte"]; ok && values[idx] != "" {
+		t, err := time.Parse(time.RFC3339Nano, values[idx])
 		if err != nil {
-			return nil, err
+			return Operation{}, err
 		}
-		if len(values) == 0 {
-			continue
+		ttfb = &t
+	}
+	end, err := time.Parse(time.RFC3339Nano, values[r.fieldIdx["end"]])
+	if err != nil {
+		return Operation{}, err
+	}
+	size, err := strconv.ParseInt(values[r.fieldIdx["bytes"]], 10, 64)
+	if err != nil {
+		return Operation{}, err
+	}
+	thread, err := strconv.ParseUint(values[r.fieldIdx["thread"]], 10, 16)
+	if err != nil {
+		return Operation{}, err
+	}
+	objs, err := strconv.ParseInt(values[r.fieldIdx["n_objects"]], 10, 64)
+	if err != nil {
+		return Operation{}, err
+	}
+	var endpoint, clientID string
+	if idx, ok := r.fieldIdx["endpoint"]; ok {
+		endpoint = values[idx]
+	}
+	if idx, ok := r.fieldIdx["client_id"]; ok {
+		clientID = values[idx]
+	}
+	var requestID string
+	if idx, ok := r.fieldIdx["request_id"]; ok {
+		requestID = values[idx]
+	}
+	var headers map[string]string
+	if idx, ok := r.fieldIdx["headers"]; ok && values[idx] != "" {
+		h, err := url.ParseQuery(values[idx])
+		if err != nil {
+			return Operation{}, err
 		}
-		if offset > 0 {
-			offset--
-			continue
+		headers = make(map[string]string, len(h))
+		for k := range h {
+			headers[k] = h.Get(k)
 		}
-		start, err := time.Parse(time.RFC3339Nano, values[fieldIdx["start"]])
+	}
+	var phases *Phases
+	if idx, ok := r.fieldIdx["phases"]; ok && values[idx] != "" {
+		phases, err = parsePhases(values[idx])
 		if err != nil {
-			return nil, err
-		}
-		var ttfb *time.Time
-		if fb := values[fieldIdx["first_byte"]]; fb != "" {
-			t, err := time.Parse(time.RFC3339Nano, fb)
-			if err != nil {
-				return nil, err
-			}
-			ttfb = &t
+			return Operation{}, err
 		}
-		end, err := time.Parse(time.RFC3339Nano, values[fieldIdx["end"]])
+	}
+	var connReused *bool
+	if idx, ok := r.fieldIdx["conn"]; ok && values[idx] != "" {
+		reused := values[idx] == "reused"
+		connReused = &reused
+	}
+	var addressing string
+	if idx, ok := r.fieldIdx["addressing"]; ok {
+		addressing = values[idx]
+	}
+	var ipFamily string
+	if idx, ok := r.fieldIdx["ip_family"]; ok {
+		ipFamily = values[idx]
+	}
+	var opID string
+	if idx, ok := r.fieldIdx["op_id"]; ok {
+		opID = values[idx]
+	}
+	var region string
+	if idx, ok := r.fieldIdx["region"]; ok {
+		region = values[idx]
+	}
+	var wireSent, wireRecv int64
+	if idx, ok := r.fieldIdx["wire_sent"]; ok && values[idx] != "" {
+		wireSent, err = strconv.ParseInt(values[idx], 10, 64)
 		if err != nil {
-			return nil, err
+			return Operation{}, err
 		}
-		size, err := strconv.ParseInt(values[fieldIdx["bytes"]], 10, 64)
+	}
+	if idx, ok := r.fieldIdx["wire_recv"]; ok && values[idx] != "" {
+		wireRecv, err = strconv.ParseInt(values[idx], 10, 64)
 		if err != nil {
-			return nil, err
+			return Operation{}, err
 		}
-		thread, err := strconv.ParseUint(values[fieldIdx["thread"]], 10, 16)
+	}
+	var stalls, stallNS int64
+	if idx, ok := r.fieldIdx["stalls"]; ok && values[idx] != "" {
+		stalls, err = strconv.ParseInt(values[idx], 10, 64)
 		if err != nil {
-			return nil, err
+			return Operation{}, err
 		}
-		objs, err := strconv.ParseInt(values[fieldIdx["n_objects"]], 10, 64)
+	}
+	if idx, ok := r.fieldIdx["stall_ns"]; ok && values[idx] != "" {
+		stallNS, err = strconv.ParseInt(values[idx], 10, 64)
 		if err != nil {
-			return nil, err
+			return Operation{}, err
 		}
-		var endpoint, clientID string
-		if idx, ok := fieldIdx["endpoint"]; ok {
-			endpoint = values[idx]
-		}
-		if idx, ok := fieldIdx["client_id"]; ok {
-			clientID = values[idx]
-		}
-		file := fileMap(values[fieldIdx["file"]])
-
-		ops = append(ops, Operation{
-			OpType:    values[fieldIdx["op"]],
-			ObjPerOp:  int(objs),
-			Start:     start,
-			FirstByte: ttfb,
-			End:       end,
-			Err:       values[fieldIdx["error"]],
-			Size:      size,
-			File:      file,
-			Thread:    uint16(thread),
-			Endpoint:  endpoint,
-			ClientID:  getClient(clientID),
-		})
-		if log != nil && len(ops)%1000000 == 0 {
-			console.Eraseline()
-			log("\r%d operations loaded...", len(ops))
+	}
+	var throttled, retryAfterNS, retryEarly, throttledNS int64
+	if idx, ok := r.fieldIdx["throttled"]; ok && values[idx] != "" {
+		throttled, err = strconv.ParseInt(values[idx], 10, 64)
+		if err != nil {
+			return Operation{}, err
 		}
-		if limit > 0 && len(ops) >= limit {
-			break
+	}
+	if idx, ok := r.fieldIdx["retry_after_ns"]; ok && values[idx] != "" {
+		retryAfterNS, err = strconv.ParseInt(values[idx], 10, 64)
+		if err != nil {
+			return Operation{}, err
 		}
 	}
-	if log != nil {
-		console.Eraseline()
-		log("\r%d operations loaded... Done!\n", len(ops))
+	if idx, ok := r.fieldIdx["retry_early"]; ok && values[idx] != "" {
+		retryEarly, err = strconv.ParseInt(values[idx], 10, 64)
+		if err != nil {
+			return Operation{}, err
+		}
 	}
-	return ops, nil
+	if idx, ok := r.fieldIdx["throttled_ns"]; ok && values[idx] != "" {
+		throttledNS, err = strconv.ParseInt(values[idx], 10, 64)
+		if err != nil {
+			return Operation{}, err
+		}
+	}
+	file := r.fileMap(values[r.fieldIdx["file"]])
+
+	return Operation{
+		OpType:    values[r.fieldIdx["op"]],
+		ObjPerOp:  int(objs),
+		Start:     start,
+		FirstByte: ttfb,
+		End:       end,
+		Err:       values[r.fieldIdx["error"]],
+		Size:      size,
+		File:      file,
+		Thread:    uint16(thread),
+		Endpoint:  endpoint,
+		ClientID:  r.getClient(clientID),
+		RequestID: requestID,
+		Headers:   headers,
+		Phases:    phases,
+
+		ConnReused: connReused,
+		Addressing: addressing,
+		IPFamily:   ipFamily,
+		OpID:       opID,
+		WireSent:   wireSent,
+		WireRecv:   wireRecv,
+		Stalls:     int(stalls),
+		StallTime:  time.Duration(stallNS),
+		Throttled:  int(throttled),
+		RetryAfter: time.Duration(retryAfterNS),
+		RetryEarly: int(retryEarly),
+
+		ThrottledTime: time.Duration(throttledNS),
+		Region:        region,
+	}, nil
 }
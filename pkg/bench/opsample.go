@@ -0,0 +1,283 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// OpSampleOp is the operation type of events with the exact results of an operation type,
+// when only a sample of the operations is recorded.
+// The sampled operation type is stored in File, the number of objects in ObjPerOp, the bytes in Size
+// and the counters in Headers. Start and End are the first start and last end of the operations.
+const OpSampleOp = "OP-SAMPLE"
+
+// Headers of sample events.
+const (
+	// OpSampleRate is n when 1 in n operations is recorded.
+	OpSampleRate = "sample"
+	// OpSampleRequests is the number of operations.
+	OpSampleRequests = "requests"
+	// OpSampleErrors is the number of failed operations.
+	OpSampleErrors = "errors"
+	// OpSampleLatency is the latency histogram of successful operations.
+	OpSampleLatency = "latency"
+)
+
+// histSubBits is the number of bits of precision of histogram buckets.
+// Values are stored with a relative error below 1/2^histSubBits.
+const histSubBits = 7
+
+// LatencyHistogram counts durations in buckets with bounded relative error.
+// The zero value is ready to use.
+type LatencyHistogram struct {
+	counts map[int]uint64
+	n      uint64
+	sum    time.Duration
+	max    time.Duration
+}
+
+// histIndex returns the bucket of v.
+func histIndex(v uint64) int {
+	if v < 1<<(histSubBits+1) {
+		return int(v)
+	}
+	shift := bits.Len64(v) - histSubBits - 1
+	return shift<<histSubBits + int(v>>shift)
+}
+
+// histValue returns the lowest value and the width of bucket idx.
+func histValue(idx int) (low, width uint64) {
+	shift := idx>>histSubBits - 1
+	if shift <= 0 {
+		return uint64(idx), 1
+	}
+	return uint64(idx-shift<<histSubBits) << shift, 1 << shift
+}
+
+// Add a duration.
+func (h *LatencyHistogram) Add(d time.Duration) {
+	if h.counts == nil {
+		h.counts = make(map[int]uint64)
+	}
+	d = max(d, 0)
+	h.counts[histIndex(uint64(d))]++
+	h.n++
+	h.sum += d
+	h.max = max(h.max, d)
+}
+
+// Merge adds the durations of other.
+func (h *LatencyHistogram) Merge(other *LatencyHistogram) {
+	if h.counts == nil {
+		h.counts = make(map[int]uint64, len(other.counts))
+	}
+	for idx, n := range other.counts {
+		h.counts[idx] += n
+	}
+	h.n += other.n
+	h.sum += other.sum
+	h.max = max(h.max, other.max)
+}
+
+// N returns the number of durations added.
+func (h *LatencyHistogram) N() uint64 {
+	return h.n
+}
+
+// Average returns the exact average duration.
+func (h *LatencyHistogram) Average() time.Duration {
+	if h.n == 0 {
+		return 0
+	}
+	return h.sum / time.Duration(h.n)
+}
+
+// Max returns the exact longest duration.
+func (h *LatencyHistogram) Max() time.Duration {
+	return h.max
+}
+
+// Quantile returns the duration below which the fraction q of the durations are.
+func (h *LatencyHistogram) Quantile(q float64) time.Duration {
+	if h.n == 0 {
+		return 0
+	}
+	idxs := make([]int, 0, len(h.counts))
+	for idx := range h.counts {
+		idxs = append(idxs, idx)
+	}
+	sort.Ints(idxs)
+	want := uint64(q * float64(h.n))
+	var seen uint64
+	for _, idx := range idxs {
+		seen += h.counts[idx]
+		if seen > want {
+			low, width := histValue(idx)
+			return min(time.Duration(low+width/2), h.max)
+		}
+	}
+	return h.max
+}
+
+// String returns the histogram as 'sum,max,bucket:count,...'.
+func (h *LatencyHistogram) String() string {
+	idxs := make([]int, 0, len(h.counts))
+	for idx := range h.counts {
+		idxs = append(idxs, idx)
+	}
+	sort.Ints(idxs)
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d,%d", h.sum, h.max)
+	for _, idx := range idxs {
+		fmt.Fprintf(&sb, ",%d:%d", idx, h.counts[idx])
+	}
+	return sb.String()
+}
+
+// ParseLatencyHistogram parses a histogram written by String.
+func ParseLatencyHistogram(s string) (*LatencyHistogram, error) {
+	fields := strings.Split(s, ",")
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("invalid latency histogram %q", s)
+	}
+	sum, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	maxD, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	h := &LatencyHistogram{counts: make(map[int]uint64, len(fields)-2), sum: time.Duration(sum), max: time.Duration(maxD)}
+	for _, f := range fields[2:] {
+		idx, count, ok := strings.Cut(f, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid latency histogram bucket %q", f)
+		}
+		i, err := strconv.Atoi(idx)
+		if err != nil {
+			return nil, err
+		}
+		n, err := strconv.ParseUint(count, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		h.counts[i] += n
+		h.n += n
+	}
+	return h, nil
+}
+
+// opSampler counts all operations and selects the operations to record.
+type opSampler struct {
+	n uint64
+	// from is the time in unix nanoseconds sampling started.
+	// Operations started before are all recorded and not counted.
+	from atomic.Int64
+
+	mu    sync.Mutex
+	stats map[string]*opSampleStats
+}
+
+// opSampleStats are the exact results of an operation type.
+type opSampleStats struct {
+	requests, errors uint64
+	bytes            int64
+	objects          int
+	start, end       time.Time
+	latency          LatencyHistogram
+}
+
+func newOpSampler(n int) *opSampler {
+	return &opSampler{n: uint64(n), stats: make(map[string]*opSampleStats)}
+}
+
+// start sampling operations started after t.
+// Only the first call has effect.
+func (s *opSampler) start(t time.Time) {
+	if s != nil {
+		s.from.CompareAndSwap(0, t.UnixNano())
+	}
+}
+
+// keep counts op and returns whether it should be recorded.
+func (s *opSampler) keep(op Operation) bool {
+	if s == nil {
+		return true
+	}
+	if from := s.from.Load(); from == 0 || op.Start.UnixNano() < from {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.stats[op.OpType]
+	if st == nil {
+		st = &opSampleStats{start: op.Start, end: op.End}
+		s.stats[op.OpType] = st
+	}
+	st.requests++
+	st.bytes += op.Size
+	st.objects += op.ObjPerOp
+	if op.Start.Before(st.start) {
+		st.start = op.Start
+	}
+	if op.End.After(st.end) {
+		st.end = op.End
+	}
+	if op.Err != "" {
+		st.errors++
+	} else {
+		st.latency.Add(op.Duration())
+	}
+	return (st.requests-1)%s.n == 0
+}
+
+// Events returns an event with the exact results of each operation type.
+func (s *opSampler) Events() Operations {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events := make(Operations, 0, len(s.stats))
+	for typ, st := range s.stats {
+		events = append(events, Operation{
+			OpType:   OpSampleOp,
+			File:     typ,
+			Start:    st.start,
+			End:      st.end,
+			Size:     st.bytes,
+			ObjPerOp: st.objects,
+			Headers: map[string]string{
+				OpSampleRate:     strconv.FormatUint(s.n, 10),
+				OpSampleRequests: strconv.FormatUint(st.requests, 10),
+				OpSampleErrors:   strconv.FormatUint(st.errors, 10),
+				OpSampleLatency:  st.latency.String(),
+			},
+		})
+	}
+	return events
+}
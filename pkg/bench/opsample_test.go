@@ -0,0 +1,98 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestLatencyHistogram(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	var h, a, b LatencyHistogram
+	durs := make([]time.Duration, 100000)
+	var sum time.Duration
+	for i := range durs {
+		// Spread from 1ns to 10s on a logarithmic scale.
+		d := time.Duration(math.Exp(rng.Float64() * math.Log(float64(10*time.Second))))
+		durs[i] = d
+		sum += d
+		h.Add(d)
+		if i%2 == 0 {
+			a.Add(d)
+		} else {
+			b.Add(d)
+		}
+	}
+	sort.Slice(durs, func(i, j int) bool { return durs[i] < durs[j] })
+
+	if h.N() != uint64(len(durs)) {
+		t.Errorf("N: got %d, want %d", h.N(), len(durs))
+	}
+	if want := sum / time.Duration(len(durs)); h.Average() != want {
+		t.Errorf("Average: got %v, want %v", h.Average(), want)
+	}
+	if want := durs[len(durs)-1]; h.Max() != want {
+		t.Errorf("Max: got %v, want %v", h.Max(), want)
+	}
+	for _, q := range []float64{0, 0.01, 0.1, 0.25, 0.5, 0.75, 0.9, 0.99, 0.999} {
+		want := durs[int(q*float64(len(durs)))]
+		got := h.Quantile(q)
+		if relErr := math.Abs(float64(got-want)) / float64(want); relErr > 1.0/(1<<histSubBits) {
+			t.Errorf("Quantile(%v): got %v, want %v, relative error %.4f", q, got, want, relErr)
+		}
+	}
+
+	a.Merge(&b)
+	if a.String() != h.String() {
+		t.Error("merged histogram differs")
+	}
+
+	parsed, err := ParseLatencyHistogram(h.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*parsed, h) {
+		t.Error("parsed histogram differs")
+	}
+	if parsed.String() != h.String() {
+		t.Errorf("round trip: got %q, want %q", parsed.String(), h.String())
+	}
+}
+
+func TestLatencyHistogram_Small(t *testing.T) {
+	// Small values are stored exactly.
+	var h LatencyHistogram
+	for d := time.Duration(0); d < 1<<(histSubBits+1); d++ {
+		h.Add(d)
+	}
+	for d := time.Duration(0); d < 1<<(histSubBits+1); d++ {
+		if got := h.Quantile(float64(d) / float64(h.N())); got != d {
+			t.Errorf("Quantile: got %v, want %v", got, d)
+		}
+	}
+	for _, s := range []string{"", "1", "1,2,3", "1,2,a:1", "x,2"} {
+		if _, err := ParseLatencyHistogram(s); err == nil {
+			t.Errorf("ParseLatencyHistogram(%q): want error", s)
+		}
+	}
+}
@@ -0,0 +1,410 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/pkg/v2/console"
+)
+
+// Conditions of uploads in the overwrite benchmark.
+const (
+	OverwriteUnconditional = ""
+	// OverwriteIfMatch reads the ETag with a HEAD request and uploads with If-Match.
+	OverwriteIfMatch = "if-match"
+	// OverwriteIfNoneMatch uploads with 'If-None-Match: *', so only the first upload of a key succeeds.
+	OverwriteIfNoneMatch = "if-none-match"
+)
+
+// Overwrite benchmarks concurrent uploads to a small set of keys.
+// All threads upload to the same keys.
+type Overwrite struct {
+	Common
+
+	// Keys is the number of keys uploaded to.
+	Keys int
+
+	// KeyPrefix is the prefix of all keys.
+	// Use the same prefix on all clients to have them upload to the same keys.
+	KeyPrefix string
+
+	// Condition of uploads. See OverwriteIfMatch and OverwriteIfNoneMatch.
+	Condition string
+
+	// EnableVersioning will enable versioning on the bucket.
+	EnableVersioning bool
+
+	// Readers is the number of threads downloading with If-Match instead of uploading.
+	// Readers use the ETag of the last upload of the key seen by this client.
+	Readers int
+
+	// statusMu protects status, readStatus and torn.
+	statusMu sync.Mutex
+	// status counts upload responses by status code.
+	status map[int]int
+	// readStatus counts download responses by status code.
+	readStatus map[int]int
+	// torn counts downloads with content not matching the ETag.
+	torn int
+
+	// etagsMu protects etags.
+	etagsMu sync.Mutex
+	// etags contains the last ETag seen of each key.
+	etags map[string]string
+}
+
+// key returns the name of key n.
+func (g *Overwrite) key(n int) string {
+	return path.Join(g.KeyPrefix, fmt.Sprintf("key-%d", n))
+}
+
+// Prepare will create an empty bucket or delete any content already there.
+func (g *Overwrite) Prepare(ctx context.Context) error {
+	if err := g.createEmptyBucket(ctx); err != nil {
+		return err
+	}
+	if g.EnableVersioning && !g.Versioned {
+		cl, done := g.Client()
+		err := cl.EnableVersioning(ctx, g.Bucket)
+		done()
+		if err != nil {
+			return err
+		}
+		g.Versioned = true
+	}
+	g.etags = make(map[string]string, g.Keys)
+	if g.Readers > 0 {
+		// Upload all keys, so readers have an ETag to start with.
+		src := g.Source()
+		for n := 0; n < g.Keys; n++ {
+			obj := src.Object()
+			opts := g.PutOpts
+			opts.ContentType = obj.ContentType
+			cl, done := g.Client()
+			res, err := cl.PutObject(ctx, g.Bucket, g.key(n), obj.Reader, obj.Size, opts)
+			done()
+			if err != nil {
+				return err
+			}
+			g.setETag(g.key(n), res.ETag)
+		}
+	}
+	g.addCollector()
+	return nil
+}
+
+// setETag records the last ETag seen of a key.
+func (g *Overwrite) setETag(name, etag string) {
+	g.etagsMu.Lock()
+	g.etags[name] = etag
+	g.etagsMu.Unlock()
+}
+
+// etag returns the last ETag seen of a key.
+func (g *Overwrite) etag(name string) string {
+	g.etagsMu.Lock()
+	defer g.etagsMu.Unlock()
+	return g.etags[name]
+}
+
+// Start will execute the main benchmark.
+// Operations should begin executing when the start channel is closed.
+func (g *Overwrite) Start(ctx context.Context, wait chan struct{}) (Operations, error) {
+	var wg sync.WaitGroup
+	wg.Add(g.Concurrency)
+	c := g.Collector
+	if g.AutoTermDur > 0 {
+		ctx = c.AutoTerm(ctx, http.MethodPut, g.AutoTermScale, autoTermCheck, autoTermSamples, g.AutoTermDur)
+	}
+	g.status = make(map[int]int)
+	g.readStatus = make(map[int]int)
+	// Non-terminating context.
+	nonTerm := context.Background()
+
+	start := time.Now()
+	for i := 0; i < g.Concurrency; i++ {
+		go func(i int) {
+			rng := rand.New(rand.NewSource(int64(i)))
+			rcv := c.Receiver()
+			defer wg.Done()
+			done := ctx.Done()
+			src := g.Source()
+
+			g.startWait(wait)
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				if g.rpsLimit(ctx) != nil {
+					return
+				}
+
+				name := g.key(rng.Intn(g.Keys))
+				if i < g.Readers {
+					rcv <- g.readIfMatch(nonTerm, i, name)
+					continue
+				}
+				obj := src.Object()
+				client, cldone := g.Client()
+				opts := g.PutOpts
+				opts.ContentType = obj.ContentType
+				switch g.Condition {
+				case OverwriteIfMatch:
+					op := Operation{
+						OpType:   "STAT",
+						Thread:   uint16(i),
+						File:     name,
+						ObjPerOp: 1,
+						Endpoint: client.EndpointURL().String(),
+					}
+					opCtx, resp := recordResponse(nonTerm)
+					op.Start = time.Now()
+					info, err := client.StatObject(opCtx, g.Bucket, name, minio.StatObjectOptions{})
+					op.End = time.Now()
+					resp.apply(&op, g.RecordHeaders)
+					switch {
+					case err == nil:
+						opts.SetMatchETag(info.ETag)
+						g.setETag(name, info.ETag)
+					case minio.ToErrorResponse(err).StatusCode == http.StatusNotFound:
+						// Not yet created, upload only if it still doesn't exist.
+						opts.SetMatchETagExcept("*")
+					default:
+						g.Error("stat error:", err)
+						op.Err = err.Error()
+					}
+					rcv <- op
+					if op.Err != "" {
+						cldone()
+						continue
+					}
+				case OverwriteIfNoneMatch:
+					opts.SetMatchETagExcept("*")
+				}
+
+				op := Operation{
+					OpType:   http.MethodPut,
+					Thread:   uint16(i),
+					Size:     obj.Size,
+					File:     name,
+					ObjPerOp: 1,
+					Endpoint: client.EndpointURL().String(),
+				}
+				opCtx, resp := recordResponse(nonTerm)
+				op.Start = time.Now()
+				res, err := client.PutObject(opCtx, g.Bucket, name, obj.Reader, obj.Size, opts)
+				op.End = time.Now()
+				resp.apply(&op, g.RecordHeaders)
+				cldone()
+				code := http.StatusOK
+				if err != nil {
+					code = minio.ToErrorResponse(err).StatusCode
+					switch code {
+					case http.StatusConflict, http.StatusPreconditionFailed:
+						// Expected under contention.
+						op.Err = "conflict: " + err.Error()
+					default:
+						g.Error("upload error:", err)
+						op.Err = err.Error()
+					}
+				} else if res.Size != obj.Size {
+					op.Err = fmt.Sprint("short upload. want:", obj.Size, ", got:", res.Size)
+					g.Error(op.Err)
+				} else {
+					g.setETag(name, res.ETag)
+				}
+				g.statusMu.Lock()
+				g.status[code]++
+				g.statusMu.Unlock()
+				rcv <- op
+			}
+		}(i)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	g.printStatus()
+	g.printReadStatus()
+	if g.Versioned {
+		g.printVersions(nonTerm, elapsed)
+	}
+	return c.Close(), nil
+}
+
+// readIfMatch downloads a key with the last ETag seen in If-Match.
+// A successful download must return the object with that ETag.
+// If the ETag is the MD5 of the content, the content is verified.
+func (g *Overwrite) readIfMatch(ctx context.Context, thread int, name string) Operation {
+	client, cldone := g.Client()
+	defer cldone()
+	etag := g.etag(name)
+	op := Operation{
+		OpType:   http.MethodGet,
+		Thread:   uint16(thread),
+		File:     name,
+		ObjPerOp: 1,
+		Endpoint: client.EndpointURL().String(),
+	}
+	opts := minio.GetObjectOptions{}
+	if etag != "" {
+		opts.SetMatchETag(etag)
+	}
+	h := md5.New()
+	opCtx, resp := recordResponse(ctx)
+	op.Start = time.Now()
+	o, err := client.GetObject(opCtx, g.Bucket, name, opts)
+	var info minio.ObjectInfo
+	if err == nil {
+		// The request is sent on the first read.
+		op.Size, err = io.Copy(h, o)
+		if err == nil {
+			info, err = o.Stat()
+		}
+		o.Close()
+	}
+	op.End = time.Now()
+	resp.apply(&op, g.RecordHeaders)
+	code := http.StatusOK
+	torn := false
+	switch {
+	case err != nil:
+		code = minio.ToErrorResponse(err).StatusCode
+		if code == http.StatusPreconditionFailed {
+			// The key was overwritten since the ETag was seen.
+			op.Err = "precondition failed: " + err.Error()
+			break
+		}
+		g.Error("download error:", err)
+		op.Err = err.Error()
+	case etag != "" && info.ETag != etag:
+		torn = true
+		op.Err = fmt.Sprintf("got ETag %s, want %s", info.ETag, etag)
+	case etagIsMD5(info) && hex.EncodeToString(h.Sum(nil)) != info.ETag:
+		torn = true
+		op.Err = fmt.Sprintf("content does not match ETag %s", info.ETag)
+	default:
+		g.setETag(name, info.ETag)
+	}
+	if torn {
+		g.Error("torn read of", name+":", op.Err)
+	}
+	g.statusMu.Lock()
+	g.readStatus[code]++
+	if torn {
+		g.torn++
+	}
+	g.statusMu.Unlock()
+	return op
+}
+
+// etagIsMD5 returns whether the ETag of the object is the MD5 of its content.
+// This is not the case for multipart uploads and objects encrypted with SSE-C or SSE-KMS.
+func etagIsMD5(info minio.ObjectInfo) bool {
+	return !strings.Contains(info.ETag, "-") &&
+		info.Metadata.Get("X-Amz-Server-Side-Encryption-Customer-Algorithm") == "" &&
+		!strings.HasPrefix(info.Metadata.Get("X-Amz-Server-Side-Encryption"), "aws:kms")
+}
+
+// printStatus prints the number of uploads by response status.
+func (g *Overwrite) printStatus() {
+	g.statusMu.Lock()
+	defer g.statusMu.Unlock()
+	if s := statusSummary(g.status); s != "" {
+		console.Eraseline()
+		console.Infof("\rUpload responses: %s\n", s)
+	}
+}
+
+// printReadStatus prints the number of downloads by response status and the number of torn reads.
+func (g *Overwrite) printReadStatus() {
+	g.statusMu.Lock()
+	defer g.statusMu.Unlock()
+	if s := statusSummary(g.readStatus); s != "" {
+		console.Eraseline()
+		console.Infof("\rDownload responses: %s. Torn reads: %d\n", s, g.torn)
+	}
+}
+
+// statusSummary returns the number and percentage of responses by status code.
+func statusSummary(status map[int]int) string {
+	var total int
+	codes := make([]int, 0, len(status))
+	for code, n := range status {
+		codes = append(codes, code)
+		total += n
+	}
+	if total == 0 {
+		return ""
+	}
+	sort.Ints(codes)
+	parts := make([]string, 0, len(codes))
+	for _, code := range codes {
+		desc := http.StatusText(code)
+		if code == 0 {
+			desc = "No response"
+		}
+		parts = append(parts, fmt.Sprintf("%d %s: %d (%.02f%%)", code, desc, status[code], 100*float64(status[code])/float64(total)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// printVersions prints the number of versions stored
+// and the rate they have accumulated at during the benchmark.
+func (g *Overwrite) printVersions(ctx context.Context, elapsed time.Duration) {
+	cl, done := g.Client()
+	defer done()
+	var versions, markers int
+	for obj := range cl.ListObjects(ctx, g.Bucket, minio.ListObjectsOptions{
+		Prefix:       g.KeyPrefix + "/",
+		Recursive:    true,
+		WithVersions: true,
+	}) {
+		if obj.Err != nil {
+			g.Error("listing versions:", obj.Err)
+			return
+		}
+		if obj.IsDeleteMarker {
+			markers++
+			continue
+		}
+		versions++
+	}
+	console.Eraseline()
+	console.Infof("\rVersions stored: %d (%.01f per key, %.02f versions/s). Delete markers: %d\n",
+		versions, float64(versions)/float64(g.Keys), float64(versions)/elapsed.Seconds(), markers)
+}
+
+// Cleanup deletes everything uploaded to the bucket.
+func (g *Overwrite) Cleanup(ctx context.Context) {
+	g.deleteAllInBucket(ctx, g.KeyPrefix)
+}
@@ -0,0 +1,128 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// PacingOp is the operation type of events with the accuracy of the request rate limiter.
+// Each event covers the requests started in PacingInterval.
+// The number of requests is stored in ObjPerOp and details in Headers.
+const PacingOp = "PACING"
+
+// PacingInterval is the time covered by each pacing event.
+const PacingInterval = time.Second
+
+// Headers of pacing events.
+const (
+	// PacingLimit is the request rate limit in requests per second at the end of the interval.
+	PacingLimit = "limit"
+	// PacingWaited is the number of requests that waited for the limiter.
+	// Other requests found no thread waiting when the limiter allowed a request.
+	PacingWaited = "waited"
+	// PacingLateNS is the total time in nanoseconds that waiting requests started after the limiter allowed them.
+	PacingLateNS = "late_ns"
+	// PacingLateMaxNS is the longest time in nanoseconds a waiting request started after the limiter allowed it.
+	PacingLateMaxNS = "late_max_ns"
+)
+
+// pacing records the accuracy of the request rate limiter as events.
+type pacing struct {
+	limiter *rate.Limiter
+
+	mu       sync.Mutex
+	start    time.Time
+	requests int
+	waited   int
+	late     time.Duration
+	lateMax  time.Duration
+	events   Operations
+}
+
+func newPacing(limiter *rate.Limiter) *pacing {
+	return &pacing{limiter: limiter}
+}
+
+// add records a request that was allowed by the limiter at intended.
+// If waited is false no thread was waiting when the limiter would allow a request.
+func (p *pacing) add(intended time.Time, waited bool) {
+	if p == nil {
+		return
+	}
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.start.IsZero() {
+		p.start = now
+	}
+	if now.Sub(p.start) >= PacingInterval {
+		p.flush(now)
+		p.start = now
+	}
+	p.requests++
+	if waited {
+		p.waited++
+		late := now.Sub(intended)
+		p.late += late
+		p.lateMax = max(p.lateMax, late)
+	}
+}
+
+// flush records an event with the current interval.
+// Intervals without a limit are not recorded.
+// Must be called with p.mu held.
+func (p *pacing) flush(now time.Time) {
+	defer func() {
+		p.requests, p.waited, p.late, p.lateMax = 0, 0, 0, 0
+	}()
+	limit := p.limiter.Limit()
+	if p.requests == 0 || limit == rate.Inf {
+		return
+	}
+	p.events = append(p.events, Operation{
+		OpType:   PacingOp,
+		Start:    p.start,
+		End:      now,
+		ObjPerOp: p.requests,
+		Headers: map[string]string{
+			PacingLimit:     strconv.FormatFloat(float64(limit), 'f', -1, 64),
+			PacingWaited:    strconv.Itoa(p.waited),
+			PacingLateNS:    strconv.FormatInt(int64(p.late), 10),
+			PacingLateMaxNS: strconv.FormatInt(int64(p.lateMax), 10),
+		},
+	})
+}
+
+// Events returns the recorded events and clears them.
+func (p *pacing) Events() Operations {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.flush(time.Now())
+	p.start = time.Time{}
+	ev := p.events
+	p.events = nil
+	return ev
+}
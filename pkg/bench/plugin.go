@@ -0,0 +1,168 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+// Plugin describes a benchmark type added to the warp command.
+// Plugins are registered using RegisterPlugin, typically from an init function
+// in a package imported by a custom main package that calls cli.Main.
+type Plugin struct {
+	// Name of the command.
+	Name string
+
+	// Usage is a one line description of the benchmark.
+	Usage string
+
+	// Options accepted by the benchmark in addition to the common options.
+	Options []PluginOption
+
+	// New returns a new benchmark.
+	// c contains the common parameters and should be embedded in the returned benchmark.
+	New func(c Common, opts PluginOptions) (Benchmark, error)
+}
+
+// PluginOption describes an option accepted by a plugin.
+// All options are specified as strings on the command line.
+type PluginOption struct {
+	Name    string
+	Usage   string
+	Default string
+}
+
+// PluginOptions contains the option values for a plugin benchmark.
+type PluginOptions map[string]string
+
+var (
+	pluginsMu sync.Mutex
+	plugins   = map[string]Plugin{}
+
+	pluginNameRe = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+)
+
+// RegisterPlugin will register a plugin benchmark.
+// Names must be unique and contain only lowercase letters, digits and dashes.
+// Plugins must be registered before the warp command is started.
+func RegisterPlugin(p Plugin) error {
+	if !pluginNameRe.MatchString(p.Name) {
+		return fmt.Errorf("invalid plugin name %q", p.Name)
+	}
+	if p.New == nil {
+		return fmt.Errorf("plugin %q: no constructor", p.Name)
+	}
+	seen := make(map[string]struct{}, len(p.Options))
+	for _, o := range p.Options {
+		if !pluginNameRe.MatchString(o.Name) {
+			return fmt.Errorf("plugin %q: invalid option name %q", p.Name, o.Name)
+		}
+		if _, ok := seen[o.Name]; ok {
+			return fmt.Errorf("plugin %q: duplicate option %q", p.Name, o.Name)
+		}
+		seen[o.Name] = struct{}{}
+	}
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	if _, ok := plugins[p.Name]; ok {
+		return fmt.Errorf("plugin %q already registered", p.Name)
+	}
+	plugins[p.Name] = p
+	return nil
+}
+
+// Plugins returns all registered plugins sorted by name.
+func Plugins() []Plugin {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	res := make([]Plugin, 0, len(plugins))
+	for _, p := range plugins {
+		res = append(res, p)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+// String returns the value of an option.
+func (p PluginOptions) String(name string) string {
+	return p[name]
+}
+
+// Int returns the value of an option as an integer.
+// An empty value returns 0.
+func (p PluginOptions) Int(name string) (int, error) {
+	if p[name] == "" {
+		return 0, nil
+	}
+	v, err := strconv.Atoi(p[name])
+	if err != nil {
+		return 0, fmt.Errorf("option %s: %w", name, err)
+	}
+	return v, nil
+}
+
+// Bool returns the value of an option as a boolean.
+// An empty value returns false.
+func (p PluginOptions) Bool(name string) (bool, error) {
+	if p[name] == "" {
+		return false, nil
+	}
+	v, err := strconv.ParseBool(p[name])
+	if err != nil {
+		return false, fmt.Errorf("option %s: %w", name, err)
+	}
+	return v, nil
+}
+
+// Duration returns the value of an option as a duration.
+// An empty value returns 0.
+func (p PluginOptions) Duration(name string) (time.Duration, error) {
+	if p[name] == "" {
+		return 0, nil
+	}
+	v, err := time.ParseDuration(p[name])
+	if err != nil {
+		return 0, fmt.Errorf("option %s: %w", name, err)
+	}
+	return v, nil
+}
+
+// Size returns the value of an option as a size in bytes, eg. '10KiB'.
+// An empty value returns 0.
+func (p PluginOptions) Size(name string) (int64, error) {
+	if p[name] == "" {
+		return 0, nil
+	}
+	v, err := humanize.ParseBytes(p[name])
+	if err != nil {
+		return 0, fmt.Errorf("option %s: %w", name, err)
+	}
+	if v > 1<<62 {
+		return 0, fmt.Errorf("option %s: size too big", name)
+	}
+	return int64(v), nil
+}
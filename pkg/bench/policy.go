@@ -0,0 +1,306 @@
+/*
+ * Warp (C) 2019-2020 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/generator"
+)
+
+// Prefixes of objects allowed and denied by the bucket policy of the policy benchmark.
+const (
+	PolicyAllowedPrefix = "allowed"
+	PolicyDeniedPrefix  = "denied"
+)
+
+// PolicyDeniedOp is the operation type of requests expected to be denied.
+const PolicyDeniedOp = http.MethodGet + "-DENIED"
+
+// policyDocument allows anonymous reads of the allowed prefix
+// and denies reads of the denied prefix to everyone.
+const policyDocument = `{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Effect": "Allow",
+      "Principal": {"AWS": ["*"]},
+      "Action": ["s3:GetObject"],
+      "Resource": ["arn:aws:s3:::%[1]s/%[2]s/*"]
+    },
+    {
+      "Effect": "Deny",
+      "Principal": {"AWS": ["*"]},
+      "Action": ["s3:GetObject"],
+      "Resource": ["arn:aws:s3:::%[1]s/%[3]s/*"]
+    }
+  ]
+}`
+
+// Policy benchmarks GET requests that are allowed and denied by a bucket policy.
+type Policy struct {
+	Common
+
+	// CreateObjects is the number of objects uploaded.
+	// Half are uploaded to each of the allowed and denied prefixes.
+	CreateObjects int
+
+	// DeniedFraction is the fraction of requests sent to the denied prefix.
+	DeniedFraction float64
+
+	// Requester returns the client used for the benchmark requests.
+	// Usually anonymous or a user without administrative rights,
+	// since these are not subject to bucket policies on all servers.
+	Requester func() (cl *minio.Client, done func())
+
+	GetOpts minio.GetObjectOptions
+
+	allowed, denied generator.Objects
+	policySet       bool
+}
+
+// Prepare will create an empty bucket or delete any content already there,
+// upload objects to the allowed and denied prefixes and set the bucket policy.
+func (g *Policy) Prepare(ctx context.Context) error {
+	if err := g.createEmptyBucket(ctx); err != nil {
+		return err
+	}
+	console.Eraseline()
+	console.Info("\rUploading ", g.CreateObjects, " objects")
+
+	var wg sync.WaitGroup
+	wg.Add(g.prepareThreads())
+	g.addCollector()
+	objs := splitObjs(g.CreateObjects, g.prepareThreads())
+	rcv := g.Collector.rcv
+	var groupErr error
+	var mu sync.Mutex
+	var uploaded int
+
+	for i, obj := range objs {
+		go func(i int, obj []struct{}) {
+			defer wg.Done()
+			src := g.Source()
+			opts := g.PutOpts
+			prep := g.preparer(i, rcv)
+
+			for j := range obj {
+				select {
+				case <-ctx.Done():
+					return
+				default:
+				}
+
+				if g.rpsLimit(ctx) != nil {
+					return
+				}
+
+				prefix := PolicyAllowedPrefix
+				if (i+j)%2 == 1 {
+					prefix = PolicyDeniedPrefix
+				}
+				obj := src.Object()
+				obj.Name = path.Join(prefix, obj.Name)
+				obj.Prefix = path.Join(prefix, obj.Prefix)
+				client, cldone := g.Client()
+				op := Operation{
+					OpType:   http.MethodPut,
+					Thread:   uint16(i),
+					Size:     obj.Size,
+					File:     obj.Name,
+					ObjPerOp: 1,
+					Endpoint: client.EndpointURL().String(),
+				}
+
+				opts.ContentType = obj.ContentType
+				op.Start = time.Now()
+				res, queued, err := prep.upload(ctx, client, &op, obj, opts)
+				op.End = time.Now()
+				cldone()
+				if err == nil && res.Size != obj.Size {
+					err = fmt.Errorf("short upload. want: %d, got %d", obj.Size, res.Size)
+				}
+				if err != nil {
+					err := fmt.Errorf("upload error: %w", err)
+					g.Error(err)
+					mu.Lock()
+					if groupErr == nil {
+						groupErr = err
+					}
+					mu.Unlock()
+					return
+				}
+				mu.Lock()
+				obj.Reader = nil
+				if prefix == PolicyDeniedPrefix {
+					g.denied = append(g.denied, *obj)
+				} else {
+					g.allowed = append(g.allowed, *obj)
+				}
+				uploaded++
+				g.prepareProgress(float64(uploaded) / float64(g.CreateObjects))
+				mu.Unlock()
+				if !queued {
+					rcv <- op
+				}
+			}
+			client, cldone := g.Client()
+			err := prep.flush(ctx, client)
+			cldone()
+			if err != nil {
+				g.Error(err)
+				mu.Lock()
+				if groupErr == nil {
+					groupErr = err
+				}
+				mu.Unlock()
+			}
+		}(i, obj)
+	}
+	wg.Wait()
+	if groupErr != nil {
+		return groupErr
+	}
+	if len(g.allowed) == 0 || len(g.denied) == 0 {
+		return errors.New("no objects uploaded to allowed and denied prefixes")
+	}
+
+	client, cldone := g.Client()
+	defer cldone()
+	policy := fmt.Sprintf(policyDocument, g.Bucket, PolicyAllowedPrefix, PolicyDeniedPrefix)
+	if err := client.SetBucketPolicy(ctx, g.Bucket, policy); err != nil {
+		return fmt.Errorf("setting bucket policy: %w", err)
+	}
+	g.policySet = true
+	return nil
+}
+
+// Start will execute the main benchmark.
+// Operations should begin executing when the start channel is closed.
+func (g *Policy) Start(ctx context.Context, wait chan struct{}) (Operations, error) {
+	var wg sync.WaitGroup
+	wg.Add(g.Concurrency)
+	c := g.Collector
+	if g.AutoTermDur > 0 {
+		ctx = c.AutoTerm(ctx, http.MethodGet, g.AutoTermScale, autoTermCheck, autoTermSamples, g.AutoTermDur)
+	}
+	// Non-terminating context.
+	nonTerm := context.Background()
+
+	for i := 0; i < g.Concurrency; i++ {
+		go func(i int) {
+			defer wg.Done()
+			rcv := c.Receiver()
+			rng := rand.New(rand.NewSource(int64(i)))
+			done := ctx.Done()
+
+			g.startWait(wait)
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if g.rpsLimit(ctx) != nil {
+					return
+				}
+				rcv <- g.get(nonTerm, rng, i, rng.Float64() < g.DeniedFraction)
+			}
+		}(i)
+	}
+	wg.Wait()
+	return c.Close(), nil
+}
+
+// get downloads a random object from the allowed or denied prefix.
+// Requests to the denied prefix are successful if rejected with 403 Forbidden.
+func (g *Policy) get(ctx context.Context, rng *rand.Rand, thread int, deny bool) Operation {
+	objs, opType := g.allowed, http.MethodGet
+	if deny {
+		objs, opType = g.denied, PolicyDeniedOp
+	}
+	obj := objs[rng.Intn(len(objs))]
+	client, cldone := g.Requester()
+	defer cldone()
+	op := Operation{
+		OpType:   opType,
+		Thread:   uint16(thread),
+		Size:     obj.Size,
+		File:     obj.Name,
+		ObjPerOp: 1,
+		Endpoint: client.EndpointURL().String(),
+	}
+	fbr := firstByteRecorder{}
+	op.Start = time.Now()
+	opCtx, resp := recordResponse(ctx)
+	var n int64
+	o, err := client.GetObject(opCtx, g.Bucket, obj.Name, g.GetOpts)
+	if err == nil {
+		fbr.r = o
+		n, err = io.Copy(io.Discard, &fbr)
+		o.Close()
+	}
+	op.FirstByte = fbr.t
+	op.End = time.Now()
+	resp.apply(&op, g.RecordHeaders)
+	if deny {
+		// Nothing is transferred by denied requests.
+		op.Size = 0
+		switch {
+		case err == nil:
+			op.Err = "request was not denied"
+			g.Error("request was not denied:", obj.Name)
+		case minio.ToErrorResponse(err).StatusCode != http.StatusForbidden:
+			op.Err = err.Error()
+			g.Error("download error:", err)
+		}
+		return op
+	}
+	if err != nil {
+		g.Error("download error:", err)
+		op.Err = err.Error()
+		return op
+	}
+	if n != obj.Size {
+		op.Err = fmt.Sprint("unexpected download size. want:", obj.Size, ", got:", n)
+		g.Error(op.Err)
+	}
+	return op
+}
+
+// Cleanup removes the bucket policy and deletes everything uploaded to the bucket.
+func (g *Policy) Cleanup(ctx context.Context) {
+	if g.policySet {
+		client, cldone := g.Client()
+		if err := client.SetBucketPolicy(ctx, g.Bucket, ""); err != nil {
+			g.Error("removing bucket policy:", err)
+		}
+		cldone()
+	}
+	g.deleteAllInBucket(ctx, generator.MergeObjectPrefixes([]generator.Objects{g.allowed, g.denied})...)
+}
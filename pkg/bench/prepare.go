@@ -0,0 +1,310 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+	"github.com/minio/warp/pkg/generator"
+)
+
+// PrepareStrategy selects how objects are created when preparing a benchmark.
+type PrepareStrategy string
+
+const (
+	// PrepareStrategyPut uploads all objects.
+	PrepareStrategyPut PrepareStrategy = "put"
+
+	// PrepareStrategyCopy uploads a number of seed objects per thread
+	// and creates the remaining objects using server side copy.
+	PrepareStrategyCopy PrepareStrategy = "copy"
+
+	// PrepareStrategySnowball uploads objects in batches using the MinIO snowball extension.
+	// If the server does not extract the batches, objects are uploaded using PUT.
+	PrepareStrategySnowball PrepareStrategy = "snowball"
+)
+
+// PrepareStrategies contains all supported prepare strategies.
+var PrepareStrategies = []PrepareStrategy{PrepareStrategyPut, PrepareStrategyCopy, PrepareStrategySnowball}
+
+const (
+	// Objects above this size are copied using multipart copy.
+	maxSingleCopySize = 5 << 30
+
+	// Maximum size of a snowball batch.
+	// Objects bigger than this will be uploaded using PUT.
+	maxSnowballBatchSize = 100 << 20
+)
+
+// PreparePrefix is the prefix of the operation types of operations made when preparing.
+const PreparePrefix = "PREPARE-"
+
+// MarkPrepare adds PreparePrefix to the operation types of operations started before t,
+// which must be the time preparing finished. Events are not changed.
+func (o Operations) MarkPrepare(t time.Time) {
+	for i, op := range o {
+		if op.Start.Before(t) && !isEvent(op) && !strings.HasPrefix(op.OpType, PreparePrefix) {
+			o[i].OpType = PreparePrefix + op.OpType
+		}
+	}
+}
+
+// SplitPrepare returns the operations without operations made when preparing
+// and the operations made when preparing separately.
+func (o Operations) SplitPrepare() (ops, prepare Operations) {
+	for _, op := range o {
+		if strings.HasPrefix(op.OpType, PreparePrefix) {
+			prepare = append(prepare, op)
+			continue
+		}
+		ops = append(ops, op)
+	}
+	if len(prepare) == 0 {
+		return o, nil
+	}
+	return ops, prepare
+}
+
+// preparer creates objects for a single prepare thread.
+type preparer struct {
+	c      *Common
+	rcv    chan<- Operation
+	thread uint16
+	seeds  generator.Objects
+	next   int
+
+	// Snowball state.
+	batch     []minio.SnowballObject
+	batchSize int64
+	batchOpts minio.PutObjectOptions
+	verified  bool
+	fallback  bool
+}
+
+// preparer returns a preparer for a single thread.
+// Operations for batched uploads are sent to rcv.
+func (c *Common) preparer(thread int, rcv chan<- Operation) *preparer {
+	return &preparer{c: c, rcv: rcv, thread: uint16(thread)}
+}
+
+// upload will create obj using the configured prepare strategy.
+// When the object is created by copying a seed, op and obj are updated to match the seed.
+// If queued is returned the object has been added to a batch, which will be uploaded later
+// and op should be discarded. Call flush when all objects have been uploaded.
+func (p *preparer) upload(ctx context.Context, client *minio.Client, op *Operation, obj *generator.Object, opts minio.PutObjectOptions) (res minio.UploadInfo, queued bool, err error) {
+	if p.c.PrepareStrategy == PrepareStrategySnowball && !p.fallback && obj.Size <= maxSnowballBatchSize {
+		content, err := io.ReadAll(obj.Reader)
+		if err != nil {
+			return res, false, err
+		}
+		if p.batchSize+obj.Size > maxSnowballBatchSize || len(p.batch) >= p.batchLen() {
+			if err := p.flush(ctx, client); err != nil {
+				return res, false, err
+			}
+		}
+		hdr := make(http.Header, 1)
+		hdr.Set("Content-Type", obj.ContentType)
+		p.batch = append(p.batch, minio.SnowballObject{
+			Key:     obj.Name,
+			Size:    obj.Size,
+			Content: bytes.NewReader(content),
+			Headers: hdr,
+		})
+		p.batchSize += obj.Size
+		p.batchOpts = opts
+		res.Size = obj.Size
+		return res, true, nil
+	}
+	if p.c.PrepareStrategy == PrepareStrategySnowball {
+		res, err = client.PutObject(ctx, p.c.Bucket, obj.Name, obj.Reader, obj.Size, opts)
+		return res, false, err
+	}
+
+	seeds := p.c.PrepareSeeds
+	if seeds <= 0 {
+		seeds = 1
+	}
+	if p.c.PrepareStrategy != PrepareStrategyCopy || len(p.seeds) < seeds {
+		res, err := client.PutObject(ctx, p.c.Bucket, obj.Name, obj.Reader, obj.Size, opts)
+		if err == nil && p.c.PrepareStrategy == PrepareStrategyCopy {
+			seed := *obj
+			seed.Reader = nil
+			seed.VersionID = res.VersionID
+			p.seeds = append(p.seeds, seed)
+		}
+		return res, false, err
+	}
+
+	seed := p.seeds[p.next%len(p.seeds)]
+	p.next++
+	op.OpType = "COPY"
+	op.Size = seed.Size
+	obj.Size = seed.Size
+	obj.ContentType = seed.ContentType
+
+	src := minio.CopySrcOptions{
+		Bucket:    p.c.Bucket,
+		Object:    seed.Name,
+		VersionID: seed.VersionID,
+	}
+	if sse := opts.ServerSideEncryption; sse != nil && sse.Type() == encrypt.SSEC {
+		src.Encryption = sse
+	}
+	dst := minio.CopyDestOptions{
+		Bucket:     p.c.Bucket,
+		Object:     obj.Name,
+		Encryption: opts.ServerSideEncryption,
+	}
+	if opts.StorageClass != "" {
+		// Metadata must be replaced to set the storage class of the copy.
+		dst.ReplaceMetadata = true
+		dst.UserMetadata = map[string]string{
+			"X-Amz-Storage-Class": opts.StorageClass,
+			"Content-Type":        seed.ContentType,
+		}
+	}
+	if seed.Size > maxSingleCopySize {
+		res, err = client.ComposeObject(ctx, dst, src)
+	} else {
+		res, err = client.CopyObject(ctx, dst, src)
+	}
+	if err == nil {
+		// Copy responses do not include the size.
+		res.Size = seed.Size
+	}
+	return res, false, err
+}
+
+// batchLen returns the maximum number of objects in a batch.
+func (p *preparer) batchLen() int {
+	if p.c.PrepareBatch <= 0 {
+		return 1000
+	}
+	return p.c.PrepareBatch
+}
+
+// flush will upload any queued objects.
+func (p *preparer) flush(ctx context.Context, client *minio.Client) error {
+	if len(p.batch) == 0 {
+		return nil
+	}
+	batch := p.batch
+	p.batch = nil
+	p.batchSize = 0
+	if p.fallback {
+		return p.putBatch(ctx, client, batch)
+	}
+
+	op := Operation{
+		OpType:   http.MethodPut,
+		Thread:   p.thread,
+		File:     path.Join(path.Dir(batch[0].Key), "snowball.tar"),
+		ObjPerOp: len(batch),
+		Endpoint: client.EndpointURL().String(),
+	}
+	objs := make(chan minio.SnowballObject, len(batch))
+	for _, obj := range batch {
+		op.Size += obj.Size
+		objs <- obj
+	}
+	close(objs)
+	op.Start = time.Now()
+	err := client.PutObjectsSnowball(ctx, p.c.Bucket, minio.SnowballOptions{Opts: p.batchOpts, InMemory: true}, objs)
+	op.End = time.Now()
+	if err != nil {
+		return fmt.Errorf("snowball upload error: %w", err)
+	}
+	if !p.verified {
+		// Servers without snowball support will store the archive as a single object.
+		var statOpts minio.StatObjectOptions
+		if sse := p.batchOpts.ServerSideEncryption; sse != nil && sse.Type() == encrypt.SSEC {
+			statOpts.ServerSideEncryption = sse
+		}
+		_, err := client.StatObject(ctx, p.c.Bucket, batch[0].Key, statOpts)
+		if err != nil {
+			if minio.ToErrorResponse(err).StatusCode != http.StatusNotFound {
+				return err
+			}
+			p.c.Error("Server did not extract snowball upload, uploading objects using PUT")
+			p.fallback = true
+			if err := p.removeSnowballArchives(ctx, client); err != nil {
+				p.c.Error("Unable to remove snowball upload: ", err)
+			}
+			for _, obj := range batch {
+				obj.Content.(*bytes.Reader).Seek(0, io.SeekStart)
+			}
+			return p.putBatch(ctx, client, batch)
+		}
+		p.verified = true
+	}
+	p.rcv <- op
+	return nil
+}
+
+// removeSnowballArchives removes archives stored as objects by servers without snowball support.
+func (p *preparer) removeSnowballArchives(ctx context.Context, client *minio.Client) error {
+	for obj := range client.ListObjects(ctx, p.c.Bucket, minio.ListObjectsOptions{Prefix: "snowball-upload-"}) {
+		if obj.Err != nil {
+			return obj.Err
+		}
+		if !strings.HasSuffix(obj.Key, ".tar") {
+			continue
+		}
+		err := client.RemoveObject(ctx, p.c.Bucket, obj.Key, minio.RemoveObjectOptions{})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// putBatch will upload objects in batch one by one.
+func (p *preparer) putBatch(ctx context.Context, client *minio.Client, batch []minio.SnowballObject) error {
+	opts := p.batchOpts
+	for _, obj := range batch {
+		op := Operation{
+			OpType:   http.MethodPut,
+			Thread:   p.thread,
+			Size:     obj.Size,
+			File:     obj.Key,
+			ObjPerOp: 1,
+			Endpoint: client.EndpointURL().String(),
+		}
+		opts.ContentType = obj.Headers.Get("Content-Type")
+		op.Start = time.Now()
+		res, err := client.PutObject(ctx, p.c.Bucket, obj.Key, obj.Content, obj.Size, opts)
+		op.End = time.Now()
+		if err != nil {
+			return fmt.Errorf("upload error: %w", err)
+		}
+		if res.Size != obj.Size {
+			return fmt.Errorf("short upload. want: %d, got %d", obj.Size, res.Size)
+		}
+		p.rcv <- op
+	}
+	return nil
+}
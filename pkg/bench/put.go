@@ -36,6 +36,7 @@ type Put struct {
 	Common
 	PostObject bool
 	prefixes   map[string]struct{}
+	prefixMu   sync.Mutex
 	cl         *http.Client
 }
 
@@ -66,14 +67,25 @@ func (u *Put) Start(ctx context.Context, wait chan struct{}) (Operations, error)
 
 	for i := 0; i < u.Concurrency; i++ {
 		src := u.Source()
+		u.prefixMu.Lock()
 		u.prefixes[src.Prefix()] = struct{}{}
+		u.prefixMu.Unlock()
 		go func(i int) {
 			rcv := c.Receiver()
 			defer wg.Done()
+			// Objects with shared names are outside the source prefix.
+			prefixes := make(map[string]struct{})
+			defer func() {
+				u.prefixMu.Lock()
+				for p := range prefixes {
+					u.prefixes[p] = struct{}{}
+				}
+				u.prefixMu.Unlock()
+			}()
 			opts := u.PutOpts
 			done := ctx.Done()
 
-			<-wait
+			u.startWait(wait)
 			for {
 				select {
 				case <-done:
@@ -86,6 +98,7 @@ func (u *Put) Start(ctx context.Context, wait chan struct{}) (Operations, error)
 				}
 
 				obj := src.Object()
+				prefixes[obj.Prefix] = struct{}{}
 				opts.ContentType = obj.ContentType
 				client, cldone := u.Client()
 				op := Operation{
@@ -97,11 +110,12 @@ func (u *Put) Start(ctx context.Context, wait chan struct{}) (Operations, error)
 					Endpoint: client.EndpointURL().String(),
 				}
 
+				opCtx, resp := recordResponse(nonTerm)
 				op.Start = time.Now()
 				var err error
 				var res minio.UploadInfo
 				if !u.PostObject {
-					res, err = client.PutObject(nonTerm, u.Bucket, obj.Name, obj.Reader, obj.Size, opts)
+					res, err = client.PutObject(opCtx, u.Bucket, obj.Name, obj.Reader, obj.Size, opts)
 				} else {
 					op.OpType = http.MethodPost
 					var verID string
@@ -112,6 +126,7 @@ func (u *Put) Start(ctx context.Context, wait chan struct{}) (Operations, error)
 					}
 				}
 				op.End = time.Now()
+				resp.apply(&op, u.RecordHeaders)
 				if err != nil {
 					u.Error("upload error: ", err)
 					op.Err = err.Error()
@@ -0,0 +1,190 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// Replay runs a recorded workload.
+// Each recorded thread is replayed by its own thread in the recorded order.
+// Operations on an object wait for the previous recorded operation on the same object,
+// so the order of operations on each object is kept across threads.
+type Replay struct {
+	Common
+
+	// Ops is the recorded workload.
+	Ops []WorkloadOp
+
+	// Speed is multiplied with the recorded pace.
+	// When 0 operations are sent as fast as possible.
+	Speed float64
+}
+
+// ReplaySupported returns whether recorded operations of the type can be replayed.
+func ReplaySupported(opType string) bool {
+	switch opType {
+	case http.MethodPut, http.MethodGet, "STAT", http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// Prepare will create an empty bucket or delete any content already there.
+// Objects are created by the recorded uploads.
+func (g *Replay) Prepare(ctx context.Context) error {
+	return g.createEmptyBucket(ctx)
+}
+
+// Start will execute the main benchmark.
+// Operations should begin executing when the start channel is closed.
+func (g *Replay) Start(ctx context.Context, wait chan struct{}) (Operations, error) {
+	var recs []WorkloadOp
+	for _, op := range g.Ops {
+		if ReplaySupported(op.OpType) {
+			recs = append(recs, op)
+		}
+	}
+	// after contains the index of the previous operation on the same key, or -1.
+	// finished is closed when an operation that others wait for has completed.
+	after := make([]int, len(recs))
+	finished := make([]chan struct{}, len(recs))
+	last := make(map[string]int)
+	threads := make(map[uint16][]int)
+	for i, rec := range recs {
+		after[i] = -1
+		if j, ok := last[rec.Key]; ok {
+			after[i] = j
+			if finished[j] == nil {
+				finished[j] = make(chan struct{})
+			}
+		}
+		last[rec.Key] = i
+		threads[rec.Thread] = append(threads[rec.Thread], i)
+	}
+	var wg sync.WaitGroup
+	wg.Add(len(threads))
+	c := g.Collector
+	var startOnce sync.Once
+	var start time.Time
+	for thread, idx := range threads {
+		go func(thread uint16, idx []int) {
+			defer wg.Done()
+			rcv := c.Receiver()
+			done := ctx.Done()
+
+			g.startWait(wait)
+			startOnce.Do(func() { start = time.Now() })
+			for _, i := range idx {
+				rec := recs[i]
+				if g.Speed > 0 {
+					wait := time.Until(start.Add(time.Duration(float64(rec.Offset) / g.Speed)))
+					if wait > 0 {
+						t := time.NewTimer(wait)
+						select {
+						case <-done:
+							t.Stop()
+							return
+						case <-t.C:
+						}
+					}
+				}
+				if j := after[i]; j >= 0 {
+					select {
+					case <-done:
+						return
+					case <-finished[j]:
+					}
+				}
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				if g.rpsLimit(ctx) != nil {
+					return
+				}
+				rcv <- g.replay(thread, rec)
+				if finished[i] != nil {
+					close(finished[i])
+				}
+			}
+		}(thread, idx)
+	}
+	wg.Wait()
+	return c.Close(), nil
+}
+
+// replay executes a recorded operation.
+func (g *Replay) replay(thread uint16, rec WorkloadOp) Operation {
+	client, cldone := g.Client()
+	defer cldone()
+	op := Operation{
+		OpType:   rec.OpType,
+		Thread:   thread,
+		Size:     rec.Size,
+		File:     rec.Key,
+		ObjPerOp: 1,
+		Endpoint: client.EndpointURL().String(),
+	}
+	opCtx, resp := recordResponse(context.Background())
+	op.Start = time.Now()
+	var err error
+	switch rec.OpType {
+	case http.MethodPut:
+		opts := g.PutOpts
+		var res minio.UploadInfo
+		res, err = client.PutObject(opCtx, g.Bucket, rec.Key, newLargeReader(rec.Key, rec.Size), rec.Size, opts)
+		if err == nil && res.Size != rec.Size {
+			err = fmt.Errorf("short upload. want: %d, got %d", rec.Size, res.Size)
+		}
+	case http.MethodGet:
+		var o *minio.Object
+		o, err = client.GetObject(opCtx, g.Bucket, rec.Key, minio.GetObjectOptions{})
+		if err == nil {
+			var n int64
+			n, err = io.Copy(io.Discard, o)
+			o.Close()
+			op.Size = n
+		}
+	case "STAT":
+		_, err = client.StatObject(opCtx, g.Bucket, rec.Key, minio.StatObjectOptions{})
+	case http.MethodDelete:
+		err = client.RemoveObject(opCtx, g.Bucket, rec.Key, minio.RemoveObjectOptions{})
+	}
+	op.End = time.Now()
+	resp.apply(&op, g.RecordHeaders)
+	if err != nil {
+		g.Error(rec.OpType, " error: ", err)
+		op.Err = err.Error()
+	}
+	return op
+}
+
+// Cleanup deletes everything uploaded to the bucket.
+func (g *Replay) Cleanup(ctx context.Context) {
+	g.deleteAllInBucket(ctx)
+}
@@ -0,0 +1,545 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RequestIDHeader is the response header containing the server request ID.
+const RequestIDHeader = "X-Amz-Request-Id"
+
+// BucketRegionHeader is the response header containing the region of the bucket.
+const BucketRegionHeader = "X-Amz-Bucket-Region"
+
+const (
+	// AddressingVirtualHost is recorded for requests using virtual-host style bucket addressing.
+	AddressingVirtualHost = "vhost"
+	// AddressingPath is recorded for requests using path style bucket addressing.
+	AddressingPath = "path"
+)
+
+const (
+	// IPv4 is recorded for requests sent on an IPv4 connection.
+	IPv4 = "ipv4"
+	// IPv6 is recorded for requests sent on an IPv6 connection.
+	IPv6 = "ipv6"
+)
+
+type responseKey struct{}
+
+// response records the last response received for an operation.
+type response struct {
+	mu     sync.Mutex
+	header http.Header
+
+	// storageClass requested, if any.
+	storageClass string
+
+	// Times of the last request, if traced.
+	traced bool
+	times  traceTimes
+
+	// Connection of the last request, if known.
+	gotConn bool
+	reused  bool
+	family  string
+
+	addressing string
+	endpoint   string
+	region     string
+
+	// opID sent with all requests of the operation, if enabled.
+	opID string
+
+	// Bytes sent and received by all requests, including headers.
+	wireSent, wireRecv atomic.Int64
+
+	// Stalls of downloads, if monitored.
+	stall *stallMonitor
+
+	// Throttled responses of all requests.
+	throttled, retryEarly int
+	retryAfter            time.Duration
+	// throttledTime is the time waited before retrying as advised by Retry-After.
+	throttledTime time.Duration
+	// retryAt is the time a retry was advised by the last throttled response, if any.
+	retryAt time.Time
+}
+
+// traceTimes contains the times of the phases of a request.
+type traceTimes struct {
+	dnsStart, dnsDone     time.Time
+	connStart, connDone   time.Time
+	tlsStart, tlsDone     time.Time
+	gotConn, wroteRequest time.Time
+	wroteHeaders, got100  time.Time
+	firstByte             time.Time
+}
+
+// ResponseRecorder wraps a RoundTripper and records response headers
+// for requests made with a context returned by recordResponse.
+type ResponseRecorder struct {
+	http.RoundTripper
+
+	// TracePhases will record the time spent in each phase of requests.
+	TracePhases bool
+
+	// Addressing is the bucket addressing style recorded with each operation, if set.
+	Addressing string
+
+	// Endpoint replaces the client endpoint recorded with each operation, if set.
+	// Used when requests are addressed to a different host than the one connected to.
+	Endpoint string
+
+	// ExpectContinue will send uploads with 'Expect: 100-continue',
+	// so the body is only sent when the server has accepted the request.
+	ExpectContinue bool
+
+	// StallSpeed enables stall detection on GET responses.
+	// When the download speed stays below StallSpeed bytes per second for StallDuration,
+	// a stall is recorded with the operation.
+	StallSpeed    int64
+	StallDuration time.Duration
+
+	// RunIDHeader will send RunID in this header with every request, if set.
+	RunIDHeader string
+	RunID       string
+	// OpIDHeader will send a random ID in this header with every request of an operation, if set.
+	// The ID is recorded with the operation.
+	OpIDHeader string
+
+	// HonorRetryAfter will delay requests retried after a 429 or 503 response
+	// until the wait advised by its Retry-After header has passed, up to MaxRetryAfter.
+	// The time waited is recorded with the operation.
+	HonorRetryAfter bool
+
+	// region is the last bucket region returned by the endpoint.
+	region atomic.Pointer[string]
+}
+
+// MaxRetryAfter is the longest wait advised by Retry-After that is honored.
+const MaxRetryAfter = time.Minute
+
+// RoundTrip implements http.RoundTripper.
+func (r *ResponseRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if r.RunIDHeader != "" {
+		req.Header.Set(r.RunIDHeader, r.RunID)
+	}
+	rec, ok := req.Context().Value(responseKey{}).(*response)
+	if !ok {
+		resp, err := r.RoundTripper.RoundTrip(req)
+		r.detectRegion(req, resp)
+		return resp, err
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), rec.trace(r.TracePhases)))
+	rec.mu.Lock()
+	var wait time.Duration
+	if !rec.retryAt.IsZero() {
+		if advised := time.Until(rec.retryAt); advised > 0 {
+			if r.HonorRetryAfter {
+				wait = min(advised, MaxRetryAfter)
+			}
+			if wait < advised {
+				rec.retryEarly++
+			}
+		}
+		rec.retryAt = time.Time{}
+	}
+	rec.addressing = r.Addressing
+	rec.endpoint = r.Endpoint
+	if r.OpIDHeader != "" {
+		if rec.opID == "" {
+			rec.opID = fmt.Sprintf("%016x", rand.Uint64())
+		}
+		req.Header.Set(r.OpIDHeader, rec.opID)
+	}
+	rec.mu.Unlock()
+	if wait > 0 {
+		start := time.Now()
+		t := time.NewTimer(wait)
+		select {
+		case <-t.C:
+		case <-req.Context().Done():
+			t.Stop()
+		}
+		rec.mu.Lock()
+		rec.throttledTime += time.Since(start)
+		rec.mu.Unlock()
+		if err := req.Context().Err(); err != nil {
+			return nil, err
+		}
+	}
+	if r.ExpectContinue && (req.Method == http.MethodPut || req.Method == http.MethodPost) && req.ContentLength > 0 {
+		req.Header.Set("Expect", "100-continue")
+	}
+	rec.wireSent.Add(requestHeadSize(req))
+	if req.Body != nil && req.Body != http.NoBody {
+		req.Body = countingBody{ReadCloser: req.Body, n: &rec.wireSent}
+	}
+	resp, err := r.RoundTripper.RoundTrip(req)
+	r.detectRegion(req, resp)
+	if resp != nil {
+		rec.mu.Lock()
+		rec.header = resp.Header
+		if region := r.region.Load(); region != nil {
+			rec.region = *region
+		}
+		rec.storageClass = req.Header.Get(storageClassHeader)
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+			rec.throttled++
+			if wait := retryAfter(resp.Header, time.Now()); wait > 0 {
+				rec.retryAfter += wait
+				rec.retryAt = time.Now().Add(wait)
+			}
+		}
+		rec.mu.Unlock()
+		rec.wireRecv.Add(responseHeadSize(resp))
+		if resp.Body != nil {
+			resp.Body = countingBody{ReadCloser: resp.Body, n: &rec.wireRecv}
+			if r.StallSpeed > 0 && r.StallDuration > 0 && req.Method == http.MethodGet {
+				m := &stallMonitor{speed: r.StallSpeed, window: r.StallDuration, winStart: time.Now()}
+				rec.mu.Lock()
+				if rec.stall != nil {
+					// Keep stalls of previous requests.
+					m.stalls, m.stalled = rec.stall.result(time.Now())
+				}
+				rec.stall = m
+				rec.mu.Unlock()
+				resp.Body = stallBody{ReadCloser: resp.Body, m: m}
+			}
+		}
+	}
+	return resp, err
+}
+
+// detectRegion stores the bucket region returned by the endpoint.
+// The region is read from the BucketRegionHeader of any response
+// and from the response of GetBucketLocation requests.
+func (r *ResponseRecorder) detectRegion(req *http.Request, resp *http.Response) {
+	if resp == nil {
+		return
+	}
+	if region := resp.Header.Get(BucketRegionHeader); region != "" {
+		r.region.Store(&region)
+		return
+	}
+	if req.Method != http.MethodGet || req.URL.RawQuery != "location" || resp.StatusCode != http.StatusOK || resp.Body == nil {
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	var location struct {
+		Region string `xml:",chardata"`
+	}
+	if xml.Unmarshal(body, &location) != nil {
+		return
+	}
+	region := strings.TrimSpace(location.Region)
+	if region == "" {
+		// Buckets in the default region have an empty location constraint.
+		region = "us-east-1"
+	}
+	r.region.Store(&region)
+}
+
+// retryAfter returns the wait advised by the Retry-After header, if any.
+// The header can contain the number of seconds to wait or a date.
+func retryAfter(h http.Header, now time.Time) time.Duration {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(max(secs, 0)) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0)
+	}
+	return 0
+}
+
+// countingBody adds the number of bytes read to n.
+type countingBody struct {
+	io.ReadCloser
+	n *atomic.Int64
+}
+
+func (c countingBody) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// stallBody reports reads to a stall monitor.
+type stallBody struct {
+	io.ReadCloser
+	m *stallMonitor
+}
+
+func (s stallBody) Read(p []byte) (int, error) {
+	n, err := s.ReadCloser.Read(p)
+	s.m.observe(n, time.Now())
+	return n, err
+}
+
+// stallMonitor measures the download speed in windows of a fixed duration.
+// Consecutive windows below the speed are counted as a single stall.
+// Windows are evaluated when a read returns, so a read that blocks is counted when it returns
+// or when the operation ends.
+type stallMonitor struct {
+	mu       sync.Mutex
+	speed    int64
+	window   time.Duration
+	winStart time.Time
+	winBytes int64
+	inStall  bool
+	stalls   int
+	stalled  time.Duration
+}
+
+func (m *stallMonitor) observe(n int, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.winBytes += int64(n)
+	if el := now.Sub(m.winStart); el >= m.window {
+		m.evaluate(el)
+		m.winStart, m.winBytes = now, 0
+	}
+}
+
+// evaluate the current window with the elapsed time.
+func (m *stallMonitor) evaluate(el time.Duration) {
+	if float64(m.winBytes)/el.Seconds() >= float64(m.speed) {
+		m.inStall = false
+		return
+	}
+	if !m.inStall {
+		m.stalls++
+		m.inStall = true
+	}
+	m.stalled += el
+}
+
+// result returns the stalls until end.
+func (m *stallMonitor) result(end time.Time) (stalls int, stalled time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if el := end.Sub(m.winStart); el >= m.window {
+		m.evaluate(el)
+		m.winStart, m.winBytes = end, 0
+	}
+	return m.stalls, m.stalled
+}
+
+// byteCounter counts the bytes written to it.
+type byteCounter int64
+
+func (b *byteCounter) Write(p []byte) (int, error) {
+	*b += byteCounter(len(p))
+	return len(p), nil
+}
+
+// requestHeadSize returns the size of the request line and headers as sent with HTTP/1.1.
+func requestHeadSize(req *http.Request) int64 {
+	var n byteCounter
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	fmt.Fprintf(&n, "%s %s HTTP/1.1\r\nHost: %s\r\n", req.Method, req.URL.RequestURI(), host)
+	if req.ContentLength > 0 {
+		fmt.Fprintf(&n, "Content-Length: %d\r\n", req.ContentLength)
+	}
+	req.Header.Write(&n)
+	return int64(n) + 2
+}
+
+// responseHeadSize returns the size of the status line and headers as received with HTTP/1.1.
+func responseHeadSize(resp *http.Response) int64 {
+	var n byteCounter
+	fmt.Fprintf(&n, "HTTP/1.1 %s\r\n", resp.Status)
+	resp.Header.Write(&n)
+	return int64(n) + 2
+}
+
+// recordResponse returns a context that will record the response for an operation.
+// Call apply on the returned value when the operation has completed.
+func recordResponse(ctx context.Context) (context.Context, *response) {
+	rec := &response{}
+	return context.WithValue(ctx, responseKey{}, rec), rec
+}
+
+// trace returns a client trace recording the connection used by a new request.
+// If phases is set the times of each phase are recorded as well.
+// Values of any previous request are cleared.
+func (r *response) trace(phases bool) *httptrace.ClientTrace {
+	r.mu.Lock()
+	r.traced = phases
+	r.times = traceTimes{}
+	r.gotConn, r.reused, r.family = false, false, ""
+	r.mu.Unlock()
+	gotConn := func(info httptrace.GotConnInfo) {
+		var family string
+		if info.Conn != nil {
+			family = ipFamily(info.Conn.RemoteAddr())
+		}
+		r.mu.Lock()
+		r.gotConn, r.reused, r.family = true, info.Reused, family
+		r.mu.Unlock()
+	}
+	if !phases {
+		return &httptrace.ClientTrace{GotConn: gotConn}
+	}
+	set := func(t *time.Time) {
+		r.mu.Lock()
+		*t = time.Now()
+		r.mu.Unlock()
+	}
+	return &httptrace.ClientTrace{
+		DNSStart:          func(httptrace.DNSStartInfo) { set(&r.times.dnsStart) },
+		DNSDone:           func(httptrace.DNSDoneInfo) { set(&r.times.dnsDone) },
+		ConnectStart:      func(string, string) { set(&r.times.connStart) },
+		ConnectDone:       func(string, string, error) { set(&r.times.connDone) },
+		TLSHandshakeStart: func() { set(&r.times.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { set(&r.times.tlsDone) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			set(&r.times.gotConn)
+			gotConn(info)
+		},
+		WroteHeaders:         func() { set(&r.times.wroteHeaders) },
+		Got100Continue:       func() { set(&r.times.got100) },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { set(&r.times.wroteRequest) },
+		GotFirstResponseByte: func() { set(&r.times.firstByte) },
+	}
+}
+
+// ipFamily returns the address family of a connection address, or an empty string if unknown.
+func ipFamily(addr net.Addr) string {
+	var ip net.IP
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		ip = a.IP
+	case nil:
+		return ""
+	default:
+		host, _, err := net.SplitHostPort(addr.String())
+		if err != nil {
+			return ""
+		}
+		ip = net.ParseIP(host)
+	}
+	switch {
+	case ip == nil:
+		return ""
+	case ip.To4() != nil:
+		return IPv4
+	}
+	return IPv6
+}
+
+// phases returns the phases of the traced request.
+// end is the time the response body was fully read.
+func (r *response) phases(end time.Time) *Phases {
+	if !r.traced {
+		return nil
+	}
+	since := func(from, to time.Time) time.Duration {
+		if from.IsZero() || to.IsZero() || to.Before(from) {
+			return 0
+		}
+		return to.Sub(from)
+	}
+	t := r.times
+	cont := since(t.wroteHeaders, t.got100)
+	return &Phases{
+		DNS:      since(t.dnsStart, t.dnsDone),
+		Connect:  since(t.connStart, t.connDone),
+		TLS:      since(t.tlsStart, t.tlsDone),
+		Send:     max(since(t.gotConn, t.wroteRequest)-cont, 0),
+		TTFB:     since(t.wroteRequest, t.firstByte),
+		Transfer: since(t.firstByte, end),
+		Continue: cont,
+	}
+}
+
+// storageClassHeader contains the storage class of an object.
+const storageClassHeader = "X-Amz-Storage-Class"
+
+// apply the request ID and selected headers of the recorded response to op.
+// If the request was traced, the phases are recorded as well.
+// Uploads do not return the storage class, so the requested storage class is recorded if selected.
+func (r *response) apply(op *Operation, headers []string) {
+	r.mu.Lock()
+	h := r.header
+	op.Phases = r.phases(op.End)
+	if r.gotConn {
+		reused := r.reused
+		op.ConnReused = &reused
+		op.IPFamily = r.family
+	}
+	op.Addressing = r.addressing
+	op.Region = r.region
+	if r.endpoint != "" {
+		op.Endpoint = r.endpoint
+	}
+	op.WireSent, op.WireRecv = r.wireSent.Load(), r.wireRecv.Load()
+	op.OpID = r.opID
+	op.Throttled, op.RetryAfter, op.RetryEarly = r.throttled, r.retryAfter, r.retryEarly
+	op.ThrottledTime = r.throttledTime
+	if r.stall != nil {
+		op.Stalls, op.StallTime = r.stall.result(op.End)
+	}
+	storageClass := r.storageClass
+	r.mu.Unlock()
+	if h == nil {
+		return
+	}
+	op.RequestID = h.Get(RequestIDHeader)
+	for _, k := range headers {
+		v := h.Get(k)
+		if v == "" && k == storageClassHeader {
+			v = storageClass
+		}
+		if v == "" {
+			continue
+		}
+		if op.Headers == nil {
+			op.Headers = make(map[string]string, len(headers))
+		}
+		op.Headers[strings.ToLower(k)] = v
+	}
+}
@@ -59,9 +59,9 @@ func (g *Retention) Prepare(ctx context.Context) error {
 	console.Eraseline()
 	console.Info("\rUploading ", g.CreateObjects, " objects with ", g.Versions, " versions each of ", src.String())
 	var wg sync.WaitGroup
-	wg.Add(g.Concurrency)
+	wg.Add(g.prepareThreads())
 	g.addCollector()
-	objs := splitObjs(g.CreateObjects, g.Concurrency)
+	objs := splitObjs(g.CreateObjects, g.prepareThreads())
 	var groupErr error
 	var mu sync.Mutex
 
@@ -162,7 +162,7 @@ func (g *Retention) Start(ctx context.Context, wait chan struct{}) (Operations,
 			done := ctx.Done()
 			var opts minio.PutObjectRetentionOptions
 
-			<-wait
+			g.startWait(wait)
 			mode := minio.Governance
 			for {
 				select {
@@ -186,22 +186,23 @@ func (g *Retention) Start(ctx context.Context, wait chan struct{}) (Operations,
 					Endpoint: client.EndpointURL().String(),
 				}
 
+				opCtx, resp := recordResponse(nonTerm)
 				op.Start = time.Now()
 				opts.VersionID = obj.VersionID
 				t := op.Start.Add(24 * time.Hour)
 				opts.RetainUntilDate = &t
 				opts.Mode = &mode
 				opts.GovernanceBypass = true
-				err := client.PutObjectRetention(nonTerm, g.Bucket, obj.Name, opts)
+				err := client.PutObjectRetention(opCtx, g.Bucket, obj.Name, opts)
+				op.End = time.Now()
+				resp.apply(&op, g.RecordHeaders)
 				if err != nil {
 					g.Error("put retention error:", err)
 					op.Err = err.Error()
-					op.End = time.Now()
 					rcv <- op
 					cldone()
 					continue
 				}
-				op.End = time.Now()
 				rcv <- op
 				cldone()
 			}
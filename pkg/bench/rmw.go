@@ -0,0 +1,282 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/pkg/v2/console"
+	"github.com/minio/warp/pkg/generator"
+)
+
+// ReadModifyWrite benchmarks overwriting objects.
+// Each iteration downloads an object and uploads new content
+// of the same size under the same key.
+// All threads operate on the same set of objects.
+type ReadModifyWrite struct {
+	Common
+	objects generator.Objects
+
+	GetOpts       minio.GetObjectOptions
+	CreateObjects int
+
+	// IfMatch will upload with the ETag of the downloaded object as precondition.
+	// Uploads fail if another thread has modified the object in the meantime.
+	IfMatch bool
+
+	// EnableVersioning will enable versioning on the bucket.
+	EnableVersioning bool
+
+	puts, conflicts atomic.Int64
+}
+
+// Prepare will create an empty bucket or delete any content already there
+// and upload a number of objects.
+func (g *ReadModifyWrite) Prepare(ctx context.Context) error {
+	if err := g.createEmptyBucket(ctx); err != nil {
+		return err
+	}
+	if g.EnableVersioning && !g.Versioned {
+		cl, done := g.Client()
+		err := cl.EnableVersioning(ctx, g.Bucket)
+		done()
+		if err != nil {
+			return err
+		}
+		g.Versioned = true
+	}
+	src := g.Source()
+	console.Eraseline()
+	console.Info("\rUploading ", g.CreateObjects, " objects of ", src.String())
+	var wg sync.WaitGroup
+	wg.Add(g.prepareThreads())
+	g.addCollector()
+	objs := splitObjs(g.CreateObjects, g.prepareThreads())
+	var mu sync.Mutex
+	var groupErr error
+	for i, obj := range objs {
+		go func(i int, obj []struct{}) {
+			defer wg.Done()
+			src := g.Source()
+			opts := g.PutOpts
+			rcv := g.Collector.Receiver()
+			done := ctx.Done()
+
+			for range obj {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				if g.rpsLimit(ctx) != nil {
+					return
+				}
+
+				obj := src.Object()
+				client, cldone := g.Client()
+				op := Operation{
+					OpType:   http.MethodPut,
+					Thread:   uint16(i),
+					Size:     obj.Size,
+					File:     obj.Name,
+					ObjPerOp: 1,
+					Endpoint: client.EndpointURL().String(),
+				}
+
+				opts.ContentType = obj.ContentType
+				op.Start = time.Now()
+				res, err := client.PutObject(ctx, g.Bucket, obj.Name, obj.Reader, obj.Size, opts)
+				op.End = time.Now()
+				cldone()
+				if err == nil && res.Size != obj.Size {
+					err = fmt.Errorf("short upload. want: %d, got %d", obj.Size, res.Size)
+				}
+				if err != nil {
+					err := fmt.Errorf("upload error: %w", err)
+					g.Error(err)
+					mu.Lock()
+					if groupErr == nil {
+						groupErr = err
+					}
+					mu.Unlock()
+					return
+				}
+				mu.Lock()
+				obj.Reader = nil
+				g.objects = append(g.objects, *obj)
+				g.prepareProgress(float64(len(g.objects)) / float64(g.CreateObjects))
+				mu.Unlock()
+				rcv <- op
+			}
+		}(i, obj)
+	}
+	wg.Wait()
+	return groupErr
+}
+
+// Start will execute the main benchmark.
+// Operations should begin executing when the start channel is closed.
+func (g *ReadModifyWrite) Start(ctx context.Context, wait chan struct{}) (Operations, error) {
+	var wg sync.WaitGroup
+	wg.Add(g.Concurrency)
+	c := g.Collector
+	if g.AutoTermDur > 0 {
+		ctx = c.AutoTerm(ctx, http.MethodPut, g.AutoTermScale, autoTermCheck, autoTermSamples, g.AutoTermDur)
+	}
+	// Non-terminating context.
+	nonTerm := context.Background()
+
+	for i := 0; i < g.Concurrency; i++ {
+		go func(i int) {
+			rng := rand.New(rand.NewSource(int64(i)))
+			rcv := c.Receiver()
+			defer wg.Done()
+			done := ctx.Done()
+			src := g.Source()
+			getOpts := g.GetOpts
+
+			g.startWait(wait)
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				if g.rpsLimit(ctx) != nil {
+					return
+				}
+
+				obj := g.objects[rng.Intn(len(g.objects))]
+				client, cldone := g.Client()
+				op := Operation{
+					OpType:   http.MethodGet,
+					Thread:   uint16(i),
+					Size:     obj.Size,
+					File:     obj.Name,
+					ObjPerOp: 1,
+					Endpoint: client.EndpointURL().String(),
+				}
+				if g.DiscardOutput {
+					op.File = ""
+				}
+
+				// Read
+				fbr := firstByteRecorder{}
+				op.Start = time.Now()
+				opCtx, resp := recordResponse(nonTerm)
+				o, err := client.GetObject(opCtx, g.Bucket, obj.Name, getOpts)
+				var etag string
+				if err == nil {
+					var info minio.ObjectInfo
+					info, err = o.Stat()
+					etag = info.ETag
+				}
+				if err != nil {
+					g.Error("download error:", err)
+					op.Err = err.Error()
+					op.End = time.Now()
+					resp.apply(&op, g.RecordHeaders)
+					rcv <- op
+					cldone()
+					if o != nil {
+						o.Close()
+					}
+					continue
+				}
+				fbr.r = o
+				n, err := io.Copy(io.Discard, &fbr)
+				if err != nil {
+					g.Error("download error:", err)
+					op.Err = err.Error()
+				}
+				op.FirstByte = fbr.t
+				op.End = time.Now()
+				o.Close()
+				resp.apply(&op, g.RecordHeaders)
+				if n != op.Size && op.Err == "" {
+					op.Err = fmt.Sprint("unexpected download size. want:", op.Size, ", got:", n)
+					g.Error(op.Err)
+				}
+				rcv <- op
+				if op.Err != "" {
+					cldone()
+					continue
+				}
+
+				// Modify and write back.
+				next := src.Object()
+				putOpts := g.PutOpts
+				putOpts.ContentType = next.ContentType
+				if g.IfMatch {
+					putOpts.SetMatchETag(etag)
+				}
+				op = Operation{
+					OpType:   http.MethodPut,
+					Thread:   uint16(i),
+					Size:     next.Size,
+					File:     op.File,
+					ObjPerOp: 1,
+					Endpoint: op.Endpoint,
+				}
+				opCtx, resp = recordResponse(nonTerm)
+				op.Start = time.Now()
+				res, err := client.PutObject(opCtx, g.Bucket, obj.Name, next.Reader, next.Size, putOpts)
+				op.End = time.Now()
+				resp.apply(&op, g.RecordHeaders)
+				cldone()
+				g.puts.Add(1)
+				if err != nil {
+					if g.IfMatch && minio.ToErrorResponse(err).StatusCode == http.StatusPreconditionFailed {
+						g.conflicts.Add(1)
+						op.Err = "conflict: " + err.Error()
+					} else {
+						g.Error("upload error:", err)
+						op.Err = err.Error()
+					}
+				} else if res.Size != next.Size {
+					op.Err = fmt.Sprint("short upload. want:", next.Size, ", got:", res.Size)
+					g.Error(op.Err)
+				}
+				rcv <- op
+			}
+		}(i)
+	}
+	wg.Wait()
+	if g.IfMatch {
+		if puts := g.puts.Load(); puts > 0 {
+			console.Eraseline()
+			console.Infof("\rConditional write conflicts: %d of %d uploads (%.02f%%)\n", g.conflicts.Load(), puts, 100*float64(g.conflicts.Load())/float64(puts))
+		}
+	}
+	return c.Close(), nil
+}
+
+// Cleanup deletes everything uploaded to the bucket.
+func (g *ReadModifyWrite) Cleanup(ctx context.Context) {
+	g.deleteAllInBucket(ctx, g.objects.Prefixes()...)
+}
@@ -0,0 +1,305 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/warp/pkg/generator"
+	"golang.org/x/time/rate"
+)
+
+// AfterPreparer is implemented by benchmarks that must
+// do additional work after all clients have prepared.
+type AfterPreparer interface {
+	AfterPrepare(ctx context.Context) error
+}
+
+// Default values used by Run when not specified.
+const (
+	DefaultBucket      = "warp-benchmark-bucket"
+	DefaultConcurrency = 20
+	DefaultDuration    = 5 * time.Minute
+)
+
+// Option configures a benchmark run. See Run.
+type Option func(r *runOptions)
+
+type runOptions struct {
+	c            *Common
+	duration     time.Duration
+	startDelay   time.Duration
+	keepData     bool
+	removeBucket bool
+	tags         Tags
+}
+
+// WithClient sets the client used for all requests.
+func WithClient(cl *minio.Client) Option {
+	return func(r *runOptions) {
+		r.c.Client = func() (*minio.Client, func()) {
+			return cl, func() {}
+		}
+	}
+}
+
+// WithClients sets a function that returns a client for each request.
+// done is called when the request has completed.
+// This can be used to distribute requests between several hosts.
+func WithClients(fn func() (cl *minio.Client, done func())) Option {
+	return func(r *runOptions) {
+		r.c.Client = fn
+	}
+}
+
+// WithSource sets the generator of uploaded objects.
+func WithSource(src func() generator.Source) Option {
+	return func(r *runOptions) {
+		r.c.Source = src
+	}
+}
+
+// WithBucket sets the bucket used for the benchmark.
+func WithBucket(bucket string) Option {
+	return func(r *runOptions) {
+		r.c.Bucket = bucket
+	}
+}
+
+// WithRegion sets the region used when creating the bucket.
+func WithRegion(region string) Option {
+	return func(r *runOptions) {
+		r.c.Location = region
+	}
+}
+
+// WithConcurrency sets the number of concurrent operations.
+func WithConcurrency(n int) Option {
+	return func(r *runOptions) {
+		r.c.Concurrency = n
+	}
+}
+
+// WithDuration sets the duration of the benchmark.
+func WithDuration(d time.Duration) Option {
+	return func(r *runOptions) {
+		r.duration = d
+	}
+}
+
+// WithStartDelay will wait the specified time after preparing before starting the benchmark.
+func WithStartDelay(d time.Duration) Option {
+	return func(r *runOptions) {
+		r.startDelay = d
+	}
+}
+
+// WithAutoTerm will terminate the benchmark when the speed has been
+// stable within the scale for the duration.
+func WithAutoTerm(dur time.Duration, scale float64) Option {
+	return func(r *runOptions) {
+		r.c.AutoTermDur = dur
+		r.c.AutoTermScale = scale
+	}
+}
+
+// WithRPSLimit limits the number of requests per second across all threads.
+func WithRPSLimit(rps float64) Option {
+	return func(r *runOptions) {
+		r.c.RpsLimiter = nil
+		if rps > 0 {
+			r.c.RpsLimiter = rate.NewLimiter(rate.Limit(rps), 1)
+		}
+	}
+}
+
+// WithClear will delete any existing objects in the bucket before preparing.
+func WithClear(clear bool) Option {
+	return func(r *runOptions) {
+		r.c.Clear = clear
+	}
+}
+
+// WithKeepData will skip cleaning up objects after the benchmark.
+func WithKeepData(keep bool) Option {
+	return func(r *runOptions) {
+		r.keepData = keep
+	}
+}
+
+// WithRemoveBucket will remove the bucket after the benchmark has been cleaned up.
+func WithRemoveBucket(remove bool) Option {
+	return func(r *runOptions) {
+		r.removeBucket = remove
+	}
+}
+
+// WithErrorHandler sets a function that receives errors as they happen.
+// Errors are recorded on the operations regardless.
+func WithErrorHandler(fn func(data ...interface{})) Option {
+	return func(r *runOptions) {
+		r.c.Error = fn
+	}
+}
+
+// WithPrepareProgress will send preparation progress between 0 and 1 to ch.
+// Updates are dropped if ch is full. The channel is not closed.
+func WithPrepareProgress(ch chan float64) Option {
+	return func(r *runOptions) {
+		r.c.PrepareProgress = ch
+	}
+}
+
+// WithOutput will send every operation to ch as it completes.
+func WithOutput(ch chan<- Operation) Option {
+	return func(r *runOptions) {
+		r.c.ExtraOut = append(r.c.ExtraOut, ch)
+	}
+}
+
+// WithTags adds tags to the results.
+func WithTags(tags Tags) Option {
+	return func(r *runOptions) {
+		r.tags = r.tags.Merge(tags)
+	}
+}
+
+// Results contains the operations of a benchmark run.
+type Results struct {
+	Ops  Operations
+	Tags Tags
+}
+
+// Run will prepare, run and clean up a benchmark.
+// Benchmark specific parameters are set on b before calling.
+// Common parameters are set using opts, or can be set on b directly.
+// A client must be provided, and unless the benchmark does not upload objects, a source.
+//
+// Preparation is aborted if ctx is canceled.
+// If ctx is canceled while the benchmark is running, the benchmark
+// is stopped and the results collected so far are returned.
+func Run(ctx context.Context, b Benchmark, opts ...Option) (*Results, error) {
+	c := b.GetCommon()
+	ro := runOptions{c: c, duration: DefaultDuration}
+	for _, opt := range opts {
+		opt(&ro)
+	}
+	if c.Client == nil {
+		return nil, errors.New("no client specified")
+	}
+	if ro.duration <= 0 {
+		return nil, errors.New("duration must be positive")
+	}
+	if c.Concurrency <= 0 {
+		c.Concurrency = DefaultConcurrency
+	}
+	if c.Bucket == "" {
+		c.Bucket = DefaultBucket
+	}
+	if c.Error == nil {
+		c.Error = func(data ...interface{}) {}
+	}
+
+	if err := b.Prepare(ctx); err != nil {
+		return nil, fmt.Errorf("prepare: %w", err)
+	}
+	if ap, ok := b.(AfterPreparer); ok {
+		if err := ap.AfterPrepare(ctx); err != nil {
+			return nil, fmt.Errorf("prepare: %w", err)
+		}
+	}
+
+	tStart := time.Now().Add(ro.startDelay)
+	runCtx, cancel := context.WithDeadline(ctx, tStart.Add(ro.duration))
+	start := make(chan struct{})
+	go func() {
+		select {
+		case <-time.After(time.Until(tStart)):
+		case <-runCtx.Done():
+		}
+		close(start)
+	}()
+	ops, err := b.Start(runCtx, start)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	ops.SortByStartTime()
+
+	if !ro.keepData {
+		cleanCtx := context.WithoutCancel(ctx)
+		b.Cleanup(cleanCtx)
+		if ro.removeBucket {
+			if err := c.RemoveBucket(cleanCtx); err != nil {
+				return &Results{Ops: ops, Tags: ro.tags}, fmt.Errorf("remove bucket: %w", err)
+			}
+		}
+	}
+	return &Results{Ops: ops, Tags: ro.tags}, nil
+}
+
+// OpTypes returns the operation types in the results, sorted by name.
+func (r *Results) OpTypes() []string {
+	types := r.Ops.OpTypes()
+	sort.Strings(types)
+	return types
+}
+
+// ByOpType returns the operations of the specified type.
+func (r *Results) ByOpType(opType string) Operations {
+	return r.Ops.FilterByOp(opType)
+}
+
+// Total returns the totals for successful operations of the specified type.
+// If opType is empty all operations are included.
+func (r *Results) Total(opType string) Segment {
+	ops := r.Ops
+	if opType != "" {
+		ops = ops.FilterByOp(opType)
+	}
+	return ops.FilterSuccessful().Total(!ops.IsMixed())
+}
+
+// Segments returns successful operations of the specified type split into segments of the duration.
+// Segments are sorted by time.
+func (r *Results) Segments(opType string, segDur time.Duration) Segments {
+	ops := r.Ops.FilterByOp(opType).FilterSuccessful()
+	segs := ops.Segment(SegmentOptions{PerSegDuration: segDur, AllThreads: true})
+	segs.SortByTime()
+	return segs
+}
+
+// Errors returns all errors recorded on operations.
+func (r *Results) Errors() []string {
+	return r.Ops.Errors()
+}
+
+// CSV writes the tags and operations in the format used by the warp command.
+// The output can be analyzed using 'warp analyze'.
+func (r *Results) CSV(w io.Writer, comment string) error {
+	if err := r.Tags.CSV(w); err != nil {
+		return err
+	}
+	return r.Ops.CSV(w, comment)
+}
@@ -144,7 +144,7 @@ func (g *S3Zip) Start(ctx context.Context, wait chan struct{}) (Operations, erro
 			done := ctx.Done()
 			var opts minio.GetObjectOptions
 
-			<-wait
+			g.startWait(wait)
 			for {
 				select {
 				case <-done:
@@ -168,14 +168,16 @@ func (g *S3Zip) Start(ctx context.Context, wait chan struct{}) (Operations, erro
 					Endpoint: client.EndpointURL().String(),
 				}
 
+				opCtx, resp := recordResponse(nonTerm)
 				op.Start = time.Now()
 				opts.Set("x-minio-extract", "true")
 
-				o, err := client.GetObject(nonTerm, g.Bucket, op.File, opts)
+				o, err := client.GetObject(opCtx, g.Bucket, op.File, opts)
 				if err != nil {
 					g.Error("download error:", err)
 					op.Err = err.Error()
 					op.End = time.Now()
+					resp.apply(&op, g.RecordHeaders)
 					rcv <- op
 					cldone()
 					continue
@@ -188,6 +190,7 @@ func (g *S3Zip) Start(ctx context.Context, wait chan struct{}) (Operations, erro
 				}
 				op.FirstByte = fbr.t
 				op.End = time.Now()
+				resp.apply(&op, g.RecordHeaders)
 				if n != op.Size && op.Err == "" {
 					op.Err = fmt.Sprint("unexpected download size. want:", op.Size, ", got:", n)
 					g.Error(op.Err)
@@ -52,9 +52,9 @@ func (g *Select) Prepare(ctx context.Context) error {
 	console.Eraseline()
 	console.Info("\rUploading ", g.CreateObjects, " objects of ", src.String())
 	var wg sync.WaitGroup
-	wg.Add(g.Concurrency)
+	wg.Add(g.prepareThreads())
 	g.addCollector()
-	objs := splitObjs(g.CreateObjects, g.Concurrency)
+	objs := splitObjs(g.CreateObjects, g.prepareThreads())
 
 	var groupErr error
 	var mu sync.Mutex
@@ -148,7 +148,7 @@ func (g *Select) Start(ctx context.Context, wait chan struct{}) (Operations, err
 			opts := g.SelectOpts
 			done := ctx.Done()
 
-			<-wait
+			g.startWait(wait)
 			for {
 				select {
 				case <-done:
@@ -172,14 +172,16 @@ func (g *Select) Start(ctx context.Context, wait chan struct{}) (Operations, err
 					Endpoint: client.EndpointURL().String(),
 				}
 
+				opCtx, resp := recordResponse(nonTerm)
 				op.Start = time.Now()
 				var err error
-				o, err := client.SelectObjectContent(nonTerm, g.Bucket, obj.Name, opts)
+				o, err := client.SelectObjectContent(opCtx, g.Bucket, obj.Name, opts)
 				fbr.r = o
 				if err != nil {
 					g.Error("download error: ", err)
 					op.Err = err.Error()
 					op.End = time.Now()
+					resp.apply(&op, g.RecordHeaders)
 					rcv <- op
 					cldone()
 					continue
@@ -191,6 +193,7 @@ func (g *Select) Start(ctx context.Context, wait chan struct{}) (Operations, err
 				}
 				op.FirstByte = fbr.t
 				op.End = time.Now()
+				resp.apply(&op, g.RecordHeaders)
 				rcv <- op
 				cldone()
 				o.Close()
@@ -0,0 +1,126 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc64"
+	"io"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// ShadowGetOp is the operation type of a download mirrored to the shadow endpoint.
+// Divergence from the primary download is recorded as an error.
+const ShadowGetOp = "SHADOW-GET"
+
+// Shadow mirrors downloads to a secondary endpoint and compares
+// the ETag, size and content with the primary download.
+type Shadow struct {
+	// Client returns a client for the shadow endpoint.
+	Client func() (cl *minio.Client, done func())
+	// Bucket on the shadow endpoint. Uses the benchmark bucket if empty.
+	Bucket string
+	// SkipETag will not compare ETags,
+	// for example when objects were copied using different part sizes.
+	SkipETag bool
+}
+
+var crc64Table = crc64.MakeTable(crc64.ECMA)
+
+// shadowReader returns a reader that hashes the content read from r,
+// if downloads are shadowed.
+func (c *Common) shadowReader(r io.Reader) (io.Reader, hash.Hash64) {
+	if c.Shadow == nil {
+		return r, nil
+	}
+	h := crc64.New(crc64Table)
+	return io.TeeReader(r, h), h
+}
+
+// shadowGet downloads the object of a successful primary download from the shadow endpoint
+// and sends an operation with any divergence from the primary as error.
+// src must be the fully read primary object and h the hash returned by shadowReader.
+func (c *Common) shadowGet(ctx context.Context, rcv chan<- Operation, primary Operation, object string, opts minio.GetObjectOptions, src *minio.Object, h hash.Hash64) {
+	if c.Shadow == nil || h == nil || primary.Err != "" {
+		return
+	}
+	want, err := src.Stat()
+	if err != nil {
+		return
+	}
+	client, done := c.Shadow.Client()
+	defer done()
+	bucket := c.Shadow.Bucket
+	if bucket == "" {
+		bucket = c.Bucket
+	}
+	op := Operation{
+		OpType:   ShadowGetOp,
+		Thread:   primary.Thread,
+		Size:     primary.Size,
+		File:     primary.File,
+		ObjPerOp: 1,
+		Endpoint: client.EndpointURL().String(),
+	}
+	// Versions are not expected to match between endpoints.
+	opts.VersionID = ""
+	fbr := firstByteRecorder{}
+	sh := crc64.New(crc64Table)
+	opCtx, resp := recordResponse(ctx)
+	op.Start = time.Now()
+	err = func() error {
+		o, err := client.GetObject(opCtx, bucket, object, opts)
+		if err != nil {
+			return err
+		}
+		defer o.Close()
+		fbr.r = o
+		n, err := io.Copy(sh, &fbr)
+		if err != nil {
+			return err
+		}
+		op.FirstByte = fbr.t
+		if n != primary.Size {
+			return fmt.Errorf("size mismatch. primary: %d, shadow: %d", primary.Size, n)
+		}
+		st, err := o.Stat()
+		if err != nil {
+			return err
+		}
+		if !c.Shadow.SkipETag && st.ETag != want.ETag {
+			return fmt.Errorf("etag mismatch. primary: %q, shadow: %q", want.ETag, st.ETag)
+		}
+		if !bytes.Equal(h.Sum(nil), sh.Sum(nil)) {
+			return errors.New("content mismatch")
+		}
+		return nil
+	}()
+	op.End = time.Now()
+	resp.apply(&op, c.RecordHeaders)
+	if err != nil {
+		c.Error("shadow download error: ", err)
+		op.Err = err.Error()
+	}
+	rcv <- op
+}
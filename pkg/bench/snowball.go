@@ -87,7 +87,7 @@ func (s *Snowball) Start(ctx context.Context, wait chan struct{}) (Operations, e
 			opts.UserMetadata = map[string]string{"X-Amz-Meta-Snowball-Auto-Extract": "true"}
 			done := ctx.Done()
 
-			<-wait
+			s.startWait(wait)
 			for {
 				select {
 				case <-done:
@@ -162,11 +162,13 @@ func (s *Snowball) Start(ctx context.Context, wait chan struct{}) (Operations, e
 
 				client, cldone := s.Client()
 				op.Endpoint = client.EndpointURL().String()
+				opCtx, resp := recordResponse(nonTerm)
 				op.Start = time.Now()
 				tarLength := int64(buf.Len())
 				// fmt.Println(op.Size, "->", tarLength, math.Round(100*float64(tarLength)/float64(op.Size)), "%")
-				res, err := client.PutObject(nonTerm, s.Bucket, obj.Name+".tar", &buf, tarLength, opts)
+				res, err := client.PutObject(opCtx, s.Bucket, obj.Name+".tar", &buf, tarLength, opts)
 				op.End = time.Now()
+				resp.apply(&op, s.RecordHeaders)
 				if err != nil {
 					s.Error("upload error: ", err)
 					op.Err = err.Error()
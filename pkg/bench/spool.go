@@ -0,0 +1,270 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"bufio"
+	"container/heap"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// spoolSegment is the number of operations of a file kept in memory
+// before they are written to the file.
+const spoolSegment = 1000
+
+// opSpool writes operations to files in a directory.
+// Each writer has its own file, so writers do not share a lock.
+type opSpool struct {
+	dir string
+
+	// mu protects files.
+	mu    sync.Mutex
+	files []*spoolFile
+	err   error
+}
+
+// spoolFile writes operations to a file.
+// It must only be used by one goroutine at a time.
+type spoolFile struct {
+	name    string
+	f       *os.File
+	bw      *bufio.Writer
+	pending []Operation
+	written int
+	err     error
+
+	// last is the latest start time added.
+	last time.Time
+	// unsorted is set if operations were not added in start time order.
+	unsorted bool
+}
+
+// newOpSpool creates a new directory for operation files inside dir.
+func newOpSpool(dir string) (*opSpool, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	d, err := os.MkdirTemp(dir, "warp-ops-")
+	if err != nil {
+		return nil, err
+	}
+	return &opSpool{dir: d}, nil
+}
+
+// newFile creates the file of a new writer.
+// Errors are kept and returned when closing.
+func (s *opSpool) newFile() *spoolFile {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := &spoolFile{
+		name:    filepath.Join(s.dir, fmt.Sprintf("ops-%05d.csv", len(s.files))),
+		pending: make([]Operation, 0, spoolSegment),
+	}
+	s.files = append(s.files, t)
+	t.f, t.err = os.Create(t.name)
+	if t.err == nil {
+		t.bw = bufio.NewWriter(t.f)
+		_, t.err = t.bw.WriteString(csvVersion + csvHeader)
+	}
+	return t
+}
+
+// add an operation. Errors are kept and returned when closing.
+func (t *spoolFile) add(op Operation) {
+	if t.err != nil {
+		return
+	}
+	if op.Start.Before(t.last) {
+		t.unsorted = true
+	} else {
+		t.last = op.Start
+	}
+	t.pending = append(t.pending, op)
+	if len(t.pending) >= spoolSegment {
+		t.err = t.flush()
+	}
+}
+
+// flush writes pending operations to the file.
+// Written data is flushed, so it can be read if the process stops.
+func (t *spoolFile) flush() error {
+	for _, op := range t.pending {
+		if err := op.writeCSV(t.bw, t.written); err != nil {
+			return err
+		}
+		t.written++
+	}
+	t.pending = t.pending[:0]
+	return t.bw.Flush()
+}
+
+// sort rewrites the file with operations sorted by start time.
+func (t *spoolFile) sort() error {
+	f, err := os.Open(t.name)
+	if err != nil {
+		return err
+	}
+	ops, err := OperationsFromCSV(f, false, 0, 0, nil)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	ops.SortByStartTime()
+	f, err = os.Create(t.name)
+	if err != nil {
+		return err
+	}
+	err = ops.CSV(f, "")
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// close writes all pending operations and closes the files.
+// Files with operations added out of start time order are sorted.
+// If an error is returned the files are kept.
+func (s *opSpool) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range s.files {
+		if t.err == nil {
+			t.err = t.flush()
+		}
+		if t.f != nil {
+			if err := t.f.Close(); t.err == nil {
+				t.err = err
+			}
+		}
+		if t.err == nil && t.unsorted {
+			t.err = t.sort()
+		}
+		if t.err != nil && s.err == nil {
+			s.err = fmt.Errorf("writing operations to %s: %w", t.name, t.err)
+		}
+	}
+	return s.err
+}
+
+// spoolCursor is the next operation of a sorted source.
+type spoolCursor struct {
+	op   Operation
+	next func() (Operation, error)
+}
+
+type spoolHeap []*spoolCursor
+
+func (h spoolHeap) Len() int           { return len(h) }
+func (h spoolHeap) Less(i, j int) bool { return h[i].op.Start.Before(h[j].op.Start) }
+func (h spoolHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *spoolHeap) Push(x any)        { *h = append(*h, x.(*spoolCursor)) }
+func (h *spoolHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// writeCSV merges the closed files and extra by start time and writes them to w as CSV.
+// Only one operation of each file is kept in memory.
+// fn is called with each operation before it is written, if set.
+// The comment, if any, is written at the end, like Operations.CSV.
+// The directory is removed when all operations have been written.
+func (s *opSpool) writeCSV(w io.Writer, extra Operations, comment string, fn func(op *Operation)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	h := make(spoolHeap, 0, len(s.files)+1)
+	add := func(next func() (Operation, error)) error {
+		op, err := next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		h = append(h, &spoolCursor{op: op, next: next})
+		return nil
+	}
+	for _, t := range s.files {
+		f, err := os.Open(t.name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r, err := newCSVOpReader(f, false)
+		if err != nil {
+			return fmt.Errorf("reading operations from %s: %w", t.name, err)
+		}
+		if err := add(r.next); err != nil {
+			return fmt.Errorf("reading operations from %s: %w", t.name, err)
+		}
+	}
+	extra = append(Operations(nil), extra...)
+	extra.SortByStartTime()
+	if err := add(func() (Operation, error) {
+		if len(extra) == 0 {
+			return Operation{}, io.EOF
+		}
+		op := extra[0]
+		extra = extra[1:]
+		return op, nil
+	}); err != nil {
+		return err
+	}
+	heap.Init(&h)
+
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(csvVersion + csvHeader); err != nil {
+		return err
+	}
+	for idx := 0; len(h) > 0; idx++ {
+		c := h[0]
+		op := c.op
+		if fn != nil {
+			fn(&op)
+		}
+		if err := op.writeCSV(bw, idx); err != nil {
+			return err
+		}
+		next, err := c.next()
+		switch err {
+		case nil:
+			c.op = next
+			heap.Fix(&h, 0)
+		case io.EOF:
+			heap.Pop(&h)
+		default:
+			return fmt.Errorf("reading operations from %s: %w", s.dir, err)
+		}
+	}
+	if err := writeCSVComment(bw, comment); err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return os.RemoveAll(s.dir)
+}
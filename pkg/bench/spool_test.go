@@ -0,0 +1,153 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSpool(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	s, err := newOpSpool(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := []*spoolFile{s.newFile(), s.newFile()}
+	var want Operations
+	for i := 0; i < spoolSegment+10; i++ {
+		for th, f := range files {
+			op := Operation{
+				OpType:   "GET",
+				Thread:   uint16(th),
+				Size:     int64(i),
+				ObjPerOp: 1,
+				File:     "obj",
+				Start:    start.Add(time.Duration(2*i+th) * time.Millisecond),
+			}
+			op.End = op.Start.Add(time.Millisecond)
+			f.add(op)
+			want = append(want, op)
+		}
+	}
+	// Out of order, so the file must be sorted.
+	late := Operation{OpType: "PUT", Thread: 1, ObjPerOp: 1, Start: start, End: start.Add(time.Second)}
+	files[1].add(late)
+	want = append(want, late)
+
+	// Simulate a crash: flushed segments can be read from the files left in place.
+	for th, f := range files {
+		r, err := os.Open(f.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ops, err := OperationsFromCSV(r, false, 0, 0, nil)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ops) != spoolSegment {
+			t.Fatalf("file %d: got %d operations, want %d", th, len(ops), spoolSegment)
+		}
+		if ops[0].Thread != uint16(th) || !ops[0].Start.Equal(want[th].Start) {
+			t.Fatalf("file %d: got first operation %+v", th, ops[0])
+		}
+	}
+
+	if err := s.close(); err != nil {
+		t.Fatal(err)
+	}
+	event := Operation{OpType: "EVENT", Start: start.Add(5 * time.Millisecond), End: start.Add(5 * time.Millisecond)}
+	want = append(want, event)
+	var buf bytes.Buffer
+	err = s.writeCSV(&buf, Operations{event}, "comment", func(op *Operation) {
+		op.ClientID = "client"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(s.dir); !os.IsNotExist(err) {
+		t.Errorf("spool directory not removed: %v", err)
+	}
+	if !bytes.HasSuffix(buf.Bytes(), []byte("# comment\n")) {
+		t.Error("comment not written")
+	}
+	got, err := OperationsFromCSV(&buf, false, 0, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want.SortByStartTime()
+	for i := range want {
+		want[i].ClientID = "client"
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d operations, want %d", len(got), len(want))
+	}
+	for i := range got {
+		if !got[i].Start.Equal(want[i].Start) || got[i].Thread != want[i].Thread || got[i].OpType != want[i].OpType {
+			t.Fatalf("operation %d: got %+v, want %+v", i, got[i], want[i])
+		}
+		got[i].Start, got[i].End = want[i].Start, want[i].End
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Fatalf("operation %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestSpoolCollector(t *testing.T) {
+	dir := t.TempDir()
+	c, err := NewSpoolCollector(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	rcvs := []chan<- Operation{c.Receiver(), c.Receiver()}
+	for i := 0; i < 10; i++ {
+		for th, rcv := range rcvs {
+			rcv <- Operation{OpType: "GET", Thread: uint16(th), Start: start.Add(time.Duration(i) * time.Second)}
+		}
+	}
+	c.AddBatch([]Operation{{OpType: "PUT", Start: start}})
+	if ops := c.Close(); len(ops) != 0 {
+		t.Fatalf("Close returned %d operations", len(ops))
+	}
+	names, _ := filepath.Glob(filepath.Join(c.SpoolDir(), "*.csv"))
+	if len(names) != 3 {
+		t.Fatalf("got files %v, want 3", names)
+	}
+	var buf bytes.Buffer
+	if err := c.WriteSpoolCSV(&buf, nil, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	ops, err := OperationsFromCSV(&buf, false, 0, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 21 {
+		t.Fatalf("got %d operations, want 21", len(ops))
+	}
+	for i := 1; i < len(ops); i++ {
+		if ops[i].Start.Before(ops[i-1].Start) {
+			t.Fatalf("operation %d not sorted", i)
+		}
+	}
+}
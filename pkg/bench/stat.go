@@ -30,6 +30,9 @@ import (
 	"github.com/minio/warp/pkg/generator"
 )
 
+// StatMissingOp is the operation type of HEAD requests for objects that don't exist.
+const StatMissingOp = "STAT-MISSING"
+
 // Stat benchmarks HEAD speed.
 type Stat struct {
 	Common
@@ -39,6 +42,13 @@ type Stat struct {
 	objects       generator.Objects
 	CreateObjects int
 	Versions      int
+
+	// Missing is the fraction of requests for objects that don't exist.
+	// These are recorded as StatMissingOp and must return 404.
+	Missing float64
+
+	// AccessOrder is the order in which objects are read.
+	AccessOrder AccessOrder
 }
 
 // Prepare will create an empty bucket or delete any content already there
@@ -66,9 +76,9 @@ func (g *Stat) Prepare(ctx context.Context) error {
 	console.Info("\rUploading ", g.CreateObjects, " objects", x)
 
 	var wg sync.WaitGroup
-	wg.Add(g.Concurrency)
+	wg.Add(g.prepareThreads())
 	g.addCollector()
-	objs := splitObjs(g.CreateObjects, g.Concurrency)
+	objs := splitObjs(g.CreateObjects, g.prepareThreads())
 	rcv := g.Collector.rcv
 	var groupErr error
 	var mu sync.Mutex
@@ -78,6 +88,7 @@ func (g *Stat) Prepare(ctx context.Context) error {
 			defer wg.Done()
 			src := g.Source()
 			opts := g.PutOpts
+			prep := g.preparer(i, rcv)
 
 			for range obj {
 				select {
@@ -109,7 +120,7 @@ func (g *Stat) Prepare(ctx context.Context) error {
 
 					opts.ContentType = obj.ContentType
 					op.Start = time.Now()
-					res, err := client.PutObject(ctx, g.Bucket, obj.Name, obj.Reader, obj.Size, opts)
+					res, queued, err := prep.upload(ctx, client, &op, obj, opts)
 					op.End = time.Now()
 					if err != nil {
 						err := fmt.Errorf("upload error: %w", err)
@@ -138,8 +149,21 @@ func (g *Stat) Prepare(ctx context.Context) error {
 					g.objects = append(g.objects, *obj)
 					g.prepareProgress(float64(len(g.objects)) / float64(g.CreateObjects*g.Versions))
 					mu.Unlock()
-					rcv <- op
+					if !queued {
+						rcv <- op
+					}
+				}
+			}
+			client, cldone := g.Client()
+			err := prep.flush(ctx, client)
+			cldone()
+			if err != nil {
+				g.Error(err)
+				mu.Lock()
+				if groupErr == nil {
+					groupErr = err
 				}
+				mu.Unlock()
 			}
 		}(i, obj)
 	}
@@ -158,16 +182,18 @@ func (g *Stat) Start(ctx context.Context, wait chan struct{}) (Operations, error
 	}
 	// Non-terminating context.
 	nonTerm := context.Background()
+	access := newAccess(g.AccessOrder, g.objects, g.Concurrency)
 
 	for i := 0; i < g.Concurrency; i++ {
 		go func(i int) {
 			rng := rand.New(rand.NewSource(int64(i)))
+			next := access.picker(i, rng)
 			rcv := c.Receiver()
 			defer wg.Done()
 			opts := g.StatOpts
 			done := ctx.Done()
 
-			<-wait
+			g.startWait(wait)
 			for {
 				select {
 				case <-done:
@@ -179,7 +205,11 @@ func (g *Stat) Start(ctx context.Context, wait chan struct{}) (Operations, error
 					return
 				}
 
-				obj := g.objects[rng.Intn(len(g.objects))]
+				obj := g.objects[next()]
+				if g.Missing > 0 && rng.Float64() < g.Missing {
+					g.statMissing(rng, i, obj.Name, rcv)
+					continue
+				}
 				client, cldone := g.Client()
 				op := Operation{
 					OpType:   "STAT",
@@ -195,16 +225,19 @@ func (g *Stat) Start(ctx context.Context, wait chan struct{}) (Operations, error
 				if g.Versions > 1 {
 					opts.VersionID = obj.VersionID
 				}
-				objI, err := client.StatObject(nonTerm, g.Bucket, obj.Name, opts)
+				opCtx, resp := recordResponse(nonTerm)
+				objI, err := client.StatObject(opCtx, g.Bucket, obj.Name, opts)
 				if err != nil {
 					g.Error("StatObject error: ", err)
 					op.Err = err.Error()
 					op.End = time.Now()
+					resp.apply(&op, g.RecordHeaders)
 					rcv <- op
 					cldone()
 					continue
 				}
 				op.End = time.Now()
+				resp.apply(&op, g.RecordHeaders)
 				if objI.Size != obj.Size && op.Err == "" {
 					op.Err = fmt.Sprint("unexpected file size. want:", obj.Size, ", got:", objI.Size)
 					g.Error(op.Err)
@@ -218,6 +251,40 @@ func (g *Stat) Start(ctx context.Context, wait chan struct{}) (Operations, error
 	return c.Close(), nil
 }
 
+// statMissing sends a HEAD request for a name next to an existing object, that doesn't exist.
+// Requests succeed when the object is not found.
+func (g *Stat) statMissing(rng *rand.Rand, thread int, name string, rcv chan<- Operation) {
+	name = missingName(rng, name)
+	client, cldone := g.Client()
+	defer cldone()
+	op := Operation{
+		OpType:   StatMissingOp,
+		Thread:   uint16(thread),
+		File:     name,
+		ObjPerOp: 1,
+		Endpoint: client.EndpointURL().String(),
+	}
+	op.Start = time.Now()
+	opCtx, resp := recordResponse(context.Background())
+	_, err := client.StatObject(opCtx, g.Bucket, name, g.StatOpts)
+	op.End = time.Now()
+	resp.apply(&op, g.RecordHeaders)
+	switch {
+	case err == nil:
+		op.Err = "object exists: " + name
+		g.Error(op.Err)
+	case minio.ToErrorResponse(err).StatusCode != http.StatusNotFound:
+		g.Error("StatObject error: ", err)
+		op.Err = err.Error()
+	}
+	rcv <- op
+}
+
+// missingName returns a random name next to an existing object, that is never created.
+func missingName(rng *rand.Rand, name string) string {
+	return fmt.Sprintf("%s.missing-%016x", name, rng.Uint64())
+}
+
 // Cleanup deletes everything uploaded to the bucket.
 func (g *Stat) Cleanup(ctx context.Context) {
 	g.deleteAllInBucket(ctx, g.objects.Prefixes()...)
@@ -0,0 +1,128 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// tagPrefix is the prefix of tag lines in the operations file header.
+const tagPrefix = "# tag: "
+
+// Tags contains user supplied key/value pairs describing a benchmark run.
+type Tags map[string]string
+
+// ParseTags will parse tags supplied as 'key=value'.
+func ParseTags(kv []string) (Tags, error) {
+	if len(kv) == 0 {
+		return nil, nil
+	}
+	t := make(Tags, len(kv))
+	for _, s := range kv {
+		key, value, ok := strings.Cut(s, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid tag %q, must be 'key=value'", s)
+		}
+		if strings.ContainsAny(key+value, "\r\n") {
+			return nil, fmt.Errorf("invalid tag %q, must not contain newlines", s)
+		}
+		t[key] = strings.TrimSpace(value)
+	}
+	return t, nil
+}
+
+// Keys returns the tag keys sorted.
+func (t Tags) Keys() []string {
+	keys := make([]string, 0, len(t))
+	for k := range t {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// String returns the tags as a comma separated list sorted by key.
+func (t Tags) String() string {
+	var sb strings.Builder
+	for i, k := range t.Keys() {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(k + "=" + t[k])
+	}
+	return sb.String()
+}
+
+// Merge will add tags from other that are not present in t.
+// If t is nil a new map will be returned.
+func (t Tags) Merge(other Tags) Tags {
+	if len(other) == 0 {
+		return t
+	}
+	if t == nil {
+		t = make(Tags, len(other))
+	}
+	for k, v := range other {
+		if _, ok := t[k]; !ok {
+			t[k] = v
+		}
+	}
+	return t
+}
+
+// CSV will write the tags as comment lines.
+// The tags should be written before the operations.
+func (t Tags) CSV(w io.Writer) error {
+	for _, k := range t.Keys() {
+		_, err := io.WriteString(w, tagPrefix+k+"="+t[k]+"\n")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TagsFromCSV will read tags from the beginning of an operations file.
+// The returned reader must be used for reading the operations.
+func TagsFromCSV(r io.Reader) (Tags, io.Reader, error) {
+	br := bufio.NewReader(r)
+	var t Tags
+	for {
+		b, err := br.Peek(len(tagPrefix))
+		if err != nil || !bytes.Equal(b, []byte(tagPrefix)) {
+			return t, br, nil
+		}
+		line, err := br.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, nil, err
+		}
+		line = strings.TrimSuffix(strings.TrimPrefix(line, tagPrefix), "\n")
+		if key, value, ok := strings.Cut(line, "="); ok {
+			if t == nil {
+				t = make(Tags)
+			}
+			t[key] = value
+		}
+	}
+}
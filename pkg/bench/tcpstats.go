@@ -0,0 +1,177 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// TCPStatsOp is the operation type of events with TCP statistics of the benchmark connections.
+// Each event covers TCPStatsInterval. The number of connections sampled is stored in ObjPerOp and the statistics in Headers.
+const TCPStatsOp = "TCP-STATS"
+
+// TCPStatsInterval is the interval connections are sampled at.
+const TCPStatsInterval = time.Second
+
+// Headers of TCP statistics events.
+const (
+	// TCPStatsRetrans is the number of segments retransmitted in the interval.
+	TCPStatsRetrans = "retrans"
+	// TCPStatsSegsOut is the number of segments sent in the interval.
+	TCPStatsSegsOut = "segs_out"
+	// TCPStatsRTTAvg is the average smoothed round trip time of the connections in microseconds.
+	TCPStatsRTTAvg = "rtt_avg_us"
+	// TCPStatsRTTMax is the highest smoothed round trip time of the connections in microseconds.
+	TCPStatsRTTMax = "rtt_max_us"
+	// TCPStatsCwndAvg is the average congestion window of the connections in segments.
+	TCPStatsCwndAvg = "cwnd_avg"
+)
+
+// tcpSample is the TCP information of a connection.
+type tcpSample struct {
+	retrans, segsOut uint32
+	rttMicros, cwnd  uint32
+}
+
+// TCPStats samples TCP information of connections and records it as events.
+// Sampling is only supported on Linux.
+type TCPStats struct {
+	mu     sync.Mutex
+	conns  map[*tcpStatsConn]tcpSample
+	start  time.Time
+	events Operations
+
+	// Counters of the current interval, including closed connections.
+	retrans, segsOut uint64
+}
+
+// TCPStatsSupported returns whether TCP statistics can be sampled on this platform.
+func TCPStatsSupported() bool {
+	return tcpStatsSupported
+}
+
+// NewTCPStats returns a sampler of the connections returned by Wrap, which runs until the process exits.
+func NewTCPStats() *TCPStats {
+	t := &TCPStats{conns: make(map[*tcpStatsConn]tcpSample), start: time.Now()}
+	go func() {
+		for range time.Tick(TCPStatsInterval) {
+			t.sample()
+		}
+	}()
+	return t
+}
+
+// Wrap returns a connection that is sampled until it is closed.
+// If sampling is not supported c is returned.
+func (t *TCPStats) Wrap(c net.Conn) net.Conn {
+	if t == nil || !tcpStatsSupported {
+		return c
+	}
+	s, ok := tcpInfo(c)
+	if !ok {
+		return c
+	}
+	tc := &tcpStatsConn{Conn: c, t: t}
+	t.mu.Lock()
+	t.conns[tc] = s
+	t.mu.Unlock()
+	return tc
+}
+
+// closed adds the counters of a connection since it was last sampled, and stops sampling it.
+func (t *TCPStats) closed(c *tcpStatsConn) {
+	s, ok := tcpInfo(c.Conn)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if last, found := t.conns[c]; found && ok {
+		t.retrans += uint64(s.retrans - last.retrans)
+		t.segsOut += uint64(s.segsOut - last.segsOut)
+	}
+	delete(t.conns, c)
+}
+
+// sample all connections and record an event.
+func (t *TCPStats) sample() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var n int
+	var rttSum, cwndSum uint64
+	var rttMax uint32
+	for c, last := range t.conns {
+		s, ok := tcpInfo(c.Conn)
+		if !ok {
+			continue
+		}
+		t.retrans += uint64(s.retrans - last.retrans)
+		t.segsOut += uint64(s.segsOut - last.segsOut)
+		t.conns[c] = s
+		n++
+		rttSum += uint64(s.rttMicros)
+		cwndSum += uint64(s.cwnd)
+		rttMax = max(rttMax, s.rttMicros)
+	}
+	now := time.Now()
+	if n > 0 || t.segsOut > 0 {
+		ev := Operation{
+			OpType:   TCPStatsOp,
+			Start:    t.start,
+			End:      now,
+			ObjPerOp: n,
+			Headers: map[string]string{
+				TCPStatsRetrans: strconv.FormatUint(t.retrans, 10),
+				TCPStatsSegsOut: strconv.FormatUint(t.segsOut, 10),
+			},
+		}
+		if n > 0 {
+			ev.Headers[TCPStatsRTTAvg] = strconv.FormatUint(rttSum/uint64(n), 10)
+			ev.Headers[TCPStatsRTTMax] = strconv.FormatUint(uint64(rttMax), 10)
+			ev.Headers[TCPStatsCwndAvg] = strconv.FormatUint(cwndSum/uint64(n), 10)
+		}
+		t.events = append(t.events, ev)
+	}
+	t.start = now
+	t.retrans, t.segsOut = 0, 0
+}
+
+// Events returns the recorded events and clears them.
+func (t *TCPStats) Events() Operations {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ev := t.events
+	t.events = nil
+	return ev
+}
+
+// tcpStatsConn is a connection sampled by TCPStats.
+type tcpStatsConn struct {
+	net.Conn
+	t    *TCPStats
+	once sync.Once
+}
+
+// Close stops sampling the connection and closes it.
+func (c *tcpStatsConn) Close() error {
+	c.once.Do(func() { c.t.closed(c) })
+	return c.Conn.Close()
+}
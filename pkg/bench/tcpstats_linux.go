@@ -0,0 +1,52 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"net"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+const tcpStatsSupported = true
+
+// tcpInfo returns the TCP information of c, if it is a TCP connection.
+func tcpInfo(c net.Conn) (tcpSample, bool) {
+	sc, ok := c.(syscall.Conn)
+	if !ok {
+		return tcpSample{}, false
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return tcpSample{}, false
+	}
+	var info *unix.TCPInfo
+	err = raw.Control(func(fd uintptr) {
+		info, err = unix.GetsockoptTCPInfo(int(fd), unix.IPPROTO_TCP, unix.TCP_INFO)
+	})
+	if err != nil || info == nil {
+		return tcpSample{}, false
+	}
+	return tcpSample{
+		retrans:   info.Total_retrans,
+		segsOut:   info.Segs_out,
+		rttMicros: info.Rtt,
+		cwnd:      info.Snd_cwnd,
+	}, true
+}
@@ -0,0 +1,169 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/pkg/v2/console"
+	"golang.org/x/time/rate"
+)
+
+// ThrottleRecoveryPrefix is the prefix of the operation types in the recovery phase of the throttle benchmark.
+const ThrottleRecoveryPrefix = "RECOVERY-"
+
+// Throttle drives the target past its limits with unlimited uploads,
+// then reduces the load to see how the target throttles requests and how fast it recovers.
+type Throttle struct {
+	Common
+
+	// Overload is the duration of the overload phase.
+	// The recovery phase runs for the rest of the benchmark.
+	Overload time.Duration
+
+	// RecoveryRps is the request rate of all threads in the recovery phase.
+	RecoveryRps float64
+
+	recoverOnce sync.Once
+	recoverAt   time.Time
+
+	prefixes map[string]struct{}
+	prefixMu sync.Mutex
+
+	// statusMu protects status.
+	statusMu sync.Mutex
+	// status counts upload responses by status code in the overload and recovery phase.
+	status [2]map[int]int
+}
+
+// Prepare will create an empty bucket or delete any content already there.
+func (g *Throttle) Prepare(ctx context.Context) error {
+	return g.createEmptyBucket(ctx)
+}
+
+// Start will execute the main benchmark.
+// Operations should begin executing when the start channel is closed.
+func (g *Throttle) Start(ctx context.Context, wait chan struct{}) (Operations, error) {
+	var wg sync.WaitGroup
+	wg.Add(g.Concurrency)
+	g.addCollector()
+	c := g.Collector
+	g.prefixes = make(map[string]struct{}, g.Concurrency)
+	g.status = [2]map[int]int{make(map[int]int), make(map[int]int)}
+	recovery := rate.NewLimiter(rate.Limit(g.RecoveryRps), 1)
+
+	// Non-terminating context.
+	nonTerm := context.Background()
+
+	for i := 0; i < g.Concurrency; i++ {
+		src := g.Source()
+		g.prefixMu.Lock()
+		g.prefixes[src.Prefix()] = struct{}{}
+		g.prefixMu.Unlock()
+		go func(i int) {
+			rcv := c.Receiver()
+			defer wg.Done()
+			done := ctx.Done()
+
+			g.startWait(wait)
+			g.recoverOnce.Do(func() {
+				g.recoverAt = time.Now().Add(g.Overload)
+			})
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if g.rpsLimit(ctx) != nil {
+					return
+				}
+				phase := 0
+				if !time.Now().Before(g.recoverAt) {
+					phase = 1
+					if recovery.Wait(ctx) != nil {
+						return
+					}
+				}
+
+				obj := src.Object()
+				opts := g.PutOpts
+				opts.ContentType = obj.ContentType
+				client, cldone := g.Client()
+				op := Operation{
+					OpType:   http.MethodPut,
+					Thread:   uint16(i),
+					Size:     obj.Size,
+					ObjPerOp: 1,
+					File:     obj.Name,
+					Endpoint: client.EndpointURL().String(),
+				}
+				if phase == 1 {
+					op.OpType = ThrottleRecoveryPrefix + http.MethodPut
+				}
+				opCtx, resp := recordResponse(nonTerm)
+				op.Start = time.Now()
+				res, err := client.PutObject(opCtx, g.Bucket, obj.Name, obj.Reader, obj.Size, opts)
+				op.End = time.Now()
+				resp.apply(&op, g.RecordHeaders)
+				cldone()
+				code := http.StatusOK
+				if err != nil {
+					code = minio.ToErrorResponse(err).StatusCode
+					op.Err = err.Error()
+				} else if res.Size != obj.Size {
+					op.Err = fmt.Sprint("short upload. want:", obj.Size, ", got:", res.Size)
+					g.Error(op.Err)
+				}
+				g.statusMu.Lock()
+				g.status[phase][code]++
+				g.statusMu.Unlock()
+				rcv <- op
+			}
+		}(i)
+	}
+	wg.Wait()
+	g.printStatus()
+	return c.Close(), nil
+}
+
+// printStatus prints the number of uploads by response status in each phase.
+func (g *Throttle) printStatus() {
+	g.statusMu.Lock()
+	defer g.statusMu.Unlock()
+	for phase, name := range []string{"Overload", "Recovery"} {
+		if s := statusSummary(g.status[phase]); s != "" {
+			console.Eraseline()
+			console.Infof("\r%s responses: %s\n", name, s)
+		}
+	}
+}
+
+// Cleanup deletes everything uploaded to the bucket.
+func (g *Throttle) Cleanup(ctx context.Context) {
+	pf := make([]string, 0, len(g.prefixes))
+	for p := range g.prefixes {
+		pf = append(pf, p)
+	}
+	g.deleteAllInBucket(ctx, pf...)
+}
@@ -0,0 +1,330 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/pkg/v2/console"
+)
+
+// Tiny benchmarks GET and PUT of very small objects with minimal client overhead.
+// Each thread generates a single payload that is reused for all uploads,
+// object names are generated without allocating per character,
+// and operations are sent to the collector in batches.
+type Tiny struct {
+	Common
+
+	GetOpts       minio.GetObjectOptions
+	CreateObjects int
+
+	// Distribution of GET and PUT operations.
+	GetDist, PutDist float64
+
+	// Verify will compare downloaded content to the uploaded payload.
+	// By default only the size is checked.
+	Verify bool
+
+	// BatchSize is the number of operations each thread collects
+	// before adding them to the collector.
+	BatchSize int
+
+	prefixes []string
+	// Object names for each thread.
+	objects [][]string
+	// Payload for each thread.
+	payloads [][]byte
+}
+
+// tinyBatchSize is the default number of operations in each batch.
+const tinyBatchSize = 256
+
+// tinyName returns the name of object n uploaded by a thread of a client.
+// The name is appended to dst.
+func tinyName(dst []byte, prefix string, client, thread, n int) []byte {
+	if prefix != "" {
+		dst = append(dst, prefix...)
+		dst = append(dst, '/')
+	}
+	dst = strconv.AppendInt(dst, int64(client), 10)
+	dst = append(dst, '.')
+	dst = strconv.AppendInt(dst, int64(thread), 10)
+	dst = append(dst, '.')
+	dst = strconv.AppendInt(dst, int64(n), 10)
+	return append(dst, ".tiny"...)
+}
+
+// tinyThread returns the thread that uploaded the named object.
+func tinyThread(name string) int {
+	name = name[strings.LastIndexByte(name, '/')+1:]
+	fields := strings.SplitN(name, ".", 3)
+	if len(fields) < 3 {
+		return 0
+	}
+	i, _ := strconv.Atoi(fields[1])
+	return i
+}
+
+// Prepare will create an empty bucket or delete any content already there
+// and upload a number of objects.
+func (t *Tiny) Prepare(ctx context.Context) error {
+	if t.GetDist < 0 || t.PutDist < 0 || t.GetDist+t.PutDist <= 0 {
+		return errors.New("invalid distribution")
+	}
+	if t.BatchSize <= 0 {
+		t.BatchSize = tinyBatchSize
+	}
+	if err := t.createEmptyBucket(ctx); err != nil {
+		return err
+	}
+	t.addCollector()
+	t.prefixes = make([]string, t.Concurrency)
+	t.payloads = make([][]byte, t.Concurrency)
+	t.objects = make([][]string, t.Concurrency)
+	for i := range t.payloads {
+		src := t.Source()
+		obj := src.Object()
+		payload, err := io.ReadAll(obj.Reader)
+		if err != nil {
+			return err
+		}
+		t.payloads[i] = payload
+		t.prefixes[i] = src.Prefix()
+	}
+	if t.GetDist == 0 {
+		return nil
+	}
+
+	console.Eraseline()
+	console.Info("\rUploading ", t.CreateObjects, " objects of ", len(t.payloads[0]), " bytes")
+	var wg sync.WaitGroup
+	wg.Add(t.Concurrency)
+	objs := splitObjs(t.CreateObjects, t.Concurrency)
+	var mu sync.Mutex
+	var groupErr error
+	uploaded := 0
+	for i, obj := range objs {
+		go func(i int, obj []struct{}) {
+			defer wg.Done()
+			opts := t.PutOpts
+			rd := bytes.NewReader(nil)
+			payload := t.payloads[i]
+			names := make([]string, 0, len(obj))
+			defer func() {
+				mu.Lock()
+				t.objects[i] = names
+				mu.Unlock()
+			}()
+			batch := make([]Operation, 0, t.BatchSize)
+			defer func() {
+				t.Collector.AddBatch(batch)
+			}()
+			var nameBuf []byte
+			for j := range obj {
+				if ctx.Err() != nil {
+					return
+				}
+				if t.rpsLimit(ctx) != nil {
+					return
+				}
+				nameBuf = tinyName(nameBuf[:0], t.prefixes[i], t.ClientIdx, i, j)
+				name := string(nameBuf)
+				rd.Reset(payload)
+				client, cldone := t.Client()
+				op := Operation{
+					OpType:   http.MethodPut,
+					Thread:   uint16(i),
+					Size:     int64(len(payload)),
+					File:     name,
+					ObjPerOp: 1,
+					Endpoint: client.EndpointURL().String(),
+				}
+				op.Start = time.Now()
+				_, err := client.PutObject(ctx, t.Bucket, name, rd, int64(len(payload)), opts)
+				op.End = time.Now()
+				cldone()
+				if err != nil {
+					err := fmt.Errorf("upload error: %w", err)
+					t.Error(err)
+					mu.Lock()
+					if groupErr == nil {
+						groupErr = err
+					}
+					mu.Unlock()
+					return
+				}
+				names = append(names, name)
+				batch = append(batch, op)
+				if len(batch) == cap(batch) {
+					t.Collector.AddBatch(batch)
+					batch = batch[:0]
+				}
+				mu.Lock()
+				uploaded++
+				t.prepareProgress(float64(uploaded) / float64(t.CreateObjects))
+				mu.Unlock()
+			}
+		}(i, obj)
+	}
+	wg.Wait()
+	return groupErr
+}
+
+// Start will execute the main benchmark.
+// Operations should begin executing when the start channel is closed.
+func (t *Tiny) Start(ctx context.Context, wait chan struct{}) (Operations, error) {
+	var wg sync.WaitGroup
+	wg.Add(t.Concurrency)
+	c := t.Collector
+	if t.AutoTermDur > 0 {
+		opType := http.MethodGet
+		if t.GetDist == 0 {
+			opType = http.MethodPut
+		}
+		ctx = c.AutoTerm(ctx, opType, t.AutoTermScale, autoTermCheck, autoTermSamples, t.AutoTermDur)
+	}
+	getFraction := t.GetDist / (t.GetDist + t.PutDist)
+	var objects []string
+	for _, objs := range t.objects {
+		objects = append(objects, objs...)
+	}
+	// Non-terminating context.
+	nonTerm := context.Background()
+
+	for i := 0; i < t.Concurrency; i++ {
+		go func(i int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(int64(i)))
+			done := ctx.Done()
+			putOpts := t.PutOpts
+			payload := t.payloads[i]
+			rd := bytes.NewReader(nil)
+			buf := make([]byte, len(payload)+1)
+			var nameBuf []byte
+			// Continue numbering after the prepared objects.
+			n := len(t.objects[i])
+			// Endpoint names by client.
+			endpoints := make(map[*minio.Client]string, 1)
+			batch := make([]Operation, 0, t.BatchSize)
+			defer func() {
+				c.AddBatch(batch)
+			}()
+
+			t.startWait(wait)
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				if t.rpsLimit(ctx) != nil {
+					return
+				}
+				client, cldone := t.Client()
+				ep, ok := endpoints[client]
+				if !ok {
+					ep = client.EndpointURL().String()
+					endpoints[client] = ep
+				}
+				op := Operation{
+					Thread:   uint16(i),
+					Size:     int64(len(payload)),
+					ObjPerOp: 1,
+					Endpoint: ep,
+				}
+				opCtx, resp := recordResponse(nonTerm)
+				if len(objects) > 0 && rng.Float64() < getFraction {
+					op.OpType = http.MethodGet
+					op.File = objects[rng.Intn(len(objects))]
+					op.Start = time.Now()
+					o, err := client.GetObject(opCtx, t.Bucket, op.File, t.GetOpts)
+					if err == nil {
+						var read int
+						read, err = io.ReadFull(o, buf)
+						if errors.Is(err, io.ErrUnexpectedEOF) {
+							err = nil
+						}
+						o.Close()
+						switch {
+						case err != nil:
+						case read != len(payload):
+							err = fmt.Errorf("unexpected download size. want: %d, got: %d", len(payload), read)
+						case t.Verify && !bytes.Equal(buf[:read], t.payloads[tinyThread(op.File)%len(t.payloads)]):
+							err = errors.New("downloaded content does not match")
+						}
+					}
+					op.End = time.Now()
+					if err != nil {
+						t.Error("download error:", err)
+						op.Err = err.Error()
+					}
+				} else {
+					nameBuf = tinyName(nameBuf[:0], t.prefixes[i], t.ClientIdx, i, n)
+					n++
+					op.OpType = http.MethodPut
+					op.File = string(nameBuf)
+					rd.Reset(payload)
+					op.Start = time.Now()
+					_, err := client.PutObject(opCtx, t.Bucket, op.File, rd, int64(len(payload)), putOpts)
+					op.End = time.Now()
+					if err != nil {
+						t.Error("upload error:", err)
+						op.Err = err.Error()
+					}
+				}
+				resp.apply(&op, t.RecordHeaders)
+				cldone()
+				if t.DiscardOutput {
+					op.File = ""
+				}
+				batch = append(batch, op)
+				if len(batch) == cap(batch) {
+					c.AddBatch(batch)
+					batch = batch[:0]
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	return c.Close(), nil
+}
+
+// Cleanup deletes everything uploaded to the bucket.
+func (t *Tiny) Cleanup(ctx context.Context) {
+	prefixes := make(map[string]struct{}, len(t.prefixes))
+	for _, p := range t.prefixes {
+		prefixes[p] = struct{}{}
+	}
+	pf := make([]string, 0, len(prefixes))
+	for p := range prefixes {
+		pf = append(pf, p)
+	}
+	t.deleteAllInBucket(ctx, pf...)
+}
@@ -64,9 +64,9 @@ func (g *Versioned) Prepare(ctx context.Context) error {
 	console.Eraseline()
 	console.Info("\rUploading ", g.CreateObjects, " objects of ", src.String())
 	var wg sync.WaitGroup
-	wg.Add(g.Concurrency)
+	wg.Add(g.prepareThreads())
 	g.addCollector()
-	objs := splitObjs(g.CreateObjects, g.Concurrency)
+	objs := splitObjs(g.CreateObjects, g.prepareThreads())
 
 	var groupErr error
 	var mu sync.Mutex
@@ -146,7 +146,7 @@ func (g *Versioned) Start(ctx context.Context, wait chan struct{}) (Operations,
 			statOpts := g.StatOpts
 			getOpts := g.GetOpts
 
-			<-wait
+			g.startWait(wait)
 			for {
 				select {
 				case <-done:
@@ -173,14 +173,16 @@ func (g *Versioned) Start(ctx context.Context, wait chan struct{}) (Operations,
 						Endpoint: client.EndpointURL().String(),
 					}
 
+					opCtx, resp := recordResponse(nonTerm)
 					op.Start = time.Now()
 					var err error
 					getOpts.VersionID = obj.VersionID
-					fbr.r, err = client.GetObject(nonTerm, g.Bucket, obj.Name, getOpts)
+					fbr.r, err = client.GetObject(opCtx, g.Bucket, obj.Name, getOpts)
 					if err != nil {
 						g.Error("download error: ", err)
 						op.Err = err.Error()
 						op.End = time.Now()
+						resp.apply(&op, g.RecordHeaders)
 						rcv <- op
 						clDone()
 						objDone()
@@ -193,6 +195,7 @@ func (g *Versioned) Start(ctx context.Context, wait chan struct{}) (Operations,
 					}
 					op.FirstByte = fbr.t
 					op.End = time.Now()
+					resp.apply(&op, g.RecordHeaders)
 					if n != obj.Size && op.Err == "" {
 						op.Err = fmt.Sprint("unexpected download size. want:", obj.Size, ", got:", n)
 						g.Error(op.Err)
@@ -213,9 +216,11 @@ func (g *Versioned) Start(ctx context.Context, wait chan struct{}) (Operations,
 						Endpoint: client.EndpointURL().String(),
 					}
 
+					opCtx, resp := recordResponse(nonTerm)
 					op.Start = time.Now()
-					res, err := client.PutObject(nonTerm, g.Bucket, obj.Name, obj.Reader, obj.Size, putOpts)
+					res, err := client.PutObject(opCtx, g.Bucket, obj.Name, obj.Reader, obj.Size, putOpts)
 					op.End = time.Now()
+					resp.apply(&op, g.RecordHeaders)
 					if err != nil {
 						g.Error("upload error: ", err)
 						op.Err = err.Error()
@@ -248,9 +253,11 @@ func (g *Versioned) Start(ctx context.Context, wait chan struct{}) (Operations,
 						ObjPerOp: 1,
 						Endpoint: client.EndpointURL().String(),
 					}
+					opCtx, resp := recordResponse(nonTerm)
 					op.Start = time.Now()
-					err := client.RemoveObject(nonTerm, g.Bucket, obj.Name, minio.RemoveObjectOptions{VersionID: obj.VersionID})
+					err := client.RemoveObject(opCtx, g.Bucket, obj.Name, minio.RemoveObjectOptions{VersionID: obj.VersionID})
 					op.End = time.Now()
+					resp.apply(&op, g.RecordHeaders)
 					clDone()
 					if err != nil {
 						g.Error("delete error:", err)
@@ -268,15 +275,17 @@ func (g *Versioned) Start(ctx context.Context, wait chan struct{}) (Operations,
 						ObjPerOp: 1,
 						Endpoint: client.EndpointURL().String(),
 					}
+					opCtx, resp := recordResponse(nonTerm)
 					op.Start = time.Now()
 					var err error
 					statOpts.VersionID = obj.VersionID
-					objI, err := client.StatObject(nonTerm, g.Bucket, obj.Name, statOpts)
+					objI, err := client.StatObject(opCtx, g.Bucket, obj.Name, statOpts)
 					if err != nil {
 						g.Error("stat error:", err)
 						op.Err = err.Error()
 					}
 					op.End = time.Now()
+					resp.apply(&op, g.RecordHeaders)
 					if objI.Size != obj.Size && op.Err == "" {
 						op.Err = fmt.Sprint("unexpected stat size. want:", obj.Size, ", got:", objI.Size)
 						g.Error(op.Err)
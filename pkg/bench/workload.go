@@ -0,0 +1,150 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// workloadMagic starts every recorded workload.
+// The last byte is the format version.
+var workloadMagic = []byte("warp-workload\x01")
+
+// WorkloadOp is a single recorded operation.
+// Version IDs are not recorded.
+type WorkloadOp struct {
+	OpType string
+	Thread uint16
+	Key    string
+	Size   int64
+	// Offset is the start of the operation relative to the first recorded operation.
+	Offset time.Duration
+}
+
+// WriteWorkload writes the operations as a workload to w.
+// Operations are written in the order they were started.
+func WriteWorkload(w io.Writer, ops Operations) error {
+	sorted := make(Operations, len(ops))
+	copy(sorted, ops)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Start.Before(sorted[j].Start)
+	})
+	if _, err := w.Write(workloadMagic); err != nil {
+		return err
+	}
+	enc, err := zstd.NewWriter(w)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(enc)
+	var tmp []byte
+	var first time.Time
+	if len(sorted) > 0 {
+		first = sorted[0].Start
+	}
+	for _, op := range sorted {
+		tmp = tmp[:0]
+		tmp = binary.AppendUvarint(tmp, uint64(op.Thread))
+		tmp = binary.AppendUvarint(tmp, uint64(len(op.OpType)))
+		tmp = append(tmp, op.OpType...)
+		tmp = binary.AppendUvarint(tmp, uint64(len(op.File)))
+		tmp = append(tmp, op.File...)
+		tmp = binary.AppendVarint(tmp, op.Size)
+		tmp = binary.AppendVarint(tmp, int64(op.Start.Sub(first)))
+		if _, err := bw.Write(tmp); err != nil {
+			enc.Close()
+			return err
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		enc.Close()
+		return err
+	}
+	return enc.Close()
+}
+
+// ReadWorkload reads a workload written by WriteWorkload.
+func ReadWorkload(r io.Reader) ([]WorkloadOp, error) {
+	magic := make([]byte, len(workloadMagic))
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return nil, fmt.Errorf("reading workload header: %w", err)
+	}
+	if string(magic[:len(magic)-1]) != string(workloadMagic[:len(workloadMagic)-1]) {
+		return nil, errors.New("not a recorded workload")
+	}
+	if v := magic[len(magic)-1]; v != workloadMagic[len(workloadMagic)-1] {
+		return nil, fmt.Errorf("unsupported workload version %d", v)
+	}
+	dec, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer dec.Close()
+	br := bufio.NewReader(dec)
+	readString := func() (string, error) {
+		n, err := binary.ReadUvarint(br)
+		if err != nil {
+			return "", err
+		}
+		b := make([]byte, n)
+		_, err = io.ReadFull(br, b)
+		return string(b), err
+	}
+	var ops []WorkloadOp
+	for {
+		thread, err := binary.ReadUvarint(br)
+		if err == io.EOF {
+			return ops, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		op := WorkloadOp{Thread: uint16(thread)}
+		if op.OpType, err = readString(); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if op.Key, err = readString(); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if op.Size, err = binary.ReadVarint(br); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		offset, err := binary.ReadVarint(br)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		op.Offset = time.Duration(offset)
+		ops = append(ops, op)
+	}
+}
+
+// unexpectedEOF converts io.EOF inside a record to io.ErrUnexpectedEOF.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
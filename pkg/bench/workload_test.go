@@ -0,0 +1,89 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bench
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWorkload(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	// Not in start order, since they are sorted when written.
+	ops := Operations{
+		{OpType: http.MethodGet, Thread: 1, File: "prefix/obj-1", Size: 1000, Start: start.Add(2 * time.Second)},
+		{OpType: http.MethodPut, Thread: 0, File: "prefix/obj-1", Size: 1000, Start: start},
+		{OpType: "STAT", Thread: 65535, File: "prefix/ö-2", Size: 0, Start: start.Add(time.Millisecond)},
+		{OpType: http.MethodDelete, Thread: 1, File: "", Size: -1, Start: start.Add(3 * time.Hour)},
+	}
+	want := []WorkloadOp{
+		{OpType: http.MethodPut, Thread: 0, Key: "prefix/obj-1", Size: 1000, Offset: 0},
+		{OpType: "STAT", Thread: 65535, Key: "prefix/ö-2", Size: 0, Offset: time.Millisecond},
+		{OpType: http.MethodGet, Thread: 1, Key: "prefix/obj-1", Size: 1000, Offset: 2 * time.Second},
+		{OpType: http.MethodDelete, Thread: 1, Key: "", Size: -1, Offset: 3 * time.Hour},
+	}
+	var buf bytes.Buffer
+	if err := WriteWorkload(&buf, ops); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadWorkload(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+
+	// An empty workload.
+	buf.Reset()
+	if err := WriteWorkload(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	got, err = ReadWorkload(&buf)
+	if err != nil || len(got) != 0 {
+		t.Fatalf("empty workload: got %v, %v", got, err)
+	}
+
+	// Other data is rejected.
+	if _, err := ReadWorkload(bytes.NewReader([]byte("not a warp workload"))); err == nil {
+		t.Error("invalid header: want error")
+	}
+}
+
+func TestWorkload_Truncated(t *testing.T) {
+	ops := make(Operations, 1000)
+	for i := range ops {
+		ops[i] = Operation{OpType: http.MethodPut, File: "obj", Size: int64(i), Start: time.Unix(0, int64(i))}
+	}
+	var buf bytes.Buffer
+	if err := WriteWorkload(&buf, ops); err != nil {
+		t.Fatal(err)
+	}
+	_, err := ReadWorkload(bytes.NewReader(buf.Bytes()[:buf.Len()-5]))
+	if err == nil {
+		t.Fatal("truncated workload: want error")
+	}
+	if errors.Is(err, io.EOF) {
+		t.Errorf("truncated workload: got %v, want unexpected EOF", err)
+	}
+}
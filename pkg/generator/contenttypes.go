@@ -0,0 +1,174 @@
+/*
+ * Warp (C) 2019-2020 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// ContentType is an object extension with the weight it is picked with.
+type ContentType struct {
+	// Ext is the extension without the leading dot, eg. "json".
+	Ext string
+	// Weight is the relative weight of the extension.
+	Weight float64
+}
+
+// contentTypes are MIME types of extensions that may not be known by the mime package.
+var contentTypes = map[string]string{
+	"json":    "application/json",
+	"csv":     "text/csv",
+	"txt":     "text/plain; charset=utf-8",
+	"log":     "text/plain; charset=utf-8",
+	"parquet": "application/vnd.apache.parquet",
+	"avro":    "application/avro",
+	"orc":     "application/x-orc",
+	"zst":     "application/zstd",
+	"gz":      "application/gzip",
+	"mp4":     "video/mp4",
+	"jpg":     "image/jpeg",
+	"png":     "image/png",
+}
+
+// Text payloads of extensions. Other extensions get incompressible random data.
+const (
+	payloadJSON = "json"
+	payloadCSV  = "csv"
+	payloadXML  = "xml"
+	payloadText = "text"
+)
+
+// ParseContentTypes parses a comma separated list of extensions with weights, eg. "jpg:60,json:30,parquet:10".
+// Extensions without a weight have weight 1.
+func ParseContentTypes(s string) ([]ContentType, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var res []ContentType
+	for _, entry := range strings.Split(s, ",") {
+		ext, weight, hasWeight := strings.Cut(strings.TrimSpace(entry), ":")
+		ct := ContentType{Ext: strings.TrimPrefix(ext, "."), Weight: 1}
+		if hasWeight {
+			w, err := strconv.ParseFloat(weight, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid weight of %q: %w", ext, err)
+			}
+			ct.Weight = w
+		}
+		res = append(res, ct)
+	}
+	return res, nil
+}
+
+// WithContentTypes will give each object of the random generator an extension picked from the list by weight.
+// The content type is set from the extension and text formats, like json, csv and xml, are given compressible content.
+func WithContentTypes(types ...ContentType) Option {
+	return func(o *Options) error {
+		var total float64
+		for _, ct := range types {
+			if ct.Ext == "" || strings.ContainsAny(ct.Ext, "/.") {
+				return fmt.Errorf("WithContentTypes: invalid extension %q", ct.Ext)
+			}
+			if ct.Weight < 0 {
+				return fmt.Errorf("WithContentTypes: weight of %q must be >= 0", ct.Ext)
+			}
+			total += ct.Weight
+		}
+		if len(types) > 0 && total <= 0 {
+			return errors.New("WithContentTypes: at least one weight must be > 0")
+		}
+		o.contentTypes = types
+		return nil
+	}
+}
+
+// pickContentType returns a random content type by weight.
+func (o Options) pickContentType(rng *rand.Rand) ContentType {
+	var total float64
+	for _, ct := range o.contentTypes {
+		total += ct.Weight
+	}
+	n := rng.Float64() * total
+	for _, ct := range o.contentTypes {
+		if n < ct.Weight {
+			return ct
+		}
+		n -= ct.Weight
+	}
+	return o.contentTypes[len(o.contentTypes)-1]
+}
+
+// ContentTypeOf returns the MIME type of an extension.
+func ContentTypeOf(ext string) string {
+	if ct, ok := contentTypes[ext]; ok {
+		return ct
+	}
+	if ct := mime.TypeByExtension("." + ext); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
+
+// payloadOf returns the text payload of an extension, or "" if it should be random data.
+func payloadOf(ext string) string {
+	switch ext {
+	case "json", "ndjson", "jsonl":
+		return payloadJSON
+	case "csv", "tsv":
+		return payloadCSV
+	case "xml", "html", "htm", "svg":
+		return payloadXML
+	}
+	if strings.HasPrefix(ContentTypeOf(ext), "text/") {
+		return payloadText
+	}
+	return ""
+}
+
+// textPayload returns size bytes of text in the format of the payload.
+// Words are picked from a small vocabulary, so the text compresses like real data.
+func textPayload(payload string, size int, rng *rand.Rand) []byte {
+	words := make([]string, 256)
+	for i := range words {
+		w := make([]byte, 3+rng.Intn(8))
+		for j := range w {
+			w[j] = byte('a' + rng.Intn(26))
+		}
+		words[i] = string(w)
+	}
+	word := func() string { return words[rng.Intn(len(words))] }
+	dst := make([]byte, 0, size+256)
+	for i := 0; len(dst) < size; i++ {
+		switch payload {
+		case payloadJSON:
+			dst = fmt.Appendf(dst, `{"id":%d,"name":%q,"value":%.3f,"tags":[%q,%q]}`+"\n", i, word(), rng.Float64()*1000, word(), word())
+		case payloadCSV:
+			dst = fmt.Appendf(dst, "%d,%s,%.3f,%s %s\n", i, word(), rng.Float64()*1000, word(), word())
+		case payloadXML:
+			dst = fmt.Appendf(dst, `<item id="%d"><name>%s</name><value>%.3f</value></item>`+"\n", i, word(), rng.Float64()*1000)
+		default:
+			dst = fmt.Appendf(dst, "%d INFO %s %s %s %s\n", i, word(), word(), word(), word())
+		}
+	}
+	return dst[:size]
+}
@@ -109,6 +109,7 @@ type csvSource struct {
 	obj Object
 
 	o       Options
+	prefix  string
 	builder []byte
 }
 
@@ -118,14 +119,11 @@ func newCsv(o Options) (Source, error) {
 	}
 	c.builder = make([]byte, 0, o.csv.maxLen+1)
 	c.buf = newCircularBuffer(make([]byte, o.csv.maxLen*(o.csv.cols+1)*(o.csv.rows+1)), o.totalSize)
-	rndSrc := rand.NewSource(int64(rand.Uint64()))
-	if o.csv.seed != nil {
-		rndSrc = rand.NewSource(*o.csv.seed)
-	}
-	c.rng = rand.New(rndSrc)
+	c.rng = rand.New(o.rngSource(o.csv.seed))
 	c.obj.ContentType = "text/csv"
 	c.obj.Size = 0
 	c.obj.setPrefix(o)
+	c.prefix = c.obj.Prefix
 
 	return &c, nil
 }
@@ -153,7 +151,9 @@ func (c *csvSource) Object() *Object {
 	c.obj.Reader = c.buf.Reset(0)
 	var nBuf [16]byte
 	randASCIIBytes(nBuf[:], c.rng)
-	c.obj.setName(string(nBuf[:]) + ".csv")
+	c.obj.Prefix = c.o.objectPrefix(c.prefix, c.rng)
+	c.obj.setName(c.o.objectName(c.obj.Prefix, string(nBuf[:])+".csv", c.rng))
+	c.o.collide(&c.obj, c.rng)
 	return &c.obj
 }
 
@@ -162,5 +162,5 @@ func (c *csvSource) String() string {
 }
 
 func (c *csvSource) Prefix() string {
-	return c.obj.Prefix
+	return c.prefix
 }
@@ -0,0 +1,225 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+)
+
+// WithFiles returns options for reading object content from path.
+// If path is a directory, each object is a file in the directory.
+// Otherwise, objects are consecutive blocks of the file or block device.
+func WithFiles(path string) FileOpts {
+	return FileOpts{path: path}
+}
+
+// FileOpts are the options for the file data source.
+type FileOpts struct {
+	path  string
+	state *fileState
+}
+
+// fileState is shared by all sources created from the same options.
+type fileState struct {
+	// Files in a directory, relative to the directory.
+	files []string
+	next  atomic.Int64
+
+	// Device or single file.
+	dev    *os.File
+	size   int64
+	offset atomic.Int64
+}
+
+// Apply file options.
+func (o FileOpts) Apply() Option {
+	return func(opts *Options) error {
+		if o.path == "" {
+			return errors.New("file: no path specified")
+		}
+		st, err := os.Stat(o.path)
+		if err != nil {
+			return err
+		}
+		o.state = &fileState{}
+		if st.IsDir() {
+			err = filepath.WalkDir(o.path, func(p string, d fs.DirEntry, err error) error {
+				if err != nil || !d.Type().IsRegular() {
+					return err
+				}
+				rel, err := filepath.Rel(o.path, p)
+				if err != nil {
+					return err
+				}
+				// Leave out files we cannot read.
+				f, err := os.Open(p)
+				if err != nil {
+					return nil
+				}
+				f.Close()
+				o.state.files = append(o.state.files, rel)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if len(o.state.files) == 0 {
+				return fmt.Errorf("file: no readable files found in %s", o.path)
+			}
+			sort.Strings(o.state.files)
+		} else {
+			f, err := os.Open(o.path)
+			if err != nil {
+				return err
+			}
+			// Block devices report size 0, so find the size by seeking.
+			size, err := f.Seek(0, io.SeekEnd)
+			if err != nil {
+				f.Close()
+				return err
+			}
+			if size <= 0 {
+				f.Close()
+				return fmt.Errorf("file: %s is empty", o.path)
+			}
+			o.state.dev, o.state.size = f, size
+		}
+		opts.file = o
+		opts.src = newFileSrc
+		return nil
+	}
+}
+
+type fileSrc struct {
+	o       Options
+	rng     *rand.Rand
+	obj     Object
+	prefix  string
+	counter uint64
+	// Currently open file.
+	f *os.File
+}
+
+func newFileSrc(o Options) (Source, error) {
+	if o.file.state == nil {
+		return nil, errors.New("file: options not applied")
+	}
+	r := fileSrc{
+		o:   o,
+		rng: rand.New(o.rngSource(nil)),
+		obj: Object{ContentType: "application/octet-stream"},
+	}
+	r.obj.setPrefix(o)
+	r.prefix = r.obj.Prefix
+	return &r, nil
+}
+
+// Object returns the next file or block.
+// The previously returned object can no longer be read.
+func (r *fileSrc) Object() *Object {
+	st := r.o.file.state
+	if r.f != nil {
+		r.f.Close()
+		r.f = nil
+	}
+	r.counter++
+	r.obj.Prefix = r.o.objectPrefix(r.prefix, r.rng)
+	r.obj.ContentType = "application/octet-stream"
+	if st.dev != nil {
+		size := min(r.o.getSize(r.rng), st.size)
+		off := (st.offset.Add(size) - size) % st.size
+		if off+size > st.size {
+			off = 0
+		}
+		r.obj.Size = size
+		r.obj.Reader = io.NewSectionReader(st.dev, off, size)
+		r.obj.setName(r.o.objectName(r.obj.Prefix, fmt.Sprintf("%d.%d.blk", r.counter, off), r.rng))
+		r.o.collide(&r.obj, r.rng)
+		return &r.obj
+	}
+
+	// Skip files that cannot be opened.
+	for range st.files {
+		n := st.next.Add(1) - 1
+		name := st.files[n%int64(len(st.files))]
+		f, err := os.Open(filepath.Join(r.o.file.path, name))
+		if err != nil {
+			continue
+		}
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			continue
+		}
+		r.f = f
+		r.obj.Size = info.Size()
+		r.obj.Reader = f
+		if ct := mime.TypeByExtension(filepath.Ext(name)); ct != "" {
+			r.obj.ContentType = ct
+		}
+		// Once all files have been used, add the pass number so names stay unique.
+		objName := filepath.ToSlash(name)
+		if pass := n / int64(len(st.files)); pass > 0 {
+			objName = path.Join(path.Dir(objName), fmt.Sprintf("%d.%s", pass, path.Base(objName)))
+		}
+		r.obj.setName(r.o.objectName(r.obj.Prefix, objName, r.rng))
+		r.o.collide(&r.obj, r.rng)
+		return &r.obj
+	}
+	// All files have become unreadable since they were listed.
+	// Return an object that fails when uploaded, so the error is recorded.
+	r.obj.Size = 1
+	r.obj.Reader = errReader{err: fmt.Errorf("file: unable to open any file in %s", r.o.file.path)}
+	r.obj.setName(r.o.objectName(r.obj.Prefix, fmt.Sprintf("%d.err", r.counter), r.rng))
+	return &r.obj
+}
+
+// errReader returns err on every read or seek.
+type errReader struct {
+	err error
+}
+
+func (e errReader) Read([]byte) (int, error) {
+	return 0, e.err
+}
+
+func (e errReader) Seek(int64, int) (int64, error) {
+	return 0, e.err
+}
+
+func (r *fileSrc) String() string {
+	st := r.o.file.state
+	if st.dev != nil {
+		return fmt.Sprintf("Blocks of %s (%d bytes)", r.o.file.path, st.size)
+	}
+	return fmt.Sprintf("%d files from %s", len(st.files), r.o.file.path)
+}
+
+func (r *fileSrc) Prefix() string {
+	return r.prefix
+}
@@ -19,11 +19,13 @@ package generator
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"math"
 	"math/rand"
 	"path"
 	"runtime"
+	"sync"
 )
 
 // Option provides options for data generation.
@@ -95,13 +97,22 @@ func MergeObjectPrefixes(o []Objects) []string {
 
 func (o *Object) setPrefix(opts Options) {
 	if opts.randomPrefix <= 0 {
-		o.Prefix = opts.customPrefix
+		o.Prefix = path.Join(opts.customPrefix, opts.nameLease)
 		return
 	}
 	b := make([]byte, opts.randomPrefix)
-	rng := rand.New(rand.NewSource(int64(rand.Uint64())))
+	rng := rand.New(opts.rngSource(nil))
 	randASCIIBytes(b, rng)
-	o.Prefix = path.Join(opts.customPrefix, string(b))
+	o.Prefix = path.Join(opts.customPrefix, opts.nameLease, string(b))
+}
+
+// objectPrefix returns the prefix of a new object.
+// With hot prefixes enabled a fraction of objects will use a hot prefix instead of prefix.
+func (o Options) objectPrefix(prefix string, rng *rand.Rand) string {
+	if o.hotPrefixes <= 0 || rng.Float64() >= o.hotFraction {
+		return prefix
+	}
+	return path.Join(o.customPrefix, fmt.Sprintf("hot-%d", rng.Intn(o.hotPrefixes)))
 }
 
 func (o *Object) setName(s string) {
@@ -140,7 +151,18 @@ func NewFn(opts ...Option) (func() Source, error) {
 		return nil, errors.New("internal error: generator Source was nil")
 	}
 
+	var mu sync.Mutex
+	var n int64
 	return func() Source {
+		options := options
+		if options.seed != nil {
+			// Derive a seed for each source.
+			mu.Lock()
+			seed := *options.seed + n*seedStep
+			n++
+			mu.Unlock()
+			options.seed = &seed
+		}
 		s, err := options.src(options)
 		if err != nil {
 			panic(err)
@@ -149,6 +171,9 @@ func NewFn(opts ...Option) (func() Source, error) {
 	}, nil
 }
 
+// seedStep separates the seeds of sources created by NewFn.
+const seedStep = 0x5851f42d4c957f2d
+
 const asciiLetters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ1234567890()"
 
 var asciiLetterBytes [len(asciiLetters)]byte
@@ -18,9 +18,12 @@
 package generator
 
 import (
+	"bytes"
 	"io"
 	"io/ioutil"
+	"strings"
 	"testing"
+	"unicode/utf8"
 )
 
 func TestNew(t *testing.T) {
@@ -225,3 +228,88 @@ func BenchmarkWithRandomData(b *testing.B) {
 		})
 	}
 }
+
+func TestNameClasses(t *testing.T) {
+	for _, class := range NameClasses {
+		t.Run(class, func(t *testing.T) {
+			src, err := New(WithNameClasses(class), WithCustomPrefix("warp-test"), WithPrefixSize(8))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 100; i++ {
+				obj := src.Object()
+				if got := NameClass(obj.Name); got != class {
+					t.Fatalf("NameClass(%q) = %q, want %q", obj.Name, got, class)
+				}
+				if !strings.HasPrefix(obj.Name, obj.Prefix+"/") {
+					t.Fatalf("name %q does not have prefix %q", obj.Name, obj.Prefix)
+				}
+				if len(obj.Name) > maxKeyLength || !utf8.ValidString(obj.Name) {
+					t.Fatalf("invalid name %q, length %d", obj.Name, len(obj.Name))
+				}
+				if class == NameLong && len(obj.Name) < maxKeyLength-1 {
+					t.Fatalf("long name is %d bytes", len(obj.Name))
+				}
+			}
+		})
+	}
+}
+
+func TestContentTypes(t *testing.T) {
+	types, err := ParseContentTypes("jpg:60,json:30,.parquet:10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := New(WithContentTypes(types...), WithSize(10<<10), WithPrefixSize(8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		obj := src.Object()
+		ext := obj.Name[strings.LastIndexByte(obj.Name, '.')+1:]
+		counts[ext]++
+		if obj.ContentType != ContentTypeOf(ext) {
+			t.Fatalf("content type of %q is %q", obj.Name, obj.ContentType)
+		}
+		if ext != "json" {
+			continue
+		}
+		b, err := io.ReadAll(obj.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(b) != 10<<10 || !bytes.HasPrefix(b, []byte(`{"id":0,`)) {
+			t.Fatalf("unexpected json payload, %d bytes: %.40q", len(b), b)
+		}
+	}
+	if len(counts) != 3 || counts["jpg"] < counts["json"] || counts["json"] < counts["parquet"] {
+		t.Fatalf("unexpected mix: %v", counts)
+	}
+}
+
+func TestSeed(t *testing.T) {
+	names := func() []string {
+		fn, err := NewFn(WithSeed(42), WithPrefixSize(8), WithSize(1<<10))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var res []string
+		for i := 0; i < 2; i++ {
+			src := fn()
+			for j := 0; j < 10; j++ {
+				res = append(res, src.Object().Name)
+			}
+		}
+		return res
+	}
+	a, b := names(), names()
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("object %d: %q != %q", i, a[i], b[i])
+		}
+	}
+	if a[0] == a[10] {
+		t.Fatalf("sources generated the same object %q", a[0])
+	}
+}
@@ -0,0 +1,169 @@
+/*
+ * Warp (C) 2019-2020 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"path"
+	"strings"
+)
+
+// Object name classes.
+// Objects of a class other than NameASCII are placed in a directory named after the class,
+// so results can be split by class.
+const (
+	// NameASCII are the default names.
+	NameASCII = "ascii"
+	// NameUnicode adds multibyte characters, including emoji and decomposed characters.
+	NameUnicode = "unicode"
+	// NameSpaces adds single, repeated and trailing spaces.
+	NameSpaces = "spaces"
+	// NameSpecial adds characters that must be escaped in URLs.
+	NameSpecial = "special"
+	// NameLong pads the full key to 1024 bytes.
+	NameLong = "long"
+)
+
+// NameClasses contains all object name classes.
+var NameClasses = []string{NameASCII, NameUnicode, NameSpaces, NameSpecial, NameLong}
+
+// maxKeyLength is the maximum length of an S3 object key in bytes.
+const maxKeyLength = 1024
+
+// maxSegmentLength is the maximum length of each path segment of long names.
+// Many servers limit the length of each segment, since they are stored as file names.
+const maxSegmentLength = 200
+
+var (
+	unicodeParts = []string{"é", "ñ", "ü", "ß", "Ж", "λ", "Ω", "日本語", "中文", "한국어", "עברית", "عربي", "😀", "🚀", "é", "ﬁ"}
+	specialParts = []string{"+", "&", "=", "?", "#", "%", "%20", ";", ",", ":", "@", "$", "!", "'", "(", ")", "*", "~", "[", "]"}
+	spaceParts   = []string{" ", "  ", " x ", "\t"}
+)
+
+// WithNameClasses will give each object a name of a random class from the list.
+// See NameClasses for the supported classes.
+func WithNameClasses(classes ...string) Option {
+	return func(o *Options) error {
+		for _, c := range classes {
+			found := false
+			for _, nc := range NameClasses {
+				found = found || c == nc
+			}
+			if !found {
+				return fmt.Errorf("WithNameClasses: unknown name class %q", c)
+			}
+		}
+		if len(classes) == 1 && classes[0] == NameASCII {
+			classes = nil
+		}
+		o.nameClasses = classes
+		return nil
+	}
+}
+
+// NameClass returns the name class of an object name or key.
+// NameASCII is returned if the name has no class directory.
+func NameClass(name string) string {
+	for _, seg := range strings.Split(name, "/") {
+		switch seg {
+		case NameUnicode, NameSpaces, NameSpecial, NameLong:
+			return seg
+		}
+	}
+	return NameASCII
+}
+
+// objectName returns the name of an object with the prefix,
+// with characters of a random name class added.
+func (o Options) objectName(prefix, name string, rng *rand.Rand) string {
+	if len(o.nameClasses) == 0 {
+		return name
+	}
+	pick := func(parts []string, n int) string {
+		var sb strings.Builder
+		for i := 0; i < n; i++ {
+			sb.WriteString(parts[rng.Intn(len(parts))])
+		}
+		return sb.String()
+	}
+	switch class := o.nameClasses[rng.Intn(len(o.nameClasses))]; class {
+	case NameUnicode:
+		return class + "/" + pick(unicodeParts, 3) + name + pick(unicodeParts, 2)
+	case NameSpaces:
+		return class + "/" + pick(spaceParts, 1) + name + pick(spaceParts, 1) + "x "
+	case NameSpecial:
+		return class + "/" + pick(specialParts, 3) + name + pick(specialParts, 2)
+	case NameLong:
+		res := class + "/" + name
+		target := maxKeyLength
+		if prefix != "" {
+			target -= len(prefix) + 1
+		}
+		for target-len(res) >= 2 {
+			seg := make([]byte, min(maxSegmentLength, target-len(res)-1))
+			randASCIIBytes(seg, rng)
+			res += "/" + string(seg)
+		}
+		return res
+	}
+	return name
+}
+
+// CollidePrefix is the prefix, under the custom prefix, of objects with shared names.
+const CollidePrefix = "collide"
+
+// WithNameLease places all objects under the lease prefix,
+// between the custom prefix and the random prefix.
+// Sources with different leases never generate the same names.
+func WithNameLease(lease string) Option {
+	return func(o *Options) error {
+		if strings.Contains(lease, "/") {
+			return errors.New("WithNameLease: lease cannot contain '/'")
+		}
+		o.nameLease = lease
+		return nil
+	}
+}
+
+// WithCollisions will give the fraction of objects one of n shared names.
+// Shared names are 'collide/key-0', 'collide/key-1', etc. under the custom prefix
+// and are the same for all sources, regardless of the lease.
+func WithCollisions(n int, fraction float64) Option {
+	return func(o *Options) error {
+		if n < 1 {
+			return errors.New("WithCollisions: number of names must be >= 1")
+		}
+		if fraction < 0 || fraction > 1 {
+			return errors.New("WithCollisions: fraction must be >= 0 and <= 1")
+		}
+		o.collideNames = n
+		o.collideFraction = fraction
+		return nil
+	}
+}
+
+// collide will give the fraction of objects selected by WithCollisions a shared name.
+func (o Options) collide(obj *Object, rng *rand.Rand) {
+	if o.collideFraction <= 0 || rng.Float64() >= o.collideFraction {
+		return
+	}
+	obj.Prefix = path.Join(o.customPrefix, CollidePrefix)
+	obj.setName(fmt.Sprintf("key-%d", rng.Intn(o.collideNames)))
+}
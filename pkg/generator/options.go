@@ -29,10 +29,20 @@ type Options struct {
 	customPrefix string
 	random       RandomOpts
 	csv          CsvOpts
+	file         FileOpts
 	minSize      int64
 	totalSize    int64
 	randomPrefix int
 	randSize     bool
+	hotPrefixes  int
+	hotFraction  float64
+	nameClasses  []string
+	contentTypes []ContentType
+	seed         *int64
+
+	nameLease       string
+	collideNames    int
+	collideFraction float64
 }
 
 // OptionApplier allows to abstract generator options.
@@ -115,6 +125,46 @@ func WithCustomPrefix(prefix string) Option {
 	}
 }
 
+// WithHotPrefixes will place the fraction of objects in n shared prefixes
+// instead of the prefix of the source.
+// Hot prefixes are named 'hot-0', 'hot-1', etc. under the custom prefix.
+func WithHotPrefixes(n int, fraction float64) Option {
+	return func(o *Options) error {
+		if n < 0 {
+			return errors.New("WithHotPrefixes: number of prefixes must be >= 0")
+		}
+		if fraction < 0 || fraction > 1 {
+			return errors.New("WithHotPrefixes: fraction must be >= 0 and <= 1")
+		}
+		o.hotPrefixes = n
+		o.hotFraction = fraction
+		return nil
+	}
+}
+
+// WithSeed makes generated names, sizes and data deterministic.
+// Sources created by the same function returned by NewFn use different seeds derived from seed,
+// so they do not generate the same objects.
+// Seeds set with RngSeed on the data type take precedence.
+func WithSeed(seed int64) Option {
+	return func(o *Options) error {
+		o.seed = &seed
+		return nil
+	}
+}
+
+// rngSource returns the random source of a new generator.
+// If seed is nil the seed of the options is used, if any.
+func (o Options) rngSource(seed *int64) rand.Source {
+	if seed != nil {
+		return rand.NewSource(*seed)
+	}
+	if o.seed != nil {
+		return rand.NewSource(*o.seed)
+	}
+	return rand.NewSource(int64(rand.Uint64()))
+}
+
 // WithPrefixSize sets prefix size.
 func WithPrefixSize(n int) Option {
 	return func(o *Options) error {
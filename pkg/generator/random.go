@@ -76,19 +76,18 @@ func randomOptsDefaults() RandomOpts {
 }
 
 type randomSrc struct {
-	buf     *scrambler
+	buf *scrambler
+	// texts contain the data of text payloads.
+	texts   map[string]*circularBuffer
 	rng     *rand.Rand
 	obj     Object
 	o       Options
+	prefix  string
 	counter uint64
 }
 
 func newRandom(o Options) (Source, error) {
-	rndSrc := rand.NewSource(int64(rand.Uint64()))
-	if o.random.seed != nil {
-		rndSrc = rand.NewSource(*o.random.seed)
-	}
-	rng := rand.New(rndSrc)
+	rng := rand.New(o.rngSource(o.random.seed))
 
 	size := o.random.size
 	if int64(size) > o.totalSize {
@@ -115,7 +114,16 @@ func newRandom(o Options) (Source, error) {
 			Size:        0,
 		},
 	}
+	for _, ct := range o.contentTypes {
+		if p := payloadOf(ct.Ext); p != "" && r.texts[p] == nil {
+			if r.texts == nil {
+				r.texts = make(map[string]*circularBuffer)
+			}
+			r.texts[p] = newCircularBuffer(textPayload(p, size, rng), o.totalSize)
+		}
+	}
 	r.obj.setPrefix(o)
+	r.prefix = r.obj.Prefix
 	return &r, nil
 }
 
@@ -124,8 +132,19 @@ func (r *randomSrc) Object() *Object {
 	var nBuf [16]byte
 	randASCIIBytes(nBuf[:], r.rng)
 	r.obj.Size = r.o.getSize(r.rng)
-	r.obj.setName(fmt.Sprintf("%d.%s.rnd", atomic.LoadUint64(&r.counter), string(nBuf[:])))
+	r.obj.Prefix = r.o.objectPrefix(r.prefix, r.rng)
+	ext := "rnd"
+	if len(r.o.contentTypes) > 0 {
+		ext = r.o.pickContentType(r.rng).Ext
+		r.obj.ContentType = ContentTypeOf(ext)
+	}
+	r.obj.setName(r.o.objectName(r.obj.Prefix, fmt.Sprintf("%d.%s.%s", atomic.LoadUint64(&r.counter), string(nBuf[:]), ext), r.rng))
+	r.o.collide(&r.obj, r.rng)
 
+	if text := r.texts[payloadOf(ext)]; text != nil {
+		r.obj.Reader = text.Reset(r.obj.Size)
+		return &r.obj
+	}
 	// Reset scrambler
 	r.obj.Reader = r.buf.Reset(r.obj.Size)
 	return &r.obj
@@ -139,5 +158,5 @@ func (r *randomSrc) String() string {
 }
 
 func (r *randomSrc) Prefix() string {
-	return r.obj.Prefix
+	return r.prefix
 }
@@ -0,0 +1,695 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package nulls3 contains a minimal in-memory S3 server.
+// Only object sizes are stored and object reads return zeros,
+// so it can be used to measure the overhead of the benchmark client itself.
+package nulls3
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// Host is the endpoint host used for the null backend.
+const Host = "warp-null"
+
+const xmlns = "http://s3.amazonaws.com/doc/2006-03-01/"
+
+type object struct {
+	size    int64
+	etag    string
+	modTime time.Time
+	// Sizes of each part of multipart uploads.
+	parts []int64
+}
+
+type upload struct {
+	bucket, object string
+	parts          map[int]int64
+}
+
+type bucket struct {
+	created time.Time
+	objects map[string]object
+}
+
+// Server is a minimal in-memory S3 server.
+// Requests are not authenticated and only the APIs used by the benchmarks are supported.
+type Server struct {
+	mu      sync.RWMutex
+	buckets map[string]*bucket
+	uploads map[string]*upload
+	ids     atomic.Uint64
+}
+
+// New returns an empty server.
+func New() *Server {
+	return &Server{
+		buckets: make(map[string]*bucket),
+		uploads: make(map[string]*upload),
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Amz-Request-Id", s.newID())
+	if r.Body != nil {
+		defer r.Body.Close()
+	}
+	bucket, object := splitPath(r)
+	q := r.URL.Query()
+	switch {
+	case bucket == "":
+		if r.Method != http.MethodGet {
+			writeError(w, r, http.StatusMethodNotAllowed, "MethodNotAllowed")
+			return
+		}
+		s.listBuckets(w)
+	case object == "":
+		s.serveBucket(w, r, bucket, q)
+	default:
+		s.serveObject(w, r, bucket, object, q)
+	}
+}
+
+// splitPath returns the bucket and object of the request.
+// Both path and virtual-host style requests are accepted.
+func splitPath(r *http.Request) (bucket, object string) {
+	p := strings.TrimPrefix(r.URL.Path, "/")
+	host, _, _ := strings.Cut(r.Host, ":")
+	if b, ok := strings.CutSuffix(host, "."+Host); ok {
+		return b, p
+	}
+	bucket, object, _ = strings.Cut(p, "/")
+	return bucket, object
+}
+
+func (s *Server) newID() string {
+	return fmt.Sprintf("%016X", s.ids.Add(1))
+}
+
+func (s *Server) newETag() string {
+	return fmt.Sprintf("%032x", s.ids.Add(1))
+}
+
+// subresources that are accepted and ignored when set.
+var ignoredSubresources = []string{"versioning", "policy", "lifecycle", "tagging", "object-lock", "encryption", "replication", "notification"}
+
+func hasAny(q url.Values, keys ...string) bool {
+	for _, k := range keys {
+		if _, ok := q[k]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) serveBucket(w http.ResponseWriter, r *http.Request, name string, q url.Values) {
+	s.mu.RLock()
+	b := s.buckets[name]
+	s.mu.RUnlock()
+	if r.Method == http.MethodPut {
+		io.Copy(io.Discard, r.Body)
+		if hasAny(q, ignoredSubresources...) {
+			if b == nil {
+				writeError(w, r, http.StatusNotFound, "NoSuchBucket")
+				return
+			}
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.buckets[name] != nil {
+			writeError(w, r, http.StatusConflict, "BucketAlreadyOwnedByYou")
+			return
+		}
+		s.buckets[name] = &bucket{created: time.Now(), objects: make(map[string]object)}
+		return
+	}
+	if b == nil {
+		writeError(w, r, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	switch r.Method {
+	case http.MethodHead:
+	case http.MethodDelete:
+		if hasAny(q, ignoredSubresources...) {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if len(b.objects) > 0 {
+			writeError(w, r, http.StatusConflict, "BucketNotEmpty")
+			return
+		}
+		delete(s.buckets, name)
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodPost:
+		if !hasAny(q, "delete") {
+			writeError(w, r, http.StatusNotImplemented, "NotImplemented")
+			return
+		}
+		s.deleteObjects(w, r, b)
+	case http.MethodGet:
+		switch {
+		case hasAny(q, "location"):
+			writeXML(w, struct {
+				XMLName xml.Name `xml:"LocationConstraint"`
+				Xmlns   string   `xml:"xmlns,attr"`
+			}{Xmlns: xmlns})
+		case hasAny(q, "versioning"):
+			writeXML(w, struct {
+				XMLName xml.Name `xml:"VersioningConfiguration"`
+				Xmlns   string   `xml:"xmlns,attr"`
+			}{Xmlns: xmlns})
+		case hasAny(q, "versions"):
+			s.listObjects(w, b, name, q, true)
+		case hasAny(q, ignoredSubresources...), hasAny(q, "uploads"):
+			writeError(w, r, http.StatusNotImplemented, "NotImplemented")
+		default:
+			s.listObjects(w, b, name, q, false)
+		}
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+func (s *Server) serveObject(w http.ResponseWriter, r *http.Request, bucketName, name string, q url.Values) {
+	s.mu.RLock()
+	b := s.buckets[bucketName]
+	s.mu.RUnlock()
+	if b == nil {
+		writeError(w, r, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	if hasAny(q, "tagging", "retention", "legal-hold", "acl", "attributes", "restore", "select") {
+		writeError(w, r, http.StatusNotImplemented, "NotImplemented")
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		switch {
+		case hasAny(q, "uploadId"):
+			s.putPart(w, r, q)
+		case r.Header.Get("X-Amz-Copy-Source") != "":
+			s.copyObject(w, r, b, name)
+		default:
+			size := readBody(r)
+			obj := object{size: size, etag: s.newETag(), modTime: time.Now()}
+			s.mu.Lock()
+			b.objects[name] = obj
+			s.mu.Unlock()
+			w.Header().Set("ETag", `"`+obj.etag+`"`)
+		}
+	case http.MethodPost:
+		switch {
+		case hasAny(q, "uploads"):
+			id := s.newID()
+			s.mu.Lock()
+			s.uploads[id] = &upload{bucket: bucketName, object: name, parts: make(map[int]int64)}
+			s.mu.Unlock()
+			writeXML(w, struct {
+				XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+				Xmlns    string   `xml:"xmlns,attr"`
+				Bucket   string
+				Key      string
+				UploadID string `xml:"UploadId"`
+			}{Xmlns: xmlns, Bucket: bucketName, Key: name, UploadID: id})
+		case hasAny(q, "uploadId"):
+			s.completeUpload(w, r, b, q.Get("uploadId"))
+		default:
+			writeError(w, r, http.StatusNotImplemented, "NotImplemented")
+		}
+	case http.MethodDelete:
+		s.mu.Lock()
+		if id := q.Get("uploadId"); id != "" {
+			delete(s.uploads, id)
+		} else {
+			delete(b.objects, name)
+		}
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodGet, http.MethodHead:
+		s.mu.RLock()
+		obj, ok := b.objects[name]
+		s.mu.RUnlock()
+		if !ok {
+			writeError(w, r, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		s.getObject(w, r, obj, q)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+// readBody reads the request body and returns the size of the object data.
+func readBody(r *http.Request) int64 {
+	n, _ := io.Copy(io.Discard, r.Body)
+	if v := r.Header.Get("X-Amz-Decoded-Content-Length"); v != "" {
+		if size, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return size
+		}
+	}
+	return n
+}
+
+func (s *Server) getObject(w http.ResponseWriter, r *http.Request, obj object, q url.Values) {
+	h := w.Header()
+	h.Set("ETag", `"`+obj.etag+`"`)
+	h.Set("Last-Modified", obj.modTime.UTC().Format(http.TimeFormat))
+	h.Set("Content-Type", "application/octet-stream")
+	h.Set("Accept-Ranges", "bytes")
+	start, length := int64(0), obj.size
+	code := http.StatusOK
+	if v := q.Get("partNumber"); v != "" {
+		part, err := strconv.Atoi(v)
+		if err != nil || part < 1 || part > max(len(obj.parts), 1) {
+			writeError(w, r, http.StatusRequestedRangeNotSatisfiable, "InvalidPartNumber")
+			return
+		}
+		if len(obj.parts) > 0 {
+			for _, size := range obj.parts[:part-1] {
+				start += size
+			}
+			length = obj.parts[part-1]
+			h.Set("X-Amz-Mp-Parts-Count", strconv.Itoa(len(obj.parts)))
+			h.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, start+length-1, obj.size))
+			code = http.StatusPartialContent
+		}
+	} else if rng := r.Header.Get("Range"); rng != "" {
+		var ok bool
+		start, length, ok = parseRange(rng, obj.size)
+		if !ok {
+			writeError(w, r, http.StatusRequestedRangeNotSatisfiable, "InvalidRange")
+			return
+		}
+		h.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, start+length-1, obj.size))
+		code = http.StatusPartialContent
+	}
+	h.Set("Content-Length", strconv.FormatInt(length, 10))
+	w.WriteHeader(code)
+	if r.Method == http.MethodHead {
+		return
+	}
+	if bs, ok := w.(bodySetter); ok {
+		bs.setBody(io.LimitReader(zeroReader{}, length))
+		return
+	}
+	io.CopyN(w, zeroReader{}, length)
+}
+
+// parseRange parses a single byte range.
+func parseRange(rng string, size int64) (start, length int64, ok bool) {
+	spec, ok := strings.CutPrefix(rng, "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return 0, 0, false
+	}
+	from, to, _ := strings.Cut(spec, "-")
+	end := size - 1
+	var err error
+	switch {
+	case from == "":
+		n, err := strconv.ParseInt(to, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, false
+		}
+		start = max(size-n, 0)
+	default:
+		start, err = strconv.ParseInt(from, 10, 64)
+		if err != nil {
+			return 0, 0, false
+		}
+		if to != "" {
+			end, err = strconv.ParseInt(to, 10, 64)
+			if err != nil {
+				return 0, 0, false
+			}
+			end = min(end, size-1)
+		}
+	}
+	if start >= size || end < start {
+		return 0, 0, false
+	}
+	return start, end - start + 1, true
+}
+
+// copySource returns the size of the copy source in the request.
+func (s *Server) copySource(r *http.Request) (int64, bool) {
+	src, err := url.PathUnescape(r.Header.Get("X-Amz-Copy-Source"))
+	if err != nil {
+		return 0, false
+	}
+	src, _, _ = strings.Cut(src, "?")
+	bucketName, name, _ := strings.Cut(strings.TrimPrefix(src, "/"), "/")
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	b := s.buckets[bucketName]
+	if b == nil {
+		return 0, false
+	}
+	obj, ok := b.objects[name]
+	if !ok {
+		return 0, false
+	}
+	if rng := r.Header.Get("X-Amz-Copy-Source-Range"); rng != "" {
+		_, length, ok := parseRange(rng, obj.size)
+		return length, ok
+	}
+	return obj.size, true
+}
+
+func (s *Server) copyObject(w http.ResponseWriter, r *http.Request, b *bucket, name string) {
+	size, ok := s.copySource(r)
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "NoSuchKey")
+		return
+	}
+	obj := object{size: size, etag: s.newETag(), modTime: time.Now()}
+	s.mu.Lock()
+	b.objects[name] = obj
+	s.mu.Unlock()
+	writeXML(w, struct {
+		XMLName      xml.Name `xml:"CopyObjectResult"`
+		Xmlns        string   `xml:"xmlns,attr"`
+		LastModified time.Time
+		ETag         string
+	}{Xmlns: xmlns, LastModified: obj.modTime.UTC(), ETag: `"` + obj.etag + `"`})
+}
+
+func (s *Server) putPart(w http.ResponseWriter, r *http.Request, q url.Values) {
+	part, err := strconv.Atoi(q.Get("partNumber"))
+	if err != nil || part < 1 {
+		writeError(w, r, http.StatusBadRequest, "InvalidArgument")
+		return
+	}
+	copied := r.Header.Get("X-Amz-Copy-Source") != ""
+	var size int64
+	if copied {
+		var ok bool
+		if size, ok = s.copySource(r); !ok {
+			writeError(w, r, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+	} else {
+		size = readBody(r)
+	}
+	s.mu.Lock()
+	up := s.uploads[q.Get("uploadId")]
+	if up != nil {
+		up.parts[part] = size
+	}
+	s.mu.Unlock()
+	if up == nil {
+		writeError(w, r, http.StatusNotFound, "NoSuchUpload")
+		return
+	}
+	etag := `"` + s.newETag() + `"`
+	if copied {
+		writeXML(w, struct {
+			XMLName      xml.Name `xml:"CopyPartResult"`
+			Xmlns        string   `xml:"xmlns,attr"`
+			LastModified time.Time
+			ETag         string
+		}{Xmlns: xmlns, LastModified: time.Now().UTC(), ETag: etag})
+		return
+	}
+	w.Header().Set("ETag", etag)
+}
+
+func (s *Server) completeUpload(w http.ResponseWriter, r *http.Request, b *bucket, id string) {
+	io.Copy(io.Discard, r.Body)
+	s.mu.Lock()
+	up := s.uploads[id]
+	delete(s.uploads, id)
+	var obj object
+	if up != nil {
+		numbers := make([]int, 0, len(up.parts))
+		for n := range up.parts {
+			numbers = append(numbers, n)
+		}
+		sort.Ints(numbers)
+		for _, n := range numbers {
+			obj.size += up.parts[n]
+			obj.parts = append(obj.parts, up.parts[n])
+		}
+		obj.etag = fmt.Sprintf("%s-%d", s.newETag(), len(up.parts))
+		obj.modTime = time.Now()
+		b.objects[up.object] = obj
+	}
+	s.mu.Unlock()
+	if up == nil {
+		writeError(w, r, http.StatusNotFound, "NoSuchUpload")
+		return
+	}
+	writeXML(w, struct {
+		XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+		Xmlns   string   `xml:"xmlns,attr"`
+		Bucket  string
+		Key     string
+		ETag    string
+	}{Xmlns: xmlns, Bucket: up.bucket, Key: up.object, ETag: `"` + obj.etag + `"`})
+}
+
+func (s *Server) deleteObjects(w http.ResponseWriter, r *http.Request, b *bucket) {
+	var req struct {
+		Quiet   bool
+		Objects []struct {
+			Key       string
+			VersionID string `xml:"VersionId"`
+		} `xml:"Object"`
+	}
+	if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, "MalformedXML")
+		return
+	}
+	type deleted struct {
+		Key       string
+		VersionID string `xml:"VersionId,omitempty"`
+	}
+	res := struct {
+		XMLName xml.Name  `xml:"DeleteResult"`
+		Xmlns   string    `xml:"xmlns,attr"`
+		Deleted []deleted `xml:"Deleted"`
+	}{Xmlns: xmlns}
+	s.mu.Lock()
+	for _, obj := range req.Objects {
+		delete(b.objects, obj.Key)
+		if !req.Quiet {
+			res.Deleted = append(res.Deleted, deleted{Key: obj.Key, VersionID: obj.VersionID})
+		}
+	}
+	s.mu.Unlock()
+	writeXML(w, res)
+}
+
+type listEntry struct {
+	Key          string
+	VersionID    string `xml:"VersionId,omitempty"`
+	IsLatest     bool   `xml:",omitempty"`
+	LastModified time.Time
+	ETag         string
+	Size         int64
+	StorageClass string
+}
+
+type commonPrefix struct {
+	Prefix string
+}
+
+// listObjects lists objects of a bucket with V1, V2 or versions listing.
+func (s *Server) listObjects(w http.ResponseWriter, b *bucket, name string, q url.Values, versions bool) {
+	prefix, delim := q.Get("prefix"), q.Get("delimiter")
+	maxKeys := 1000
+	if v, err := strconv.Atoi(q.Get("max-keys")); err == nil && v > 0 && v < maxKeys {
+		maxKeys = v
+	}
+	v2 := q.Get("list-type") == "2"
+	var marker string
+	switch {
+	case versions:
+		marker = q.Get("key-marker")
+	case v2:
+		marker = q.Get("continuation-token")
+		if marker == "" {
+			marker = q.Get("start-after")
+		}
+	default:
+		marker = q.Get("marker")
+	}
+
+	s.mu.RLock()
+	keys := make([]string, 0, len(b.objects))
+	for k := range b.objects {
+		if strings.HasPrefix(k, prefix) && k > marker {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var entries []listEntry
+	var prefixes []commonPrefix
+	var next string
+	truncated := false
+	for _, k := range keys {
+		var p string
+		if delim != "" {
+			if i := strings.Index(k[len(prefix):], delim); i >= 0 {
+				p = k[:len(prefix)+i+len(delim)]
+				if n := len(prefixes); n > 0 && prefixes[n-1].Prefix == p {
+					continue
+				}
+			}
+		}
+		if len(entries)+len(prefixes) >= maxKeys {
+			truncated = true
+			break
+		}
+		if p != "" {
+			prefixes = append(prefixes, commonPrefix{Prefix: p})
+			// Continue after all keys with the prefix.
+			next = p + string(utf8.MaxRune)
+			continue
+		}
+		obj := b.objects[k]
+		e := listEntry{Key: k, LastModified: obj.modTime.UTC(), ETag: `"` + obj.etag + `"`, Size: obj.size, StorageClass: "STANDARD"}
+		if versions {
+			e.VersionID, e.IsLatest = "null", true
+		}
+		entries = append(entries, e)
+		next = k
+	}
+	s.mu.RUnlock()
+	if !truncated {
+		next = ""
+	}
+
+	if versions {
+		writeXML(w, struct {
+			XMLName        xml.Name `xml:"ListVersionsResult"`
+			Xmlns          string   `xml:"xmlns,attr"`
+			Name           string
+			Prefix         string
+			KeyMarker      string
+			NextKeyMarker  string `xml:",omitempty"`
+			MaxKeys        int
+			Delimiter      string `xml:",omitempty"`
+			IsTruncated    bool
+			Versions       []listEntry    `xml:"Version"`
+			CommonPrefixes []commonPrefix `xml:"CommonPrefixes"`
+		}{Xmlns: xmlns, Name: name, Prefix: prefix, KeyMarker: marker, NextKeyMarker: next, MaxKeys: maxKeys, Delimiter: delim, IsTruncated: truncated, Versions: entries, CommonPrefixes: prefixes})
+		return
+	}
+	res := struct {
+		XMLName               xml.Name `xml:"ListBucketResult"`
+		Xmlns                 string   `xml:"xmlns,attr"`
+		Name                  string
+		Prefix                string
+		Marker                string `xml:",omitempty"`
+		NextMarker            string `xml:",omitempty"`
+		ContinuationToken     string `xml:",omitempty"`
+		NextContinuationToken string `xml:",omitempty"`
+		KeyCount              int    `xml:",omitempty"`
+		MaxKeys               int
+		Delimiter             string `xml:",omitempty"`
+		IsTruncated           bool
+		Contents              []listEntry    `xml:"Contents"`
+		CommonPrefixes        []commonPrefix `xml:"CommonPrefixes"`
+	}{Xmlns: xmlns, Name: name, Prefix: prefix, MaxKeys: maxKeys, Delimiter: delim, IsTruncated: truncated, Contents: entries, CommonPrefixes: prefixes}
+	if v2 {
+		res.ContinuationToken = q.Get("continuation-token")
+		res.NextContinuationToken = next
+		res.KeyCount = len(entries) + len(prefixes)
+	} else {
+		res.Marker = marker
+		res.NextMarker = next
+	}
+	writeXML(w, res)
+}
+
+func (s *Server) listBuckets(w http.ResponseWriter) {
+	type bucketInfo struct {
+		Name         string
+		CreationDate time.Time
+	}
+	s.mu.RLock()
+	buckets := make([]bucketInfo, 0, len(s.buckets))
+	for name, b := range s.buckets {
+		buckets = append(buckets, bucketInfo{Name: name, CreationDate: b.created.UTC()})
+	}
+	s.mu.RUnlock()
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Name < buckets[j].Name })
+	writeXML(w, struct {
+		XMLName xml.Name `xml:"ListAllMyBucketsResult"`
+		Xmlns   string   `xml:"xmlns,attr"`
+		Owner   struct {
+			ID          string
+			DisplayName string
+		}
+		Buckets []bucketInfo `xml:"Buckets>Bucket"`
+	}{Xmlns: xmlns, Buckets: buckets})
+}
+
+func writeXML(w http.ResponseWriter, v interface{}) {
+	b, err := xml.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.Header().Set("Content-Length", strconv.Itoa(len(xml.Header)+len(b)))
+	io.WriteString(w, xml.Header)
+	w.Write(b)
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, status int, code string) {
+	if r.Method == http.MethodHead {
+		w.WriteHeader(status)
+		return
+	}
+	b, _ := xml.Marshal(struct {
+		XMLName   xml.Name `xml:"Error"`
+		Code      string
+		Message   string
+		Resource  string
+		RequestID string `xml:"RequestId"`
+	}{Code: code, Message: http.StatusText(status), Resource: r.URL.Path, RequestID: w.Header().Get("X-Amz-Request-Id")})
+	w.Header().Set("Content-Type", "application/xml")
+	w.Header().Set("Content-Length", strconv.Itoa(len(xml.Header)+len(b)))
+	w.WriteHeader(status)
+	io.WriteString(w, xml.Header)
+	w.Write(b)
+}
+
+// zeroReader returns an infinite stream of zeros.
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
@@ -0,0 +1,98 @@
+/*
+ * Warp (C) 2019-2024 MinIO, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package nulls3
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// Transport is a http.RoundTripper that serves all requests in-process by Server,
+// without any network or serialization of object data.
+type Transport struct {
+	Server *Server
+}
+
+// bodySetter is implemented by response writers that accept a reader as the response body.
+type bodySetter interface {
+	setBody(r io.Reader)
+}
+
+// responseWriter collects the response of a request served in-process.
+type responseWriter struct {
+	header http.Header
+	code   int
+	buf    bytes.Buffer
+	body   io.Reader
+}
+
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.buf.Write(b)
+}
+
+func (w *responseWriter) setBody(r io.Reader) {
+	w.body = r
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil {
+		req = req.WithContext(req.Context())
+		req.Body = http.NoBody
+	}
+	w := responseWriter{header: make(http.Header)}
+	t.Server.ServeHTTP(&w, req)
+	w.WriteHeader(http.StatusOK)
+	body := w.body
+	if body == nil {
+		body = bytes.NewReader(w.buf.Bytes())
+	}
+	length := int64(w.buf.Len())
+	if v, err := strconv.ParseInt(w.header.Get("Content-Length"), 10, 64); err == nil {
+		length = v
+	}
+	resp := &http.Response{
+		Status:        fmt.Sprintf("%d %s", w.code, http.StatusText(w.code)),
+		StatusCode:    w.code,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        w.header,
+		Body:          io.NopCloser(body),
+		ContentLength: length,
+		Request:       req,
+	}
+	if req.Method == http.MethodHead {
+		resp.Body = http.NoBody
+	}
+	return resp, nil
+}